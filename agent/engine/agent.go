@@ -5,6 +5,7 @@ import (
 	"crypto/md5"
 	"encoding/hex"
 	"encoding/json"
+	stderrors "errors"
 	"fmt"
 	"log/slog"
 	"sort"
@@ -12,8 +13,11 @@ import (
 	"sync"
 	"sync/atomic"
 	"time"
+	"unicode/utf8"
 
+	"github.com/google/uuid"
 	"github.com/xichan96/cortex/agent/ratelimit"
+	"github.com/xichan96/cortex/agent/tools"
 	"github.com/xichan96/cortex/agent/types"
 	"github.com/xichan96/cortex/pkg/errors"
 	"github.com/xichan96/cortex/pkg/logger"
@@ -47,6 +51,7 @@ type Agent interface {
 
 	// Lifecycle management
 	Stop()
+	Shutdown(ctx context.Context) error
 }
 
 // AgentEngine agent engine
@@ -58,28 +63,50 @@ type AgentEngine struct {
 	model        types.LLMProvider     // LLM model provider
 	tools        []types.Tool          // Available tools list
 	toolsMap     map[string]types.Tool // Tool mapping table for quick lookup
+	disabled     map[string]bool       // Names of registered tools hidden from the model
+	allowedTools map[string]bool       // Per-request allowlist of the running execution (nil means all tools)
 	memory       types.MemoryProvider  // Memory system
 	outputParser types.OutputParser    // Output parser
+	approvalHook ApprovalHook          // Optional human-in-the-loop tool approval
+	toolHooks    []ToolHook            // Hooks run before and after each tool execution
+	moderator    types.Moderator       // Optional safety check of user input and output
+	summaryModel types.LLMProvider     // Optional cheaper model for result summaries and titles
+	title        string                // Cached conversation title
+	tokenCounter types.TokenCounter    // Optional tokenizer shared with the memory provider
+
+	toolResultFormatter ToolResultFormatter // Optional override of how tool results are sent to the model
+
+	checkpointStore types.CheckpointStore // Optional store for resumable executions
 
 	// Configuration and state
 	config *types.AgentConfig // Engine configuration
 	logger *logger.Logger     // Structured logger
 
 	// Internal state management
-	mu        sync.RWMutex       // State mutex lock
-	isRunning atomic.Bool        // Running state (atomic for thread safety)
-	ctx       context.Context    // Context
-	cancel    context.CancelFunc // Cancel function
+	mu           sync.RWMutex       // State mutex lock
+	isRunning    atomic.Bool        // Running state (atomic for thread safety)
+	shuttingDown atomic.Bool        // Set once Shutdown is called, rejects new executions
+	pendingSave  chan struct{}      // Closed once the AsyncMemorySave of the last streamed turn is done, nil if none
+	ctx          context.Context    // Context
+	cancel       context.CancelFunc // Cancel function
 
 	// Performance optimization
 	toolCache     map[string]*toolCacheEntry // Tool execution result cache
 	toolCacheMu   sync.RWMutex               // Cache read-write lock
 	toolCacheSize int                        // Cache size limit
+	toolCacheTTL  time.Duration              // Cache entry lifetime
 	toolCacheHead *toolCacheEntry            // LRU list head (most recently used)
 	toolCacheTail *toolCacheEntry            // LRU list tail (least recently used)
+	cacheHits     atomic.Int64               // Lookups served from the cache
+	cacheMisses   atomic.Int64               // Lookups without a live entry
+	cacheEvicted  atomic.Int64               // Entries evicted at the size limit
+	summaryCache  summaryCache               // Result summaries keyed by output
 
 	// Rate limiting
 	rateLimiter ratelimit.RateLimiter // Rate limiter for request throttling
+
+	// Metrics
+	streamStats streamStatsCollector // Streaming latency per model
 }
 
 // NewAgentEngine creates a new agent engine
@@ -96,13 +123,18 @@ func NewAgentEngine(model types.LLMProvider, config *types.AgentConfig) *AgentEn
 		config = types.NewAgentConfig()
 	}
 
+	if config.ResponseFormat != "" {
+		forwardResponseFormat(model, config.ResponseFormat)
+	}
+
 	return &AgentEngine{
 		model:         model,
 		config:        config,
 		tools:         make([]types.Tool, 0),
 		toolsMap:      make(map[string]types.Tool),
 		toolCache:     make(map[string]*toolCacheEntry),
-		toolCacheSize: DefaultCacheSize, // Using constant-defined cache size
+		toolCacheSize: toolCacheSizeOrDefault(config.ToolCacheSize),
+		toolCacheTTL:  toolCacheTTLOrDefault(config.ToolCacheTTL),
 		logger:        logger.NewLogger(),
 		ctx:           ctx,
 		cancel:        cancel,
@@ -122,12 +154,83 @@ func (ae *AgentEngine) SetMemory(memory types.MemoryProvider) {
 	ae.mu.Lock()
 	defer ae.mu.Unlock()
 	ae.memory = memory
+	ae.title = ""
 
 	if ae.config != nil && ae.config.MaxHistoryMessages > 0 {
 		if provider, ok := memory.(interface{ SetMaxHistoryMessages(int) }); ok {
 			provider.SetMaxHistoryMessages(ae.config.MaxHistoryMessages)
 		}
 	}
+	if ae.tokenCounter != nil {
+		if provider, ok := memory.(interface{ SetTokenCounter(types.TokenCounter) }); ok {
+			provider.SetTokenCounter(ae.tokenCounter)
+		}
+	}
+}
+
+// SetTokenCounter sets how message tokens are counted for CompactAtContextFraction
+// The counter is also set on memory providers with token-based history limits, so both agree on counts;
+// nil restores types.HeuristicTokenCounter
+func (ae *AgentEngine) SetTokenCounter(counter types.TokenCounter) {
+	ae.mu.Lock()
+	defer ae.mu.Unlock()
+	ae.tokenCounter = counter
+	if provider, ok := ae.memory.(interface{ SetTokenCounter(types.TokenCounter) }); ok {
+		provider.SetTokenCounter(counter)
+	}
+}
+
+// GetMessagesPaged returns a page of the conversation stored in memory, oldest first
+// Unlike the prompt history it is not capped by MaxHistoryMessages, no memory means no messages
+func (ae *AgentEngine) GetMessagesPaged(offset, limit int) ([]types.Message, int, error) {
+	ae.mu.RLock()
+	memory := ae.memory
+	ae.mu.RUnlock()
+	if memory == nil {
+		return []types.Message{}, 0, nil
+	}
+
+	messages, total, err := types.PageMessages(memory, offset, limit)
+	if err != nil {
+		return nil, 0, errors.NewError(errors.EC_MEMORY_HISTORY_FAILED.Code, errors.EC_MEMORY_HISTORY_FAILED.Message).Wrap(err)
+	}
+	return messages, total, nil
+}
+
+// GetChatHistory returns the history the engine sends with prompts, capped like the prompt history
+// No memory means no messages
+func (ae *AgentEngine) GetChatHistory() ([]types.Message, error) {
+	ae.mu.RLock()
+	memory := ae.memory
+	ae.mu.RUnlock()
+	if memory == nil {
+		return []types.Message{}, nil
+	}
+
+	history, err := memory.GetChatHistory()
+	if err != nil {
+		return nil, errors.NewError(errors.EC_MEMORY_HISTORY_FAILED.Code, errors.EC_MEMORY_HISTORY_FAILED.Message).Wrap(err)
+	}
+	if history == nil {
+		history = []types.Message{}
+	}
+	return history, nil
+}
+
+// ClearMemory removes the stored conversation, and with it the cached title
+func (ae *AgentEngine) ClearMemory() error {
+	ae.mu.Lock()
+	memory := ae.memory
+	ae.title = ""
+	ae.mu.Unlock()
+	if memory == nil {
+		return nil
+	}
+
+	if err := memory.Clear(); err != nil {
+		return errors.NewError(errors.EC_MEMORY_ERROR.Code, errors.EC_MEMORY_ERROR.Message).Wrap(err)
+	}
+	return nil
 }
 
 // SetOutputParser sets the output parser
@@ -189,6 +292,26 @@ func (ae *AgentEngine) SetStopSequences(sequences []string) {
 	})
 }
 
+// SetResponseFormat sets the output format requested from the model: types.ResponseFormatText or
+// types.ResponseFormatJSONObject. The format is passed on to models that support it, such as LangChainLLMProvider
+func (ae *AgentEngine) SetResponseFormat(format string) {
+	ae.setConfigValue(func() {
+		ae.config.ResponseFormat = format
+	})
+
+	ae.mu.RLock()
+	model := ae.model
+	ae.mu.RUnlock()
+	forwardResponseFormat(model, format)
+}
+
+// forwardResponseFormat passes the response format to models that support it
+func forwardResponseFormat(model types.LLMProvider, format string) {
+	if cfg, ok := model.(interface{ SetResponseFormat(string) }); ok {
+		cfg.SetResponseFormat(format)
+	}
+}
+
 // SetTimeout sets timeout duration
 func (ae *AgentEngine) SetTimeout(timeout time.Duration) {
 	ae.setConfigValue(func() {
@@ -217,6 +340,49 @@ func (ae *AgentEngine) SetEnableToolRetry(enable bool) {
 	})
 }
 
+// SetMaxToolCalls sets the maximum number of tool calls per execution (0 means unlimited)
+func (ae *AgentEngine) SetMaxToolCalls(maxToolCalls int) {
+	ae.setConfigValue(func() {
+		ae.config.MaxToolCalls = maxToolCalls
+	})
+}
+
+// SetParallelToolCalls enables running independent tool calls of an iteration concurrently
+func (ae *AgentEngine) SetParallelToolCalls(enable bool) {
+	ae.setConfigValue(func() {
+		ae.config.ParallelToolCalls = enable
+	})
+}
+
+// SetToolPriorityOverrides sets per-tool priorities that take precedence over tool metadata
+func (ae *AgentEngine) SetToolPriorityOverrides(overrides map[string]int) {
+	ae.setConfigValue(func() {
+		ae.config.ToolPriorityOverrides = overrides
+	})
+}
+
+// SetApprovalTimeout sets how long to wait for a tool call approval decision
+func (ae *AgentEngine) SetApprovalTimeout(timeout time.Duration) {
+	ae.setConfigValue(func() {
+		ae.config.ApprovalTimeout = timeout
+	})
+}
+
+// SetApprovalHook sets the hook consulted before each tool call is executed
+func (ae *AgentEngine) SetApprovalHook(hook ApprovalHook) {
+	ae.mu.Lock()
+	defer ae.mu.Unlock()
+	ae.approvalHook = hook
+}
+
+// SetCheckpointStore sets the store used to checkpoint blocking executions
+// Checkpoints are only written when AgentConfig.EnableCheckpoints is set
+func (ae *AgentEngine) SetCheckpointStore(store types.CheckpointStore) {
+	ae.mu.Lock()
+	defer ae.mu.Unlock()
+	ae.checkpointStore = store
+}
+
 // SetConfig sets the complete configuration
 func (ae *AgentEngine) SetConfig(config *types.AgentConfig) {
 	ae.mu.Lock()
@@ -226,6 +392,20 @@ func (ae *AgentEngine) SetConfig(config *types.AgentConfig) {
 	} else {
 		ae.config = config
 	}
+	forwardResponseFormat(ae.model, ae.config.ResponseFormat)
+}
+
+// validateConfig rejects configurations the engine cannot run with, such as MaxIterations <= 0
+func (ae *AgentEngine) validateConfig() error {
+	ae.mu.RLock()
+	defer ae.mu.RUnlock()
+	if ae.config == nil {
+		return nil
+	}
+	if err := ae.config.Validate(); err != nil {
+		return errors.NewError(errors.EC_PARAMETER_INVALID.Code, err.Error())
+	}
+	return nil
 }
 
 // SetRateLimiter sets the rate limiter
@@ -236,27 +416,120 @@ func (ae *AgentEngine) SetRateLimiter(limiter ratelimit.RateLimiter) {
 }
 
 // AddTool adds a tool
+// With ToolSchemaValidation "error" a tool with a malformed schema is logged and skipped, use RegisterTool to get the error
 func (ae *AgentEngine) AddTool(tool types.Tool) {
+	if err := ae.RegisterTool(tool); err != nil {
+		ae.logger.LogError("AddTool", err, slog.String("tool_name", tool.Name()))
+	}
+}
+
+// ==================== Tool Management Methods ====================
+
+// RegisterTool adds a tool after checking its schema
+// Fails with EC_TOOL_VALIDATION_FAILED when ToolSchemaValidation is "error" and the schema is malformed
+func (ae *AgentEngine) RegisterTool(tool types.Tool) error {
 	ae.mu.Lock()
 	defer ae.mu.Unlock()
 
-	toolName := tool.Name()
+	if err := ae.checkToolSchema(tool); err != nil {
+		return err
+	}
 	ae.tools = append(ae.tools, tool)
-	ae.toolsMap[toolName] = tool
+	ae.toolsMap[tool.Name()] = tool
+	return nil
 }
 
-// ==================== Tool Management Methods ====================
-
 // AddTools adds multiple tools
+// Tools rejected by schema validation are logged and skipped like in AddTool
 func (ae *AgentEngine) AddTools(tools []types.Tool) {
 	ae.mu.Lock()
 	defer ae.mu.Unlock()
 
 	for _, tool := range tools {
-		toolName := tool.Name()
+		if err := ae.checkToolSchema(tool); err != nil {
+			ae.logger.LogError("AddTools", err, slog.String("tool_name", tool.Name()))
+			continue
+		}
 		ae.tools = append(ae.tools, tool)
-		ae.toolsMap[toolName] = tool
+		ae.toolsMap[tool.Name()] = tool
+	}
+}
+
+// DisableTool hides a registered tool from the model until EnableTool is called
+// Calls to a disabled tool are treated like calls to an unknown tool and InvokeTool rejects it
+func (ae *AgentEngine) DisableTool(name string) error {
+	ae.mu.Lock()
+	defer ae.mu.Unlock()
+
+	if _, exists := ae.toolsMap[name]; !exists {
+		return errors.NewError(errors.EC_TOOL_NOT_FOUND.Code, fmt.Sprintf("tool '%s' not found", name))
 	}
+	if ae.disabled == nil {
+		ae.disabled = make(map[string]bool)
+	}
+	ae.disabled[name] = true
+	return nil
+}
+
+// EnableTool makes a disabled tool available to the model again
+func (ae *AgentEngine) EnableTool(name string) error {
+	ae.mu.Lock()
+	defer ae.mu.Unlock()
+
+	if _, exists := ae.toolsMap[name]; !exists {
+		return errors.NewError(errors.EC_TOOL_NOT_FOUND.Code, fmt.Sprintf("tool '%s' not found", name))
+	}
+	delete(ae.disabled, name)
+	return nil
+}
+
+// IsToolEnabled reports whether a tool is registered and not disabled
+func (ae *AgentEngine) IsToolEnabled(name string) bool {
+	ae.mu.RLock()
+	defer ae.mu.RUnlock()
+
+	_, exists := ae.toolsMap[name]
+	return exists && !ae.disabled[name]
+}
+
+// enabledTools returns the registered tools that are not disabled, callers must hold ae.mu
+func (ae *AgentEngine) enabledTools() []types.Tool {
+	if len(ae.disabled) == 0 {
+		return ae.tools
+	}
+	enabled := make([]types.Tool, 0, len(ae.tools))
+	for _, tool := range ae.tools {
+		if !ae.disabled[tool.Name()] {
+			enabled = append(enabled, tool)
+		}
+	}
+	return enabled
+}
+
+// EnabledTools returns a copy of the tools the running execution may call:
+// registered, not disabled and, when the request set one, on its tool allowlist
+func (ae *AgentEngine) EnabledTools() []types.Tool {
+	ae.mu.RLock()
+	defer ae.mu.RUnlock()
+
+	enabled := ae.enabledTools()
+	tools := make([]types.Tool, 0, len(enabled))
+	for _, tool := range enabled {
+		if ae.allowedTools == nil || ae.allowedTools[tool.Name()] {
+			tools = append(tools, tool)
+		}
+	}
+	return tools
+}
+
+// Tools returns a copy of the registered tools, including disabled ones
+func (ae *AgentEngine) Tools() []types.Tool {
+	ae.mu.RLock()
+	defer ae.mu.RUnlock()
+
+	tools := make([]types.Tool, len(ae.tools))
+	copy(tools, ae.tools)
+	return tools
 }
 
 // ==================== Core Execution Methods ====================
@@ -271,6 +544,31 @@ func (ae *AgentEngine) AddTools(tools []types.Tool) {
 //   - execution result containing output, tool calls, and intermediate steps
 //   - error information
 func (ae *AgentEngine) Execute(input string, previousRequests []types.ToolCallData) (*AgentResult, error) {
+	return ae.ExecuteWithOptions(input, previousRequests, nil)
+}
+
+// ExecuteContext executes the agent task, cancelling it when ctx is done
+// A running model call or tool is abandoned once ctx is done and an EC_TIMEOUT error wrapping ctx.Err() is returned
+func (ae *AgentEngine) ExecuteContext(ctx context.Context, input string, previousRequests []types.ToolCallData) (*AgentResult, error) {
+	result, err := ae.ExecuteWithOptions(input, previousRequests, &ExecuteOptions{Context: ctx})
+	if ctx.Err() != nil && (err != nil || result.StoppedReason == StoppedReasonCancelled) {
+		return nil, contextDoneError(ctx)
+	}
+	return result, err
+}
+
+// ExecuteWithOptions executes the agent task with per-request options
+// Options only apply to this call and never modify engine state, nil behaves like Execute
+func (ae *AgentEngine) ExecuteWithOptions(input string, previousRequests []types.ToolCallData, opts *ExecuteOptions) (*AgentResult, error) {
+	if ae.shuttingDown.Load() {
+		return nil, errors.EC_AGENT_SHUTTING_DOWN
+	}
+	if err := ae.validateConfig(); err != nil {
+		return nil, err
+	}
+	if err := ae.waitPendingSave(opts.context()); err != nil {
+		return nil, err
+	}
 	if !ae.isRunning.CompareAndSwap(false, true) {
 		return nil, errors.EC_AGENT_BUSY
 	}
@@ -283,32 +581,95 @@ func (ae *AgentEngine) Execute(input string, previousRequests []types.ToolCallDa
 		slog.String("input", truncateString(input, 100)),
 		slog.Int("previousRequests", len(previousRequests)))
 
-	ae.mu.RLock()
-	limiter := ae.rateLimiter
-	ctx := ae.ctx
-	ae.mu.RUnlock()
-
-	if limiter != nil {
-		if ctx == nil {
-			ctx = context.Background()
-		}
-		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
-		defer cancel()
-		if err := limiter.Wait(ctx); err != nil {
-			ae.logger.LogError("Execute", err, slog.String("phase", "rate_limit"))
-			return nil, errors.NewError(errors.EC_SYSTEM_OVERLOAD.Code, "rate limit exceeded").Wrap(err)
-		}
+	if err := ae.waitRateLimit("Execute", opts); err != nil {
+		return nil, err
 	}
 
 	// Pre-allocate slice capacity to reduce memory reallocations
-	messages, err := ae.prepareMessages(input, previousRequests)
+	messages, err := ae.prepareMessages(input, previousRequests, opts)
 	if err != nil {
 		ae.logger.LogError("Execute", err, slog.String("phase", "prepare_messages"))
+		if isModerationError(err) {
+			return nil, err
+		}
 		return nil, errors.NewError(errors.EC_PREPARE_MESSAGES_FAILED.Code, errors.EC_PREPARE_MESSAGES_FAILED.Message).Wrap(err)
 	}
 
+	ae.mu.RLock()
+	state := newExecutionState(ae.config)
+	checkpointsEnabled := ae.checkpointStore != nil && ae.config != nil && ae.config.EnableCheckpoints
+	ae.mu.RUnlock()
+	ae.applyToolAllowlist(state, opts)
+	release := ae.applyExecutionContext(state, opts)
+	defer release()
+
+	checkpointID := ""
+	if checkpointsEnabled {
+		checkpointID = uuid.New().String()
+		if opts != nil && opts.ExecutionID != "" {
+			checkpointID = opts.ExecutionID
+		}
+	}
+
+	return ae.runIterations(input, messages, 0, state, checkpointID, startTime)
+}
+
+// ResumeExecution continues an execution from its last checkpoint
+// The checkpoint is written after each iteration when EnableCheckpoints is set and a
+// checkpoint store is configured, and deleted once the execution completes
+func (ae *AgentEngine) ResumeExecution(checkpointID string) (*AgentResult, error) {
+	if ae.shuttingDown.Load() {
+		return nil, errors.EC_AGENT_SHUTTING_DOWN
+	}
+	if err := ae.validateConfig(); err != nil {
+		return nil, err
+	}
+	if err := ae.waitPendingSave(nil); err != nil {
+		return nil, err
+	}
+	if !ae.isRunning.CompareAndSwap(false, true) {
+		return nil, errors.EC_AGENT_BUSY
+	}
+	defer ae.isRunning.Store(false)
+
+	ae.mu.RLock()
+	store := ae.checkpointStore
+	ctx := ae.ctx
+	state := newExecutionState(ae.config)
+	ae.mu.RUnlock()
+	if store == nil {
+		return nil, errors.NewError(errors.EC_MISSING_CONFIG.Code, "checkpoint store is not configured")
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	checkpoint, err := store.Load(ctx, checkpointID)
+	if err != nil {
+		ae.logger.LogError("ResumeExecution", err, slog.String("checkpoint_id", checkpointID))
+		return nil, err
+	}
+
+	state.toolCalls = checkpoint.ToolCallCount
+	state.warnings = checkpoint.Warnings
+	if len(checkpoint.AllowedTools) > 0 {
+		state.allowedTools = make(map[string]bool, len(checkpoint.AllowedTools))
+		for _, name := range checkpoint.AllowedTools {
+			state.allowedTools[name] = true
+		}
+	}
+	ae.activateAllowlist(state)
+
+	ae.logger.LogExecution("ResumeExecution", checkpoint.Iteration, "Resuming execution from checkpoint",
+		slog.String("checkpoint_id", checkpointID),
+		slog.Int("messages", len(checkpoint.Messages)))
+	return ae.runIterations(checkpoint.Input, checkpoint.Messages, checkpoint.Iteration, state, checkpointID, time.Now())
+}
+
+// runIterations runs the iteration loop of a blocking execution starting at iteration
+// When checkpointID is set, progress is checkpointed after each iteration
+func (ae *AgentEngine) runIterations(input string, messages []types.Message, iteration int, state *executionState, checkpointID string, startTime time.Time) (*AgentResult, error) {
 	var finalResult *AgentResult
-	iteration := 0
 	ae.mu.RLock()
 	maxIterations := 10
 	if ae.config != nil {
@@ -321,10 +682,20 @@ func (ae *AgentEngine) Execute(input string, previousRequests []types.ToolCallDa
 
 	// Iterate until no tool calls or maximum iterations reached
 	for iteration < maxIterations {
+		if ae.stopRequested(state) {
+			ae.logger.LogExecution("Execute", iteration, "Engine stopped, ending execution")
+			finalResult.StoppedReason = StoppedReasonCancelled
+			break
+		}
 		ae.logger.LogExecution("Execute", iteration, fmt.Sprintf("Starting iteration %d/%d", iteration+1, maxIterations))
 
 		// Execute single iteration
-		result, continueIterating, err := ae.executeIteration(messages, iteration)
+		result, continueIterating, err := ae.executeIteration(messages, iteration, state)
+		if err != nil && ae.stopRequested(state) {
+			ae.logger.LogExecution("Execute", iteration, "Execution cancelled during iteration")
+			finalResult.StoppedReason = StoppedReasonCancelled
+			break
+		}
 		if err != nil {
 			ae.logger.LogError("Execute", err, slog.Int("iteration", iteration+1))
 			return nil, errors.NewError(errors.EC_ITERATION_FAILED.Code, fmt.Sprintf("iteration %d failed", iteration+1)).Wrap(err)
@@ -333,6 +704,26 @@ func (ae *AgentEngine) Execute(input string, previousRequests []types.ToolCallDa
 		// Save final result
 		finalResult = result
 
+		// Tool call budget exhausted or the model is stuck, let it conclude without further tools
+		if stoppedReason, notice, stop := ae.earlyStop(result, continueIterating, state); stop {
+			messages = ae.buildNextMessages(messages, result, state)
+			response, err := ae.concludeWithoutTools(messages, notice, state)
+			if err != nil {
+				ae.logger.LogError("Execute", err, slog.String("phase", "conclude_without_tools"))
+				return nil, errors.NewError(errors.EC_CHAT_FAILED.Code, fmt.Sprintf("failed to get final answer after stopping early (%s)", stoppedReason)).Wrap(err)
+			}
+			response, err = ae.handleFinishReason(messages, response, state)
+			if err != nil {
+				return nil, err
+			}
+			finalResult = &AgentResult{
+				Output:        response.Content,
+				StoppedReason: stoppedReason,
+				FinishReason:  response.FinishReason,
+			}
+			break
+		}
+
 		// If no tool calls or continuation not needed, end
 		if !continueIterating || len(result.ToolCalls) == 0 {
 			ae.logger.LogExecution("Execute", iteration, "Execution completed, no more tool calls")
@@ -340,13 +731,14 @@ func (ae *AgentEngine) Execute(input string, previousRequests []types.ToolCallDa
 		}
 
 		// Prepare next round messages
-		messages = ae.buildNextMessages(messages, result)
+		messages = ae.buildNextMessages(messages, result, state)
 		iteration++
+		ae.saveCheckpoint(checkpointID, input, messages, iteration, result, state)
 
 		// Avoid too fast execution - only delay if there are more iterations
 		if iteration < maxIterations {
 			ae.logger.LogExecution("Execute", iteration, "Preparing next iteration")
-			time.Sleep(IterationDelay)
+			state.pause()
 		} else {
 			ae.logger.LogExecution("Execute", iteration, "Reached maximum iterations")
 		}
@@ -355,6 +747,23 @@ func (ae *AgentEngine) Execute(input string, previousRequests []types.ToolCallDa
 	if iteration >= maxIterations {
 		ae.logger.LogExecution("Execute", iteration, fmt.Sprintf("Reached maximum iteration limit: %d", maxIterations))
 	}
+	markEmptyOutput(finalResult, state)
+
+	// Validate the final output, asking the model to repair it on failure
+	ae.mu.RLock()
+	parser := ae.outputParser
+	maxRepairAttempts := 0
+	if ae.config != nil {
+		maxRepairAttempts = ae.config.MaxRepairAttempts
+	}
+	ae.mu.RUnlock()
+	if parser != nil {
+		finalResult.Output, finalResult.ParsedOutput = ae.parseOutputWithRepair(messages, finalResult.Output, parser, maxRepairAttempts, state)
+	}
+	if err := ae.moderateOutput(finalResult.Output); err != nil {
+		ae.deleteCheckpoint(checkpointID)
+		return nil, err
+	}
 
 	executionTime := time.Since(startTime)
 	outputLength := 0
@@ -372,38 +781,21 @@ func (ae *AgentEngine) Execute(input string, previousRequests []types.ToolCallDa
 		outputMap := map[string]interface{}{"output": finalResult.Output}
 		if err := ae.memory.SaveContext(inputMap, outputMap); err != nil {
 			ae.logger.LogError("Execute", err, slog.String("phase", "save_context"))
+			state.addWarning("failed to save conversation to memory: %v", err)
 			// Do not interrupt execution as main flow is complete
 		} else {
-			// Check if memory compression is needed
-			ae.mu.RLock()
-			enableCompress := false
-			compressThreshold := 0
-			if ae.config != nil {
-				enableCompress = ae.config.EnableMemoryCompress
-				compressThreshold = ae.config.MemoryCompressThreshold
-			}
-			ae.mu.RUnlock()
-
-			if enableCompress && compressThreshold > 0 {
-				history, err := ae.memory.GetChatHistory()
-				if err == nil && len(history) > compressThreshold {
-					ae.mu.RLock()
-					llm := ae.model
-					ae.mu.RUnlock()
-					if llm != nil {
-						if err := ae.memory.CompressMemory(llm, compressThreshold); err != nil {
-							ae.logger.LogError("Execute", err, slog.String("phase", "compress_memory"))
-						} else {
-							ae.logger.Info("Memory compressed successfully",
-								slog.Int("original_count", len(history)),
-								slog.Int("threshold", compressThreshold))
-						}
-					}
-				}
-			}
+			ae.compressMemoryIfNeeded("Execute", state)
 		}
 	}
 
+	ae.deleteCheckpoint(checkpointID)
+	finalResult.Citations = state.citations.resolve(finalResult.Output)
+	finalResult.ToolPlans = state.plans
+	ae.summarizeResult(finalResult, state)
+	finalResult.ExecutionID = checkpointID
+	finalResult.Warnings = state.warnings
+	finalResult.Usage = state.usage
+	finalResult.FinishReason = runFinishReason(finalResult)
 	return finalResult, nil
 }
 
@@ -417,38 +809,98 @@ func (ae *AgentEngine) Execute(input string, previousRequests []types.ToolCallDa
 //   - streaming result channel for real-time content delivery during execution
 //   - error information (only during initialization)
 func (ae *AgentEngine) ExecuteStream(input string, previousRequests []types.ToolCallData) (<-chan StreamResult, error) {
+	return ae.ExecuteStreamWithOptions(input, previousRequests, nil)
+}
+
+// ExecuteStreamContext executes the agent task with streaming, cancelling it when ctx is done
+// A cancelled stream ends with an EC_TIMEOUT error event wrapping ctx.Err() instead of the end event
+func (ae *AgentEngine) ExecuteStreamContext(ctx context.Context, input string, previousRequests []types.ToolCallData) (<-chan StreamResult, error) {
+	stream, err := ae.ExecuteStreamWithOptions(input, previousRequests, &ExecuteOptions{Context: ctx})
+	if err != nil {
+		return nil, err
+	}
+
+	resultChan := make(chan StreamResult, DefaultChannelBuffer)
+	go func() {
+		defer close(resultChan)
+		for event := range stream {
+			if ctx.Err() != nil && (event.Type == "error" || (event.Type == "end" && event.Result != nil && event.Result.StoppedReason == StoppedReasonCancelled)) {
+				event = StreamResult{Type: "error", Error: contextDoneError(ctx)}
+			}
+			resultChan <- event
+		}
+	}()
+	return resultChan, nil
+}
+
+// contextDoneError reports an execution ended by its caller's context
+func contextDoneError(ctx context.Context) error {
+	return errors.NewError(errors.EC_TIMEOUT.Code, "execution cancelled by context").Wrap(ctx.Err())
+}
+
+// waitRateLimit waits for a rate limiter token for at most 5 seconds
+// The wait also ends when the engine stops or ExecuteOptions.Context is cancelled; a cancelled
+// request is reported as a context error rather than as overload
+func (ae *AgentEngine) waitRateLimit(operation string, opts *ExecuteOptions) error {
+	ae.mu.RLock()
+	limiter := ae.rateLimiter
+	parent := ae.ctx
+	ae.mu.RUnlock()
+	if limiter == nil {
+		return nil
+	}
+	if parent == nil {
+		parent = context.Background()
+	}
+
+	ctx, cancel := context.WithTimeout(parent, 5*time.Second)
+	defer cancel()
+	requestCtx := opts.context()
+	if requestCtx != nil {
+		stop := context.AfterFunc(requestCtx, cancel)
+		defer stop()
+	}
+	if err := limiter.Wait(ctx); err != nil {
+		ae.logger.LogError(operation, err, slog.String("phase", "rate_limit"))
+		if requestCtx != nil && requestCtx.Err() != nil {
+			return contextDoneError(requestCtx)
+		}
+		return errors.NewError(errors.EC_SYSTEM_OVERLOAD.Code, "rate limit exceeded").Wrap(err)
+	}
+	return nil
+}
+
+// ExecuteStreamWithOptions executes the agent task with streaming and per-request options
+// Options only apply to this call and never modify engine state, nil behaves like ExecuteStream
+func (ae *AgentEngine) ExecuteStreamWithOptions(input string, previousRequests []types.ToolCallData, opts *ExecuteOptions) (<-chan StreamResult, error) {
+	if ae.shuttingDown.Load() {
+		return nil, errors.EC_AGENT_SHUTTING_DOWN
+	}
+	if err := ae.validateConfig(); err != nil {
+		return nil, err
+	}
+	if capability, ok := ae.model.(types.StreamingCapability); ok && !capability.SupportsStreaming() {
+		return ae.executeAsStream(input, previousRequests, opts)
+	}
+	if err := ae.waitPendingSave(opts.context()); err != nil {
+		return nil, err
+	}
 	if !ae.isRunning.CompareAndSwap(false, true) {
 		return nil, errors.EC_AGENT_BUSY
 	}
 
 	resultChan := make(chan StreamResult, DefaultChannelBuffer)
+	startTime := time.Now()
 
 	go func() {
 		defer close(resultChan)
 		defer ae.isRunning.Store(false)
 
-		startTime := time.Now()
 		ae.logger.LogExecution("ExecuteStream", 0, "Starting stream execution", slog.String("input", truncateString(input, 100)), slog.Int("previousRequests", len(previousRequests)))
 
-		ae.mu.RLock()
-		limiter := ae.rateLimiter
-		ctx := ae.ctx
-		ae.mu.RUnlock()
-
-		if limiter != nil {
-			if ctx == nil {
-				ctx = context.Background()
-			}
-			ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
-			defer cancel()
-			if err := limiter.Wait(ctx); err != nil {
-				ae.logger.LogError("ExecuteStream", err, slog.String("phase", "rate_limit"))
-				resultChan <- StreamResult{
-					Type:  "error",
-					Error: errors.NewError(errors.EC_SYSTEM_OVERLOAD.Code, "rate limit exceeded").Wrap(err),
-				}
-				return
-			}
+		if err := ae.waitRateLimit("ExecuteStream", opts); err != nil {
+			resultChan <- StreamResult{Type: "error", Error: err}
+			return
 		}
 
 		defer func() {
@@ -462,18 +914,21 @@ func (ae *AgentEngine) ExecuteStream(input string, previousRequests []types.Tool
 		}()
 
 		// Prepare initial messages
-		messages, err := ae.prepareMessages(input, previousRequests)
+		messages, err := ae.prepareMessages(input, previousRequests, opts)
 		if err != nil {
 			ae.logger.LogError("ExecuteStream", err, slog.String("phase", "prepare_messages"))
+			if !isModerationError(err) {
+				err = errors.NewError(errors.EC_PREPARE_MESSAGES_FAILED.Code, "failed to prepare messages").Wrap(err)
+			}
 			resultChan <- StreamResult{
 				Type:  "error",
-				Error: errors.NewError(errors.EC_PREPARE_MESSAGES_FAILED.Code, "failed to prepare messages").Wrap(err),
+				Error: err,
 			}
 			return
 		}
 
 		// Stream iterative execution
-		ae.executeStreamWithIterations(messages, resultChan)
+		ae.executeStreamWithIterations(messages, resultChan, opts, startTime)
 
 		ae.logger.LogExecution("ExecuteStream", 0, "Stream execution completed", slog.Duration("total_duration", time.Since(startTime)))
 	}()
@@ -481,22 +936,99 @@ func (ae *AgentEngine) ExecuteStream(input string, previousRequests []types.Tool
 	return resultChan, nil
 }
 
+// executeAsStream runs a blocking Execute for models without streaming support
+// and delivers the full output as a single chunk followed by the end event
+func (ae *AgentEngine) executeAsStream(input string, previousRequests []types.ToolCallData, opts *ExecuteOptions) (<-chan StreamResult, error) {
+	if ae.isRunning.Load() {
+		return nil, errors.EC_AGENT_BUSY
+	}
+	ae.logger.Info("Model does not support streaming, falling back to blocking execution",
+		slog.String("model", ae.model.GetModelName()))
+
+	resultChan := make(chan StreamResult, MinChannelBuffer)
+
+	go func() {
+		defer close(resultChan)
+
+		result, err := ae.ExecuteWithOptions(input, previousRequests, opts)
+		if err != nil {
+			resultChan <- StreamResult{
+				Type:  "error",
+				Error: err,
+			}
+			return
+		}
+
+		for _, warning := range result.Warnings {
+			resultChan <- StreamResult{
+				Type:    "warning",
+				Content: warning,
+			}
+		}
+		if result.Output != "" {
+			resultChan <- StreamResult{
+				Type:    "chunk",
+				Content: result.Output,
+			}
+		}
+		resultChan <- StreamResult{
+			Type:         "end",
+			Result:       result,
+			FinishReason: result.FinishReason,
+		}
+	}()
+
+	return resultChan, nil
+}
+
 // prepareMessages prepares messages
 // Builds a complete message list including system messages, chat history, tool call context, and user input
 // Parameters:
 //   - input: user input
 //   - previousRequests: previous tool call requests
+//   - opts: per-request options (may be nil)
 //
 // Returns:
 //   - built message list
 //   - error information
-func (ae *AgentEngine) prepareMessages(input string, previousRequests []types.ToolCallData) ([]types.Message, error) {
+func (ae *AgentEngine) prepareMessages(input string, previousRequests []types.ToolCallData, opts *ExecuteOptions) ([]types.Message, error) {
+	if err := ae.moderateInput(input); err != nil {
+		return nil, err
+	}
+	messages, _, err := ae.prepareMessagesWithSources(input, previousRequests, opts)
+	return messages, err
+}
+
+// PreparePreview builds the prompt for the given input without calling the model
+// Each message is annotated with its source (system prompt, memory history, injected context, or user input),
+// which helps diagnose why the model sees a particular message
+func (ae *AgentEngine) PreparePreview(input string, previousRequests []types.ToolCallData) (*PromptPreview, error) {
+	messages, sources, err := ae.prepareMessagesWithSources(input, previousRequests, nil)
+	if err != nil {
+		return nil, errors.NewError(errors.EC_PREPARE_MESSAGES_FAILED.Code, errors.EC_PREPARE_MESSAGES_FAILED.Message).Wrap(err)
+	}
+
+	preview := &PromptPreview{
+		Messages: make([]PreviewMessage, len(messages)),
+	}
+	for i, msg := range messages {
+		preview.Messages[i] = PreviewMessage{
+			Source:  sources[i],
+			Message: msg,
+		}
+	}
+	return preview, nil
+}
+
+// prepareMessagesWithSources prepares messages along with a parallel slice of message sources
+// Sources are kept out of types.Message so they are never sent to the provider
+func (ae *AgentEngine) prepareMessagesWithSources(input string, previousRequests []types.ToolCallData, opts *ExecuteOptions) ([]types.Message, []string, error) {
 	var history []types.Message
 	var historyErr error
 	if ae.memory != nil {
 		history, historyErr = ae.memory.GetChatHistory()
 		if historyErr != nil {
-			return nil, errors.NewError(errors.EC_MEMORY_HISTORY_FAILED.Code, errors.EC_MEMORY_HISTORY_FAILED.Message).Wrap(historyErr)
+			return nil, nil, errors.NewError(errors.EC_MEMORY_HISTORY_FAILED.Code, errors.EC_MEMORY_HISTORY_FAILED.Message).Wrap(historyErr)
 		}
 	}
 
@@ -504,20 +1036,27 @@ func (ae *AgentEngine) prepareMessages(input string, previousRequests []types.To
 	config := ae.config
 	ae.mu.RUnlock()
 
+	systemPrompt, err := resolveSystemPrompt(config, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	estimatedSize := 1 +
 		len(history) +
 		len(previousRequests)
-	if config != nil && config.SystemMessage != "" {
+	if systemPrompt != "" {
 		estimatedSize++
 	}
 
 	messages := make([]types.Message, 0, estimatedSize)
+	sources := make([]string, 0, estimatedSize)
 
-	if config != nil && config.SystemMessage != "" {
+	if systemPrompt != "" {
 		messages = append(messages, types.Message{
 			Role:    "system",
-			Content: config.SystemMessage,
+			Content: systemPrompt,
 		})
+		sources = append(sources, MessageSourceSystem)
 	}
 
 	if len(history) > 0 {
@@ -525,6 +1064,9 @@ func (ae *AgentEngine) prepareMessages(input string, previousRequests []types.To
 			history = history[len(history)-config.MaxHistoryMessages:]
 		}
 		messages = append(messages, history...)
+		for range history {
+			sources = append(sources, MessageSourceMemory)
+		}
 	}
 
 	// Add tool call context if previous requests exist
@@ -534,6 +1076,17 @@ func (ae *AgentEngine) prepareMessages(input string, previousRequests []types.To
 			Role:    "system",
 			Content: context,
 		})
+		sources = append(sources, MessageSourceInjectedContext)
+	}
+
+	// Add request-scoped documents, summarized if they exceed the token budget
+	if opts != nil && len(opts.Documents) > 0 {
+		documentContext := ae.buildDocumentContext(input, opts.Documents)
+		messages = append(messages, types.Message{
+			Role:    "system",
+			Content: documentContext,
+		})
+		sources = append(sources, MessageSourceDocument)
 	}
 
 	// Add user input
@@ -541,8 +1094,60 @@ func (ae *AgentEngine) prepareMessages(input string, previousRequests []types.To
 		Role:    "user",
 		Content: input,
 	})
+	sources = append(sources, MessageSourceUser)
+
+	if config != nil && config.MergeSystemMessages {
+		messages, sources = mergeSystemMessages(messages, sources)
+	}
+
+	return messages, sources, nil
+}
+
+// resolveSystemPrompt returns the per-request system prompt if set, the engine prompt otherwise
+func resolveSystemPrompt(config *types.AgentConfig, opts *ExecuteOptions) (string, error) {
+	if opts != nil && opts.SystemPrompt != "" {
+		if n := utf8.RuneCountInString(opts.SystemPrompt); n > MaxSystemPromptLength {
+			return "", errors.EC_PARAMETER_INVALID.Wrap(fmt.Errorf("system prompt has %d characters, at most %d are allowed", n, MaxSystemPromptLength))
+		}
+		return opts.SystemPrompt, nil
+	}
+	if config != nil {
+		return config.SystemMessage, nil
+	}
+	return "", nil
+}
+
+// mergeSystemMessages concatenates all system messages, in order, into a single leading system message
+// Some providers only honor the first system message, this keeps the prompt identical across providers
+func mergeSystemMessages(messages []types.Message, sources []string) ([]types.Message, []string) {
+	var systemParts []string
+	systemSource := ""
+	for i, msg := range messages {
+		if msg.Role == "system" {
+			systemParts = append(systemParts, msg.Content)
+			if systemSource == "" {
+				systemSource = sources[i]
+			}
+		}
+	}
+	if len(systemParts) == 0 || (len(systemParts) == 1 && messages[0].Role == "system") {
+		return messages, sources
+	}
 
-	return messages, nil
+	merged := make([]types.Message, 0, len(messages)-len(systemParts)+1)
+	mergedSources := make([]string, 0, cap(merged))
+	merged = append(merged, types.Message{
+		Role:    "system",
+		Content: strings.Join(systemParts, "\n\n"),
+	})
+	mergedSources = append(mergedSources, systemSource)
+	for i, msg := range messages {
+		if msg.Role != "system" {
+			merged = append(merged, msg)
+			mergedSources = append(mergedSources, sources[i])
+		}
+	}
+	return merged, mergedSources
 }
 
 // buildContextFromPreviousRequests builds context from previous requests
@@ -557,29 +1162,207 @@ func (ae *AgentEngine) buildContextFromPreviousRequests(requests []types.ToolCal
 	return builder.String()
 }
 
+// earlyStop decides whether an iteration that wants to continue must conclude instead
+// Returns the stop reason and the notice asking the model for its final answer
+func (ae *AgentEngine) earlyStop(result *AgentResult, continueIterating bool, state *executionState) (string, types.Message, bool) {
+	if !continueIterating {
+		return "", types.Message{}, false
+	}
+	if state.toolBudgetExhausted() {
+		ae.logger.Info("Tool call budget exhausted, requesting final answer",
+			slog.Int("tool_calls", state.toolCalls),
+			slog.Int("max_tool_calls", state.maxToolCalls))
+		return StoppedReasonMaxToolCalls, toolBudgetNotice(state), true
+	}
+	if len(result.ToolCalls) > 0 && state.recordResponse(result.Output) {
+		ae.logger.Info("Model repeated the same response, requesting final answer",
+			slog.Int("repeated_responses", state.repeatedResponses))
+		state.addWarning("model returned the same response %d times in a row, stopped for lack of progress", state.repeatedResponses)
+		return StoppedReasonNoProgress, noProgressNotice(state), true
+	}
+	return "", types.Message{}, false
+}
+
+// noProgressNotice builds the message telling the model it is repeating itself
+func noProgressNotice(state *executionState) types.Message {
+	return types.Message{
+		Role: "user",
+		Content: fmt.Sprintf("You have given the same response %d times in a row without making progress. "+
+			"Do not request any more tools; provide your final answer based on the information gathered so far.", state.repeatedResponses),
+	}
+}
+
+// toolBudgetNotice builds the message telling the model that no more tool calls are allowed
+func toolBudgetNotice(state *executionState) types.Message {
+	return types.Message{
+		Role: "user",
+		Content: fmt.Sprintf("The tool call limit for this task (%d calls) has been reached. "+
+			"Do not request any more tools; provide your final answer based on the information gathered so far.", state.maxToolCalls),
+	}
+}
+
+// compressMemoryIfNeeded compresses the chat history once a turn has been saved
+// Compression is triggered by message count (EnableMemoryCompress + MemoryCompressThreshold)
+// or by estimated history tokens exceeding CompactAtContextFraction of ContextWindow
+func (ae *AgentEngine) compressMemoryIfNeeded(caller string, state *executionState) {
+	ae.mu.RLock()
+	enableCompress := false
+	compressThreshold := 0
+	compactFraction := float32(0)
+	contextWindow := 0
+	compressRatio := float32(0)
+	if ae.config != nil {
+		enableCompress = ae.config.EnableMemoryCompress
+		compressThreshold = ae.config.MemoryCompressThreshold
+		compactFraction = ae.config.CompactAtContextFraction
+		contextWindow = ae.config.ContextWindow
+		compressRatio = ae.config.MemoryCompressRatio
+	}
+	llm := ae.model
+	counter := ae.tokenCounter
+	ae.mu.RUnlock()
+
+	countDriven := enableCompress && compressThreshold > 0
+	tokenDriven := compactFraction > 0 && contextWindow > 0
+	if (!countDriven && !tokenDriven) || llm == nil {
+		return
+	}
+
+	history, err := ae.memory.GetChatHistory()
+	if err != nil {
+		return
+	}
+
+	keep := len(history)
+	reason := ""
+	if countDriven && len(history) > compressThreshold {
+		keep = compressThreshold
+		reason = "message_count"
+	}
+	if tokenDriven {
+		tokens := types.CountMessageTokens(counter, history)
+		if limit := int(float32(contextWindow) * compactFraction); tokens > limit {
+			// Keep the most recent share of the history, summarize the rest
+			if compressRatio <= 0 || compressRatio >= 1 {
+				compressRatio = 0.5
+			}
+			if tokenKeep := int(float32(len(history)) * compressRatio); tokenKeep < keep {
+				keep = tokenKeep
+				reason = "context_tokens"
+			}
+			ae.logger.Info("History exceeds context budget",
+				slog.Int("estimated_tokens", tokens),
+				slog.Int("token_limit", limit))
+		}
+	}
+	if reason == "" {
+		return
+	}
+	if keep < 1 {
+		keep = 1
+	}
+
+	if err := ae.memory.CompressMemory(llm, keep); err != nil {
+		ae.logger.LogError(caller, err, slog.String("phase", "compress_memory"))
+		state.addWarning("failed to compress memory: %v", err)
+		return
+	}
+	ae.logger.Info("Memory compressed successfully",
+		slog.Int("original_count", len(history)),
+		slog.Int("kept_messages", keep),
+		slog.String("reason", reason))
+}
+
+// concludeWithoutTools asks the model for a final answer once tool calls are no longer allowed
+// notice tells the model why it has to conclude
+func (ae *AgentEngine) concludeWithoutTools(messages []types.Message, notice types.Message, state *executionState) (types.Message, error) {
+	if ae.model == nil {
+		return types.Message{}, errors.NewError(errors.EC_LLM_CALL_FAILED.Code, "LLM model provider is nil")
+	}
+	response, err := ae.model.Chat(append(messages, notice))
+	state.addModelWarnings(response)
+	state.addUsage(response.Usage)
+	return response, err
+}
+
+// parseOutputWithRepair parses the output with the output parser
+// On failure the parser error is fed back to the model as a corrective message, up to maxAttempts times
+// Returns the (possibly repaired) output and its parsed value. If the output still fails to parse, the
+// original output is returned with a nil value and a warning, the run itself does not fail
+func (ae *AgentEngine) parseOutputWithRepair(messages []types.Message, output string, parser types.OutputParser, maxAttempts int, state *executionState) (string, interface{}) {
+	parsed, parseErr := parser.Parse(output)
+	if parseErr == nil {
+		return output, parsed
+	}
+	rawOutput := output
+
+	repairMessages := make([]types.Message, len(messages), len(messages)+2*maxAttempts)
+	copy(repairMessages, messages)
+
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		ae.logger.Info("Output failed validation, requesting repair",
+			slog.Int("attempt", attempt),
+			slog.Int("max_attempts", maxAttempts),
+			slog.String("error", parseErr.Error()))
+		state.addWarning("output failed validation (repair attempt %d/%d): %v", attempt, maxAttempts, parseErr)
+
+		correction := fmt.Sprintf("Your previous response failed validation: %v\n"+
+			"Please respond again with a corrected answer that fixes this error.", parseErr)
+		if instructions := parser.GetFormatInstructions(); instructions != "" {
+			correction += "\n" + instructions
+		}
+		repairMessages = append(repairMessages,
+			types.Message{Role: "assistant", Content: output},
+			types.Message{Role: "user", Content: correction},
+		)
+
+		if ae.model == nil {
+			state.addWarning("failed to repair output: LLM model provider is nil, returning the unparsed output")
+			return rawOutput, nil
+		}
+		response, err := ae.model.Chat(repairMessages)
+		if err != nil {
+			ae.logger.LogError("parseOutputWithRepair", err, slog.Int("attempt", attempt))
+			state.addWarning("failed to repair output: %v, returning the unparsed output", err)
+			return rawOutput, nil
+		}
+		state.addUsage(response.Usage)
+
+		output = response.Content
+		if parsed, parseErr = parser.Parse(output); parseErr == nil {
+			return output, parsed
+		}
+	}
+
+	state.addWarning("output failed validation, returning the unparsed output: %v", parseErr)
+	return rawOutput, nil
+}
+
 // executeIteration executes a single iteration
 // Processes one round of LLM calling and tool execution, supporting caching and error handling
 // Parameters:
 //   - messages: current round messages
 //   - iteration: current iteration index
+//   - state: per-run accounting (tool call budget)
 //
 // Returns:
 //   - execution result
 //   - whether to continue iteration
 //   - error information
-func (ae *AgentEngine) executeIteration(messages []types.Message, iteration int) (*AgentResult, bool, error) {
+func (ae *AgentEngine) executeIteration(messages []types.Message, iteration int, state *executionState) (*AgentResult, bool, error) {
 	ae.mu.RLock()
 	maxIterations := 10
 	timeout := time.Duration(0)
-	toolExecutionTimeout := time.Duration(0)
 	if ae.config != nil {
 		maxIterations = ae.config.MaxIterations
 		timeout = ae.config.Timeout
-		toolExecutionTimeout = ae.config.ToolExecutionTimeout
 	}
-	tools := ae.tools
+	tools := state.visibleTools(ae.enabledTools())
 	ctx := ae.ctx
 	ae.mu.RUnlock()
+	if state.ctx != nil {
+		ctx = state.ctx
+	}
 	startTime := time.Now()
 	ae.logger.LogExecution("executeIteration", iteration, fmt.Sprintf("Starting iteration %d/%d", iteration+1, maxIterations))
 
@@ -597,14 +1380,43 @@ func (ae *AgentEngine) executeIteration(messages []types.Message, iteration int)
 		return nil, false, errors.NewError(errors.EC_LLM_CALL_FAILED.Code, "LLM model provider is nil")
 	}
 
-	response, err := ae.model.ChatWithTools(messages, tools)
+	messages = ae.withBudgetHint(messages, iteration, maxIterations, state)
+
+	// Tool-less agents use plain chat, some providers reject tool plumbing with an empty tool list
+	var response types.Message
+	var err error
+	if len(tools) == 0 {
+		response, err = awaitModel(state, func() (types.Message, error) { return ae.model.Chat(messages) })
+	} else {
+		definitions := ae.toolDefinitions(tools, iteration)
+		response, err = awaitModel(state, func() (types.Message, error) { return ae.model.ChatWithTools(messages, definitions) })
+	}
 	if err != nil {
 		ae.logger.LogError("executeIteration", err, slog.Int("iteration", iteration))
 		return nil, false, errors.NewError(errors.EC_CHAT_FAILED.Code, "failed to chat with tools").Wrap(err)
 	}
+	state.addModelWarnings(response)
+	state.addUsage(response.Usage)
+	iterationUsage := response.Usage
+
+	response, err = ae.handleFinishReason(messages, response, state)
+	if err != nil {
+		return nil, false, err
+	}
 
 	result := &AgentResult{
-		Output: response.Content,
+		Output:       response.Content,
+		FinishReason: response.FinishReason,
+		Usage:        iterationUsage,
+	}
+
+	// No tools are registered, so any requested tool call cannot be served
+	if len(tools) == 0 && len(response.ToolCalls) > 0 {
+		ae.logger.Info("LLM requested tool calls but no tools are registered, ignoring",
+			slog.Int("tool_count", len(response.ToolCalls)),
+			slog.Int("iteration", iteration+1))
+		state.addWarning("model requested %d tool call(s) but no tools are registered", len(response.ToolCalls))
+		return result, false, nil
 	}
 
 	// Handle tool calls
@@ -617,117 +1429,26 @@ func (ae *AgentEngine) executeIteration(messages []types.Message, iteration int)
 			ae.logger.Info("Reached maximum iterations, skipping tool execution",
 				slog.Int("iteration", iteration+1),
 				slog.Int("max_iterations", maxIterations))
+			state.addWarning("maximum iterations (%d) reached, %d requested tool call(s) were not executed", maxIterations, len(response.ToolCalls))
+			result.StoppedReason = StoppedReasonMaxIterations
 			return result, false, nil
 		}
 
 		// Sort tool calls by priority and dependencies
-		sortedToolCalls, err := ae.sortToolCallsByDependencies(response.ToolCalls)
-		if err != nil {
-			ae.logger.LogError("executeIteration", err, slog.String("phase", "sort_tool_calls"))
-			// Continue with original order if sorting fails
-			sortedToolCalls = response.ToolCalls
+		sortedToolCalls := ae.planToolCalls(response.ToolCalls, iteration, state)
+
+		sortedToolCallRequests := make([]types.ToolCallRequest, 0, len(sortedToolCalls))
+		for _, tc := range sortedToolCalls {
+			sortedToolCallRequests = append(sortedToolCallRequests, types.ToolCallToRequest(tc))
 		}
 
 		toolCalls := make([]types.ToolCallRequest, 0, len(sortedToolCalls))
 		intermediateSteps := make([]types.ToolCallData, 0, len(sortedToolCalls))
-
-		for _, toolCall := range sortedToolCalls {
-			ae.logger.Info("Executing tool",
-				slog.String("tool_name", toolCall.Function.Name),
-				slog.Int("iteration", iteration+1))
-
-			ae.mu.RLock()
-			tool, exists := ae.toolsMap[toolCall.Function.Name]
-			ae.mu.RUnlock()
-			if !exists {
-				errMsg := fmt.Sprintf("tool '%s' not found in available tools", toolCall.Function.Name)
-				ae.logger.Info("Tool not found",
-					slog.String("tool_name", toolCall.Function.Name),
-					slog.Int("iteration", iteration+1))
-				intermediateSteps = append(intermediateSteps, types.ToolCallData{
-					Action: types.ToolActionStep{
-						Tool:       toolCall.Function.Name,
-						ToolInput:  toolCall.Function.Arguments,
-						ToolCallID: toolCall.ID,
-						Type:       toolCall.Type,
-					},
-					Observation: errMsg,
-				})
-				continue
+		for _, outcome := range ae.executeToolCalls(ctx, sortedToolCallRequests, iteration, state) {
+			if outcome.executed {
+				toolCalls = append(toolCalls, outcome.call)
 			}
-
-			// Check cache
-			toolStartTime := time.Now()
-			toolResult, err, cached := ae.getCachedToolResult(toolCall.Function.Name, toolCall.Function.Arguments)
-			if cached {
-				ae.logger.LogToolExecution(toolCall.Function.Name, true, 0, slog.Bool("cached", true))
-				if err != nil {
-					errMsg := fmt.Sprintf("Tool '%s' execution failed (cached error): %v", toolCall.Function.Name, err)
-					ae.logger.LogToolExecution(toolCall.Function.Name, false, 0,
-						slog.String("error", err.Error()),
-						slog.Bool("cached", true))
-					intermediateSteps = append(intermediateSteps, types.ToolCallData{
-						Action: types.ToolActionStep{
-							Tool:       toolCall.Function.Name,
-							ToolInput:  toolCall.Function.Arguments,
-							ToolCallID: toolCall.ID,
-							Type:       toolCall.Type,
-						},
-						Observation: errMsg,
-					})
-					continue
-				}
-			} else {
-				// Execute tool with timeout
-				toolResult, err = ae.executeToolWithTimeout(tool, toolCall.Function.Arguments, toolExecutionTimeout)
-				duration := time.Since(toolStartTime)
-
-				if err != nil {
-					errMsg := fmt.Sprintf("Tool '%s' execution failed: %v", toolCall.Function.Name, err)
-					ae.logger.LogToolExecution(toolCall.Function.Name, false, duration,
-						slog.String("error", err.Error()),
-						slog.String("tool_input", fmt.Sprintf("%v", toolCall.Function.Arguments)))
-					intermediateSteps = append(intermediateSteps, types.ToolCallData{
-						Action: types.ToolActionStep{
-							Tool:       toolCall.Function.Name,
-							ToolInput:  toolCall.Function.Arguments,
-							ToolCallID: toolCall.ID,
-							Type:       toolCall.Type,
-						},
-						Observation: errMsg,
-					})
-					continue
-				}
-
-				// Cache tool result
-				ae.setCachedToolResult(toolCall.Function.Name, toolCall.Function.Arguments, toolResult, err)
-				ae.logger.LogToolExecution(toolCall.Function.Name, true, duration, slog.Bool("cached", false))
-			}
-
-			ae.logger.Info("Tool executed successfully",
-				slog.String("tool_name", toolCall.Function.Name),
-				slog.Int("iteration", iteration+1))
-
-			toolCalls = append(toolCalls, types.ToolCallRequest{
-				Tool:       toolCall.Function.Name,
-				ToolInput:  toolCall.Function.Arguments,
-				ToolCallID: toolCall.ID,
-				Type:       toolCall.Type,
-			})
-
-			// Format observation from tool result
-			truncationLength := ae.getToolTruncationLength(toolCall.Function.Name)
-			observation := truncateString(formatToolResult(toolResult), truncationLength)
-
-			intermediateSteps = append(intermediateSteps, types.ToolCallData{
-				Action: types.ToolActionStep{
-					Tool:       toolCall.Function.Name,
-					ToolInput:  toolCall.Function.Arguments,
-					ToolCallID: toolCall.ID,
-					Type:       toolCall.Type,
-				},
-				Observation: observation,
-			})
+			intermediateSteps = append(intermediateSteps, outcome.step)
 		}
 
 		result.ToolCalls = toolCalls
@@ -750,7 +1471,7 @@ func (ae *AgentEngine) executeIteration(messages []types.Message, iteration int)
 // ==================== Message Building Methods ====================
 
 // buildNextMessages builds messages for the next round
-func (ae *AgentEngine) buildNextMessages(previousMessages []types.Message, result *AgentResult) []types.Message {
+func (ae *AgentEngine) buildNextMessages(previousMessages []types.Message, result *AgentResult, state *executionState) []types.Message {
 	// Keep system messages, user's original question, and assistant's previous response
 	// Pre-allocate slice capacity: system messages + user message + assistant response + tool results
 	messages := make([]types.Message, 0, 4)
@@ -770,20 +1491,23 @@ func (ae *AgentEngine) buildNextMessages(previousMessages []types.Message, resul
 		}
 	}
 
-	// Keep assistant's previous response if it has content
-	// This preserves context between iterations
-	if result != nil && result.Output != "" {
-		// Convert ToolCallRequest to ToolCall for message format
+	if result == nil {
+		return messages
+	}
+	toolResults := ae.toolResultMessages(result.IntermediateSteps, state)
+
+	// Keep assistant's previous response if it has content, or its tool calls when they are answered
+	// by native tool messages. This preserves context between iterations
+	nativeResults := hasToolMessages(toolResults)
+	if result.Output != "" || nativeResults {
 		toolCalls := make([]types.ToolCall, 0, len(result.ToolCalls))
 		for _, tc := range result.ToolCalls {
-			toolCalls = append(toolCalls, types.ToolCall{
-				ID:   tc.ToolCallID,
-				Type: tc.Type,
-				Function: types.ToolFunction{
-					Name:      tc.Tool,
-					Arguments: tc.ToolInput,
-				},
-			})
+			toolCalls = append(toolCalls, types.ToolCallRequestToToolCall(tc))
+		}
+		if nativeResults {
+			// Every tool message must answer a tool call of the assistant message, including
+			// calls that failed or were skipped and are therefore missing from result.ToolCalls
+			toolCalls = stepToolCalls(result.IntermediateSteps)
 		}
 		messages = append(messages, types.Message{
 			Role:      "assistant",
@@ -792,32 +1516,13 @@ func (ae *AgentEngine) buildNextMessages(previousMessages []types.Message, resul
 		})
 	}
 
-	// Build summary of tool execution results
-	var toolResults strings.Builder
-	if result != nil && len(result.IntermediateSteps) > 0 {
-		toolResults.WriteString("Based on previous tool execution results:\n")
-		for _, step := range result.IntermediateSteps {
-			toolResults.WriteString(fmt.Sprintf("- Tool %s returned: %s\n", step.Action.Tool, step.Observation))
-		}
-		toolResults.WriteString("\nPlease continue analysis or complete the task based on these results.")
-	}
-
-	// Add tool call results to messages
-	if toolResults.Len() > 0 {
-		toolResultMessage := types.Message{
-			Role:    "user",
-			Content: toolResults.String(),
-		}
-		messages = append(messages, toolResultMessage)
-	}
-
-	return messages
+	return append(messages, toolResults...)
 }
 
 // ==================== Streaming Execution Methods ====================
 
 // executeStreamWithIterations executes streaming iterations (supports multi-round tool calling)
-func (ae *AgentEngine) executeStreamWithIterations(initialMessages []types.Message, resultChan chan<- StreamResult) {
+func (ae *AgentEngine) executeStreamWithIterations(initialMessages []types.Message, resultChan chan<- StreamResult, opts *ExecuteOptions, startTime time.Time) {
 	messages := initialMessages
 	finalResult := &AgentResult{}
 
@@ -826,19 +1531,37 @@ func (ae *AgentEngine) executeStreamWithIterations(initialMessages []types.Messa
 	if ae.config != nil {
 		maxIterations = ae.config.MaxIterations
 	}
+	state := newExecutionState(ae.config)
 	ae.mu.RUnlock()
+	state.emit = func(event StreamResult) {
+		resultChan <- event
+	}
+	state.timing = newStreamTiming(startTime)
+	ae.applyToolAllowlist(state, opts)
+	release := ae.applyExecutionContext(state, opts)
+	defer release()
 
 	estimatedToolCalls := maxIterations * 3
 	toolCalls := make([]types.ToolCallRequest, 0, estimatedToolCalls)
 	intermediateSteps := make([]types.ToolCallData, 0, estimatedToolCalls)
 
 	for iteration := 0; iteration < maxIterations; iteration++ {
+		if ae.stopRequested(state) {
+			ae.logger.LogExecution("executeStreamWithIterations", iteration, "Engine stopped, ending execution")
+			finalResult.StoppedReason = StoppedReasonCancelled
+			break
+		}
 		iterationStartTime := time.Now()
 		ae.logger.LogExecution("executeStreamWithIterations", iteration,
 			fmt.Sprintf("Starting streaming iteration %d/%d", iteration+1, maxIterations))
 
 		// Execute single round iteration with streaming
-		iterationResult, hasMore, err := ae.executeStreamIteration(messages, resultChan, iteration)
+		iterationResult, hasMore, err := ae.executeStreamIteration(messages, resultChan, iteration, state)
+		if err != nil && ae.stopRequested(state) {
+			ae.logger.LogExecution("executeStreamWithIterations", iteration, "Execution cancelled during iteration")
+			finalResult.StoppedReason = StoppedReasonCancelled
+			break
+		}
 		if err != nil {
 			ae.logger.LogError("executeStreamWithIterations", err, slog.Int("iteration", iteration+1))
 			resultChan <- StreamResult{
@@ -850,6 +1573,8 @@ func (ae *AgentEngine) executeStreamWithIterations(initialMessages []types.Messa
 
 		// Accumulate final result
 		finalResult.Output = iterationResult.Output
+		finalResult.StoppedReason = iterationResult.StoppedReason
+		finalResult.FinishReason = iterationResult.FinishReason
 		toolCalls = append(toolCalls, iterationResult.ToolCalls...)
 		intermediateSteps = append(intermediateSteps, iterationResult.IntermediateSteps...)
 
@@ -862,64 +1587,211 @@ func (ae *AgentEngine) executeStreamWithIterations(initialMessages []types.Messa
 			break
 		}
 
+		// Tool call budget exhausted or the model is stuck, let it conclude without further tools
+		if stoppedReason, notice, stop := ae.earlyStop(iterationResult, hasMore, state); stop {
+			messages = ae.buildNextMessages(messages, iterationResult, state)
+			output, finishReason, err := ae.streamConclusionWithoutTools(messages, notice, state, resultChan)
+			if err != nil {
+				ae.logger.LogError("executeStreamWithIterations", err, slog.String("phase", "conclude_without_tools"))
+				resultChan <- StreamResult{
+					Type:  "error",
+					Error: errors.NewError(errors.EC_STREAM_CHAT_FAILED.Code, fmt.Sprintf("failed to get final answer after stopping early (%s)", stoppedReason)).Wrap(err),
+				}
+				return
+			}
+			finalResult.Output = output
+			finalResult.StoppedReason = stoppedReason
+			finalResult.FinishReason = finishReason
+			break
+		}
+
 		if iteration+1 < maxIterations {
 			ae.logger.LogExecution("executeStreamWithIterations", iteration, "Preparing next iteration messages")
-			messages = ae.buildNextMessages(messages, iterationResult)
+			messages = ae.buildNextMessages(messages, iterationResult, state)
+			state.pause()
 		} else {
 			ae.logger.LogExecution("executeStreamWithIterations", iteration, "Reached maximum iterations")
 		}
 	}
 
-	// Save to memory system
-	if ae.memory != nil && len(initialMessages) > 0 {
-		input := map[string]interface{}{"input": initialMessages[len(initialMessages)-1].Content}
-		output := map[string]interface{}{"output": finalResult.Output}
-		if err := ae.memory.SaveContext(input, output); err != nil {
-			ae.logger.LogError("executeStreamWithIterations", err, slog.String("phase", "save_context"))
-			// Do not interrupt execution as main flow is complete
+	markEmptyOutput(finalResult, state)
+
+	// Chunks already streamed cannot be repaired, so the output is parsed once without repair attempts
+	ae.mu.RLock()
+	parser := ae.outputParser
+	ae.mu.RUnlock()
+	if parser != nil {
+		if parsed, err := parser.Parse(finalResult.Output); err != nil {
+			state.addWarning("output failed validation, returning the unparsed output: %v", err)
 		} else {
-			// Check if memory compression is needed
-			ae.mu.RLock()
-			enableCompress := false
-			compressThreshold := 0
-			if ae.config != nil {
-				enableCompress = ae.config.EnableMemoryCompress
-				compressThreshold = ae.config.MemoryCompressThreshold
-			}
-			ae.mu.RUnlock()
-
-			if enableCompress && compressThreshold > 0 {
-				history, err := ae.memory.GetChatHistory()
-				if err == nil && len(history) > compressThreshold {
-					ae.mu.RLock()
-					llm := ae.model
-					ae.mu.RUnlock()
-					if llm != nil {
-						if err := ae.memory.CompressMemory(llm, compressThreshold); err != nil {
-							ae.logger.LogError("executeStreamWithIterations", err, slog.String("phase", "compress_memory"))
-						} else {
-							ae.logger.Info("Memory compressed successfully",
-								slog.Int("original_count", len(history)),
-								slog.Int("threshold", compressThreshold))
-						}
-					}
-				}
-			}
+			finalResult.ParsedOutput = parsed
 		}
 	}
 
-	// Set final result's tool calls and intermediate steps
+	if err := ae.moderateOutput(finalResult.Output); err != nil {
+		// Chunks already streamed cannot be recalled, the error tells clients to discard them
+		resultChan <- StreamResult{
+			Type:  "error",
+			Error: err,
+		}
+		return
+	}
+
+	ae.mu.RLock()
+	asyncMemorySave := ae.config != nil && ae.config.AsyncMemorySave
+	includeStreamMetrics := ae.config != nil && ae.config.IncludeStreamMetrics
+	ae.mu.RUnlock()
+
+	ae.streamStats.record(ae.modelName(), state.timing)
+	if includeStreamMetrics {
+		finalResult.StreamMetrics = state.timing.metrics()
+	}
+
+	// Save to memory system
+	if !asyncMemorySave {
+		ae.saveStreamMemory(initialMessages, finalResult.Output, state)
+	}
+
+	// Set final result's tool calls, intermediate steps and warnings
 	finalResult.ToolCalls = toolCalls
 	finalResult.IntermediateSteps = intermediateSteps
+	finalResult.Citations = state.citations.resolve(finalResult.Output)
+	finalResult.ToolPlans = state.plans
+	ae.summarizeResult(finalResult, state)
+	finalResult.Warnings = state.warnings
+	finalResult.Usage = state.usage
+	finalResult.FinishReason = runFinishReason(finalResult)
 
 	ae.logger.LogExecution("executeStreamWithIterations", 0, "Stream execution completed successfully",
 		slog.Int("total_iterations", len(toolCalls)),
 		slog.Int("total_tools", len(toolCalls)))
 
 	resultChan <- StreamResult{
-		Type:   "end",
-		Result: finalResult,
+		Type:         "end",
+		Result:       finalResult,
+		FinishReason: finalResult.FinishReason,
+	}
+
+	if asyncMemorySave {
+		// The end event is already delivered, memory failures can only be logged from here on.
+		// The engine is free once the stream closes, the next execution waits for the save so it sees this turn.
+		state.emit = nil
+		saved := make(chan struct{})
+		ae.mu.Lock()
+		ae.pendingSave = saved
+		ae.mu.Unlock()
+		go func() {
+			defer close(saved)
+			ae.saveStreamMemory(initialMessages, finalResult.Output, state)
+		}()
+	}
+}
+
+// waitPendingSave waits for the AsyncMemorySave of the previous streamed turn, so the next execution sees it
+// Returns the cancellation error when ctx is done first
+func (ae *AgentEngine) waitPendingSave(ctx context.Context) error {
+	ae.mu.RLock()
+	pending := ae.pendingSave
+	ae.mu.RUnlock()
+	if pending == nil {
+		return nil
+	}
+
+	var done <-chan struct{}
+	if ctx != nil {
+		done = ctx.Done()
+	}
+	select {
+	case <-pending:
+		return nil
+	case <-done:
+		return contextDoneError(ctx)
+	}
+}
+
+// markEmptyOutput explains a run that ended without output and without a stop reason
+// so clients get empty_output and a warning instead of a blank result
+func markEmptyOutput(result *AgentResult, state *executionState) {
+	if result.StoppedReason != "" || strings.TrimSpace(result.Output) != "" {
+		return
+	}
+	result.StoppedReason = StoppedReasonEmptyOutput
+	state.addWarning("the run ended without any output from the model")
+}
+
+// saveStreamMemory saves the streamed turn to memory and compresses it if needed
+func (ae *AgentEngine) saveStreamMemory(initialMessages []types.Message, finalOutput string, state *executionState) {
+	if ae.memory == nil || len(initialMessages) == 0 {
+		return
+	}
+	input := map[string]interface{}{"input": initialMessages[len(initialMessages)-1].Content}
+	output := map[string]interface{}{"output": finalOutput}
+	if err := ae.memory.SaveContext(input, output); err != nil {
+		ae.logger.LogError("executeStreamWithIterations", err, slog.String("phase", "save_context"))
+		state.addWarning("failed to save conversation to memory: %v", err)
+		// Do not interrupt execution as main flow is complete
+		return
+	}
+	ae.compressMemoryIfNeeded("executeStreamWithIterations", state)
+}
+
+// streamConclusionWithoutTools streams the model's final answer once tool calls are no longer allowed
+// Returns the accumulated output
+func (ae *AgentEngine) streamConclusionWithoutTools(messages []types.Message, notice types.Message, state *executionState, resultChan chan<- StreamResult) (string, string, error) {
+	if ae.model == nil {
+		return "", "", errors.NewError(errors.EC_STREAM_CHAT_FAILED.Code, "LLM model provider is nil")
 	}
+
+	partial := state.newPartialJSON()
+	messages = append(messages, notice)
+	output, finishReason, err := ae.streamChat(messages, state, resultChan, partial)
+	if err != nil {
+		return "", "", err
+	}
+	output, finishReason, err = ae.handleStreamFinishReason(messages, output, finishReason, state, resultChan, partial)
+	if err != nil {
+		return "", "", err
+	}
+	if value, ok := partial.finish(); ok {
+		resultChan <- StreamResult{Type: "partial_json", JSON: value}
+	}
+	return output, finishReason, nil
+}
+
+// streamChat streams a plain chat response, forwarding chunks to the result channel
+// Returns the full output and the provider's finish reason
+func (ae *AgentEngine) streamChat(messages []types.Message, state *executionState, resultChan chan<- StreamResult, partial *partialJSONStream) (string, string, error) {
+	stream, err := ae.model.ChatStream(messages)
+	if err != nil {
+		return "", "", err
+	}
+
+	var outputBuilder strings.Builder
+	finishReason := ""
+	for {
+		msg, ok := receiveStream(state, stream)
+		if !ok {
+			break
+		}
+		switch msg.Type {
+		case "chunk":
+			state.recordChunk()
+			outputBuilder.WriteString(msg.Content)
+			resultChan <- StreamResult{
+				Type:    "chunk",
+				Content: msg.Content,
+			}
+			if value, ok := partial.write(msg.Content); ok {
+				resultChan <- StreamResult{Type: "partial_json", JSON: value}
+			}
+		case "end":
+			finishReason = msg.FinishReason
+			state.addUsage(msg.Usage)
+		case "error":
+			return "", "", errors.NewError(errors.EC_STREAM_ERROR.Code, "stream error occurred").Wrap(fmt.Errorf("%s", msg.Error))
+		}
+	}
+	return outputBuilder.String(), finishReason, nil
 }
 
 // executeStreamIteration executes a single streaming iteration
@@ -928,26 +1800,28 @@ func (ae *AgentEngine) executeStreamWithIterations(initialMessages []types.Messa
 //   - messages: current round messages
 //   - resultChan: streaming result channel
 //   - iteration: current iteration index
+//   - state: per-run accounting (tool call budget)
 //
 // Returns:
 //   - execution result
 //   - whether to continue iteration
 //   - error information
-func (ae *AgentEngine) executeStreamIteration(messages []types.Message, resultChan chan<- StreamResult, iteration int) (*AgentResult, bool, error) {
+func (ae *AgentEngine) executeStreamIteration(messages []types.Message, resultChan chan<- StreamResult, iteration int, state *executionState) (*AgentResult, bool, error) {
 	result := &AgentResult{}
 
 	ae.mu.RLock()
-	tools := ae.tools
+	tools := state.visibleTools(ae.enabledTools())
 	maxIterations := 10
 	timeout := time.Duration(0)
-	toolExecutionTimeout := time.Duration(0)
 	if ae.config != nil {
 		maxIterations = ae.config.MaxIterations
 		timeout = ae.config.Timeout
-		toolExecutionTimeout = ae.config.ToolExecutionTimeout
 	}
 	ctx := ae.ctx
 	ae.mu.RUnlock()
+	if state.ctx != nil {
+		ctx = state.ctx
+	}
 
 	// Create context with timeout if configured
 	if ctx == nil {
@@ -963,7 +1837,19 @@ func (ae *AgentEngine) executeStreamIteration(messages []types.Message, resultCh
 		return nil, false, errors.NewError(errors.EC_STREAM_CHAT_FAILED.Code, "LLM model provider is nil")
 	}
 
-	stream, err := ae.model.ChatWithToolsStream(messages, tools)
+	messages = ae.withBudgetHint(messages, iteration, maxIterations, state)
+
+	// Tool-less agents stream plain chat, some providers reject tool plumbing with an empty tool list
+	var stream <-chan types.StreamMessage
+	var err error
+	if len(tools) == 0 {
+		stream, err = awaitModel(state, func() (<-chan types.StreamMessage, error) { return ae.model.ChatStream(messages) })
+	} else {
+		definitions := ae.toolDefinitions(tools, iteration)
+		stream, err = awaitModel(state, func() (<-chan types.StreamMessage, error) {
+			return ae.model.ChatWithToolsStream(messages, definitions)
+		})
+	}
 	if err != nil {
 		return nil, false, errors.NewError(errors.EC_STREAM_CHAT_FAILED.Code, "failed to chat with tools stream").Wrap(err)
 	}
@@ -971,30 +1857,54 @@ func (ae *AgentEngine) executeStreamIteration(messages []types.Message, resultCh
 	intermediateSteps := []types.ToolCallData{}
 	var outputBuilder strings.Builder
 	outputBuilder.Grow(2048)
+	partial := state.newPartialJSON()
+	finishReason := ""
 
-	for msg := range stream {
+	for {
+		msg, ok := receiveStream(state, stream)
+		if !ok {
+			break
+		}
 		switch msg.Type {
 		case "chunk":
+			state.recordChunk()
 			outputBuilder.WriteString(msg.Content)
 			resultChan <- StreamResult{
 				Type:    "chunk",
 				Content: msg.Content,
 			}
+			if value, ok := partial.write(msg.Content); ok {
+				resultChan <- StreamResult{Type: "partial_json", JSON: value}
+			}
 		case "tool_calls":
 			for _, tc := range msg.ToolCalls {
-				result.ToolCalls = append(result.ToolCalls, types.ToolCallRequest{
-					Tool:       tc.Function.Name,
-					ToolInput:  tc.Function.Arguments,
-					ToolCallID: tc.ID,
-					Type:       tc.Type,
-				})
+				result.ToolCalls = append(result.ToolCalls, types.ToolCallToRequest(tc))
 			}
+		case "end":
+			finishReason = msg.FinishReason
+			result.Usage = msg.Usage
+			state.addUsage(msg.Usage)
 		case "error":
 			return nil, false, errors.NewError(errors.EC_STREAM_ERROR.Code, "stream error occurred").Wrap(fmt.Errorf("%s", msg.Error))
 		}
 	}
 
 	result.Output = outputBuilder.String()
+	if state.cancelled() {
+		ae.logger.LogExecution("executeStreamIteration", iteration, "Execution cancelled, discarding the rest of the response")
+		result.ToolCalls = nil
+		result.StoppedReason = StoppedReasonCancelled
+		return result, false, nil
+	}
+	if finishReason == types.FinishReasonLength && len(result.ToolCalls) > 0 {
+		state.addWarning("response hit the output token limit, tool call arguments may be truncated")
+	} else if result.Output, finishReason, err = ae.handleStreamFinishReason(messages, result.Output, finishReason, state, resultChan, partial); err != nil {
+		return nil, false, err
+	}
+	result.FinishReason = finishReason
+	if value, ok := partial.finish(); ok {
+		resultChan <- StreamResult{Type: "partial_json", JSON: value}
+	}
 
 	if len(result.ToolCalls) > 0 {
 		ae.logger.LogExecution("executeStreamIteration", iteration, "Processing tool calls",
@@ -1002,127 +1912,28 @@ func (ae *AgentEngine) executeStreamIteration(messages []types.Message, resultCh
 
 		if iteration+1 >= maxIterations {
 			ae.logger.LogExecution("executeStreamIteration", iteration, "Reached maximum iterations, skipping tool execution")
+			state.addWarning("maximum iterations (%d) reached, %d requested tool call(s) were not executed", maxIterations, len(result.ToolCalls))
+			result.StoppedReason = StoppedReasonMaxIterations
 			return result, false, nil
 		}
 
 		// Convert ToolCallRequest to ToolCall for sorting
 		toolCallsForSorting := make([]types.ToolCall, 0, len(result.ToolCalls))
 		for _, tc := range result.ToolCalls {
-			toolCallsForSorting = append(toolCallsForSorting, types.ToolCall{
-				ID:   tc.ToolCallID,
-				Type: tc.Type,
-				Function: types.ToolFunction{
-					Name:      tc.Tool,
-					Arguments: tc.ToolInput,
-				},
-			})
+			toolCallsForSorting = append(toolCallsForSorting, types.ToolCallRequestToToolCall(tc))
 		}
 
 		// Sort tool calls by priority and dependencies
-		sortedToolCalls, err := ae.sortToolCallsByDependencies(toolCallsForSorting)
-		if err != nil {
-			ae.logger.LogError("executeStreamIteration", err, slog.String("phase", "sort_tool_calls"))
-			// Continue with original order if sorting fails
-			sortedToolCalls = toolCallsForSorting
-		}
+		sortedToolCalls := ae.planToolCalls(toolCallsForSorting, iteration, state)
 
 		// Convert back to ToolCallRequest
 		sortedToolCallRequests := make([]types.ToolCallRequest, 0, len(sortedToolCalls))
 		for _, tc := range sortedToolCalls {
-			sortedToolCallRequests = append(sortedToolCallRequests, types.ToolCallRequest{
-				Tool:       tc.Function.Name,
-				ToolInput:  tc.Function.Arguments,
-				ToolCallID: tc.ID,
-				Type:       tc.Type,
-			})
-		}
-
-		for _, toolCall := range sortedToolCallRequests {
-			ae.logger.LogExecution("executeStreamIteration", iteration, "Executing tool",
-				slog.String("tool_name", toolCall.Tool))
-
-			ae.mu.RLock()
-			tool, exists := ae.toolsMap[toolCall.Tool]
-			ae.mu.RUnlock()
-			if !exists {
-				errMsg := fmt.Sprintf("tool '%s' not found in available tools", toolCall.Tool)
-				ae.logger.LogError("executeStreamIteration", fmt.Errorf("tool %q not found in available tools", toolCall.Tool),
-					slog.String("tool_name", toolCall.Tool))
-				intermediateSteps = append(intermediateSteps, types.ToolCallData{
-					Action: types.ToolActionStep{
-						Tool:       toolCall.Tool,
-						ToolInput:  toolCall.ToolInput,
-						ToolCallID: toolCall.ToolCallID,
-						Type:       toolCall.Type,
-					},
-					Observation: errMsg,
-				})
-				continue
-			}
-
-			// Check cache first
-			toolStartTime := time.Now()
-			toolResult, err, cached := ae.getCachedToolResult(toolCall.Tool, toolCall.ToolInput)
-			if cached {
-				ae.logger.LogToolExecution(toolCall.Tool, true, 0, slog.Bool("cached", true), slog.String("context", "streaming"))
-				if err != nil {
-					errMsg := fmt.Sprintf("Tool '%s' execution failed (cached error): %v", toolCall.Tool, err)
-					ae.logger.LogToolExecution(toolCall.Tool, false, 0,
-						slog.String("error", err.Error()),
-						slog.Bool("cached", true),
-						slog.String("context", "streaming"))
-					intermediateSteps = append(intermediateSteps, types.ToolCallData{
-						Action: types.ToolActionStep{
-							Tool:       toolCall.Tool,
-							ToolInput:  toolCall.ToolInput,
-							ToolCallID: toolCall.ToolCallID,
-							Type:       toolCall.Type,
-						},
-						Observation: errMsg,
-					})
-					continue
-				}
-			} else {
-				// Execute tool with timeout
-				toolResult, err = ae.executeToolWithTimeout(tool, toolCall.ToolInput, toolExecutionTimeout)
-				duration := time.Since(toolStartTime)
-
-				if err != nil {
-					errMsg := fmt.Sprintf("Tool '%s' execution failed: %v", toolCall.Tool, err)
-					ae.logger.LogToolExecution(toolCall.Tool, false, duration,
-						slog.String("error", err.Error()),
-						slog.String("tool_input", fmt.Sprintf("%v", toolCall.ToolInput)),
-						slog.String("context", "streaming"))
-					intermediateSteps = append(intermediateSteps, types.ToolCallData{
-						Action: types.ToolActionStep{
-							Tool:       toolCall.Tool,
-							ToolInput:  toolCall.ToolInput,
-							ToolCallID: toolCall.ToolCallID,
-							Type:       toolCall.Type,
-						},
-						Observation: errMsg,
-					})
-					continue
-				}
-
-				// Cache tool result
-				ae.setCachedToolResult(toolCall.Tool, toolCall.ToolInput, toolResult, err)
-				ae.logger.LogToolExecution(toolCall.Tool, true, duration, slog.Bool("cached", false), slog.String("context", "streaming"))
-			}
-
-			// Format observation from tool result
-			truncationLength := ae.getToolTruncationLength(toolCall.Tool)
-			observation := truncateString(formatToolResult(toolResult), truncationLength)
+			sortedToolCallRequests = append(sortedToolCallRequests, types.ToolCallToRequest(tc))
+		}
 
-			intermediateSteps = append(intermediateSteps, types.ToolCallData{
-				Action: types.ToolActionStep{
-					Tool:       toolCall.Tool,
-					ToolInput:  toolCall.ToolInput,
-					ToolCallID: toolCall.ToolCallID,
-					Type:       toolCall.Type,
-				},
-				Observation: observation,
-			})
+		for _, outcome := range ae.executeToolCalls(ctx, sortedToolCallRequests, iteration, state, slog.String("context", "streaming")) {
+			intermediateSteps = append(intermediateSteps, outcome.step)
 		}
 
 		result.IntermediateSteps = intermediateSteps
@@ -1140,56 +1951,210 @@ func (ae *AgentEngine) executeStreamIteration(messages []types.Message, resultCh
 
 // ==================== Tool Execution Methods ====================
 
-// executeToolWithTimeout executes a tool with timeout control
-// Uses goroutine + channel to implement timeout without modifying Tool interface
-// Note: The goroutine will continue running after timeout, but will naturally complete.
-// This is an acceptable trade-off since the Tool interface doesn't support context cancellation.
-// The goroutine will finish and clean up resources automatically, preventing leaks.
-func (ae *AgentEngine) executeToolWithTimeout(tool types.Tool, args map[string]interface{}, timeout time.Duration) (interface{}, error) {
-	if timeout <= 0 {
-		// No timeout, execute directly
-		return tool.Execute(args)
+// applyToolAllowlist restricts the tools advertised in this execution to the request allowlist
+// Names that are not registered on the engine are ignored with a warning
+func (ae *AgentEngine) applyToolAllowlist(state *executionState, opts *ExecuteOptions) {
+	defer ae.activateAllowlist(state)
+	if opts == nil || len(opts.Tools) == 0 {
+		return
 	}
 
-	type result struct {
-		value interface{}
-		err   error
+	ae.mu.RLock()
+	allowed := make(map[string]bool, len(opts.Tools))
+	var unknown []string
+	for _, name := range opts.Tools {
+		if _, exists := ae.toolsMap[name]; exists {
+			allowed[name] = true
+		} else {
+			unknown = append(unknown, name)
+		}
 	}
+	ae.mu.RUnlock()
 
-	resultChan := make(chan result, 1)
-	// Use buffered channel to prevent goroutine leak if timeout occurs
-	go func() {
-		var value interface{}
-		var err error
+	state.allowedTools = allowed
+	for _, name := range unknown {
+		ae.logger.Info("Requested tool is not registered, ignoring", slog.String("tool_name", name))
+		state.addWarning("requested tool '%s' is not registered, ignored", name)
+	}
+}
 
-		defer func() {
-			// Recover from any panic in tool execution
-			if r := recover(); r != nil {
-				err = fmt.Errorf("tool execution panic: %v", r)
-				value = nil
-			}
-			// Non-blocking send (buffered channel)
-			// This ensures we always try to send the result, even if timeout occurred
-			select {
-			case resultChan <- result{value: value, err: err}:
-			default:
-				// Channel already closed or receiver gone (timeout occurred), ignore
-			}
-		}()
+// activateAllowlist publishes the allowlist of the execution that is starting, so EnabledTools reflects it
+func (ae *AgentEngine) activateAllowlist(state *executionState) {
+	ae.mu.Lock()
+	ae.allowedTools = state.allowedTools
+	ae.mu.Unlock()
+}
+
+// applyExecutionContext derives the execution context from the engine context and ExecuteOptions.Context
+// The returned function releases the context and must be called when the execution ends
+func (ae *AgentEngine) applyExecutionContext(state *executionState, opts *ExecuteOptions) context.CancelFunc {
+	ae.mu.RLock()
+	parent := ae.ctx
+	ae.mu.RUnlock()
+	if parent == nil {
+		parent = context.Background()
+	}
+
+	ctx, cancel := context.WithCancel(parent)
+	state.ctx = ctx
+	if opts == nil || opts.Context == nil {
+		return cancel
+	}
+	stop := context.AfterFunc(opts.Context, cancel)
+	return func() {
+		stop()
+		cancel()
+	}
+}
+
+// requestApproval consults the approval hook before a tool call is executed
+// When streaming, an approval_required event is emitted first so clients can prompt the user
+// The decision is awaited on a response channel until the approval timeout expires
+// Returns whether the call may proceed and, if not, the reason
+func (ae *AgentEngine) requestApproval(toolCall types.ToolCallRequest, state *executionState) (bool, string) {
+	ae.mu.RLock()
+	hook := ae.approvalHook
+	timeout := time.Duration(0)
+	if ae.config != nil {
+		timeout = ae.config.ApprovalTimeout
+	}
+	ctx := ae.ctx
+	ae.mu.RUnlock()
+	// The execution context ends the wait when the caller cancels or disconnects
+	if state.ctx != nil {
+		ctx = state.ctx
+	}
+
+	if hook == nil {
+		return true, ""
+	}
 
-		value, err = tool.Execute(args)
+	if state.emit != nil {
+		call := toolCall
+		state.emit(StreamResult{
+			Type:     "approval_required",
+			Content:  toolCall.Tool,
+			ToolCall: &call,
+		})
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	responseChan := make(chan approvalDecision, 1)
+	go func() {
+		approved, err := hook(ctx, toolCall)
+		responseChan <- approvalDecision{approved: approved, err: err}
 	}()
 
 	select {
-	case res := <-resultChan:
-		return res.value, res.err
-	case <-time.After(timeout):
-		// Timeout occurred, but goroutine will continue and complete naturally
-		// This is acceptable since tool interface doesn't support cancellation
-		return nil, errors.EC_TOOL_EXECUTION_TIMEOUT.Wrap(fmt.Errorf("tool execution timeout after %v", timeout))
+	case decision := <-responseChan:
+		if decision.err != nil {
+			ae.logger.LogError("requestApproval", decision.err, slog.String("tool_name", toolCall.Tool))
+			return false, fmt.Sprintf("approval failed: %v", decision.err)
+		}
+		if !decision.approved {
+			ae.logger.Info("Tool call rejected", slog.String("tool_name", toolCall.Tool))
+			return false, "rejected by approver"
+		}
+		return true, ""
+	case <-ctx.Done():
+		if stderrors.Is(ctx.Err(), context.Canceled) {
+			ae.logger.Info("Tool call approval cancelled", slog.String("tool_name", toolCall.Tool))
+			return false, "approval cancelled"
+		}
+		ae.logger.Info("Tool call approval timed out",
+			slog.String("tool_name", toolCall.Tool),
+			slog.Duration("timeout", timeout))
+		return false, "approval timed out"
 	}
 }
 
+// InvokeTool executes a registered tool directly, bypassing the model
+// Argument checks, the approval hook, tool hooks, tool rate limits and tool timeouts apply as for calls
+// requested by the model. The call ends when ctx is cancelled or the engine is stopped
+func (ae *AgentEngine) InvokeTool(ctx context.Context, name string, args map[string]interface{}) (interface{}, error) {
+	ae.mu.RLock()
+	tool, exists := ae.toolsMap[name]
+	disabled := ae.disabled[name]
+	timeout := time.Duration(0)
+	if ae.config != nil {
+		timeout = defaultToolTimeout(ae.config)
+	}
+	ae.mu.RUnlock()
+
+	if !exists {
+		return nil, errors.NewError(errors.EC_TOOL_NOT_FOUND.Code, fmt.Sprintf("tool '%s' not found", name))
+	}
+	if disabled {
+		return nil, errors.NewError(errors.EC_PERMISSION_DENIED.Code, fmt.Sprintf("tool '%s' is disabled", name))
+	}
+
+	args, errMsg, valid := ae.checkToolArgs(tool, args)
+	if !valid {
+		return nil, errors.NewError(errors.EC_TOOL_PARAMETER_INVALID.Code, errMsg)
+	}
+	if err := tools.ValidateInput(tool.Schema(), args); err != nil {
+		return nil, errors.NewError(errors.EC_TOOL_PARAMETER_INVALID.Code, fmt.Sprintf("tool '%s' not executed: %v", name, err))
+	}
+
+	state := newExecutionState(nil)
+	release := ae.applyExecutionContext(state, &ExecuteOptions{Context: ctx})
+	defer release()
+	ctx = state.ctx
+
+	if approved, reason := ae.requestApproval(types.ToolCallRequest{
+		Tool:      name,
+		ToolInput: args,
+		Type:      "function",
+	}, state); !approved {
+		return nil, errors.NewError(errors.EC_PERMISSION_DENIED.Code, fmt.Sprintf("tool '%s' not executed: %s", name, reason))
+	}
+	args, err := ae.beforeToolHooks(name, args)
+	if err != nil {
+		return nil, errors.NewError(errors.EC_PERMISSION_DENIED.Code, toolHookRejection(name, err))
+	}
+	if allowed, msg := ae.acquireToolRateLimit(tool, state); !allowed {
+		return nil, errors.NewError(errors.ErrRateLimitExceeded.Code, msg)
+	}
+
+	startTime := time.Now()
+	result, err := ae.executeToolWithTimeout(ctx, tool, args, toolTimeout(tool, timeout))
+	ae.invalidateCachesAfter(tool)
+	result, err = ae.afterToolHooks(name, result, err)
+	if err != nil {
+		ae.logger.LogToolExecution(name, false, time.Since(startTime), slog.String("error", err.Error()), slog.String("context", "invoke"))
+		if _, ok := err.(*errors.Error); ok {
+			return nil, err
+		}
+		return nil, errors.NewError(errors.EC_TOOL_EXECUTION_FAILED.Code, fmt.Sprintf("tool '%s' execution failed", name)).Wrap(err)
+	}
+	ae.logger.LogToolExecution(name, true, time.Since(startTime), slog.String("context", "invoke"))
+	return result, nil
+}
+
+// executeToolWithTimeout executes a tool with panic isolation and timeout control
+// The tool is cancelled when ctx is done or after timeout (0 means no per-tool timeout)
+func (ae *AgentEngine) executeToolWithTimeout(ctx context.Context, tool types.Tool, args map[string]interface{}, timeout time.Duration) (interface{}, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	outcome := tools.SafeExecute(ctx, tool, args)
+	if outcome.Panicked {
+		ae.logger.LogError("executeToolWithTimeout", outcome.Err, slog.String("tool_name", tool.Name()))
+	}
+	return outcome.Result, outcome.Err
+}
+
 // ==================== Cache Management Methods ====================
 
 // generateToolCacheKey generates a tool cache key
@@ -1260,17 +2225,20 @@ func (ae *AgentEngine) getCachedToolResult(toolName string, args map[string]inte
 
 	entry, exists := ae.toolCache[cacheKey]
 	if !exists {
+		ae.cacheMisses.Add(1)
 		return nil, nil, false
 	}
 
 	// Check expiration
-	if time.Since(entry.timestamp) >= CacheExpirationTime {
+	if time.Since(entry.timestamp) >= ae.toolCacheTTL {
 		ae.removeCacheEntry(entry)
+		ae.cacheMisses.Add(1)
 		return nil, nil, false
 	}
 
 	// Move to head (most recently used)
 	ae.moveToHead(entry)
+	ae.cacheHits.Add(1)
 	return entry.result, entry.err, true
 }
 
@@ -1303,6 +2271,7 @@ func (ae *AgentEngine) setCachedToolResult(toolName string, args map[string]inte
 	// If cache is still full, remove least recently used entries (from tail)
 	for len(ae.toolCache) >= ae.toolCacheSize && ae.toolCacheTail != nil {
 		ae.removeCacheEntry(ae.toolCacheTail)
+		ae.cacheEvicted.Add(1)
 	}
 
 	// Create new entry and add to head
@@ -1311,18 +2280,121 @@ func (ae *AgentEngine) setCachedToolResult(toolName string, args map[string]inte
 		err:       err,
 		timestamp: time.Now(),
 		key:       cacheKey,
+		toolName:  toolName,
 	}
 	ae.toolCache[cacheKey] = entry
 	ae.addToHead(entry)
 }
 
+// SetToolCacheSize sets the maximum number of cached tool results, size <= 0 restores DefaultCacheSize
+// Shrinking the cache evicts the least recently used entries right away
+func (ae *AgentEngine) SetToolCacheSize(size int) {
+	ae.setConfigValue(func() {
+		ae.config.ToolCacheSize = size
+	})
+
+	ae.toolCacheMu.Lock()
+	defer ae.toolCacheMu.Unlock()
+	ae.toolCacheSize = toolCacheSizeOrDefault(size)
+	for len(ae.toolCache) > ae.toolCacheSize && ae.toolCacheTail != nil {
+		ae.removeCacheEntry(ae.toolCacheTail)
+		ae.cacheEvicted.Add(1)
+	}
+}
+
+// CacheStats returns the tool result cache size and its hit, miss and eviction counters
+// Expired and invalidated entries are dropped without counting as evictions
+func (ae *AgentEngine) CacheStats() ToolCacheStats {
+	ae.toolCacheMu.RLock()
+	size := len(ae.toolCache)
+	ae.toolCacheMu.RUnlock()
+
+	return ToolCacheStats{
+		Size:      size,
+		Hits:      int(ae.cacheHits.Load()),
+		Misses:    int(ae.cacheMisses.Load()),
+		Evictions: int(ae.cacheEvicted.Load()),
+	}
+}
+
+// SetToolCacheTTL sets how long tool results stay cached, ttl <= 0 restores CacheExpirationTime
+func (ae *AgentEngine) SetToolCacheTTL(ttl time.Duration) {
+	ae.setConfigValue(func() {
+		ae.config.ToolCacheTTL = ttl
+	})
+
+	ae.toolCacheMu.Lock()
+	defer ae.toolCacheMu.Unlock()
+	ae.toolCacheTTL = toolCacheTTLOrDefault(ttl)
+}
+
+// SetIterationDelay sets the pause between iterations of Execute and ExecuteStream, 0 disables it
+// Set it to IterationDelay to restore the former fixed 100ms delay
+func (ae *AgentEngine) SetIterationDelay(delay time.Duration) {
+	ae.setConfigValue(func() {
+		ae.config.IterationDelay = delay
+	})
+}
+
+// toolCacheSizeOrDefault returns size, or DefaultCacheSize when it is not positive
+func toolCacheSizeOrDefault(size int) int {
+	if size <= 0 {
+		return DefaultCacheSize
+	}
+	return size
+}
+
+// toolCacheTTLOrDefault returns ttl, or CacheExpirationTime when it is not positive
+func toolCacheTTLOrDefault(ttl time.Duration) time.Duration {
+	if ttl <= 0 {
+		return CacheExpirationTime
+	}
+	return ttl
+}
+
+// InvalidateCache drops the cached results of a tool
+func (ae *AgentEngine) InvalidateCache(toolName string) {
+	ae.toolCacheMu.Lock()
+	defer ae.toolCacheMu.Unlock()
+
+	current := ae.toolCacheTail
+	for current != nil {
+		next := current.prev
+		if current.toolName == toolName {
+			ae.removeCacheEntry(current)
+		}
+		current = next
+	}
+}
+
+// InvalidateAllCache drops all cached tool results
+func (ae *AgentEngine) InvalidateAllCache() {
+	ae.toolCacheMu.Lock()
+	defer ae.toolCacheMu.Unlock()
+
+	ae.toolCache = make(map[string]*toolCacheEntry)
+	ae.toolCacheHead = nil
+	ae.toolCacheTail = nil
+}
+
+// invalidateCachesAfter drops the cached results a tool declares stale once it has run
+func (ae *AgentEngine) invalidateCachesAfter(tool types.Tool) {
+	for _, name := range tool.Metadata().InvalidatesCaches {
+		if name == "*" {
+			ae.InvalidateAllCache()
+			return
+		}
+		ae.InvalidateCache(name)
+	}
+}
+
 // removeExpiredEntries removes all expired cache entries
 func (ae *AgentEngine) removeExpiredEntries() {
 	now := time.Now()
 	current := ae.toolCacheTail
 	for current != nil {
 		next := current.prev
-		if now.Sub(current.timestamp) >= CacheExpirationTime {
+		if now.Sub(current.timestamp) >= ae.toolCacheTTL {
 			ae.removeCacheEntry(current)
 		}
 		current = next
@@ -1405,8 +2477,20 @@ func (ae *AgentEngine) sortToolCallsByDependencies(toolCalls []types.ToolCall) (
 	for k, v := range ae.toolsMap {
 		toolsMap[k] = v
 	}
+	var priorityOverrides map[string]int
+	if ae.config != nil && len(ae.config.ToolPriorityOverrides) > 0 {
+		priorityOverrides = make(map[string]int, len(ae.config.ToolPriorityOverrides))
+		for k, v := range ae.config.ToolPriorityOverrides {
+			priorityOverrides[k] = v
+		}
+	}
 	ae.mu.RUnlock()
 
+	// Nothing to order by without registered tools
+	if len(toolsMap) == 0 {
+		return toolCalls, nil
+	}
+
 	// Build dependency graph and priority map
 	dependencyGraph := make(map[string][]string)   // tool -> dependencies
 	priorityMap := make(map[string]int)            // tool -> priority
@@ -1426,6 +2510,11 @@ func (ae *AgentEngine) sortToolCallsByDependencies(toolCalls []types.ToolCall) (
 		} else {
 			priorityMap[toolName] = 0
 		}
+
+		// Configured overrides take precedence over metadata priority
+		if priority, ok := priorityOverrides[toolName]; ok {
+			priorityMap[toolName] = priority
+		}
 	}
 
 	// Detect circular dependencies
@@ -1549,3 +2638,91 @@ func (ae *AgentEngine) Stop() {
 	}
 	ae.isRunning.Store(false)
 }
+
+// IsRunning reports whether an execution is in progress
+func (ae *AgentEngine) IsRunning() bool {
+	return ae.isRunning.Load()
+}
+
+// awaitModel runs a model call, returning early with an EC_TIMEOUT error once the execution is cancelled
+// Providers take no context, so an abandoned call finishes in the background and its reply is dropped
+func awaitModel[T any](state *executionState, call func() (T, error)) (T, error) {
+	if state.ctx == nil {
+		return call()
+	}
+
+	type reply struct {
+		value T
+		err   error
+	}
+	replies := make(chan reply, 1)
+	go func() {
+		value, err := call()
+		replies <- reply{value: value, err: err}
+	}()
+
+	select {
+	case r := <-replies:
+		return r.value, r.err
+	case <-state.ctx.Done():
+		var zero T
+		return zero, errors.NewError(errors.EC_TIMEOUT.Code, "model call cancelled").Wrap(state.ctx.Err())
+	}
+}
+
+// receiveStream returns the next provider stream message, ok is false once the stream is closed or the execution is cancelled
+// A cancelled execution stops waiting on the provider, which finishes the response in the background
+func receiveStream(state *executionState, stream <-chan types.StreamMessage) (types.StreamMessage, bool) {
+	var done <-chan struct{}
+	if state.ctx != nil {
+		done = state.ctx.Done()
+	}
+	select {
+	case msg, ok := <-stream:
+		if !ok || !state.cancelled() {
+			return msg, ok
+		}
+	case <-done:
+	}
+	go func() {
+		for range stream {
+		}
+	}()
+	return types.StreamMessage{}, false
+}
+
+// stopRequested reports whether Stop has cancelled the engine context or the execution was cancelled
+func (ae *AgentEngine) stopRequested(state *executionState) bool {
+	ae.mu.RLock()
+	ctx := ae.ctx
+	ae.mu.RUnlock()
+	return (ctx != nil && ctx.Err() != nil) || state.cancelled()
+}
+
+// Shutdown gracefully shuts down the agent engine
+// New executions are rejected immediately; an in-flight execution is allowed to finish
+// until ctx is done, after which the engine is stopped
+func (ae *AgentEngine) Shutdown(ctx context.Context) error {
+	ae.shuttingDown.Store(true)
+
+	ticker := time.NewTicker(ShutdownPollInterval)
+	defer ticker.Stop()
+
+	for ae.isRunning.Load() {
+		select {
+		case <-ctx.Done():
+			ae.logger.LogError("Shutdown", ctx.Err(), slog.String("phase", "wait_running"))
+			ae.Stop()
+			return errors.NewError(errors.EC_TIMEOUT.Code, "timed out waiting for running execution").Wrap(ctx.Err())
+		case <-ticker.C:
+		}
+	}
+	if err := ae.waitPendingSave(ctx); err != nil {
+		ae.logger.LogError("Shutdown", err, slog.String("phase", "wait_memory_save"))
+		ae.Stop()
+		return err
+	}
+
+	ae.Stop()
+	return nil
+}
@@ -0,0 +1,96 @@
+package engine
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/xichan96/cortex/agent/types"
+)
+
+// echoTool returns its name
+type echoTool struct {
+	name string
+}
+
+func (t echoTool) Name() string                   { return t.name }
+func (t echoTool) Description() string            { return "echo" }
+func (t echoTool) Schema() map[string]interface{} { return nil }
+func (t echoTool) Metadata() types.ToolMetadata   { return types.ToolMetadata{ToolType: "test"} }
+func (t echoTool) Execute(input map[string]interface{}) (interface{}, error) {
+	return t.name, nil
+}
+
+// toolCallingLLM requests the echo tool for a number of rounds, then answers
+type toolCallingLLM struct {
+	mu     sync.Mutex
+	rounds int
+}
+
+func (m *toolCallingLLM) Chat(messages []types.Message) (types.Message, error) {
+	return types.Message{Role: "assistant", Content: "done"}, nil
+}
+
+func (m *toolCallingLLM) ChatStream(messages []types.Message) (<-chan types.StreamMessage, error) {
+	return nil, fmt.Errorf("not supported")
+}
+
+func (m *toolCallingLLM) ChatWithTools(messages []types.Message, tools []types.Tool) (types.Message, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.rounds == 0 {
+		return types.Message{Role: "assistant", Content: "done"}, nil
+	}
+	m.rounds--
+	return types.Message{
+		Role:    "assistant",
+		Content: "calling echo",
+		ToolCalls: []types.ToolCall{{
+			ID:       fmt.Sprintf("call_%d", m.rounds),
+			Type:     "function",
+			Function: types.ToolFunction{Name: "echo", Arguments: map[string]interface{}{"round": m.rounds}},
+		}},
+	}, nil
+}
+
+func (m *toolCallingLLM) ChatWithToolsStream(messages []types.Message, tools []types.Tool) (<-chan types.StreamMessage, error) {
+	return nil, fmt.Errorf("not supported")
+}
+
+func (m *toolCallingLLM) GetModelName() string                  { return "tool-calling" }
+func (m *toolCallingLLM) GetModelMetadata() types.ModelMetadata { return types.ModelMetadata{} }
+func (m *toolCallingLLM) SupportsStreaming() bool               { return false }
+
+// Run with -race: tools added while an execution iterates must not race with its tool snapshots
+func TestAddToolDuringExecution(t *testing.T) {
+	config := types.NewAgentConfig()
+	config.MaxIterations = 20
+	ae := NewAgentEngine(&toolCallingLLM{rounds: 10}, config)
+	defer ae.Stop()
+	ae.AddTool(echoTool{name: "echo"})
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; ; i++ {
+			select {
+			case <-done:
+				return
+			default:
+				ae.AddTool(echoTool{name: fmt.Sprintf("hot_%d", i)})
+			}
+		}
+	}()
+
+	result, err := ae.Execute("run echo", nil)
+	close(done)
+	wg.Wait()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.Output != "done" {
+		t.Errorf("Expected final answer, got %q", result.Output)
+	}
+}
@@ -0,0 +1,44 @@
+package engine
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/xichan96/cortex/agent/types"
+)
+
+// DefaultBudgetHintTemplate default wording of the budget hint
+// Placeholders: {iterations_remaining}, {max_iterations}, {tool_calls_remaining} ("unlimited" without a tool call limit)
+const DefaultBudgetHintTemplate = "Budget: {iterations_remaining} of {max_iterations} iterations remaining, {tool_calls_remaining} tool calls remaining. Plan your remaining steps and conclude before the budget runs out."
+
+// withBudgetHint returns a copy of messages with the remaining iteration and tool call budget appended
+// The caller's messages are left untouched, so the hint never carries over into the next iteration
+func (ae *AgentEngine) withBudgetHint(messages []types.Message, iteration, maxIterations int, state *executionState) []types.Message {
+	ae.mu.RLock()
+	enabled := ae.config != nil && ae.config.InjectBudgetHints
+	template := DefaultBudgetHintTemplate
+	if ae.config != nil && ae.config.BudgetHintTemplate != "" {
+		template = ae.config.BudgetHintTemplate
+	}
+	ae.mu.RUnlock()
+	if !enabled {
+		return messages
+	}
+
+	toolCallsRemaining := "unlimited"
+	if state.maxToolCalls > 0 {
+		toolCallsRemaining = strconv.Itoa(max(state.maxToolCalls-state.toolCalls, 0))
+	}
+	hint := strings.NewReplacer(
+		"{iterations_remaining}", strconv.Itoa(max(maxIterations-iteration, 0)),
+		"{max_iterations}", strconv.Itoa(maxIterations),
+		"{tool_calls_remaining}", toolCallsRemaining,
+	).Replace(template)
+
+	hinted := make([]types.Message, len(messages), len(messages)+1)
+	copy(hinted, messages)
+	return append(hinted, types.Message{
+		Role:    "user",
+		Content: hint,
+	})
+}
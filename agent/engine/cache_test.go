@@ -0,0 +1,69 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/xichan96/cortex/agent/types"
+)
+
+func TestToolCacheLimits(t *testing.T) {
+	config := types.NewAgentConfig()
+	config.ToolCacheSize = 2
+	config.ToolCacheTTL = 50 * time.Millisecond
+	ae := NewAgentEngine(&toolCallingLLM{}, config)
+	defer ae.Stop()
+
+	for i := 0; i < 3; i++ {
+		ae.setCachedToolResult("echo", map[string]interface{}{"n": i}, i, nil)
+	}
+	if _, _, ok := ae.getCachedToolResult("echo", map[string]interface{}{"n": 0}); ok {
+		t.Error("least recently used entry was not evicted at ToolCacheSize")
+	}
+	if _, _, ok := ae.getCachedToolResult("echo", map[string]interface{}{"n": 2}); !ok {
+		t.Error("newest entry missing from cache")
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if _, _, ok := ae.getCachedToolResult("echo", map[string]interface{}{"n": 2}); ok {
+		t.Error("entry served after ToolCacheTTL expired")
+	}
+
+	ae.SetToolCacheTTL(time.Minute)
+	ae.SetToolCacheSize(5)
+	for i := 0; i < 5; i++ {
+		ae.setCachedToolResult("echo", map[string]interface{}{"n": i}, i, nil)
+	}
+	ae.SetToolCacheSize(1)
+	for i := 0; i < 5; i++ {
+		_, _, ok := ae.getCachedToolResult("echo", map[string]interface{}{"n": i})
+		if ok != (i == 4) {
+			t.Errorf("after shrinking to 1, entry %d cached = %v", i, ok)
+		}
+	}
+}
+
+func TestToolCacheStats(t *testing.T) {
+	config := types.NewAgentConfig()
+	config.ToolCacheSize = 2
+	ae := NewAgentEngine(&toolCallingLLM{}, config)
+	defer ae.Stop()
+
+	for i := 0; i < 3; i++ {
+		ae.setCachedToolResult("echo", map[string]interface{}{"n": i}, i, nil)
+	}
+	ae.getCachedToolResult("echo", map[string]interface{}{"n": 0}) // evicted
+	ae.getCachedToolResult("echo", map[string]interface{}{"n": 1})
+	ae.getCachedToolResult("echo", map[string]interface{}{"n": 2})
+
+	stats := ae.CacheStats()
+	want := ToolCacheStats{Size: 2, Hits: 2, Misses: 1, Evictions: 1}
+	if stats != want {
+		t.Errorf("CacheStats() = %+v, want %+v", stats, want)
+	}
+
+	ae.InvalidateAllCache()
+	if stats := ae.CacheStats(); stats.Size != 0 || stats.Evictions != 1 {
+		t.Errorf("after invalidation CacheStats() = %+v, want empty cache without new evictions", stats)
+	}
+}
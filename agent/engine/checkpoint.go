@@ -0,0 +1,71 @@
+package engine
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/xichan96/cortex/agent/types"
+)
+
+// saveCheckpoint persists the progress of a blocking execution after an iteration
+// Failures are reported as warnings and never interrupt the execution
+func (ae *AgentEngine) saveCheckpoint(checkpointID, input string, messages []types.Message, iteration int, result *AgentResult, state *executionState) {
+	if checkpointID == "" {
+		return
+	}
+	store, ctx := ae.checkpointContext()
+	if store == nil {
+		return
+	}
+
+	checkpoint := &types.Checkpoint{
+		ID:                checkpointID,
+		Input:             input,
+		Messages:          messages,
+		Iteration:         iteration,
+		ToolCallCount:     state.toolCalls,
+		Output:            result.Output,
+		ToolCalls:         result.ToolCalls,
+		IntermediateSteps: result.IntermediateSteps,
+		Warnings:          state.warnings,
+		UpdatedAt:         time.Now(),
+	}
+	if state.allowedTools != nil {
+		checkpoint.AllowedTools = make([]string, 0, len(state.allowedTools))
+		for name := range state.allowedTools {
+			checkpoint.AllowedTools = append(checkpoint.AllowedTools, name)
+		}
+		sort.Strings(checkpoint.AllowedTools)
+	}
+
+	if err := store.Save(ctx, checkpoint); err != nil {
+		ae.logger.LogError("saveCheckpoint", err,
+			slog.String("checkpoint_id", checkpointID),
+			slog.Int("iteration", iteration))
+		state.addWarning("failed to save checkpoint after iteration %d: %v", iteration, err)
+	}
+}
+
+// deleteCheckpoint removes the checkpoint of a completed execution
+func (ae *AgentEngine) deleteCheckpoint(checkpointID string) {
+	if checkpointID == "" {
+		return
+	}
+	store, ctx := ae.checkpointContext()
+	if store == nil {
+		return
+	}
+	if err := store.Delete(ctx, checkpointID); err != nil {
+		ae.logger.LogError("deleteCheckpoint", err, slog.String("checkpoint_id", checkpointID))
+	}
+}
+
+// checkpointContext returns the checkpoint store and a context that outlives Stop,
+// so progress is still recorded when an execution is cancelled
+func (ae *AgentEngine) checkpointContext() (types.CheckpointStore, context.Context) {
+	ae.mu.RLock()
+	defer ae.mu.RUnlock()
+	return ae.checkpointStore, context.Background()
+}
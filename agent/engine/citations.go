@@ -0,0 +1,60 @@
+package engine
+
+import (
+	"regexp"
+	"strconv"
+
+	"github.com/xichan96/cortex/agent/types"
+)
+
+// citationInstruction asks the model to cite tool observations by their markers
+const citationInstruction = "Cite the results you rely on with their markers, e.g. [1]. Only cite markers listed above."
+
+// citationMarker matches citation markers such as [1] in the model output
+var citationMarker = regexp.MustCompile(`\[(\d+)\]`)
+
+// Citation tool observation cited in the final output
+type Citation struct {
+	ID          int         `json:"id"`
+	Tool        string      `json:"tool"`
+	ToolInput   interface{} `json:"tool_input"`
+	ToolCallID  interface{} `json:"tool_call_id,omitempty"`
+	Observation string      `json:"observation"`
+}
+
+// citationTracker assigns citation IDs to tool observations of an execution
+type citationTracker struct {
+	sources []types.ToolCallData // sources[i] has citation ID i+1
+}
+
+// register assigns the next citation ID to a tool observation
+func (t *citationTracker) register(step types.ToolCallData) int {
+	t.sources = append(t.sources, step)
+	return len(t.sources)
+}
+
+// resolve maps the markers cited in output to the observations that produced them
+// Citations are returned in order of first appearance, unknown markers are ignored
+func (t *citationTracker) resolve(output string) []Citation {
+	if t == nil {
+		return nil
+	}
+	var citations []Citation
+	seen := make(map[int]bool)
+	for _, match := range citationMarker.FindAllStringSubmatch(output, -1) {
+		id, err := strconv.Atoi(match[1])
+		if err != nil || id < 1 || id > len(t.sources) || seen[id] {
+			continue
+		}
+		seen[id] = true
+		step := t.sources[id-1]
+		citations = append(citations, Citation{
+			ID:          id,
+			Tool:        step.Action.Tool,
+			ToolInput:   step.Action.ToolInput,
+			ToolCallID:  step.Action.ToolCallID,
+			Observation: step.Observation,
+		})
+	}
+	return citations
+}
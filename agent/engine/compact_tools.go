@@ -0,0 +1,78 @@
+package engine
+
+import (
+	"encoding/json"
+	"log/slog"
+	"strings"
+
+	"github.com/xichan96/cortex/agent/types"
+)
+
+// maxCompactDescriptionLength maximum characters of a compact tool description
+const maxCompactDescriptionLength = 120
+
+// compactTool advertises a tool by name and the first line of its description, without its parameter schema
+// It only shapes the definitions sent to the model, calls are still resolved against the registered tools
+type compactTool struct {
+	types.Tool
+	description string
+}
+
+func (t compactTool) Description() string { return t.description }
+
+func (t compactTool) Schema() map[string]interface{} {
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": map[string]interface{}{},
+	}
+}
+
+// toolDefinitions returns the tools to advertise in an iteration
+// With CompactToolDefsAfterFirst, iterations after the first send compact definitions; the estimated
+// token savings are logged so the option can be evaluated against real tool sets
+func (ae *AgentEngine) toolDefinitions(tools []types.Tool, iteration int) []types.Tool {
+	ae.mu.RLock()
+	compact := ae.config != nil && ae.config.CompactToolDefsAfterFirst
+	ae.mu.RUnlock()
+	if !compact || iteration == 0 || len(tools) == 0 {
+		return tools
+	}
+
+	compacted := make([]types.Tool, 0, len(tools))
+	fullTokens, compactTokens := 0, 0
+	for _, tool := range tools {
+		short := compactTool{Tool: tool, description: compactDescription(tool.Description())}
+		compacted = append(compacted, short)
+		fullTokens += toolDefinitionTokens(tool)
+		compactTokens += toolDefinitionTokens(short)
+	}
+	ae.logger.LogExecution("toolDefinitions", iteration, "Sending compact tool definitions",
+		slog.Int("tools", len(tools)),
+		slog.Int("full_tokens", fullTokens),
+		slog.Int("compact_tokens", compactTokens),
+		slog.Int("saved_tokens", fullTokens-compactTokens))
+	return compacted
+}
+
+// compactDescription returns the first line of a description, cut to maxCompactDescriptionLength characters
+func compactDescription(description string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(description), "\n")
+	line = strings.TrimSpace(line)
+	if runes := []rune(line); len(runes) > maxCompactDescriptionLength {
+		line = strings.TrimSpace(string(runes[:maxCompactDescriptionLength-1])) + "…"
+	}
+	return line
+}
+
+// toolDefinitionTokens estimates the tokens of a tool definition as serialized for the model
+func toolDefinitionTokens(tool types.Tool) int {
+	data, err := json.Marshal(map[string]interface{}{
+		"name":        tool.Name(),
+		"description": tool.Description(),
+		"parameters":  tool.Schema(),
+	})
+	if err != nil {
+		return types.EstimateTextTokens(tool.Name() + tool.Description())
+	}
+	return types.EstimateTextTokens(string(data))
+}
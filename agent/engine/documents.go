@@ -0,0 +1,174 @@
+package engine
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/xichan96/cortex/agent/types"
+)
+
+// Document-related constants
+const (
+	DefaultDocumentTokenBudget = 8000 // default token budget for request documents
+	documentChunkTokens        = 2000 // approximate tokens per chunk when summarizing
+	maxDocumentChunks          = 20   // chunks summarized per document, the remainder is dropped
+	minChunkSummaryTokens      = 50   // lower bound for a single chunk summary
+)
+
+// buildDocumentContext renders request documents into a single context message
+// Documents are included verbatim when they fit the token budget, oversized ones
+// are chunked and summarized with the question in mind
+func (ae *AgentEngine) buildDocumentContext(question string, documents []Document) string {
+	ae.mu.RLock()
+	budget := 0
+	if ae.config != nil {
+		budget = ae.config.DocumentTokenBudget
+	}
+	ae.mu.RUnlock()
+	if budget <= 0 {
+		budget = DefaultDocumentTokenBudget
+	}
+
+	total := 0
+	for _, doc := range documents {
+		total += types.EstimateTextTokens(doc.Content)
+	}
+
+	// Each document gets an equal share once the total exceeds the budget
+	share := budget
+	if total > budget {
+		share = budget / len(documents)
+	}
+
+	var builder strings.Builder
+	builder.WriteString("The user attached the following documents. Use them to answer the question.\n")
+	for i, doc := range documents {
+		name := doc.Name
+		if name == "" {
+			name = fmt.Sprintf("document %d", i+1)
+		}
+
+		tokens := types.EstimateTextTokens(doc.Content)
+		if total <= budget || tokens <= share {
+			fmt.Fprintf(&builder, "\n=== Document: %s ===\n%s\n", name, doc.Content)
+			continue
+		}
+
+		ae.logger.Info("Document exceeds token budget, summarizing",
+			slog.String("document", name),
+			slog.Int("estimated_tokens", tokens),
+			slog.Int("budget", share))
+		fmt.Fprintf(&builder, "\n=== Document: %s (summarized) ===\n%s\n", name, ae.summarizeDocument(question, name, doc.Content, share))
+	}
+	return builder.String()
+}
+
+// summarizeDocument condenses a document into roughly budget tokens
+// Falls back to truncation when no model is available or summarization fails
+func (ae *AgentEngine) summarizeDocument(question, name, content string, budget int) string {
+	chunks := chunkText(content, documentChunkTokens)
+	if len(chunks) > maxDocumentChunks {
+		ae.logger.Info("Document has too many chunks, dropping the remainder",
+			slog.String("document", name),
+			slog.Int("chunks", len(chunks)),
+			slog.Int("max_chunks", maxDocumentChunks))
+		chunks = chunks[:maxDocumentChunks]
+	}
+
+	if ae.model == nil {
+		return truncateToTokens(content, budget)
+	}
+
+	perChunk := budget / len(chunks)
+	if perChunk < minChunkSummaryTokens {
+		perChunk = minChunkSummaryTokens
+	}
+
+	summaries := make([]string, 0, len(chunks))
+	for i, chunk := range chunks {
+		response, err := ae.model.Chat([]types.Message{
+			{
+				Role:    "system",
+				Content: "You summarize document excerpts, preserving facts, figures and names that may be needed to answer the user's question.",
+			},
+			{
+				Role: "user",
+				Content: fmt.Sprintf("Question: %s\n\nSummarize part %d/%d of %q in at most about %d words, keeping details relevant to the question:\n\n%s",
+					question, i+1, len(chunks), name, perChunk*3/4, chunk),
+			},
+		})
+		if err != nil {
+			ae.logger.LogError("summarizeDocument", err,
+				slog.String("document", name),
+				slog.Int("chunk", i+1))
+			return truncateToTokens(content, budget)
+		}
+		summaries = append(summaries, response.Content)
+	}
+
+	return truncateToTokens(strings.Join(summaries, "\n"), budget)
+}
+
+// chunkText splits text into chunks of at most maxTokens, preferring paragraph boundaries
+func chunkText(text string, maxTokens int) []string {
+	var chunks []string
+	var current strings.Builder
+	currentTokens := 0
+
+	flush := func() {
+		if current.Len() > 0 {
+			chunks = append(chunks, current.String())
+			current.Reset()
+			currentTokens = 0
+		}
+	}
+
+	for _, paragraph := range strings.Split(text, "\n\n") {
+		tokens := types.EstimateTextTokens(paragraph)
+		if tokens > maxTokens {
+			// Hard-split paragraphs that do not fit in a single chunk
+			flush()
+			runes := []rune(paragraph)
+			step := maxTokens * 4
+			for start := 0; start < len(runes); {
+				end := start + step
+				if end > len(runes) {
+					end = len(runes)
+				}
+				for end > start+1 && types.EstimateTextTokens(string(runes[start:end])) > maxTokens {
+					end = start + (end-start)*9/10
+				}
+				chunks = append(chunks, string(runes[start:end]))
+				start = end
+			}
+			continue
+		}
+		if currentTokens+tokens > maxTokens {
+			flush()
+		}
+		if current.Len() > 0 {
+			current.WriteString("\n\n")
+		}
+		current.WriteString(paragraph)
+		currentTokens += tokens
+	}
+	flush()
+	return chunks
+}
+
+// truncateToTokens cuts text so its estimated token count stays within maxTokens
+func truncateToTokens(text string, maxTokens int) string {
+	if types.EstimateTextTokens(text) <= maxTokens {
+		return text
+	}
+	runes := []rune(text)
+	end := maxTokens * 4
+	if end > len(runes) {
+		end = len(runes)
+	}
+	for end > 0 && types.EstimateTextTokens(string(runes[:end])) > maxTokens {
+		end = end * 9 / 10
+	}
+	return string(runes[:end]) + "..."
+}
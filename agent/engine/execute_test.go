@@ -0,0 +1,573 @@
+package engine
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xichan96/cortex/agent/providers"
+	"github.com/xichan96/cortex/agent/ratelimit"
+	"github.com/xichan96/cortex/agent/types"
+	"github.com/xichan96/cortex/pkg/errors"
+)
+
+// blockingLLM never answers until released
+type blockingLLM struct {
+	toolCallingLLM
+	release chan struct{}
+}
+
+func (m *blockingLLM) ChatWithTools(messages []types.Message, tools []types.Tool) (types.Message, error) {
+	<-m.release
+	return types.Message{Role: "assistant", Content: "done"}, nil
+}
+
+func TestExecuteContextCancelsModelCall(t *testing.T) {
+	model := &blockingLLM{release: make(chan struct{})}
+	defer close(model.release)
+	ae := NewAgentEngine(model, types.NewAgentConfig())
+	defer ae.Stop()
+	ae.AddTool(echoTool{name: "echo"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	result, err := ae.ExecuteContext(ctx, "hello", nil)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("ExecuteContext returned after %s, want prompt return", elapsed)
+	}
+	var e *errors.Error
+	if result != nil || !stderrors.As(err, &e) || e.Code != errors.EC_TIMEOUT.Code {
+		t.Fatalf("ExecuteContext = %v, %v, want EC_TIMEOUT error", result, err)
+	}
+	if !stderrors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("error %v does not wrap the context error", err)
+	}
+}
+
+// usageLLM calls the echo tool once, reporting token usage on every response
+type usageLLM struct {
+	toolCallingLLM
+}
+
+func (m *usageLLM) ChatWithTools(messages []types.Message, tools []types.Tool) (types.Message, error) {
+	response, err := m.toolCallingLLM.ChatWithTools(messages, tools)
+	response.Usage = &types.Usage{PromptTokens: 10, CompletionTokens: 2, TotalTokens: 12}
+	return response, err
+}
+
+func TestExecuteAccumulatesUsage(t *testing.T) {
+	ae := NewAgentEngine(&usageLLM{toolCallingLLM{rounds: 1}}, types.NewAgentConfig())
+	defer ae.Stop()
+	ae.AddTool(echoTool{name: "echo"})
+
+	result, err := ae.Execute("hello", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := types.Usage{PromptTokens: 20, CompletionTokens: 4, TotalTokens: 24}
+	if result.Usage == nil || *result.Usage != want {
+		t.Errorf("Usage = %+v, want %+v", result.Usage, want)
+	}
+}
+
+func TestExecuteIterationDelay(t *testing.T) {
+	model := &toolCallingLLM{rounds: 2}
+	ae := NewAgentEngine(model, types.NewAgentConfig())
+	defer ae.Stop()
+	ae.AddTool(echoTool{name: "echo"})
+
+	start := time.Now()
+	if _, err := ae.Execute("hello", nil); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed >= 2*IterationDelay {
+		t.Errorf("Execute took %s without an iteration delay", elapsed)
+	}
+
+	ae.SetIterationDelay(50 * time.Millisecond)
+	model.rounds = 2
+	start = time.Now()
+	if _, err := ae.Execute("hello", nil); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("Execute took %s, want two 50ms pauses between three iterations", elapsed)
+	}
+}
+
+// prefixParser accepts outputs starting with prefix and parses them to upper case
+type prefixParser struct {
+	prefix string
+}
+
+func (p prefixParser) Parse(output string) (interface{}, error) {
+	if !strings.HasPrefix(output, p.prefix) {
+		return nil, fmt.Errorf("output must start with %q", p.prefix)
+	}
+	return strings.ToUpper(output), nil
+}
+
+func (p prefixParser) GetFormatInstructions() string {
+	return "Start the answer with " + p.prefix
+}
+
+func TestExecuteParsedOutput(t *testing.T) {
+	config := types.NewAgentConfig()
+	config.MaxRepairAttempts = 1
+	ae := NewAgentEngine(&toolCallingLLM{}, config)
+	defer ae.Stop()
+
+	ae.SetOutputParser(prefixParser{prefix: "do"})
+	result, err := ae.Execute("hello", nil)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if result.ParsedOutput != "DONE" || len(result.Warnings) != 0 {
+		t.Errorf("result = %+v, want parsed output DONE without warnings", result)
+	}
+
+	ae.SetOutputParser(prefixParser{prefix: "{"})
+	result, err = ae.Execute("hello", nil)
+	if err != nil {
+		t.Fatalf("parse failure failed the run: %v", err)
+	}
+	if result.Output != "done" || result.ParsedOutput != nil {
+		t.Errorf("result = %+v, want raw output without parsed value", result)
+	}
+	if len(result.Warnings) == 0 || !strings.Contains(result.Warnings[len(result.Warnings)-1], "unparsed output") {
+		t.Errorf("warnings = %v, want a validation warning", result.Warnings)
+	}
+}
+
+// streamingLLM streams its answer in two chunks
+type streamingLLM struct {
+	toolCallingLLM
+}
+
+func (m *streamingLLM) ChatWithToolsStream(messages []types.Message, tools []types.Tool) (<-chan types.StreamMessage, error) {
+	return m.ChatStream(messages)
+}
+
+func (m *streamingLLM) ChatStream(messages []types.Message) (<-chan types.StreamMessage, error) {
+	stream := make(chan types.StreamMessage, 3)
+	stream <- types.StreamMessage{Type: "chunk", Content: "do"}
+	stream <- types.StreamMessage{Type: "chunk", Content: "ne"}
+	stream <- types.StreamMessage{Type: "end"}
+	close(stream)
+	return stream, nil
+}
+
+func (m *streamingLLM) SupportsStreaming() bool { return true }
+
+func TestExecuteStreamParsedOutput(t *testing.T) {
+	ae := NewAgentEngine(&streamingLLM{}, types.NewAgentConfig())
+	defer ae.Stop()
+	ae.SetOutputParser(prefixParser{prefix: "do"})
+
+	stream, err := ae.ExecuteStream("hello", nil)
+	if err != nil {
+		t.Fatalf("ExecuteStream failed: %v", err)
+	}
+	var end *AgentResult
+	for event := range stream {
+		if event.Type == "error" {
+			t.Fatalf("stream error: %v", event.Error)
+		}
+		if event.Type == "end" {
+			end = event.Result
+		}
+	}
+	if end == nil || end.Output != "done" || end.ParsedOutput != "DONE" {
+		t.Errorf("end result = %+v, want accumulated output parsed to DONE", end)
+	}
+}
+
+// toollessStreamingLLM rejects tool streaming with an empty tool list, as some backends do
+type toollessStreamingLLM struct {
+	streamingLLM
+	toolStreams int
+}
+
+func (m *toollessStreamingLLM) ChatWithToolsStream(messages []types.Message, tools []types.Tool) (<-chan types.StreamMessage, error) {
+	m.toolStreams++
+	if len(tools) == 0 {
+		return nil, fmt.Errorf("tools must not be empty")
+	}
+	return m.ChatStream(messages)
+}
+
+// capabilityFreeLLM hides SupportsStreaming, like providers written before types.StreamingCapability
+type capabilityFreeLLM struct {
+	types.LLMProvider
+}
+
+func TestExecuteStreamWithoutStreamingCapability(t *testing.T) {
+	ae := NewAgentEngine(capabilityFreeLLM{&streamingLLM{}}, types.NewAgentConfig())
+	defer ae.Stop()
+
+	stream, err := ae.ExecuteStream("hello", nil)
+	if err != nil {
+		t.Fatalf("ExecuteStream failed: %v", err)
+	}
+	var chunks []string
+	for event := range stream {
+		if event.Type == "chunk" {
+			chunks = append(chunks, event.Content)
+		}
+	}
+	if strings.Join(chunks, "|") != "do|ne" {
+		t.Errorf("chunks = %v, want the provider's stream", chunks)
+	}
+}
+
+func TestExecuteStreamWithoutTools(t *testing.T) {
+	model := &toollessStreamingLLM{}
+	ae := NewAgentEngine(model, types.NewAgentConfig())
+	defer ae.Stop()
+
+	stream, err := ae.ExecuteStream("hello", nil)
+	if err != nil {
+		t.Fatalf("ExecuteStream failed: %v", err)
+	}
+	var chunks []string
+	var end *AgentResult
+	for event := range stream {
+		switch event.Type {
+		case "error":
+			t.Fatalf("stream error: %v", event.Error)
+		case "chunk":
+			chunks = append(chunks, event.Content)
+		case "end":
+			end = event.Result
+		}
+	}
+	if strings.Join(chunks, "|") != "do|ne" {
+		t.Errorf("chunks = %v, want the streamed answer", chunks)
+	}
+	if end == nil || end.Output != "done" {
+		t.Errorf("end result = %+v, want output done", end)
+	}
+	if model.toolStreams != 0 {
+		t.Errorf("ChatWithToolsStream called %d times for an engine without tools", model.toolStreams)
+	}
+}
+
+func TestExecuteFinishReason(t *testing.T) {
+	ae := NewAgentEngine(&toolCallingLLM{rounds: 1}, types.NewAgentConfig())
+	defer ae.Stop()
+	ae.AddTool(echoTool{name: "echo"})
+
+	result, err := ae.Execute("hello", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.FinishReason != types.FinishReasonStop {
+		t.Errorf("FinishReason = %q, want %q", result.FinishReason, types.FinishReasonStop)
+	}
+
+	config := types.NewAgentConfig()
+	config.MaxIterations = 1
+	limited := NewAgentEngine(&toolCallingLLM{rounds: 5}, config)
+	defer limited.Stop()
+	limited.AddTool(echoTool{name: "echo"})
+
+	result, err = limited.Execute("hello", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.FinishReason != FinishReasonMaxIterations {
+		t.Errorf("FinishReason = %q, want %q", result.FinishReason, FinishReasonMaxIterations)
+	}
+}
+
+func TestExecuteStreamFinishReason(t *testing.T) {
+	ae := NewAgentEngine(&streamingLLM{}, types.NewAgentConfig())
+	defer ae.Stop()
+
+	stream, err := ae.ExecuteStream("hello", nil)
+	if err != nil {
+		t.Fatalf("ExecuteStream failed: %v", err)
+	}
+	var end *StreamResult
+	for event := range stream {
+		if event.Type == "error" {
+			t.Fatalf("stream error: %v", event.Error)
+		}
+		if event.Type == "end" {
+			end = &event
+		}
+	}
+	if end == nil || end.FinishReason != types.FinishReasonStop || end.Result.FinishReason != types.FinishReasonStop {
+		t.Errorf("end event = %+v, want finish reason %q", end, types.FinishReasonStop)
+	}
+}
+
+// toolStreamingLLM streams the echo tool calls of toolCallingLLM, then answers
+type toolStreamingLLM struct {
+	toolCallingLLM
+	toolStreams int
+}
+
+func (m *toolStreamingLLM) ChatWithToolsStream(messages []types.Message, tools []types.Tool) (<-chan types.StreamMessage, error) {
+	m.toolStreams++
+	response, err := m.ChatWithTools(messages, tools)
+	if err != nil {
+		return nil, err
+	}
+	stream := make(chan types.StreamMessage, 3)
+	stream <- types.StreamMessage{Type: "chunk", Content: response.Content}
+	if len(response.ToolCalls) > 0 {
+		stream <- types.StreamMessage{Type: "tool_calls", ToolCalls: response.ToolCalls}
+	}
+	stream <- types.StreamMessage{Type: "end"}
+	close(stream)
+	return stream, nil
+}
+
+func (m *toolStreamingLLM) ChatStream(messages []types.Message) (<-chan types.StreamMessage, error) {
+	return (&streamingLLM{}).ChatStream(messages)
+}
+
+func (m *toolStreamingLLM) SupportsStreaming() bool { return true }
+
+func TestExecuteToolBudgetExhausted(t *testing.T) {
+	config := types.NewAgentConfig()
+	config.MaxIterations = 10
+	config.MaxToolCalls = 2
+	model := &toolCallingLLM{rounds: 5}
+	ae := NewAgentEngine(model, config)
+	defer ae.Stop()
+	ae.AddTool(echoTool{name: "echo"})
+
+	result, err := ae.Execute("hello", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.FinishReason != FinishReasonToolBudgetExhausted || result.StoppedReason != StoppedReasonMaxToolCalls {
+		t.Errorf("FinishReason = %q, StoppedReason = %q, want %q, %q",
+			result.FinishReason, result.StoppedReason, FinishReasonToolBudgetExhausted, StoppedReasonMaxToolCalls)
+	}
+	if result.Output != "done" {
+		t.Errorf("Output = %q, want the final answer without tools", result.Output)
+	}
+	if model.rounds != 3 {
+		t.Errorf("model requested %d tool rounds, want 2 within the budget", 5-model.rounds)
+	}
+}
+
+func TestExecuteStreamToolBudgetExhausted(t *testing.T) {
+	config := types.NewAgentConfig()
+	config.MaxIterations = 10
+	config.MaxToolCalls = 2
+	model := &toolStreamingLLM{toolCallingLLM: toolCallingLLM{rounds: 5}}
+	ae := NewAgentEngine(model, config)
+	defer ae.Stop()
+	ae.AddTool(echoTool{name: "echo"})
+
+	stream, err := ae.ExecuteStream("hello", nil)
+	if err != nil {
+		t.Fatalf("ExecuteStream failed: %v", err)
+	}
+	var end *StreamResult
+	for event := range stream {
+		if event.Type == "error" {
+			t.Fatalf("stream error: %v", event.Error)
+		}
+		if event.Type == "end" {
+			end = &event
+		}
+	}
+	if end == nil || end.FinishReason != FinishReasonToolBudgetExhausted || end.Result.StoppedReason != StoppedReasonMaxToolCalls {
+		t.Fatalf("end event = %+v, want finish reason %q", end, FinishReasonToolBudgetExhausted)
+	}
+	if end.Result.Output != "done" {
+		t.Errorf("Output = %q, want the final answer without tools", end.Result.Output)
+	}
+	if model.toolStreams != 2 {
+		t.Errorf("ChatWithToolsStream called %d times, want 2 within the budget", model.toolStreams)
+	}
+}
+
+func TestSetTokenCounterSharedWithMemory(t *testing.T) {
+	ae := NewAgentEngine(&toolCallingLLM{}, types.NewAgentConfig())
+	defer ae.Stop()
+	memory := providers.NewSimpleMemoryProvider()
+	memory.SetMaxHistoryTokens(10)
+	for _, content := range []string{"one", "two", "three"} {
+		memory.AddMessage(context.Background(), types.Message{Role: "user", Content: content})
+	}
+
+	// A counter set before the memory is passed on when the memory is set
+	ae.SetTokenCounter(types.TokenCounterFunc(func(types.Message) int { return 4 }))
+	ae.SetMemory(memory)
+	if history, _ := memory.GetChatHistory(); len(history) != 2 {
+		t.Errorf("history = %d messages, want 2 within the budget of the engine's counter", len(history))
+	}
+
+	// And a counter set later replaces the memory's
+	ae.SetTokenCounter(types.TokenCounterFunc(func(types.Message) int { return 10 }))
+	if history, _ := memory.GetChatHistory(); len(history) != 1 {
+		t.Errorf("history = %d messages, want 1 after replacing the counter", len(history))
+	}
+}
+
+// slowSaveMemory simple memory whose SaveContext takes a while, like a save followed by compression
+type slowSaveMemory struct {
+	*providers.SimpleMemoryProvider
+}
+
+func (m slowSaveMemory) SaveContext(input, output map[string]interface{}) error {
+	time.Sleep(200 * time.Millisecond)
+	return m.SimpleMemoryProvider.SaveContext(input, output)
+}
+
+func TestAsyncMemorySaveDoesNotBlockNextTurn(t *testing.T) {
+	config := types.NewAgentConfig()
+	config.AsyncMemorySave = true
+	ae := NewAgentEngine(&streamingLLM{}, config)
+	defer ae.Stop()
+	memory := slowSaveMemory{providers.NewSimpleMemoryProvider()}
+	ae.SetMemory(memory)
+
+	for turn := 1; turn <= 2; turn++ {
+		stream, err := ae.ExecuteStream(fmt.Sprintf("turn %d", turn), nil)
+		if err != nil {
+			t.Fatalf("turn %d: ExecuteStream failed: %v", turn, err)
+		}
+		for event := range stream {
+			if event.Type == "error" {
+				t.Fatalf("turn %d: stream error: %v", turn, event.Error)
+			}
+			if event.Type == "end" {
+				break
+			}
+		}
+		// The engine is free right after the end event, while the save is still running
+		deadline := time.Now().Add(100 * time.Millisecond)
+		for ae.IsRunning() {
+			if time.Now().After(deadline) {
+				t.Fatalf("turn %d: engine still busy after the end event", turn)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+	if err := ae.waitPendingSave(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	history, err := memory.GetChatHistory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var inputs []string
+	for _, msg := range history {
+		if msg.Role == "user" {
+			inputs = append(inputs, msg.Content)
+		}
+	}
+	// The second turn waited for the first save, so both are stored in order
+	if strings.Join(inputs, ",") != "turn 1,turn 2" {
+		t.Errorf("saved inputs = %v, want both turns in order", inputs)
+	}
+}
+
+// enabledToolsProbe records the tools the engine reports as callable while it runs
+type enabledToolsProbe struct {
+	echoTool
+	ae   *AgentEngine
+	seen []string
+}
+
+func (p *enabledToolsProbe) Execute(input map[string]interface{}) (interface{}, error) {
+	p.seen = p.seen[:0]
+	for _, tool := range p.ae.EnabledTools() {
+		p.seen = append(p.seen, tool.Name())
+	}
+	return "ok", nil
+}
+
+func TestEnabledToolsFollowsAllowlistAndDisabledTools(t *testing.T) {
+	tests := []struct {
+		name string
+		opts *ExecuteOptions
+		want string
+	}{
+		{"no allowlist", nil, "echo,other,unlisted"},
+		{"allowlist", &ExecuteOptions{Tools: []string{"echo", "other", "hidden"}}, "echo,other"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ae := NewAgentEngine(&toolCallingLLM{rounds: 1}, types.NewAgentConfig())
+			defer ae.Stop()
+			probe := &enabledToolsProbe{echoTool: echoTool{name: "echo"}, ae: ae}
+			ae.AddTools([]types.Tool{probe, echoTool{name: "other"}, echoTool{name: "hidden"}, echoTool{name: "unlisted"}})
+			if err := ae.DisableTool("hidden"); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := ae.ExecuteWithOptions("probe", nil, tt.opts); err != nil {
+				t.Fatalf("ExecuteWithOptions failed: %v", err)
+			}
+			if got := strings.Join(probe.seen, ","); got != tt.want {
+				t.Errorf("enabled tools during the execution = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// blockingLimiter never hands out a token, Wait returns only when its context ends
+type blockingLimiter struct{}
+
+func (blockingLimiter) Allow(ctx context.Context) error { return fmt.Errorf("no tokens") }
+func (blockingLimiter) Wait(ctx context.Context) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+func (blockingLimiter) Stats() ratelimit.Stats { return ratelimit.Stats{} }
+
+func TestRateLimitWaitHonorsRequestContext(t *testing.T) {
+	ae := NewAgentEngine(&streamingLLM{}, types.NewAgentConfig())
+	defer ae.Stop()
+	ae.SetRateLimiter(blockingLimiter{})
+
+	wantCancelled := func(t *testing.T, err error, start time.Time) {
+		t.Helper()
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Fatalf("returned after %s, want prompt return on cancellation", elapsed)
+		}
+		var e *errors.Error
+		if !stderrors.As(err, &e) || e.Code != errors.EC_TIMEOUT.Code || !stderrors.Is(err, context.Canceled) {
+			t.Fatalf("error = %v, want the cancellation error rather than overload", err)
+		}
+	}
+
+	t.Run("execute", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(20*time.Millisecond, cancel)
+		start := time.Now()
+		_, err := ae.ExecuteWithOptions("hello", nil, &ExecuteOptions{Context: ctx})
+		wantCancelled(t, err, start)
+	})
+
+	t.Run("stream", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(20*time.Millisecond, cancel)
+		start := time.Now()
+		stream, err := ae.ExecuteStreamWithOptions("hello", nil, &ExecuteOptions{Context: ctx})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var streamErr error
+		for event := range stream {
+			if event.Type == "error" {
+				streamErr = event.Error
+			}
+		}
+		wantCancelled(t, streamErr, start)
+	})
+}
@@ -0,0 +1,180 @@
+package engine
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/xichan96/cortex/pkg/errors"
+)
+
+// Fan-out policies for consumers whose buffer is full
+const (
+	FanOutDropNewest = "drop_newest" // the new event is dropped for that consumer (default)
+	FanOutDropOldest = "drop_oldest" // the oldest buffered event is dropped to make room
+	FanOutBlock      = "block"       // wait for the consumer, a slow consumer holds back all of them
+)
+
+// FanOutOptions stream fan-out options
+type FanOutOptions struct {
+	Buffer int    // events buffered per consumer (default DefaultChannelBuffer)
+	Policy string // what to do when a consumer's buffer is full (default FanOutDropNewest)
+}
+
+// FanOut duplicates a stream to n independent consumers with the default options
+func FanOut(stream <-chan StreamResult, n int) []<-chan StreamResult {
+	consumers, _ := FanOutWithOptions(stream, n, FanOutOptions{})
+	return consumers
+}
+
+// FanOutWithOptions duplicates a stream to n independent consumers, each receiving every event
+// A consumer that falls behind loses events according to the policy without blocking the others,
+// and is told how many it lost by a warning event before the terminal event
+// Terminal events (end, error) are never dropped and are forwarded as soon as they arrive; every consumer
+// channel is closed after the source closes, so consumers must read until their channel is closed
+// Fails with EC_PARAMETER_INVALID for an unknown policy
+func FanOutWithOptions(stream <-chan StreamResult, n int, opts FanOutOptions) ([]<-chan StreamResult, error) {
+	switch opts.Policy {
+	case "":
+		opts.Policy = FanOutDropNewest
+	case FanOutDropNewest, FanOutDropOldest, FanOutBlock:
+	default:
+		return nil, errors.NewError(errors.EC_PARAMETER_INVALID.Code, fmt.Sprintf("unknown fan-out policy %q", opts.Policy))
+	}
+	if n < 1 {
+		n = 1
+	}
+	if opts.Buffer <= 0 {
+		opts.Buffer = DefaultChannelBuffer
+	}
+
+	outs := make([]chan StreamResult, n)
+	consumers := make([]<-chan StreamResult, n)
+	for i := range outs {
+		outs[i] = make(chan StreamResult, opts.Buffer)
+		consumers[i] = outs[i]
+	}
+
+	go func() {
+		dropped := make([]int, n)
+		// tails take over delivery to a consumer once its terminal event arrives,
+		// so a slow consumer cannot hold back the others
+		tails := make([]*fanOutTail, n)
+		startTail := func(i int) {
+			tails[i] = newFanOutTail(outs[i])
+			if dropped[i] > 0 {
+				tails[i].push(StreamResult{
+					Type:    StreamEventWarning,
+					Content: fmt.Sprintf("%d stream events were dropped because this consumer fell behind", dropped[i]),
+				})
+			}
+		}
+
+		for event := range stream {
+			terminal := event.Type == StreamEventEnd || event.Type == StreamEventError
+			for i, out := range outs {
+				if tails[i] == nil && terminal {
+					startTail(i)
+				}
+				if tails[i] != nil {
+					tails[i].push(event)
+					continue
+				}
+				dropped[i] += fanOutSend(out, event, opts.Policy)
+			}
+		}
+
+		for i := range outs {
+			if tails[i] == nil {
+				startTail(i)
+			}
+			tails[i].close()
+		}
+	}()
+
+	return consumers, nil
+}
+
+// fanOutTail delivers the remaining events of one consumer without blocking the fan-out
+type fanOutTail struct {
+	mu     sync.Mutex
+	events []StreamResult
+	closed bool
+	wake   chan struct{}
+}
+
+func newFanOutTail(out chan StreamResult) *fanOutTail {
+	t := &fanOutTail{wake: make(chan struct{}, 1)}
+	go t.drain(out)
+	return t
+}
+
+// push queues an event for the consumer
+func (t *fanOutTail) push(event StreamResult) {
+	t.mu.Lock()
+	t.events = append(t.events, event)
+	t.mu.Unlock()
+	t.signal()
+}
+
+// close closes the consumer channel once the queued events are delivered
+func (t *fanOutTail) close() {
+	t.mu.Lock()
+	t.closed = true
+	t.mu.Unlock()
+	t.signal()
+}
+
+func (t *fanOutTail) signal() {
+	select {
+	case t.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (t *fanOutTail) drain(out chan StreamResult) {
+	for range t.wake {
+		t.mu.Lock()
+		events, closed := t.events, t.closed
+		t.events = nil
+		t.mu.Unlock()
+
+		for _, event := range events {
+			out <- event
+		}
+		// close is the last call, so the events taken with it were the last ones
+		if closed {
+			close(out)
+			return
+		}
+	}
+}
+
+// fanOutSend delivers an event to one consumer and returns the number of events dropped
+func fanOutSend(out chan StreamResult, event StreamResult, policy string) int {
+	if policy == FanOutBlock {
+		out <- event
+		return 0
+	}
+
+	select {
+	case out <- event:
+		return 0
+	default:
+	}
+	if policy != FanOutDropOldest {
+		return 1
+	}
+
+	dropped := 0
+	select {
+	case <-out:
+		dropped++
+	default:
+	}
+	select {
+	case out <- event:
+	default:
+		dropped++
+	}
+	return dropped
+}
@@ -0,0 +1,173 @@
+package engine
+
+import (
+	stderrors "errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// collect reads a consumer until its channel is closed, failing the test if it stays open
+func collect(t *testing.T, ch <-chan StreamResult) []StreamResult {
+	t.Helper()
+	var events []StreamResult
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case event, ok := <-ch:
+			if !ok {
+				return events
+			}
+			events = append(events, event)
+		case <-timeout:
+			t.Fatalf("consumer channel not closed, got %d events", len(events))
+			return nil
+		}
+	}
+}
+
+// chunks returns the contents of the chunk events
+func chunks(events []StreamResult) []string {
+	var contents []string
+	for _, event := range events {
+		if event.Type == StreamEventChunk {
+			contents = append(contents, event.Content)
+		}
+	}
+	return contents
+}
+
+// fillStream queues n chunk events and an end event on a closed source channel
+func fillStream(n int) <-chan StreamResult {
+	stream := make(chan StreamResult, n+1)
+	for i := 0; i < n; i++ {
+		stream <- StreamResult{Type: StreamEventChunk, Content: fmt.Sprint(i)}
+	}
+	stream <- StreamResult{Type: StreamEventEnd}
+	close(stream)
+	return stream
+}
+
+func TestFanOutDeliversToAllConsumers(t *testing.T) {
+	consumers := FanOut(fillStream(3), 2)
+	for i, consumer := range consumers {
+		events := collect(t, consumer)
+		if got := strings.Join(chunks(events), ","); got != "0,1,2" {
+			t.Errorf("consumer %d chunks = %q, want 0,1,2", i, got)
+		}
+		if last := events[len(events)-1]; last.Type != StreamEventEnd {
+			t.Errorf("consumer %d last event = %q, want end", i, last.Type)
+		}
+	}
+}
+
+func TestFanOutPolicies(t *testing.T) {
+	tests := []struct {
+		policy  string
+		chunks  string
+		dropped int
+	}{
+		{FanOutDropNewest, "0,1", 3},
+		{FanOutDropOldest, "3,4", 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			// The second consumer is not read until the source is drained, so its buffer of 2 overflows
+			consumers, err := FanOutWithOptions(fillStream(5), 2, FanOutOptions{Buffer: 2, Policy: tt.policy})
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Reading the first consumer to its close drains the source before the second one is read
+			if events := collect(t, consumers[0]); events[len(events)-1].Type != StreamEventEnd {
+				t.Errorf("first consumer events = %+v, want the end event last", events)
+			}
+			events := collect(t, consumers[1])
+
+			if got := strings.Join(chunks(events), ","); got != tt.chunks {
+				t.Errorf("chunks = %q, want %q", got, tt.chunks)
+			}
+			n := len(events)
+			if n < 2 || events[n-2].Type != StreamEventWarning || events[n-1].Type != StreamEventEnd {
+				t.Fatalf("events = %+v, want a warning then the end event", events)
+			}
+			if want := fmt.Sprintf("%d stream events were dropped", tt.dropped); !strings.HasPrefix(events[n-2].Content, want) {
+				t.Errorf("warning = %q, want %q", events[n-2].Content, want)
+			}
+		})
+	}
+}
+
+func TestFanOutBlockDropsNothing(t *testing.T) {
+	consumers, err := FanOutWithOptions(fillStream(5), 2, FanOutOptions{Buffer: 1, Policy: FanOutBlock})
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan []StreamResult)
+	go func() { done <- collect(t, consumers[1]) }()
+	fast := collect(t, consumers[0])
+	slow := <-done
+
+	for _, events := range [][]StreamResult{fast, slow} {
+		if got := strings.Join(chunks(events), ","); got != "0,1,2,3,4" || len(events) != 6 {
+			t.Errorf("events = %+v, want every chunk and the end event without a warning", events)
+		}
+	}
+}
+
+func TestFanOutForwardsTerminalBeforeSourceCloses(t *testing.T) {
+	stream := make(chan StreamResult)
+	consumers := FanOut(stream, 2)
+	defer close(stream)
+
+	stream <- StreamResult{Type: StreamEventEnd}
+	for i, consumer := range consumers {
+		select {
+		case event := <-consumer:
+			if event.Type != StreamEventEnd {
+				t.Errorf("consumer %d got %q, want end", i, event.Type)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("consumer %d did not get the end event while the source was open", i)
+		}
+	}
+}
+
+func TestFanOutClosesAfterSource(t *testing.T) {
+	stream := make(chan StreamResult, 2)
+	consumers := FanOut(stream, 2)
+
+	stream <- StreamResult{Type: StreamEventError, Error: stderrors.New("failed")}
+	// Events after the terminal event still reach the consumers, in order
+	stream <- StreamResult{Type: StreamEventWarning, Content: "late"}
+	select {
+	case <-consumers[0]:
+	case <-time.After(time.Second):
+		t.Fatal("error event not forwarded")
+	}
+	select {
+	case <-consumers[0]:
+	case <-time.After(time.Second):
+		t.Fatal("late event not forwarded")
+	}
+	select {
+	case _, ok := <-consumers[0]:
+		t.Fatalf("consumer got an event or was closed (ok=%v) before the source closed", ok)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(stream)
+	if events := collect(t, consumers[0]); len(events) != 0 {
+		t.Errorf("consumer 0 got %d events after the source closed", len(events))
+	}
+	events := collect(t, consumers[1])
+	if len(events) != 2 || events[0].Type != StreamEventError || events[1].Content != "late" {
+		t.Errorf("consumer 1 events = %+v, want the error then the late warning", events)
+	}
+}
+
+func TestFanOutRejectsUnknownPolicy(t *testing.T) {
+	if _, err := FanOutWithOptions(make(chan StreamResult), 2, FanOutOptions{Policy: "drop_all"}); err == nil {
+		t.Error("unknown policy accepted")
+	}
+}
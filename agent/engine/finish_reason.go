@@ -0,0 +1,128 @@
+package engine
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/xichan96/cortex/agent/types"
+	"github.com/xichan96/cortex/pkg/errors"
+)
+
+// continuationPrompt asks the model to resume a response cut off by the output token limit
+const continuationPrompt = "Your previous response was cut off. Continue exactly where it stopped, without repeating anything."
+
+// contentFilteredError reports a response stopped by the provider's content filter
+func contentFilteredError(partial string) error {
+	return errors.NewError(errors.EC_LLM_CONTENT_FILTERED.Code,
+		fmt.Sprintf("response was stopped by the provider's content filter after %d characters", len(partial)))
+}
+
+// lengthContinuations returns how many continuation requests are allowed for a truncated response
+func (ae *AgentEngine) lengthContinuations() int {
+	ae.mu.RLock()
+	defer ae.mu.RUnlock()
+	if ae.config == nil {
+		return 0
+	}
+	return ae.config.LengthContinuations
+}
+
+// continuationMessages builds the request that resumes a truncated response
+func continuationMessages(messages []types.Message, partial string) []types.Message {
+	next := make([]types.Message, len(messages), len(messages)+2)
+	copy(next, messages)
+	return append(next,
+		types.Message{Role: "assistant", Content: partial},
+		types.Message{Role: "user", Content: continuationPrompt},
+	)
+}
+
+// handleFinishReason reacts to why the model stopped generating
+// A content_filter stop is returned as an error instead of silently returning partial text.
+// A length stop without tool calls is continued up to LengthContinuations times, and a warning is
+// recorded if the response is still truncated
+func (ae *AgentEngine) handleFinishReason(messages []types.Message, response types.Message, state *executionState) (types.Message, error) {
+	if response.FinishReason == types.FinishReasonContentFilter {
+		return response, contentFilteredError(response.Content)
+	}
+	if response.FinishReason != types.FinishReasonLength {
+		return response, nil
+	}
+	if len(response.ToolCalls) > 0 {
+		state.addWarning("response hit the output token limit, tool call arguments may be truncated")
+		return response, nil
+	}
+
+	maxContinuations := ae.lengthContinuations()
+	for attempt := 1; attempt <= maxContinuations && response.FinishReason == types.FinishReasonLength; attempt++ {
+		ae.logger.Info("Response truncated by output token limit, requesting continuation",
+			slog.Int("attempt", attempt),
+			slog.Int("max_continuations", maxContinuations))
+		next, err := ae.model.Chat(continuationMessages(messages, response.Content))
+		if err != nil {
+			ae.logger.LogError("handleFinishReason", err, slog.Int("attempt", attempt))
+			break
+		}
+		state.addUsage(next.Usage)
+		response.Content += next.Content
+		response.FinishReason = next.FinishReason
+		if next.FinishReason == types.FinishReasonContentFilter {
+			return response, contentFilteredError(response.Content)
+		}
+	}
+
+	if response.FinishReason == types.FinishReasonLength {
+		state.addWarning("response truncated: the model hit its output token limit")
+	}
+	return response, nil
+}
+
+// handleStreamFinishReason is the streaming counterpart of handleFinishReason
+// Continuations are streamed to the result channel as regular chunks
+// Returns the output and the finish reason of its last part
+func (ae *AgentEngine) handleStreamFinishReason(messages []types.Message, output, finishReason string, state *executionState, resultChan chan<- StreamResult, partial *partialJSONStream) (string, string, error) {
+	if finishReason == types.FinishReasonContentFilter {
+		return output, finishReason, contentFilteredError(output)
+	}
+	if finishReason != types.FinishReasonLength {
+		return output, finishReason, nil
+	}
+
+	maxContinuations := ae.lengthContinuations()
+	for attempt := 1; attempt <= maxContinuations && finishReason == types.FinishReasonLength; attempt++ {
+		ae.logger.Info("Streamed response truncated by output token limit, requesting continuation",
+			slog.Int("attempt", attempt),
+			slog.Int("max_continuations", maxContinuations))
+		next, nextReason, err := ae.streamChat(continuationMessages(messages, output), state, resultChan, partial)
+		if err != nil {
+			ae.logger.LogError("handleStreamFinishReason", err, slog.Int("attempt", attempt))
+			break
+		}
+		output += next
+		finishReason = nextReason
+		if finishReason == types.FinishReasonContentFilter {
+			return output, finishReason, contentFilteredError(output)
+		}
+	}
+
+	if finishReason == types.FinishReasonLength {
+		state.addWarning("response truncated: the model hit its output token limit")
+	}
+	return output, finishReason, nil
+}
+
+// runFinishReason reports why a run ended, for AgentResult.FinishReason
+// Reaching MaxIterations or MaxToolCalls takes precedence, otherwise it is the provider finish reason of
+// the final response ("length" when it is still truncated), defaulting to "stop" for providers that report none
+func runFinishReason(result *AgentResult) string {
+	switch result.StoppedReason {
+	case StoppedReasonMaxIterations:
+		return FinishReasonMaxIterations
+	case StoppedReasonMaxToolCalls:
+		return FinishReasonToolBudgetExhausted
+	}
+	if result.FinishReason == "" {
+		return types.FinishReasonStop
+	}
+	return result.FinishReason
+}
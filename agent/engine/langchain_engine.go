@@ -1,6 +1,7 @@
 package engine
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"strings"
@@ -272,6 +273,11 @@ func (e *LangChainAgentEngine) SetStopSequences(sequences []string) {
 	}
 }
 
+// SetResponseFormat sets the output format requested from the model
+func (e *LangChainAgentEngine) SetResponseFormat(format string) {
+	forwardResponseFormat(e.llm, format)
+}
+
 // SetTimeout sets timeout duration
 func (e *LangChainAgentEngine) SetTimeout(timeout time.Duration) {
 	if cfg, ok := e.llm.(interface{ SetTimeout(time.Duration) }); ok {
@@ -309,6 +315,7 @@ func (e *LangChainAgentEngine) SetConfig(config *types.AgentConfig) {
 	e.SetFrequencyPenalty(config.FrequencyPenalty)
 	e.SetPresencePenalty(config.PresencePenalty)
 	e.SetStopSequences(config.StopSequences)
+	e.SetResponseFormat(config.ResponseFormat)
 	e.SetTimeout(config.Timeout)
 	e.SetRetryAttempts(config.RetryAttempts)
 	e.SetRetryDelay(config.RetryDelay)
@@ -422,3 +429,8 @@ func (e *LangChainAgentEngine) ExecuteStream(input string, previousRequests []ty
 func (e *LangChainAgentEngine) Stop() {
 	// LangChain engine requires no special stop operation
 }
+
+// Shutdown shuts down the agent engine (LangChain engine requires no special shutdown operation)
+func (e *LangChainAgentEngine) Shutdown(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,141 @@
+package engine
+
+import (
+	"sync"
+	"time"
+)
+
+// StreamMetrics latency measurements of a single streaming execution
+type StreamMetrics struct {
+	TimeToFirstTokenMs     int64   `json:"time_to_first_token_ms"`     // from the ExecuteStream call to the first chunk
+	AvgInterTokenLatencyMs float64 `json:"avg_inter_token_latency_ms"` // mean gap between consecutive chunks
+	MaxInterTokenLatencyMs int64   `json:"max_inter_token_latency_ms"` // largest gap, includes tool execution between iterations
+	Chunks                 int     `json:"chunks"`
+}
+
+// ModelStreamStats streaming latency aggregated per model
+type ModelStreamStats struct {
+	Streams              int64
+	AvgTimeToFirstToken  time.Duration
+	MaxTimeToFirstToken  time.Duration
+	AvgInterTokenLatency time.Duration
+}
+
+// streamTiming chunk timing of a streaming execution
+type streamTiming struct {
+	start    time.Time
+	first    time.Time
+	last     time.Time
+	chunks   int
+	gapTotal time.Duration
+	gapMax   time.Duration
+}
+
+// newStreamTiming starts timing at the ExecuteStream call
+func newStreamTiming(start time.Time) *streamTiming {
+	return &streamTiming{start: start}
+}
+
+// recordChunk records the arrival of a chunk
+func (t *streamTiming) recordChunk(now time.Time) {
+	if t.chunks == 0 {
+		t.first = now
+	} else {
+		gap := now.Sub(t.last)
+		t.gapTotal += gap
+		if gap > t.gapMax {
+			t.gapMax = gap
+		}
+	}
+	t.last = now
+	t.chunks++
+}
+
+// metrics returns the measurements, nil if no chunk was streamed
+func (t *streamTiming) metrics() *StreamMetrics {
+	if t == nil || t.chunks == 0 {
+		return nil
+	}
+	m := &StreamMetrics{
+		TimeToFirstTokenMs:     t.first.Sub(t.start).Milliseconds(),
+		MaxInterTokenLatencyMs: t.gapMax.Milliseconds(),
+		Chunks:                 t.chunks,
+	}
+	if t.chunks > 1 {
+		m.AvgInterTokenLatencyMs = float64(t.gapTotal.Microseconds()) / float64(t.chunks-1) / 1000
+	}
+	return m
+}
+
+// streamStatsCollector aggregates stream timings per model
+type streamStatsCollector struct {
+	mu     sync.Mutex
+	models map[string]*modelStreamTotals
+}
+
+// modelStreamTotals running totals for one model
+type modelStreamTotals struct {
+	streams  int64
+	ttfTotal time.Duration
+	ttfMax   time.Duration
+	gapTotal time.Duration
+	gaps     int64
+}
+
+// record adds a finished stream to the model totals
+func (c *streamStatsCollector) record(model string, t *streamTiming) {
+	if t == nil || t.chunks == 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.models == nil {
+		c.models = make(map[string]*modelStreamTotals)
+	}
+	totals, ok := c.models[model]
+	if !ok {
+		totals = &modelStreamTotals{}
+		c.models[model] = totals
+	}
+
+	ttft := t.first.Sub(t.start)
+	totals.streams++
+	totals.ttfTotal += ttft
+	if ttft > totals.ttfMax {
+		totals.ttfMax = ttft
+	}
+	totals.gapTotal += t.gapTotal
+	totals.gaps += int64(t.chunks - 1)
+}
+
+// snapshot returns the aggregated stats of every model
+func (c *streamStatsCollector) snapshot() map[string]ModelStreamStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := make(map[string]ModelStreamStats, len(c.models))
+	for model, totals := range c.models {
+		s := ModelStreamStats{
+			Streams:             totals.streams,
+			AvgTimeToFirstToken: totals.ttfTotal / time.Duration(totals.streams),
+			MaxTimeToFirstToken: totals.ttfMax,
+		}
+		if totals.gaps > 0 {
+			s.AvgInterTokenLatency = totals.gapTotal / time.Duration(totals.gaps)
+		}
+		stats[model] = s
+	}
+	return stats
+}
+
+// StreamStats returns streaming latency (time to first token, inter-token latency) per model
+func (ae *AgentEngine) StreamStats() map[string]ModelStreamStats {
+	return ae.streamStats.snapshot()
+}
+
+// modelName identifies the engine's model for per-model metrics
+func (ae *AgentEngine) modelName() string {
+	if ae.model == nil {
+		return ""
+	}
+	return ae.model.GetModelName()
+}
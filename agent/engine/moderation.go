@@ -0,0 +1,66 @@
+package engine
+
+import (
+	stderrors "errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/xichan96/cortex/agent/types"
+	"github.com/xichan96/cortex/pkg/errors"
+)
+
+// SetModerator sets the moderator checking user input before it reaches the model, nil disables moderation
+// With ModerateOutput the final output is checked as well
+func (ae *AgentEngine) SetModerator(moderator types.Moderator) {
+	ae.mu.Lock()
+	defer ae.mu.Unlock()
+	ae.moderator = moderator
+}
+
+// moderateInput checks user input with the moderator
+// Blocked input and moderator failures both reject the request, the model is never called with unchecked input
+func (ae *AgentEngine) moderateInput(input string) error {
+	ae.mu.RLock()
+	moderator := ae.moderator
+	ae.mu.RUnlock()
+	if moderator == nil {
+		return nil
+	}
+	return ae.moderate(moderator, "input", input)
+}
+
+// moderateOutput checks the final output when ModerateOutput is enabled
+func (ae *AgentEngine) moderateOutput(output string) error {
+	ae.mu.RLock()
+	moderator := ae.moderator
+	enabled := ae.config != nil && ae.config.ModerateOutput
+	ae.mu.RUnlock()
+	if moderator == nil || !enabled || output == "" {
+		return nil
+	}
+	return ae.moderate(moderator, "output", output)
+}
+
+// moderate runs one moderation check, kind is "input" or "output"
+func (ae *AgentEngine) moderate(moderator types.Moderator, kind, text string) error {
+	allowed, reason, err := moderator.Check(text)
+	if err != nil {
+		ae.logger.LogError("moderate", err, slog.String("kind", kind))
+		return errors.NewError(errors.EC_FORBIDDEN.Code, fmt.Sprintf("%s could not be moderated", kind)).Wrap(err)
+	}
+	if allowed {
+		return nil
+	}
+	if reason == "" {
+		reason = "flagged"
+	}
+	ae.logger.Info("Moderation blocked "+kind, slog.String("reason", reason))
+	return errors.NewError(errors.EC_FORBIDDEN.Code, fmt.Sprintf("%s blocked by moderation: %s", kind, reason))
+}
+
+// isModerationError reports whether err rejects a request on moderation grounds
+// Such errors are returned as-is so callers can tell them apart by the EC_FORBIDDEN code
+func isModerationError(err error) bool {
+	var e *errors.Error
+	return stderrors.As(err, &e) && e.Code == errors.EC_FORBIDDEN.Code
+}
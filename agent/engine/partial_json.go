@@ -0,0 +1,147 @@
+package engine
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// partialJSONStream incrementally parses JSON output as content chunks arrive
+type partialJSONStream struct {
+	buf  strings.Builder
+	last string // last emitted object, marshaled
+}
+
+// newPartialJSON creates a partial JSON parser when partial JSON streaming is enabled
+func (s *executionState) newPartialJSON() *partialJSONStream {
+	if !s.partialJSON {
+		return nil
+	}
+	return &partialJSONStream{}
+}
+
+// write appends a chunk and returns the object parsed so far if it changed
+func (p *partialJSONStream) write(chunk string) (interface{}, bool) {
+	if p == nil {
+		return nil, false
+	}
+	p.buf.WriteString(chunk)
+	value, ok := parsePartialJSON(p.buf.String())
+	if !ok {
+		return nil, false
+	}
+	return p.changed(value)
+}
+
+// finish parses the complete output strictly and returns the final object if it differs from the last one emitted
+func (p *partialJSONStream) finish() (interface{}, bool) {
+	if p == nil {
+		return nil, false
+	}
+	s := p.buf.String()
+	start := strings.IndexAny(s, "{[")
+	if start < 0 {
+		return nil, false
+	}
+	var value interface{}
+	if err := json.NewDecoder(strings.NewReader(s[start:])).Decode(&value); err != nil {
+		return nil, false
+	}
+	return p.changed(value)
+}
+
+// changed reports whether value differs from the last emitted object and records it
+func (p *partialJSONStream) changed(value interface{}) (interface{}, bool) {
+	data, err := json.Marshal(value)
+	if err != nil || string(data) == p.last {
+		return nil, false
+	}
+	p.last = string(data)
+	return value, true
+}
+
+// parsePartialJSON parses the JSON object or array at the start of s, closing whatever is still open
+// Leading prose or a code fence before the first bracket is skipped. Unterminated strings are kept,
+// incomplete keys, literals and trailing commas are dropped
+func parsePartialJSON(s string) (interface{}, bool) {
+	start := strings.IndexAny(s, "{[")
+	if start < 0 {
+		return nil, false
+	}
+	s = s[start:]
+
+	// cut is a prefix end at which the document can be completed by appending closers
+	type cut struct {
+		end     int
+		closers string
+	}
+	var (
+		stack    []byte
+		cuts     []cut
+		inString bool
+		escaped  bool
+	)
+	closers := func() string {
+		b := make([]byte, len(stack))
+		for i, open := range stack {
+			if open == '{' {
+				b[len(stack)-1-i] = '}'
+			} else {
+				b[len(stack)-1-i] = ']'
+			}
+		}
+		return string(b)
+	}
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+				cuts = append(cuts, cut{i + 1, closers()})
+			}
+			continue
+		}
+		switch c {
+		case '"':
+			inString = true
+		case '{', '[':
+			stack = append(stack, c)
+			cuts = append(cuts, cut{i + 1, closers()})
+		case '}', ']':
+			stack = stack[:len(stack)-1]
+			cuts = append(cuts, cut{i + 1, closers()})
+		case ',':
+			cuts = append(cuts, cut{i, closers()})
+		}
+		if len(stack) == 0 {
+			s = s[:i+1]
+			break
+		}
+	}
+
+	var candidates []string
+	if inString {
+		tail := s
+		if escaped {
+			tail = tail[:len(tail)-1]
+		}
+		candidates = append(candidates, tail+`"`+closers())
+	}
+	candidates = append(candidates, s+closers())
+	for i := len(cuts) - 1; i >= 0; i-- {
+		candidates = append(candidates, s[:cuts[i].end]+cuts[i].closers)
+	}
+
+	for _, candidate := range candidates {
+		var value interface{}
+		if err := json.Unmarshal([]byte(candidate), &value); err == nil {
+			return value, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,101 @@
+package engine
+
+import (
+	stderrors "errors"
+
+	"github.com/xichan96/cortex/agent/types"
+	"github.com/xichan96/cortex/pkg/errors"
+)
+
+// Stream event types
+// Every stream delivers zero or more chunk, warning, approval_required, partial_json, plan, tool_start and tool_end events
+// followed by exactly one terminal event (end or error), after which the channel is closed
+const (
+	StreamEventChunk            = "chunk"             // Content holds the next piece of model output
+	StreamEventWarning          = "warning"           // Content holds a non-fatal warning
+	StreamEventApprovalRequired = "approval_required" // Content holds the tool name, Data the *types.ToolCallRequest awaiting approval
+	StreamEventPartialJSON      = "partial_json"      // Data holds the (possibly incomplete) JSON object parsed so far
+	StreamEventPlan             = "plan"              // Data holds the *ToolPlan of the tool calls about to run
+	StreamEventToolStart        = "tool_start"        // Content holds the tool name, Data the *ToolEvent of the call about to run
+	StreamEventToolEnd          = "tool_end"          // Content holds the tool name, Data the *ToolEvent with the call's outcome
+	StreamEventError            = "error"             // Error is set, terminal
+	StreamEventEnd              = "end"               // Data holds the *AgentResult, terminal
+)
+
+// RawStreamEvent transport-neutral stream event
+// Unlike StreamResult it is fully JSON-serializable, so proxies can re-frame it for SSE, gRPC or a message queue
+type RawStreamEvent struct {
+	Seq     int64           `json:"seq"` // 1-based position in the stream
+	Type    string          `json:"type"`
+	Content string          `json:"content,omitempty"`
+	Data    interface{}     `json:"data,omitempty"`
+	Error   *RawStreamError `json:"error,omitempty"`
+}
+
+// RawStreamError error carried by a terminal error event
+type RawStreamError struct {
+	Code    int    `json:"code,omitempty"` // pkg/errors code, 0 for errors outside the catalog
+	Message string `json:"message"`
+}
+
+// IsTerminal reports whether no further events follow
+func (e RawStreamEvent) IsTerminal() bool {
+	return e.Type == StreamEventEnd || e.Type == StreamEventError
+}
+
+// ExecuteStreamRaw executes the agent task with streaming and delivers RawStreamEvent values
+// It is the same stream as ExecuteStreamWithOptions without any transport framing
+func (ae *AgentEngine) ExecuteStreamRaw(input string, previousRequests []types.ToolCallData, opts *ExecuteOptions) (<-chan RawStreamEvent, error) {
+	stream, err := ae.ExecuteStreamWithOptions(input, previousRequests, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	rawChan := make(chan RawStreamEvent, DefaultChannelBuffer)
+	go func() {
+		defer close(rawChan)
+		var seq int64
+		for result := range stream {
+			seq++
+			event := ToRawStreamEvent(result)
+			event.Seq = seq
+			rawChan <- event
+		}
+	}()
+	return rawChan, nil
+}
+
+// ToRawStreamEvent converts a stream result into its transport-neutral form, Seq is left unset
+func ToRawStreamEvent(result StreamResult) RawStreamEvent {
+	event := RawStreamEvent{
+		Type:    result.Type,
+		Content: result.Content,
+	}
+	switch result.Type {
+	case StreamEventApprovalRequired:
+		event.Data = result.ToolCall
+	case StreamEventPartialJSON:
+		event.Data = result.JSON
+	case StreamEventPlan:
+		event.Data = result.Plan
+	case StreamEventToolStart, StreamEventToolEnd:
+		event.Data = result.ToolEvent
+	case StreamEventEnd:
+		event.Data = result.Result
+	case StreamEventError:
+		event.Error = toRawStreamError(result.Error)
+	}
+	return event
+}
+
+// toRawStreamError flattens an error into its code and message
+func toRawStreamError(err error) *RawStreamError {
+	if err == nil {
+		return &RawStreamError{Message: "unknown error"}
+	}
+	var e *errors.Error
+	if stderrors.As(err, &e) {
+		return &RawStreamError{Code: e.Code, Message: e.Message}
+	}
+	return &RawStreamError{Message: err.Error()}
+}
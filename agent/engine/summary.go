@@ -0,0 +1,117 @@
+package engine
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/xichan96/cortex/agent/types"
+)
+
+// Summary-related constants
+const (
+	DefaultSummaryMaxTokens = 100  // default token cap of AgentResult.Summary
+	summaryInputTokens      = 4000 // output tokens sent to the summary model, the remainder is cut
+	summaryCacheSize        = 256  // summaries kept per engine
+)
+
+// summaryCache bounded cache of summaries keyed by output and token cap, oldest entries are evicted first
+type summaryCache struct {
+	mu      sync.Mutex
+	entries map[string]string
+	order   []string
+}
+
+func (c *summaryCache) get(key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	summary, ok := c.entries[key]
+	return summary, ok
+}
+
+func (c *summaryCache) put(key, summary string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = make(map[string]string)
+	}
+	if _, ok := c.entries[key]; ok {
+		return
+	}
+	if len(c.order) >= summaryCacheSize {
+		delete(c.entries, c.order[0])
+		c.order = c.order[1:]
+	}
+	c.entries[key] = summary
+	c.order = append(c.order, key)
+}
+
+// SetSummaryModel sets the model used for AgentResult.Summary and generated titles, typically a cheaper one
+// nil falls back to the engine model
+func (ae *AgentEngine) SetSummaryModel(model types.LLMProvider) {
+	ae.mu.Lock()
+	defer ae.mu.Unlock()
+	ae.summaryModel = model
+}
+
+// summarizeResult sets result.Summary when GenerateSummary is enabled
+// Failures only add a warning, the full output is still returned
+func (ae *AgentEngine) summarizeResult(result *AgentResult, state *executionState) {
+	ae.mu.RLock()
+	enabled := ae.config != nil && ae.config.GenerateSummary
+	maxTokens := 0
+	if ae.config != nil {
+		maxTokens = ae.config.SummaryMaxTokens
+	}
+	model := ae.summaryModel
+	if model == nil {
+		model = ae.model
+	}
+	ae.mu.RUnlock()
+
+	if !enabled || result == nil || result.Output == "" {
+		return
+	}
+	if maxTokens <= 0 {
+		maxTokens = DefaultSummaryMaxTokens
+	}
+
+	// Short answers are their own summary
+	if types.EstimateTextTokens(result.Output) <= maxTokens {
+		result.Summary = result.Output
+		return
+	}
+
+	hash := sha256.Sum256([]byte(result.Output))
+	key := fmt.Sprintf("%d:%s", maxTokens, hex.EncodeToString(hash[:]))
+	if summary, ok := ae.summaryCache.get(key); ok {
+		result.Summary = summary
+		return
+	}
+
+	if model == nil {
+		state.addWarning("summary not generated: no model available")
+		return
+	}
+
+	response, err := model.Chat([]types.Message{
+		{
+			Role:    "system",
+			Content: "You write short summaries of answers for notifications such as emails and chat alerts. Keep the key conclusion and any required action, omit details and formatting.",
+		},
+		{
+			Role:    "user",
+			Content: fmt.Sprintf("Summarize the following answer in at most about %d words:\n\n%s", maxTokens*3/4, truncateToTokens(result.Output, summaryInputTokens)),
+		},
+	})
+	if err != nil {
+		ae.logger.LogError("summarizeResult", err, slog.Int("output_length", len(result.Output)))
+		state.addWarning("failed to generate summary: %v", err)
+		return
+	}
+
+	result.Summary = truncateToTokens(response.Content, maxTokens)
+	ae.summaryCache.put(key, result.Summary)
+}
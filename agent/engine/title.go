@@ -0,0 +1,156 @@
+package engine
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"unicode"
+
+	"github.com/xichan96/cortex/agent/types"
+	"github.com/xichan96/cortex/pkg/errors"
+)
+
+// Title-related constants
+const (
+	MaxTitleLength     = 60      // maximum title length in characters
+	TitleMetadataKey   = "title" // memory metadata key of the stored title
+	titleInputTokens   = 500     // tokens of the first message sent to the title model
+	titleSearchWindow  = 20      // leading stored messages searched for the first user message
+	titleMarkdownChars = "#*_`>[]"
+)
+
+// Title returns a short title for the conversation in the engine memory, "" when it has no user message yet
+// The title is derived from the first user message: it is used as-is when it fits on one line within
+// MaxTitleLength characters, otherwise it is shortened, by the summary model when GenerateTitles is set
+// The title is cached on the engine and stored in the memory metadata when the provider supports it
+func (ae *AgentEngine) Title() (string, error) {
+	ae.mu.RLock()
+	title := ae.title
+	memory := ae.memory
+	generate := ae.config != nil && ae.config.GenerateTitles
+	model := ae.summaryModel
+	if model == nil {
+		model = ae.model
+	}
+	ae.mu.RUnlock()
+
+	if title != "" || memory == nil {
+		return title, nil
+	}
+
+	store, hasStore := memory.(types.MemoryMetadataStore)
+	if hasStore {
+		stored, ok, err := store.GetMetadata(TitleMetadataKey)
+		if err != nil {
+			ae.logger.LogError("Title", err, slog.String("phase", "load_metadata"))
+		} else if ok && stored != "" {
+			ae.setTitle(stored)
+			return stored, nil
+		}
+	}
+
+	first, err := firstUserMessage(memory)
+	if err != nil || first == "" {
+		return "", err
+	}
+
+	title, complete := heuristicTitle(first)
+	if !complete && generate && model != nil {
+		if generated, err := ae.generateTitle(model, first); err != nil {
+			ae.logger.LogError("Title", err, slog.String("phase", "generate"))
+		} else if generated != "" {
+			title = generated
+		}
+	}
+
+	ae.setTitle(title)
+	if hasStore {
+		if err := store.SetMetadata(TitleMetadataKey, title); err != nil {
+			ae.logger.LogError("Title", err, slog.String("phase", "store_metadata"))
+		}
+	}
+	return title, nil
+}
+
+// StoredTitle returns the title of the conversation in memory without an engine, "" when it has no user message yet
+// It returns the title stored in the memory metadata, or else the first user message shortened like Title
+// without a model; nothing is stored, so a later Title call can still generate one
+func StoredTitle(memory types.MemoryProvider) (string, error) {
+	if store, ok := memory.(types.MemoryMetadataStore); ok {
+		if stored, ok, err := store.GetMetadata(TitleMetadataKey); err == nil && ok && stored != "" {
+			return stored, nil
+		}
+	}
+	first, err := firstUserMessage(memory)
+	if err != nil || first == "" {
+		return "", err
+	}
+	title, _ := heuristicTitle(first)
+	return title, nil
+}
+
+// firstUserMessage returns the first non-empty user message among the leading stored messages
+func firstUserMessage(memory types.MemoryProvider) (string, error) {
+	messages, _, err := types.PageMessages(memory, 0, titleSearchWindow)
+	if err != nil {
+		return "", errors.NewError(errors.EC_MEMORY_HISTORY_FAILED.Code, errors.EC_MEMORY_HISTORY_FAILED.Message).Wrap(err)
+	}
+	for _, msg := range messages {
+		if msg.Role == "user" && strings.TrimSpace(msg.Content) != "" {
+			return msg.Content, nil
+		}
+	}
+	return "", nil
+}
+
+// setTitle caches the conversation title on the engine
+func (ae *AgentEngine) setTitle(title string) {
+	ae.mu.Lock()
+	defer ae.mu.Unlock()
+	ae.title = title
+}
+
+// generateTitle asks the model for a title of the conversation starting with message
+func (ae *AgentEngine) generateTitle(model types.LLMProvider, message string) (string, error) {
+	response, err := model.Chat([]types.Message{
+		{
+			Role:    "system",
+			Content: "You write short titles for chat conversations, like an email subject. Reply with the title only, without quotes or punctuation at the end.",
+		},
+		{
+			Role: "user",
+			Content: fmt.Sprintf("Write a title of at most %d characters for a conversation that starts with this message:\n\n%s",
+				MaxTitleLength, truncateToTokens(message, titleInputTokens)),
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+	title, _ := heuristicTitle(strings.Trim(strings.TrimSpace(response.Content), `"'`))
+	return title, nil
+}
+
+// heuristicTitle derives a title from the first line of text, cut at a word boundary to MaxTitleLength
+// complete reports whether the text fit on one line without cutting
+func heuristicTitle(text string) (string, bool) {
+	text = strings.TrimSpace(text)
+	line, rest, _ := strings.Cut(text, "\n")
+	line = strings.Join(strings.Fields(strings.Trim(line, titleMarkdownChars+" \t\r")), " ")
+	complete := strings.TrimSpace(rest) == ""
+
+	runes := []rune(line)
+	if len(runes) <= MaxTitleLength {
+		return line, complete
+	}
+
+	cut := MaxTitleLength - 1
+	for i := cut; i > MaxTitleLength/2; i-- {
+		if unicode.IsSpace(runes[i]) {
+			cut = i
+			break
+		}
+	}
+	return strings.TrimRightFunc(string(runes[:cut]), func(r rune) bool {
+		return unicode.IsSpace(r) || unicode.IsPunct(r)
+	}) + "…", false
+}
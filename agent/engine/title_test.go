@@ -0,0 +1,31 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/xichan96/cortex/agent/providers"
+)
+
+func TestStoredTitle(t *testing.T) {
+	memory := providers.NewSimpleMemoryProvider()
+	if title, err := StoredTitle(memory); err != nil || title != "" {
+		t.Errorf("empty memory title = %q, %v, want empty", title, err)
+	}
+
+	if err := memory.SaveContext(map[string]interface{}{"input": "How do I reset my password?"}, map[string]interface{}{"output": "Open settings."}); err != nil {
+		t.Fatal(err)
+	}
+	if title, err := StoredTitle(memory); err != nil || title != "How do I reset my password?" {
+		t.Errorf("title = %q, %v, want the first user message", title, err)
+	}
+	if _, ok, _ := memory.GetMetadata(TitleMetadataKey); ok {
+		t.Error("StoredTitle stored the derived title")
+	}
+
+	if err := memory.SetMetadata(TitleMetadataKey, "Password reset"); err != nil {
+		t.Fatal(err)
+	}
+	if title, err := StoredTitle(memory); err != nil || title != "Password reset" {
+		t.Errorf("title = %q, %v, want the stored title", title, err)
+	}
+}
@@ -0,0 +1,68 @@
+package engine
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+
+	"github.com/xichan96/cortex/agent/types"
+)
+
+// checkToolArgs removes or rejects argument keys the tool schema does not declare
+// With StrictToolArgs the call is rejected and the returned observation lists the unknown keys,
+// otherwise they are stripped and the cleaned arguments are returned
+// Schemas that declare no properties or allow additional properties accept any key
+func (ae *AgentEngine) checkToolArgs(tool types.Tool, args map[string]interface{}) (map[string]interface{}, string, bool) {
+	unknown := unknownToolArgs(tool.Schema(), args)
+	if len(unknown) == 0 {
+		return args, "", true
+	}
+
+	ae.mu.RLock()
+	strict := ae.config != nil && ae.config.StrictToolArgs
+	ae.mu.RUnlock()
+
+	if strict {
+		return nil, fmt.Sprintf("tool '%s' not executed: unknown parameters %s, use only the parameters declared in the tool schema",
+			tool.Name(), strings.Join(unknown, ", ")), false
+	}
+
+	ae.logger.Info("Stripped unknown tool parameters",
+		slog.String("tool_name", tool.Name()),
+		slog.Any("parameters", unknown))
+	cleaned := make(map[string]interface{}, len(args)-len(unknown))
+	for key, value := range args {
+		cleaned[key] = value
+	}
+	for _, key := range unknown {
+		delete(cleaned, key)
+	}
+	return cleaned, "", true
+}
+
+// unknownToolArgs returns the sorted argument keys missing from the schema properties
+func unknownToolArgs(schema map[string]interface{}, args map[string]interface{}) []string {
+	if len(args) == 0 {
+		return nil
+	}
+	properties, ok := schema["properties"].(map[string]interface{})
+	if !ok || len(properties) == 0 {
+		return nil
+	}
+	if additional, ok := schema["additionalProperties"]; ok && additional != false {
+		return nil
+	}
+	if _, ok := schema["patternProperties"]; ok {
+		return nil
+	}
+
+	var unknown []string
+	for key := range args {
+		if _, declared := properties[key]; !declared {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
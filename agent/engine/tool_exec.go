@@ -0,0 +1,289 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/xichan96/cortex/agent/tools"
+	"github.com/xichan96/cortex/agent/types"
+)
+
+// DefaultMaxParallelToolCalls maximum tool calls run at once when ParallelToolCalls is enabled
+const DefaultMaxParallelToolCalls = 4
+
+// toolCallOutcome result of one planned tool call
+type toolCallOutcome struct {
+	call     types.ToolCallRequest // the call with resolved tool name and checked arguments
+	step     types.ToolCallData
+	executed bool // the tool ran (or was served from cache) successfully
+}
+
+// preparedToolCall a tool call that passed the checks before execution and is ready to run
+type preparedToolCall struct {
+	index int
+	tool  types.Tool
+	call  types.ToolCallRequest
+}
+
+// executeToolCalls runs the planned tool calls of an iteration, outcomes keep the planned order
+// Resolution, argument checks, budget and approval always happen in planned order. With ParallelToolCalls
+// the approved calls then run concurrently, a call only starting once the earlier calls it depends on finished
+func (ae *AgentEngine) executeToolCalls(ctx context.Context, calls []types.ToolCallRequest, iteration int, state *executionState, attrs ...slog.Attr) []toolCallOutcome {
+	ae.mu.RLock()
+	parallel := false
+	timeout := time.Duration(0)
+	if ae.config != nil {
+		parallel = ae.config.ParallelToolCalls
+		timeout = defaultToolTimeout(ae.config)
+	}
+	ae.mu.RUnlock()
+
+	outcomes := make([]toolCallOutcome, len(calls))
+	if !parallel || len(calls) <= 1 {
+		for i, call := range calls {
+			prepared, outcome := ae.prepareToolCall(call, iteration, state, attrs...)
+			if prepared == nil {
+				outcomes[i] = outcome
+				continue
+			}
+			outcomes[i] = ae.runToolCall(ctx, prepared.tool, prepared.call, timeout, state, attrs...)
+		}
+		return outcomes
+	}
+
+	pending := make([]preparedToolCall, 0, len(calls))
+	for i, call := range calls {
+		prepared, outcome := ae.prepareToolCall(call, iteration, state, attrs...)
+		if prepared == nil {
+			outcomes[i] = outcome
+			continue
+		}
+		prepared.index = i
+		pending = append(pending, *prepared)
+	}
+
+	levels := toolCallLevels(pending)
+	ae.logger.LogExecution("executeToolCalls", iteration, "Running tool calls in parallel",
+		append([]slog.Attr{
+			slog.Int("tool_calls", len(pending)),
+			slog.Int("levels", len(levels)),
+		}, attrs...)...)
+
+	sem := make(chan struct{}, DefaultMaxParallelToolCalls)
+	for _, level := range levels {
+		var wg sync.WaitGroup
+		for _, p := range level {
+			wg.Add(1)
+			sem <- struct{}{}
+			go func(p preparedToolCall) {
+				defer wg.Done()
+				defer func() { <-sem }()
+				outcomes[p.index] = ae.runToolCall(ctx, p.tool, p.call, timeout, state, attrs...)
+			}(p)
+		}
+		wg.Wait()
+	}
+	return outcomes
+}
+
+// toolCallLevels groups prepared calls into levels that may run concurrently
+// A call is placed one level after the latest earlier call whose tool it depends on, so the
+// topological order from planning still holds between dependent calls
+func toolCallLevels(calls []preparedToolCall) [][]preparedToolCall {
+	var levels [][]preparedToolCall
+	levelOf := make(map[string]int, len(calls)) // tool name -> highest level it was scheduled at
+	for _, call := range calls {
+		level := 0
+		for _, dep := range call.tool.Metadata().Dependencies {
+			if l, ok := levelOf[dep]; ok && l+1 > level {
+				level = l + 1
+			}
+		}
+		if len(levels) <= level {
+			levels = append(levels, nil)
+		}
+		levels[level] = append(levels[level], call)
+		if l, ok := levelOf[call.call.Tool]; !ok || level > l {
+			levelOf[call.call.Tool] = level
+		}
+	}
+	return levels
+}
+
+// prepareToolCall resolves the tool and checks arguments, the tool call budget and approval, then runs the
+// BeforeExecute tool hooks. It returns nil with the outcome to record when the call must not run
+func (ae *AgentEngine) prepareToolCall(call types.ToolCallRequest, iteration int, state *executionState, attrs ...slog.Attr) (*preparedToolCall, toolCallOutcome) {
+	ae.logger.Info("Executing tool", append([]slog.Attr{
+		slog.String("tool_name", call.Tool),
+		slog.Int("iteration", iteration+1),
+	}, attrs...)...)
+
+	tool, resolvedName, suggestions := ae.resolveTool(call.Tool, state)
+	if tool == nil {
+		ae.logger.Info("Tool not found", append([]slog.Attr{
+			slog.String("tool_name", call.Tool),
+			slog.Int("iteration", iteration+1),
+		}, attrs...)...)
+		state.addWarning("tool '%s' not found, call skipped", call.Tool)
+		return nil, observedToolCall(call, toolNotFoundMessage(call.Tool, suggestions))
+	}
+	call.Tool = resolvedName
+
+	args, errMsg, valid := ae.checkToolArgs(tool, call.ToolInput)
+	if !valid {
+		state.addWarning("tool '%s' rejected: unknown parameters", call.Tool)
+		return nil, observedToolCall(call, errMsg)
+	}
+	call.ToolInput = args
+
+	if err := tools.ValidateInput(tool.Schema(), call.ToolInput); err != nil {
+		ae.logger.Info("Tool input failed schema validation",
+			slog.String("tool_name", call.Tool),
+			slog.String("error", err.Error()))
+		state.addWarning("tool '%s' rejected: input does not match its schema", call.Tool)
+		return nil, observedToolCall(call, fmt.Sprintf("tool '%s' not executed: %v, fix the arguments and call the tool again", call.Tool, err))
+	}
+
+	if !state.reserveToolCall() {
+		ae.logger.Info("Tool call budget exhausted, skipping tool",
+			slog.String("tool_name", call.Tool),
+			slog.Int("max_tool_calls", state.maxToolCalls))
+		state.addWarning("tool '%s' skipped: tool call limit of %d reached", call.Tool, state.maxToolCalls)
+		return nil, observedToolCall(call, fmt.Sprintf("tool '%s' skipped: tool call limit of %d reached", call.Tool, state.maxToolCalls))
+	}
+
+	if approved, reason := ae.requestApproval(call, state); !approved {
+		state.addWarning("tool '%s' not executed: %s", call.Tool, reason)
+		return nil, observedToolCall(call, fmt.Sprintf("tool '%s' not executed: %s", call.Tool, reason))
+	}
+
+	args, err := ae.beforeToolHooks(call.Tool, call.ToolInput)
+	if err != nil {
+		state.addWarning("tool '%s' not executed: rejected by tool hook", call.Tool)
+		return nil, observedToolCall(call, toolHookRejection(call.Tool, err))
+	}
+	call.ToolInput = args
+
+	return &preparedToolCall{tool: tool, call: call}, toolCallOutcome{}
+}
+
+// defaultToolTimeout returns the timeout of tools without ToolMetadata.Timeout
+// ToolCallTimeout when set, otherwise ToolExecutionTimeout
+func defaultToolTimeout(config *types.AgentConfig) time.Duration {
+	if config.ToolCallTimeout > 0 {
+		return config.ToolCallTimeout
+	}
+	return config.ToolExecutionTimeout
+}
+
+// toolTimeout returns the execution timeout of tool, its ToolMetadata.Timeout or fallback
+func toolTimeout(tool types.Tool, fallback time.Duration) time.Duration {
+	if timeout := tool.Metadata().Timeout; timeout > 0 {
+		return timeout
+	}
+	return fallback
+}
+
+// runToolCall runs a prepared tool call, emitting tool_start and tool_end events when streaming
+func (ae *AgentEngine) runToolCall(ctx context.Context, tool types.Tool, call types.ToolCallRequest, timeout time.Duration, state *executionState, attrs ...slog.Attr) toolCallOutcome {
+	timeout = toolTimeout(tool, timeout)
+	if state.emit == nil {
+		return ae.callTool(ctx, tool, call, timeout, state, attrs...)
+	}
+
+	state.emit(StreamResult{
+		Type:    StreamEventToolStart,
+		Content: call.Tool,
+		ToolEvent: &ToolEvent{
+			Tool:       call.Tool,
+			ToolCallID: call.ToolCallID,
+			Arguments:  call.ToolInput,
+		},
+	})
+	startTime := time.Now()
+	outcome := ae.callTool(ctx, tool, call, timeout, state, attrs...)
+	state.emit(StreamResult{
+		Type:    StreamEventToolEnd,
+		Content: call.Tool,
+		ToolEvent: &ToolEvent{
+			Tool:        call.Tool,
+			ToolCallID:  call.ToolCallID,
+			Arguments:   call.ToolInput,
+			Observation: truncateString(outcome.step.Observation, MaxToolEventObservationLength),
+			Success:     outcome.executed,
+			DurationMs:  time.Since(startTime).Milliseconds(),
+		},
+	})
+	return outcome
+}
+
+// callTool runs a prepared tool call, serving it from the cache when possible
+// The AfterExecute tool hooks see every result, the cache keeps the tool's own
+func (ae *AgentEngine) callTool(ctx context.Context, tool types.Tool, call types.ToolCallRequest, timeout time.Duration, state *executionState, attrs ...slog.Attr) toolCallOutcome {
+	toolResult, err, cached := ae.getCachedToolResult(call.Tool, call.ToolInput)
+	if cached {
+		ae.logger.LogToolExecution(call.Tool, true, 0, append([]slog.Attr{slog.Bool("cached", true)}, attrs...)...)
+		if err != nil {
+			ae.logger.LogToolExecution(call.Tool, false, 0, append([]slog.Attr{
+				slog.String("error", err.Error()),
+				slog.Bool("cached", true),
+			}, attrs...)...)
+		}
+	} else {
+		if allowed, msg := ae.acquireToolRateLimit(tool, state); !allowed {
+			return observedToolCall(call, msg)
+		}
+		toolStartTime := time.Now()
+
+		// Execute tool with timeout
+		toolResult, err = ae.executeToolWithRetry(ctx, tool, call.ToolInput, timeout, attrs...)
+		duration := time.Since(toolStartTime)
+		// Even a failed call may have changed state
+		ae.invalidateCachesAfter(tool)
+
+		if err != nil {
+			ae.logger.LogToolExecution(call.Tool, false, duration, append([]slog.Attr{
+				slog.String("error", err.Error()),
+				slog.String("tool_input", fmt.Sprintf("%v", call.ToolInput)),
+			}, attrs...)...)
+		} else {
+			// Cache tool result
+			ae.setCachedToolResult(call.Tool, call.ToolInput, toolResult, err)
+			ae.logger.LogToolExecution(call.Tool, true, duration, append([]slog.Attr{slog.Bool("cached", false)}, attrs...)...)
+		}
+	}
+
+	toolResult, err = ae.afterToolHooks(call.Tool, toolResult, err)
+	if err != nil {
+		if cached {
+			return observedToolCall(call, fmt.Sprintf("Tool '%s' execution failed (cached error): %v", call.Tool, err))
+		}
+		return observedToolCall(call, fmt.Sprintf("Tool '%s' execution failed: %v", call.Tool, err))
+	}
+
+	// Format observation from tool result
+	observation, toolMessages := ae.toolObservation(call.Tool, toolResult)
+	outcome := observedToolCall(call, observation)
+	outcome.step.Messages = toolMessages
+	outcome.executed = true
+	return outcome
+}
+
+// observedToolCall records a tool call with its observation
+func observedToolCall(call types.ToolCallRequest, observation string) toolCallOutcome {
+	return toolCallOutcome{
+		call: call,
+		step: types.ToolCallData{
+			Action: types.ToolActionStep{
+				Tool:       call.Tool,
+				ToolInput:  call.ToolInput,
+				ToolCallID: call.ToolCallID,
+				Type:       call.Type,
+			},
+			Observation: observation,
+		},
+	}
+}
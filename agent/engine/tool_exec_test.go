@@ -0,0 +1,333 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/xichan96/cortex/agent/types"
+	"github.com/xichan96/cortex/pkg/errors"
+)
+
+// sleepTool sleeps before returning its name and records when it finished
+type sleepTool struct {
+	name         string
+	dependencies []string
+	delay        time.Duration
+	timeout      time.Duration
+	finished     *sync.Map
+}
+
+func (t sleepTool) Name() string                   { return t.name }
+func (t sleepTool) Description() string            { return "sleep" }
+func (t sleepTool) Schema() map[string]interface{} { return nil }
+func (t sleepTool) Metadata() types.ToolMetadata {
+	return types.ToolMetadata{ToolType: "test", Dependencies: t.dependencies, Timeout: t.timeout}
+}
+func (t sleepTool) Execute(input map[string]interface{}) (interface{}, error) {
+	time.Sleep(t.delay)
+	t.finished.Store(t.name, time.Now())
+	return t.name, nil
+}
+
+func TestParallelToolCalls(t *testing.T) {
+	finished := &sync.Map{}
+	config := types.NewAgentConfig()
+	config.ParallelToolCalls = true
+	ae := NewAgentEngine(&toolCallingLLM{}, config)
+	defer ae.Stop()
+	ae.AddTools([]types.Tool{
+		sleepTool{name: "slow", delay: 100 * time.Millisecond, finished: finished},
+		sleepTool{name: "fast", delay: 10 * time.Millisecond, finished: finished},
+		sleepTool{name: "after_slow", dependencies: []string{"slow"}, finished: finished},
+	})
+
+	calls := []types.ToolCallRequest{
+		{Tool: "slow", ToolInput: map[string]interface{}{}, ToolCallID: "1"},
+		{Tool: "fast", ToolInput: map[string]interface{}{}, ToolCallID: "2"},
+		{Tool: "after_slow", ToolInput: map[string]interface{}{}, ToolCallID: "3"},
+	}
+	state := newExecutionState(config)
+	state.ctx = ae.ctx
+
+	start := time.Now()
+	outcomes := ae.executeToolCalls(ae.ctx, calls, 0, state)
+	elapsed := time.Since(start)
+
+	if elapsed >= 110*time.Millisecond {
+		t.Errorf("independent calls did not run concurrently, took %s", elapsed)
+	}
+	for i, outcome := range outcomes {
+		if !outcome.executed || outcome.step.Action.ToolCallID != calls[i].ToolCallID {
+			t.Errorf("outcome %d = %+v, want executed call %s", i, outcome.step, calls[i].ToolCallID)
+		}
+	}
+
+	slowDone, _ := finished.Load("slow")
+	afterDone, _ := finished.Load("after_slow")
+	if afterDone.(time.Time).Before(slowDone.(time.Time)) {
+		t.Error("after_slow finished before the slow call it depends on")
+	}
+}
+
+func TestToolLifecycleEvents(t *testing.T) {
+	ae := NewAgentEngine(&toolCallingLLM{}, types.NewAgentConfig())
+	defer ae.Stop()
+	ae.AddTool(echoTool{name: "echo"})
+
+	var events []StreamResult
+	state := newExecutionState(ae.config)
+	state.emit = func(event StreamResult) {
+		events = append(events, event)
+	}
+	calls := []types.ToolCallRequest{
+		{Tool: "echo", ToolInput: map[string]interface{}{}, ToolCallID: "1"},
+		{Tool: "missing", ToolInput: map[string]interface{}{}, ToolCallID: "2"},
+	}
+	ae.executeToolCalls(ae.ctx, calls, 0, state)
+
+	var lifecycle []string
+	for _, event := range events {
+		if event.Type == StreamEventToolStart || event.Type == StreamEventToolEnd {
+			lifecycle = append(lifecycle, event.Type+":"+event.ToolEvent.Tool)
+		}
+	}
+	if len(lifecycle) != 2 || lifecycle[0] != "tool_start:echo" || lifecycle[1] != "tool_end:echo" {
+		t.Fatalf("lifecycle events = %v, want tool_start and tool_end for echo only", lifecycle)
+	}
+	end := events[len(events)-1]
+	for _, event := range events {
+		if event.Type == StreamEventToolEnd {
+			end = event
+		}
+	}
+	if !end.ToolEvent.Success || !strings.Contains(end.ToolEvent.Observation, "echo") {
+		t.Errorf("tool_end = %+v, want successful call observing echo", end.ToolEvent)
+	}
+}
+
+func TestToolTimeouts(t *testing.T) {
+	finished := &sync.Map{}
+	config := types.NewAgentConfig()
+	config.ToolCallTimeout = 30 * time.Millisecond
+	config.EnableToolRetry = false
+	ae := NewAgentEngine(&toolCallingLLM{}, config)
+	defer ae.Stop()
+	ae.AddTools([]types.Tool{
+		sleepTool{name: "slow", delay: 100 * time.Millisecond, finished: finished},
+		sleepTool{name: "patient", delay: 50 * time.Millisecond, timeout: 200 * time.Millisecond, finished: finished},
+		sleepTool{name: "strict", delay: 50 * time.Millisecond, timeout: 10 * time.Millisecond, finished: finished},
+	})
+
+	calls := []types.ToolCallRequest{
+		{Tool: "slow", ToolInput: map[string]interface{}{}, ToolCallID: "1"},
+		{Tool: "patient", ToolInput: map[string]interface{}{}, ToolCallID: "2"},
+		{Tool: "strict", ToolInput: map[string]interface{}{}, ToolCallID: "3"},
+	}
+	outcomes := ae.executeToolCalls(ae.ctx, calls, 0, newExecutionState(config))
+
+	timedOut := []bool{true, false, true}
+	for i, outcome := range outcomes {
+		got := strings.Contains(outcome.step.Observation, "timeout")
+		if got != timedOut[i] {
+			t.Errorf("%s observation = %q, timed out = %v, want %v", calls[i].Tool, outcome.step.Observation, got, timedOut[i])
+		}
+	}
+}
+
+func TestDisableTool(t *testing.T) {
+	ae := NewAgentEngine(&toolCallingLLM{}, types.NewAgentConfig())
+	defer ae.Stop()
+	ae.AddTools([]types.Tool{echoTool{name: "echo"}, echoTool{name: "other"}})
+
+	if err := ae.DisableTool("echo"); err != nil {
+		t.Fatalf("DisableTool failed: %v", err)
+	}
+	if ae.IsToolEnabled("echo") || len(ae.Tools()) != 2 {
+		t.Errorf("echo enabled = %v, registered tools = %d", ae.IsToolEnabled("echo"), len(ae.Tools()))
+	}
+	if _, err := ae.InvokeTool(context.Background(), "echo", nil); err == nil {
+		t.Error("InvokeTool ran a disabled tool")
+	}
+
+	calls := []types.ToolCallRequest{{Tool: "echo", ToolInput: map[string]interface{}{}, ToolCallID: "1"}}
+	outcomes := ae.executeToolCalls(ae.ctx, calls, 0, newExecutionState(ae.config))
+	if !strings.Contains(outcomes[0].step.Observation, "not found") {
+		t.Error("model call to a disabled tool was executed")
+	}
+
+	if err := ae.EnableTool("echo"); err != nil {
+		t.Fatalf("EnableTool failed: %v", err)
+	}
+	result, err := ae.InvokeTool(context.Background(), "echo", nil)
+	if err != nil || result != "echo" {
+		t.Errorf("InvokeTool after EnableTool = %v, %v", result, err)
+	}
+	if err := ae.DisableTool("missing"); err == nil {
+		t.Error("DisableTool accepted an unknown tool")
+	}
+}
+
+// searchTool requires a string query
+type searchTool struct{ echoTool }
+
+func (t searchTool) Schema() map[string]interface{} {
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": map[string]interface{}{"query": map[string]interface{}{"type": "string"}},
+		"required":   []string{"query"},
+	}
+}
+
+func TestToolInputValidation(t *testing.T) {
+	ae := NewAgentEngine(&toolCallingLLM{}, types.NewAgentConfig())
+	defer ae.Stop()
+	ae.AddTool(searchTool{echoTool{name: "search"}})
+
+	calls := []types.ToolCallRequest{
+		{Tool: "search", ToolInput: map[string]interface{}{}, ToolCallID: "1"},
+		{Tool: "search", ToolInput: map[string]interface{}{"query": 42.0}, ToolCallID: "2"},
+		{Tool: "search", ToolInput: map[string]interface{}{"query": "go"}, ToolCallID: "3"},
+	}
+	outcomes := ae.executeToolCalls(ae.ctx, calls, 0, newExecutionState(ae.config))
+
+	problems := []string{`missing required field "query"`, `field "query" must be string`, ""}
+	for i, outcome := range outcomes {
+		if problems[i] == "" {
+			if !outcome.executed {
+				t.Errorf("call %s not executed: %q", calls[i].ToolCallID, outcome.step.Observation)
+			}
+			continue
+		}
+		if outcome.executed || !strings.Contains(outcome.step.Observation, problems[i]) {
+			t.Errorf("call %s observation = %q, want rejection naming %s", calls[i].ToolCallID, outcome.step.Observation, problems[i])
+		}
+	}
+}
+
+func TestInvokeToolValidatesArguments(t *testing.T) {
+	config := types.NewAgentConfig()
+	config.StrictToolArgs = true
+	ae := NewAgentEngine(&toolCallingLLM{}, config)
+	defer ae.Stop()
+	ae.AddTool(searchTool{echoTool{name: "search"}})
+
+	invalid := []map[string]interface{}{
+		{},
+		{"query": 42.0},
+		{"query": "go", "limit": 3.0},
+	}
+	for _, args := range invalid {
+		_, err := ae.InvokeTool(context.Background(), "search", args)
+		if e, ok := err.(*errors.Error); !ok || e.Code != errors.EC_TOOL_PARAMETER_INVALID.Code {
+			t.Errorf("InvokeTool(%v) error = %v, want EC_TOOL_PARAMETER_INVALID", args, err)
+		}
+	}
+	if result, err := ae.InvokeTool(context.Background(), "search", map[string]interface{}{"query": "go"}); err != nil || result != "search" {
+		t.Errorf("InvokeTool with valid arguments = %v, %v", result, err)
+	}
+}
+
+// inputTool returns the value of its "value" argument
+type inputTool struct{ echoTool }
+
+func (t inputTool) Execute(input map[string]interface{}) (interface{}, error) {
+	return fmt.Sprintf("%s:%v", t.name, input["value"]), nil
+}
+
+// redactHook rewrites "value" arguments, masks "secret" in results and rejects the "blocked" tool
+type redactHook struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (h *redactHook) BeforeExecute(toolName string, args map[string]interface{}) (map[string]interface{}, error) {
+	h.mu.Lock()
+	h.calls = append(h.calls, "before:"+toolName)
+	h.mu.Unlock()
+	if toolName == "blocked" {
+		return nil, fmt.Errorf("blocked by policy")
+	}
+	if args["value"] == "token" {
+		return map[string]interface{}{"value": "secret"}, nil
+	}
+	return nil, nil
+}
+
+func (h *redactHook) AfterExecute(toolName string, result interface{}, err error) (interface{}, error) {
+	h.mu.Lock()
+	h.calls = append(h.calls, "after:"+toolName)
+	h.mu.Unlock()
+	if s, ok := result.(string); ok {
+		result = strings.ReplaceAll(s, "secret", "[redacted]")
+	}
+	return result, err
+}
+
+func TestToolHooks(t *testing.T) {
+	ae := NewAgentEngine(&toolCallingLLM{}, types.NewAgentConfig())
+	defer ae.Stop()
+	ae.AddTools([]types.Tool{inputTool{echoTool{name: "lookup"}}, inputTool{echoTool{name: "blocked"}}})
+	hook := &redactHook{}
+	ae.AddToolHook(hook)
+
+	calls := []types.ToolCallRequest{
+		{Tool: "lookup", ToolInput: map[string]interface{}{"value": "token"}, ToolCallID: "1"},
+		{Tool: "blocked", ToolInput: map[string]interface{}{"value": "x"}, ToolCallID: "2"},
+		{Tool: "lookup", ToolInput: map[string]interface{}{"value": "plain"}, ToolCallID: "3"},
+	}
+	outcomes := ae.executeToolCalls(ae.ctx, calls, 0, newExecutionState(ae.config))
+
+	if !outcomes[0].executed || outcomes[0].call.ToolInput["value"] != "secret" ||
+		!strings.Contains(outcomes[0].step.Observation, "lookup:[redacted]") {
+		t.Errorf("rewritten call = %+v, want rewritten input and redacted observation", outcomes[0].step)
+	}
+	if outcomes[1].executed || !strings.Contains(outcomes[1].step.Observation, "blocked by policy") {
+		t.Errorf("rejected call = %+v, want skipped call observing the hook error", outcomes[1].step)
+	}
+	if !outcomes[2].executed || !strings.Contains(outcomes[2].step.Observation, "lookup:plain") {
+		t.Errorf("unchanged call = %+v, want original input", outcomes[2].step)
+	}
+
+	want := []string{"before:lookup", "after:lookup", "before:blocked", "before:lookup", "after:lookup"}
+	if strings.Join(hook.calls, ",") != strings.Join(want, ",") {
+		t.Errorf("hook calls = %v, want %v", hook.calls, want)
+	}
+
+	if _, err := ae.InvokeTool(context.Background(), "blocked", nil); err == nil {
+		t.Error("InvokeTool ran a call rejected by a hook")
+	}
+	result, err := ae.InvokeTool(context.Background(), "lookup", map[string]interface{}{"value": "token"})
+	if err != nil || result != "lookup:[redacted]" {
+		t.Errorf("InvokeTool = %v, %v, want redacted result", result, err)
+	}
+}
+
+func TestApprovalEndsWithExecutionContext(t *testing.T) {
+	ae := NewAgentEngine(&toolCallingLLM{}, types.NewAgentConfig())
+	defer ae.Stop()
+	release := make(chan struct{})
+	defer close(release)
+	ae.SetApprovalHook(func(ctx context.Context, _ types.ToolCallRequest) (bool, error) {
+		// An approver that never answers
+		<-release
+		return true, nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	state := newExecutionState(ae.config)
+	state.ctx = ctx
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	start := time.Now()
+	approved, reason := ae.requestApproval(types.ToolCallRequest{Tool: "echo"}, state)
+	if approved || reason != "approval cancelled" {
+		t.Errorf("got approved %v reason %q, want a cancelled approval", approved, reason)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("approval waited %s after the execution was cancelled", elapsed)
+	}
+}
@@ -0,0 +1,70 @@
+package engine
+
+import (
+	"fmt"
+	"log/slog"
+)
+
+// ToolHook observes and may rewrite tool calls around their execution, e.g. to audit calls or redact secrets
+// Hooks run for every tool call requested by the model and for InvokeTool, in the order they were added
+type ToolHook interface {
+	// BeforeExecute is called with the checked arguments once the call is approved, before it runs
+	// The returned arguments replace the call's arguments (nil keeps them); they are not validated
+	// against the tool schema again. An error skips the tool and is recorded as the observation
+	BeforeExecute(toolName string, args map[string]interface{}) (map[string]interface{}, error)
+	// AfterExecute is called with the tool's result and error, including results served from the cache
+	// The returned result and error replace the tool's, so a hook may redact output or clear an error
+	AfterExecute(toolName string, result interface{}, err error) (interface{}, error)
+}
+
+// AddToolHook adds a hook run before and after each tool execution
+func (ae *AgentEngine) AddToolHook(hook ToolHook) {
+	if hook == nil {
+		return
+	}
+	ae.mu.Lock()
+	defer ae.mu.Unlock()
+	ae.toolHooks = append(ae.toolHooks, hook)
+}
+
+// getToolHooks returns a snapshot of the registered tool hooks
+func (ae *AgentEngine) getToolHooks() []ToolHook {
+	ae.mu.RLock()
+	defer ae.mu.RUnlock()
+	if len(ae.toolHooks) == 0 {
+		return nil
+	}
+	hooks := make([]ToolHook, len(ae.toolHooks))
+	copy(hooks, ae.toolHooks)
+	return hooks
+}
+
+// beforeToolHooks runs the BeforeExecute hooks in order, each one receiving the previous one's arguments
+func (ae *AgentEngine) beforeToolHooks(toolName string, args map[string]interface{}) (map[string]interface{}, error) {
+	for _, hook := range ae.getToolHooks() {
+		rewritten, err := hook.BeforeExecute(toolName, args)
+		if err != nil {
+			ae.logger.Info("Tool call rejected by hook",
+				slog.String("tool_name", toolName),
+				slog.String("error", err.Error()))
+			return args, err
+		}
+		if rewritten != nil {
+			args = rewritten
+		}
+	}
+	return args, nil
+}
+
+// afterToolHooks runs the AfterExecute hooks in order, each one receiving the previous one's result
+func (ae *AgentEngine) afterToolHooks(toolName string, result interface{}, err error) (interface{}, error) {
+	for _, hook := range ae.getToolHooks() {
+		result, err = hook.AfterExecute(toolName, result, err)
+	}
+	return result, err
+}
+
+// toolHookRejection is the observation of a call skipped by a BeforeExecute hook
+func toolHookRejection(toolName string, err error) string {
+	return fmt.Sprintf("tool '%s' not executed: %v", toolName, err)
+}
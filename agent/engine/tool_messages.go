@@ -0,0 +1,34 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/xichan96/cortex/agent/types"
+)
+
+// toolObservation formats a tool result as an observation
+// Tools returning []types.Message also get their messages back so they can be spliced into the conversation,
+// the observation then only summarizes them for logs, citations and checkpoints
+func (ae *AgentEngine) toolObservation(toolName string, result interface{}) (string, []types.Message) {
+	truncationLength := ae.getToolTruncationLength(toolName)
+
+	messages, ok := result.([]types.Message)
+	if !ok || len(messages) == 0 {
+		if ok {
+			result = nil
+		}
+		return truncateString(formatToolResult(result), truncationLength), nil
+	}
+
+	spliced := make([]types.Message, 0, len(messages))
+	var summary strings.Builder
+	for _, msg := range messages {
+		if msg.Role == "" {
+			msg.Role = "assistant"
+		}
+		spliced = append(spliced, msg)
+		summary.WriteString(fmt.Sprintf("[%s] %s\n", msg.Role, msg.Content))
+	}
+	return truncateString(strings.TrimSuffix(summary.String(), "\n"), truncationLength), spliced
+}
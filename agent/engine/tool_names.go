@@ -0,0 +1,110 @@
+package engine
+
+import (
+	"log/slog"
+	"sort"
+	"strings"
+
+	"github.com/xichan96/cortex/agent/types"
+)
+
+// maxToolNameSuggestions number of similar tool names reported when a tool is not found
+const maxToolNameSuggestions = 3
+
+// resolveTool maps a model-provided tool name to a tool available in this execution
+// Exact names win, then names equal after normalization (trimmed, case-insensitive, '-' and ' ' as '_'),
+// then the single nearest name within ToolNameMaxEditDistance
+// Returns the tool and its registered name, or nil and the closest names as suggestions
+func (ae *AgentEngine) resolveTool(name string, state *executionState) (types.Tool, string, []string) {
+	ae.mu.RLock()
+	tool, exists := ae.toolsMap[name]
+	exists = exists && !ae.disabled[name]
+	candidates := state.visibleTools(ae.enabledTools())
+	maxDistance := 0
+	if ae.config != nil {
+		maxDistance = ae.config.ToolNameMaxEditDistance
+	}
+	ae.mu.RUnlock()
+
+	if exists && state.toolAllowed(name) {
+		return tool, name, nil
+	}
+
+	normalized := normalizeToolName(name)
+	byName := make(map[string]types.Tool, len(candidates))
+	distances := make(map[string]int, len(candidates))
+	names := make([]string, 0, len(candidates))
+	for _, candidate := range candidates {
+		candidateName := candidate.Name()
+		if normalizeToolName(candidateName) == normalized {
+			ae.logger.Info("Corrected tool name",
+				slog.String("requested", name),
+				slog.String("resolved", candidateName))
+			return candidate, candidateName, nil
+		}
+		byName[candidateName] = candidate
+		distances[candidateName] = levenshtein(normalized, normalizeToolName(candidateName))
+		names = append(names, candidateName)
+	}
+
+	sort.SliceStable(names, func(i, j int) bool {
+		return distances[names[i]] < distances[names[j]]
+	})
+
+	// Only accept the nearest name when it is unambiguous
+	if maxDistance > 0 && len(names) > 0 && distances[names[0]] <= maxDistance &&
+		(len(names) == 1 || distances[names[1]] > distances[names[0]]) {
+		ae.logger.Info("Corrected tool name",
+			slog.String("requested", name),
+			slog.String("resolved", names[0]),
+			slog.Int("edit_distance", distances[names[0]]))
+		return byName[names[0]], names[0], nil
+	}
+
+	// Suggest names that share at least half of the requested name
+	suggestions := make([]string, 0, maxToolNameSuggestions)
+	for _, candidateName := range names {
+		if len(suggestions) == maxToolNameSuggestions || distances[candidateName] > (len(normalized)+1)/2 {
+			break
+		}
+		suggestions = append(suggestions, candidateName)
+	}
+	return nil, name, suggestions
+}
+
+// toolNotFoundMessage builds the observation recorded for an unknown tool
+func toolNotFoundMessage(name string, suggestions []string) string {
+	msg := "tool '" + name + "' not found in available tools"
+	if len(suggestions) > 0 {
+		msg += ", did you mean: " + strings.Join(suggestions, ", ") + "?"
+	}
+	return msg
+}
+
+// normalizeToolName trims and lowercases a tool name and unifies separators
+func normalizeToolName(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	return strings.NewReplacer("-", "_", " ", "_").Replace(name)
+}
+
+// levenshtein returns the edit distance between two strings
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
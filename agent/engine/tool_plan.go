@@ -0,0 +1,78 @@
+package engine
+
+import (
+	"log/slog"
+
+	"github.com/xichan96/cortex/agent/types"
+)
+
+// ToolPlan execution order chosen for the tool calls of one iteration
+type ToolPlan struct {
+	Iteration int            `json:"iteration"`       // 1-based iteration number
+	Steps     []ToolPlanStep `json:"steps"`           // tool calls in execution order
+	Error     string         `json:"error,omitempty"` // set when ordering failed and the model's order was kept
+}
+
+// ToolPlanStep planned tool call with the metadata that determined its position
+type ToolPlanStep struct {
+	Tool       string   `json:"tool"`
+	ToolCallID string   `json:"tool_call_id,omitempty"`
+	Priority   int      `json:"priority"`             // metadata priority or ToolPriorityOverrides value
+	DependsOn  []string `json:"depends_on,omitempty"` // declared dependencies also called in this iteration
+}
+
+// planToolCalls orders the tool calls of an iteration by priority and dependencies
+// The resulting plan is recorded for AgentResult.ToolPlans and emitted as a plan event when streaming
+func (ae *AgentEngine) planToolCalls(toolCalls []types.ToolCall, iteration int, state *executionState) []types.ToolCall {
+	plan := ToolPlan{Iteration: iteration + 1}
+
+	sorted, err := ae.sortToolCallsByDependencies(toolCalls)
+	if err != nil {
+		ae.logger.LogError("planToolCalls", err,
+			slog.Int("iteration", iteration+1),
+			slog.String("phase", "sort_tool_calls"))
+		state.addWarning("failed to order tool calls by dependencies, using original order: %v", err)
+		// Continue with original order if sorting fails
+		sorted = toolCalls
+		plan.Error = err.Error()
+	}
+
+	requested := make(map[string]bool, len(toolCalls))
+	for _, tc := range toolCalls {
+		requested[tc.Function.Name] = true
+	}
+
+	ae.mu.RLock()
+	plan.Steps = make([]ToolPlanStep, 0, len(sorted))
+	for _, tc := range sorted {
+		step := ToolPlanStep{
+			Tool:       tc.Function.Name,
+			ToolCallID: tc.ID,
+		}
+		if tool, exists := ae.toolsMap[tc.Function.Name]; exists {
+			metadata := tool.Metadata()
+			step.Priority = metadata.Priority
+			for _, dep := range metadata.Dependencies {
+				if requested[dep] {
+					step.DependsOn = append(step.DependsOn, dep)
+				}
+			}
+		}
+		if ae.config != nil {
+			if priority, ok := ae.config.ToolPriorityOverrides[tc.Function.Name]; ok {
+				step.Priority = priority
+			}
+		}
+		plan.Steps = append(plan.Steps, step)
+	}
+	ae.mu.RUnlock()
+
+	state.plans = append(state.plans, plan)
+	if state.emit != nil {
+		state.emit(StreamResult{
+			Type: StreamEventPlan,
+			Plan: &plan,
+		})
+	}
+	return sorted
+}
@@ -0,0 +1,110 @@
+package engine
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/xichan96/cortex/agent/types"
+)
+
+// toolBucket token bucket of a single tool
+type toolBucket struct {
+	limit  types.ToolRateLimit
+	tokens float64
+	last   time.Time
+}
+
+// toolLimiter rate limits of all engines in the process
+// Buckets are keyed by tool name only, so engines built per session share each tool's limit
+var toolLimiter = &toolRateLimiter{}
+
+// toolRateLimiter token buckets per tool name, configured by ToolMetadata.RateLimit
+type toolRateLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]*toolBucket
+}
+
+// reserve takes a token for the tool if one is available within maxWait
+// Returns how long the caller has to wait before executing, and false if the wait would exceed maxWait
+func (l *toolRateLimiter) reserve(name string, limit types.ToolRateLimit, now time.Time) (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.buckets == nil {
+		l.buckets = make(map[string]*toolBucket)
+	}
+	bucket, ok := l.buckets[name]
+	if !ok || bucket.limit.Requests != limit.Requests || bucket.limit.Interval != limit.Interval {
+		bucket = &toolBucket{limit: limit, tokens: float64(limit.Requests), last: now}
+		l.buckets[name] = bucket
+	}
+
+	// Refill at Requests per Interval, capped at a full bucket
+	perToken := limit.Interval / time.Duration(limit.Requests)
+	bucket.tokens += float64(now.Sub(bucket.last)) / float64(perToken)
+	if bucket.tokens > float64(limit.Requests) {
+		bucket.tokens = float64(limit.Requests)
+	}
+	bucket.last = now
+
+	var wait time.Duration
+	if bucket.tokens < 1 {
+		wait = time.Duration((1 - bucket.tokens) * float64(perToken))
+		if wait > limit.MaxWait {
+			return wait, false
+		}
+	}
+	// Tokens may go negative so waiting callers queue up behind each other
+	bucket.tokens--
+	return wait, true
+}
+
+// release returns a token reserved by a call that did not run
+func (l *toolRateLimiter) release(name string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if bucket, ok := l.buckets[name]; ok && bucket.tokens < float64(bucket.limit.Requests) {
+		bucket.tokens++
+	}
+}
+
+// acquireToolRateLimit enforces the tool's rate limit before execution
+// Waits up to RateLimit.MaxWait for a token, otherwise returns false and an observation telling the model to back off
+// A wait cut short by cancelling the execution gives the token back and returns false
+func (ae *AgentEngine) acquireToolRateLimit(tool types.Tool, state *executionState) (bool, string) {
+	limit := tool.Metadata().RateLimit
+	if limit == nil || limit.Requests <= 0 || limit.Interval <= 0 {
+		return true, ""
+	}
+
+	wait, ok := toolLimiter.reserve(tool.Name(), *limit, time.Now())
+	if !ok {
+		msg := fmt.Sprintf("tool '%s' rate limited: at most %d calls per %s, retry after %s",
+			tool.Name(), limit.Requests, limit.Interval, wait.Round(time.Second))
+		ae.logger.Info("Tool rate limited",
+			slog.String("tool_name", tool.Name()),
+			slog.Duration("retry_after", wait))
+		state.addWarning("%s", msg)
+		return false, msg
+	}
+	if wait > 0 {
+		ae.logger.Info("Waiting for tool rate limit",
+			slog.String("tool_name", tool.Name()),
+			slog.Duration("wait", wait))
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		var done <-chan struct{}
+		if state.ctx != nil {
+			done = state.ctx.Done()
+		}
+		select {
+		case <-timer.C:
+		case <-done:
+			toolLimiter.release(tool.Name())
+			return false, fmt.Sprintf("tool '%s' call cancelled while waiting for its rate limit", tool.Name())
+		}
+	}
+	return true, ""
+}
@@ -0,0 +1,58 @@
+package engine
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/xichan96/cortex/agent/types"
+	"github.com/xichan96/cortex/pkg/errors"
+)
+
+// limitedTool echo tool with a rate limit, names must be unique per test as the limiter is process-wide
+type limitedTool struct {
+	echoTool
+	limit types.ToolRateLimit
+}
+
+func (t limitedTool) Metadata() types.ToolMetadata {
+	return types.ToolMetadata{ToolType: "test", RateLimit: &t.limit}
+}
+
+func TestToolRateLimitSharedAcrossEngines(t *testing.T) {
+	tool := limitedTool{echoTool{name: "limited_shared"}, types.ToolRateLimit{Requests: 1, Interval: time.Hour}}
+	first := NewAgentEngine(&toolCallingLLM{}, types.NewAgentConfig())
+	defer first.Stop()
+	second := NewAgentEngine(&toolCallingLLM{}, types.NewAgentConfig())
+	defer second.Stop()
+	first.AddTool(tool)
+	second.AddTool(tool)
+
+	if _, err := first.InvokeTool(context.Background(), tool.name, map[string]interface{}{"n": 1.0}); err != nil {
+		t.Fatalf("first call failed: %v", err)
+	}
+	_, err := second.InvokeTool(context.Background(), tool.name, map[string]interface{}{"n": 2.0})
+	if e, ok := err.(*errors.Error); !ok || e.Code != errors.ErrRateLimitExceeded.Code {
+		t.Errorf("call on another engine error = %v, want the shared limit to be exceeded", err)
+	}
+}
+
+func TestToolRateLimitWaitEndsWithContext(t *testing.T) {
+	tool := limitedTool{echoTool{name: "limited_wait"}, types.ToolRateLimit{Requests: 1, Interval: time.Hour, MaxWait: time.Hour}}
+	ae := NewAgentEngine(&toolCallingLLM{}, types.NewAgentConfig())
+	defer ae.Stop()
+	ae.AddTool(tool)
+
+	if _, err := ae.InvokeTool(context.Background(), tool.name, map[string]interface{}{"n": 1.0}); err != nil {
+		t.Fatalf("first call failed: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := ae.InvokeTool(ctx, tool.name, map[string]interface{}{"n": 2.0}); err == nil {
+		t.Error("call waiting for the rate limit succeeded after its context was cancelled")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("cancelled call returned after %s, want it to stop waiting with its context", elapsed)
+	}
+}
@@ -0,0 +1,145 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/xichan96/cortex/agent/types"
+)
+
+// Tool result formats accepted by AgentConfig.ToolResultFormat
+const (
+	ToolResultFormatProse  = "prose"  // all observations summarized in one user message (default)
+	ToolResultFormatNative = "native" // one tool message per call, answering the assistant's tool calls
+)
+
+// ToolResultFormatter turns the tool calls executed in one iteration into the messages sent back to the model
+// Returned messages with role "tool" and a ToolCallID answer the assistant's tool calls natively,
+// in which case the engine keeps the assistant tool call message in front of them
+type ToolResultFormatter func(steps []types.ToolCallData) []types.Message
+
+// SetToolResultFormatter overrides how tool results are represented to the model, nil restores ToolResultFormat
+func (ae *AgentEngine) SetToolResultFormatter(formatter ToolResultFormatter) {
+	ae.mu.Lock()
+	defer ae.mu.Unlock()
+	ae.toolResultFormatter = formatter
+}
+
+// toolResultMessages builds the messages reporting tool results of an iteration
+// A custom formatter takes precedence over ToolResultFormat
+func (ae *AgentEngine) toolResultMessages(steps []types.ToolCallData, state *executionState) []types.Message {
+	if len(steps) == 0 {
+		return nil
+	}
+
+	ae.mu.RLock()
+	formatter := ae.toolResultFormatter
+	format := ""
+	if ae.config != nil {
+		format = ae.config.ToolResultFormat
+	}
+	ae.mu.RUnlock()
+
+	switch {
+	case formatter != nil:
+		return formatter(steps)
+	case format == ToolResultFormatNative:
+		return nativeToolResults(steps, state)
+	default:
+		return proseToolResults(steps, state)
+	}
+}
+
+// proseToolResults splices tool message lists as-is and summarizes all observations in one user message
+func proseToolResults(steps []types.ToolCallData, state *executionState) []types.Message {
+	var messages []types.Message
+	// Tools returning message lists are spliced into the conversation as-is
+	for _, step := range steps {
+		messages = append(messages, step.Messages...)
+	}
+
+	var toolResults strings.Builder
+	toolResults.WriteString("Based on previous tool execution results:\n")
+	for _, step := range steps {
+		observation := step.Observation
+		if len(step.Messages) > 0 {
+			observation = fmt.Sprintf("%d messages, see above", len(step.Messages))
+		}
+		if state.citations != nil {
+			id := state.citations.register(step)
+			toolResults.WriteString(fmt.Sprintf("- [%d] Tool %s returned: %s\n", id, step.Action.Tool, observation))
+			continue
+		}
+		toolResults.WriteString(fmt.Sprintf("- Tool %s returned: %s\n", step.Action.Tool, observation))
+	}
+	toolResults.WriteString("\nPlease continue analysis or complete the task based on these results.")
+	if state.citations != nil {
+		toolResults.WriteString(" " + citationInstruction)
+	}
+
+	return append(messages, types.Message{
+		Role:    "user",
+		Content: toolResults.String(),
+	})
+}
+
+// nativeToolResults answers each tool call with a tool message carrying its observation
+// Tool message lists follow the tool messages, since providers expect the answers right after the tool calls
+func nativeToolResults(steps []types.ToolCallData, state *executionState) []types.Message {
+	messages := make([]types.Message, 0, len(steps))
+	var spliced []types.Message
+	for _, step := range steps {
+		observation := step.Observation
+		if len(step.Messages) > 0 {
+			observation = fmt.Sprintf("%d messages, see below", len(step.Messages))
+			spliced = append(spliced, step.Messages...)
+		}
+		if state.citations != nil {
+			observation = fmt.Sprintf("[%d] %s", state.citations.register(step), observation)
+		}
+		toolCallID, _ := step.Action.ToolCallID.(string)
+		messages = append(messages, types.Message{
+			Role:       "tool",
+			Name:       step.Action.Tool,
+			ToolCallID: toolCallID,
+			Content:    observation,
+		})
+	}
+	messages = append(messages, spliced...)
+	if state.citations != nil {
+		messages = append(messages, types.Message{
+			Role:    "user",
+			Content: citationInstruction,
+		})
+	}
+	return messages
+}
+
+// stepToolCalls rebuilds the tool calls the model requested from the steps answering them
+func stepToolCalls(steps []types.ToolCallData) []types.ToolCall {
+	toolCalls := make([]types.ToolCall, 0, len(steps))
+	for _, step := range steps {
+		id, _ := step.Action.ToolCallID.(string)
+		callType, _ := step.Action.Type.(string)
+		args, _ := step.Action.ToolInput.(map[string]interface{})
+		toolCalls = append(toolCalls, types.ToolCall{
+			ID:   id,
+			Type: callType,
+			Function: types.ToolFunction{
+				Name:      step.Action.Tool,
+				Arguments: args,
+			},
+		})
+	}
+	return toolCalls
+}
+
+// hasToolMessages reports whether messages contain native tool result messages
+func hasToolMessages(messages []types.Message) bool {
+	for _, msg := range messages {
+		if msg.Role == "tool" && msg.ToolCallID != "" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,80 @@
+package engine
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/xichan96/cortex/agent/types"
+)
+
+// failingTool always fails
+type failingTool struct{}
+
+func (failingTool) Name() string                   { return "fail" }
+func (failingTool) Description() string            { return "fails" }
+func (failingTool) Schema() map[string]interface{} { return nil }
+func (failingTool) Metadata() types.ToolMetadata   { return types.ToolMetadata{ToolType: "test"} }
+func (failingTool) Execute(input map[string]interface{}) (interface{}, error) {
+	return nil, fmt.Errorf("boom")
+}
+
+// mixedToolCallsLLM requests a succeeding, a failing and an unknown tool once, recording the follow-up messages
+type mixedToolCallsLLM struct {
+	toolCallingLLM
+	mu       sync.Mutex
+	called   bool
+	followUp []types.Message
+}
+
+func (m *mixedToolCallsLLM) ChatWithTools(messages []types.Message, tools []types.Tool) (types.Message, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.called {
+		m.followUp = messages
+		return types.Message{Role: "assistant", Content: "done"}, nil
+	}
+	m.called = true
+	return types.Message{
+		Role: "assistant",
+		ToolCalls: []types.ToolCall{
+			{ID: "call_echo", Type: "function", Function: types.ToolFunction{Name: "echo", Arguments: map[string]interface{}{}}},
+			{ID: "call_fail", Type: "function", Function: types.ToolFunction{Name: "fail", Arguments: map[string]interface{}{}}},
+			{ID: "call_missing", Type: "function", Function: types.ToolFunction{Name: "missing", Arguments: map[string]interface{}{}}},
+		},
+	}, nil
+}
+
+func TestNativeToolResultsAnswerEveryToolCall(t *testing.T) {
+	config := types.NewAgentConfig()
+	config.ToolResultFormat = ToolResultFormatNative
+	config.EnableToolRetry = false
+	model := &mixedToolCallsLLM{}
+	ae := NewAgentEngine(model, config)
+	defer ae.Stop()
+	ae.AddTool(echoTool{name: "echo"})
+	ae.AddTool(failingTool{})
+
+	if _, err := ae.Execute("hello", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	requested := map[string]bool{}
+	answered := map[string]bool{}
+	for _, msg := range model.followUp {
+		for _, call := range msg.ToolCalls {
+			requested[call.ID] = true
+		}
+		if msg.Role == "tool" {
+			if !requested[msg.ToolCallID] {
+				t.Errorf("tool message %q does not answer a preceding assistant tool call", msg.ToolCallID)
+			}
+			answered[msg.ToolCallID] = true
+		}
+	}
+	for _, id := range []string{"call_echo", "call_fail", "call_missing"} {
+		if !answered[id] {
+			t.Errorf("tool call %q has no tool message, follow-up = %+v", id, model.followUp)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package engine
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/xichan96/cortex/agent/types"
+	"github.com/xichan96/cortex/pkg/errors"
+)
+
+// executeToolWithRetry executes a tool, retrying failed calls with the same arguments
+// With EnableToolRetry a failed call is retried up to RetryAttempts times, RetryDelay apart; failed attempts
+// before the last are logged here, the caller handles the final outcome. Cancellation is never retried
+func (ae *AgentEngine) executeToolWithRetry(ctx context.Context, tool types.Tool, args map[string]interface{}, timeout time.Duration, attrs ...slog.Attr) (interface{}, error) {
+	ae.mu.RLock()
+	retries, delay := 0, time.Duration(0)
+	if ae.config != nil && ae.config.EnableToolRetry {
+		retries = max(ae.config.RetryAttempts, 0)
+		delay = ae.config.RetryDelay
+	}
+	ae.mu.RUnlock()
+
+	for attempt := 1; ; attempt++ {
+		startTime := time.Now()
+		result, err := ae.executeToolWithTimeout(ctx, tool, args, timeout)
+		if err == nil || attempt > retries || !retryableToolError(ctx, err) {
+			return result, err
+		}
+
+		ae.logger.LogToolExecution(tool.Name(), false, time.Since(startTime), append([]slog.Attr{
+			slog.String("error", err.Error()),
+			slog.String("tool_input", fmt.Sprintf("%v", args)),
+			slog.Int("attempt", attempt),
+			slog.Int("max_attempts", retries+1),
+		}, attrs...)...)
+
+		if delay > 0 {
+			timer := time.NewTimer(delay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return result, err
+			case <-timer.C:
+			}
+		}
+	}
+}
+
+// retryableToolError reports whether a failed tool call may be retried
+// Calls are not retried once the execution is cancelled or out of time, nor when the arguments
+// were rejected, since the same arguments fail the same way
+func retryableToolError(ctx context.Context, err error) bool {
+	if ctx.Err() != nil || stderrors.Is(err, context.Canceled) {
+		return false
+	}
+	var e *errors.Error
+	if stderrors.As(err, &e) {
+		switch e.Code {
+		case errors.EC_PARAMETER_MISSING.Code, errors.EC_PARAMETER_INVALID.Code, errors.EC_TOOL_PARAMETER_INVALID.Code:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,29 @@
+package engine
+
+import (
+	"log/slog"
+
+	"github.com/xichan96/cortex/agent/tools"
+	"github.com/xichan96/cortex/agent/types"
+)
+
+// checkToolSchema validates a tool schema according to ToolSchemaValidation, the caller holds ae.mu
+// Only the "error" mode returns the validation error, "warn" (the default) logs it
+func (ae *AgentEngine) checkToolSchema(tool types.Tool) error {
+	mode := tools.SchemaValidationWarn
+	if ae.config != nil && ae.config.ToolSchemaValidation != "" {
+		mode = ae.config.ToolSchemaValidation
+	}
+	if mode == tools.SchemaValidationOff {
+		return nil
+	}
+
+	err := tools.ValidateToolSchema(tool)
+	if err == nil || mode == tools.SchemaValidationError {
+		return err
+	}
+	ae.logger.LogError("checkToolSchema", err,
+		slog.String("tool_name", tool.Name()),
+		slog.String("mode", mode))
+	return nil
+}
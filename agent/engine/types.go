@@ -1,8 +1,10 @@
 package engine
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
@@ -20,9 +22,31 @@ const (
 	MaxTruncationLength  = 2048 // maximum truncation length
 	MinChannelBuffer     = 10   // minimum channel buffer size
 
+	// Request-related constants
+	MaxSystemPromptLength = 32000 // maximum characters of a per-request system prompt
+
 	// Performance-related constants
 	DefaultBufferPoolSize = 1024                   // default buffer pool size (1KB)
-	IterationDelay        = 100 * time.Millisecond // inter-iteration delay
+	IterationDelay        = 100 * time.Millisecond // former fixed inter-iteration delay, see AgentConfig.IterationDelay
+
+	// Lifecycle-related constants
+	ShutdownPollInterval = 50 * time.Millisecond // interval for checking in-flight executions during shutdown
+)
+
+// Stop reasons reported in AgentResult.StoppedReason when a run ends early
+const (
+	StoppedReasonMaxToolCalls  = "max_tool_calls" // cumulative tool call budget exhausted
+	StoppedReasonMaxIterations = "max_iterations" // iteration limit reached with tool calls still pending
+	StoppedReasonCancelled     = "cancelled"      // engine stopped while the run was in progress
+	StoppedReasonNoProgress    = "no_progress"    // the model repeated the same response MaxRepeatedResponses times
+	StoppedReasonEmptyOutput   = "empty_output"   // the run ended without the model producing any output
+)
+
+// Run finish reasons reported in AgentResult.FinishReason besides the provider finish reasons
+// (types.FinishReasonStop, types.FinishReasonLength, ...)
+const (
+	FinishReasonMaxIterations       = "max_iterations"        // MaxIterations reached with tool calls pending
+	FinishReasonToolBudgetExhausted = "tool_budget_exhausted" // MaxToolCalls reached, the model concluded without tools
 )
 
 // bufferPool for reusing byte buffers to reduce GC pressure
@@ -37,8 +61,228 @@ var bufferPool = sync.Pool{
 // AgentResult agent execution result
 type AgentResult struct {
 	Output            string                  `json:"output"`
+	ParsedOutput      interface{}             `json:"parsed_output,omitempty"` // value returned by the output parser, nil without a parser or when parsing failed
 	ToolCalls         []types.ToolCallRequest `json:"tool_calls"`
 	IntermediateSteps []types.ToolCallData    `json:"intermediate_steps"`
+	StoppedReason     string                  `json:"stopped_reason,omitempty"` // set when the run was cut short
+	FinishReason      string                  `json:"finish_reason,omitempty"`  // why the run ended: stop, length, tool_calls, max_iterations, ...
+	Warnings          []string                `json:"warnings,omitempty"`       // non-fatal degradations during the run
+	ExecutionID       string                  `json:"execution_id,omitempty"`   // checkpoint key, set when checkpointing is enabled
+	StreamMetrics     *StreamMetrics          `json:"stream_metrics,omitempty"` // streaming latency, set when IncludeStreamMetrics is enabled
+	Citations         []Citation              `json:"citations,omitempty"`      // tool observations cited in the output, set when EnableCitations is enabled
+	Summary           string                  `json:"summary,omitempty"`        // short version of the output for notifications, set when GenerateSummary is enabled
+	ToolPlans         []ToolPlan              `json:"tool_plans,omitempty"`     // tool execution order of each iteration that called tools
+	Usage             *types.Usage            `json:"usage,omitempty"`          // token counts summed over the run's model calls, nil when the provider reports none
+}
+
+// Completed reports whether the run finished normally rather than being cut short
+func (r *AgentResult) Completed() bool {
+	return r.StoppedReason == ""
+}
+
+// Message sources describing where each prepared prompt message came from
+const (
+	MessageSourceSystem          = "system"           // engine system prompt
+	MessageSourceMemory          = "memory"           // chat history loaded from memory
+	MessageSourceInjectedContext = "injected_context" // context built from previous tool requests
+	MessageSourceDocument        = "document"         // request-scoped documents
+	MessageSourceUser            = "user"             // current user input
+)
+
+// Document request-scoped document included as prompt context
+type Document struct {
+	Name    string `json:"name"`
+	Content string `json:"content"`
+}
+
+// ExecuteOptions per-request execution options
+type ExecuteOptions struct {
+	Documents   []Document // documents to answer questions about, only used for this request
+	Tools       []string   // allowlist of registered tool names advertised for this request (empty means all)
+	ExecutionID string     // checkpoint key of a blocking execution when checkpointing is enabled (random when empty)
+
+	// SystemPrompt replaces AgentConfig.SystemMessage for this request (empty uses the engine prompt)
+	// At most MaxSystemPromptLength characters
+	SystemPrompt string
+
+	// Context cancels this execution when done, ending it with StoppedReason "cancelled" while the engine stays usable
+	// (nil means the execution only ends early when the engine is stopped)
+	Context context.Context
+}
+
+// context returns the execution context of the options, nil when none is set
+func (o *ExecuteOptions) context() context.Context {
+	if o == nil {
+		return nil
+	}
+	return o.Context
+}
+
+// PreviewMessage prompt message annotated with its provenance
+type PreviewMessage struct {
+	Source  string        `json:"source"`
+	Message types.Message `json:"message"`
+}
+
+// PromptPreview prompt that would be sent to the model for a given input
+type PromptPreview struct {
+	Messages []PreviewMessage `json:"messages"`
+}
+
+// executionState per-run accounting shared across iterations of a single execution
+type executionState struct {
+	toolCalls    int                // cumulative executed tool calls
+	maxToolCalls int                // tool call budget (0 means unlimited)
+	warnings     []string           // non-fatal warnings accumulated during the run
+	warningsMu   sync.Mutex         // guards warnings, tool calls may run concurrently
+	emit         func(StreamResult) // streaming only, forwards warnings as they happen
+	allowedTools map[string]bool    // per-request tool allowlist (nil means all tools)
+	timing       *streamTiming      // streaming only, chunk timing for latency metrics
+	citations    *citationTracker   // citation IDs of tool observations (nil when citations are disabled)
+	partialJSON  bool               // streaming only, emit partial_json events while JSON output streams in
+	plans        []ToolPlan         // tool execution order of each iteration that called tools
+	usage        *types.Usage       // token counts summed over all model calls (nil until a provider reports usage)
+	ctx          context.Context    // done when the engine is stopped or the request context is cancelled (nil before applyExecutionContext)
+	delay        time.Duration      // pause between iterations (0 means none)
+
+	maxRepeatedResponses int    // identical consecutive responses that stop the run (below 2 disables the check)
+	lastResponse         string // normalized content of the previous iteration's response
+	repeatedResponses    int    // consecutive iterations that returned lastResponse
+}
+
+// newExecutionState creates the state for a single execution
+func newExecutionState(config *types.AgentConfig) *executionState {
+	state := &executionState{}
+	if config != nil {
+		state.maxToolCalls = config.MaxToolCalls
+		if config.EnableCitations {
+			state.citations = &citationTracker{}
+		}
+		state.partialJSON = config.StreamPartialJSON
+		state.maxRepeatedResponses = config.MaxRepeatedResponses
+		state.delay = config.IterationDelay
+	}
+	return state
+}
+
+// cancelled reports whether the execution context is done
+func (s *executionState) cancelled() bool {
+	return s.ctx != nil && s.ctx.Err() != nil
+}
+
+// pause waits the configured delay before the next iteration, returning early when the execution is cancelled
+func (s *executionState) pause() {
+	if s.delay <= 0 {
+		return
+	}
+	timer := time.NewTimer(s.delay)
+	defer timer.Stop()
+	if s.ctx == nil {
+		<-timer.C
+		return
+	}
+	select {
+	case <-timer.C:
+	case <-s.ctx.Done():
+	}
+}
+
+// visibleTools returns a copy of tools filtered by the per-request allowlist, callers must hold ae.mu
+func (s *executionState) visibleTools(tools []types.Tool) []types.Tool {
+	if s.allowedTools == nil {
+		// Copy so the snapshot never shares a backing array with later AddTool appends
+		return append([]types.Tool(nil), tools...)
+	}
+	visible := make([]types.Tool, 0, len(s.allowedTools))
+	for _, tool := range tools {
+		if s.allowedTools[tool.Name()] {
+			visible = append(visible, tool)
+		}
+	}
+	return visible
+}
+
+// toolAllowed reports whether a tool may be called in this execution
+func (s *executionState) toolAllowed(name string) bool {
+	return s.allowedTools == nil || s.allowedTools[name]
+}
+
+// recordChunk records chunk timing when streaming
+func (s *executionState) recordChunk() {
+	if s.timing != nil {
+		s.timing.recordChunk(time.Now())
+	}
+}
+
+// reserveToolCall consumes one unit of the tool call budget
+// Returns false if the budget is already exhausted
+func (s *executionState) reserveToolCall() bool {
+	if s.maxToolCalls > 0 && s.toolCalls >= s.maxToolCalls {
+		return false
+	}
+	s.toolCalls++
+	return true
+}
+
+// addModelWarnings records the warnings a provider attached to its response
+func (s *executionState) addModelWarnings(response types.Message) {
+	for _, warning := range response.Warnings {
+		s.addWarning("%s", warning)
+	}
+}
+
+// addUsage adds the token counts of a model call to the run total
+func (s *executionState) addUsage(usage *types.Usage) {
+	if usage == nil {
+		return
+	}
+	if s.usage == nil {
+		s.usage = &types.Usage{}
+	}
+	s.usage.Add(usage)
+}
+
+// addWarning records a non-fatal warning, forwarding it to the stream when streaming
+func (s *executionState) addWarning(format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	s.warningsMu.Lock()
+	defer s.warningsMu.Unlock()
+	s.warnings = append(s.warnings, msg)
+	if s.emit != nil {
+		s.emit(StreamResult{
+			Type:    "warning",
+			Content: msg,
+		})
+	}
+}
+
+// recordResponse tracks identical consecutive responses and reports whether the limit is reached
+// Content is compared trimmed, empty responses (tool calls without text) never count as repeated
+func (s *executionState) recordResponse(content string) bool {
+	if s.maxRepeatedResponses <= 0 {
+		return false
+	}
+	content = strings.TrimSpace(content)
+	if content == "" || content != s.lastResponse {
+		s.lastResponse = content
+		s.repeatedResponses = 1
+		return false
+	}
+	s.repeatedResponses++
+	return s.repeatedResponses >= s.maxRepeatedResponses
+}
+
+// toolBudgetExhausted reports whether no further tool calls are allowed
+func (s *executionState) toolBudgetExhausted() bool {
+	return s.maxToolCalls > 0 && s.toolCalls >= s.maxToolCalls
+}
+
+// ToolCacheStats tool result cache counters since the engine was created
+type ToolCacheStats struct {
+	Size      int `json:"size"`      // entries currently cached
+	Hits      int `json:"hits"`      // calls served from the cache
+	Misses    int `json:"misses"`    // lookups without a live entry, expired entries included
+	Evictions int `json:"evictions"` // least recently used entries dropped to make room
 }
 
 // toolCacheEntry tool cache entry with LRU support
@@ -49,14 +293,45 @@ type toolCacheEntry struct {
 	prev      *toolCacheEntry
 	next      *toolCacheEntry
 	key       string
+	toolName  string
 }
 
 // StreamResult streaming result
 type StreamResult struct {
-	Type    string
-	Content string
-	Result  *AgentResult
-	Error   error
+	Type      string
+	Content   string
+	Result    *AgentResult
+	Error     error
+	ToolCall  *types.ToolCallRequest // set on approval_required events
+	JSON      interface{}            // set on partial_json events, the (possibly incomplete) object parsed so far
+	Plan      *ToolPlan              // set on plan events, the execution order of the upcoming tool calls
+	ToolEvent *ToolEvent             // set on tool_start and tool_end events
+	// FinishReason is set on end events, the run's AgentResult.FinishReason
+	FinishReason string
+}
+
+// MaxToolEventObservationLength maximum characters of the observation carried by a tool_end event
+const MaxToolEventObservationLength = 500
+
+// ToolEvent progress of a tool call, carried by tool_start and tool_end stream events
+type ToolEvent struct {
+	Tool        string                 `json:"tool"`
+	ToolCallID  string                 `json:"tool_call_id,omitempty"`
+	Arguments   map[string]interface{} `json:"arguments,omitempty"`
+	Observation string                 `json:"observation,omitempty"` // tool_end only, truncated to MaxToolEventObservationLength
+	Success     bool                   `json:"success,omitempty"`     // tool_end only, the tool ran (or was served from cache) without error
+	DurationMs  int64                  `json:"duration_ms,omitempty"` // tool_end only
+}
+
+// ApprovalHook decides whether a tool call may be executed
+// It is called before each tool call and may block until a decision is made
+// Returning false (or an error) rejects the call
+type ApprovalHook func(ctx context.Context, toolCall types.ToolCallRequest) (bool, error)
+
+// approvalDecision result delivered by the approval hook
+type approvalDecision struct {
+	approved bool
+	err      error
 }
 
 // truncateString truncates a string to the specified length
@@ -0,0 +1,85 @@
+package llm
+
+import (
+	"time"
+
+	"github.com/tmc/langchaingo/llms/anthropic"
+	"github.com/xichan96/cortex/agent/providers"
+	"github.com/xichan96/cortex/agent/types"
+	"github.com/xichan96/cortex/pkg/errors"
+)
+
+// AnthropicOptions Anthropic configuration options
+type AnthropicOptions struct {
+	APIKey         string
+	BaseURL        string
+	Model          string
+	RequestTimeout time.Duration                   // per-request timeout, 0 uses providers.DefaultRequestTimeout
+	ConnectionPool *providers.ConnectionPoolConfig // dedicated connection pool, nil shares the global pool
+}
+
+// NewAnthropicClient creates a new Anthropic Claude client and returns LLMProvider
+func NewAnthropicClient(opts AnthropicOptions) (types.LLMProvider, error) {
+	if opts.APIKey == "" {
+		return nil, errors.EC_LLM_API_KEY_REQUIRED
+	}
+
+	if opts.Model == "" {
+		opts.Model = ClaudeSonnet4
+	}
+
+	if opts.BaseURL == "" {
+		opts.BaseURL = "https://api.anthropic.com/v1"
+	}
+	baseURL, err := normalizeBaseURL("anthropic", opts.BaseURL)
+	if err != nil {
+		return nil, err
+	}
+	opts.BaseURL = baseURL
+
+	pooledClient := providers.NewHTTPClient(opts.RequestTimeout, opts.ConnectionPool)
+
+	client, err := anthropic.New(
+		anthropic.WithToken(opts.APIKey),
+		anthropic.WithBaseURL(opts.BaseURL),
+		anthropic.WithModel(opts.Model),
+		anthropic.WithHTTPClient(pooledClient),
+	)
+	if err != nil {
+		return nil, errors.NewError(errors.EC_LLM_CLIENT_CREATE_FAILED.Code, errors.EC_LLM_CLIENT_CREATE_FAILED.Message).Wrap(err)
+	}
+
+	// Directly return LLMProvider
+	return providers.NewLangChainLLMProvider(client, opts.Model), nil
+}
+
+// QuickAnthropicProvider quickly creates an Anthropic Claude provider
+func QuickAnthropicProvider(apiKey, model string) (types.LLMProvider, error) {
+	if model == "" {
+		model = ClaudeSonnet4
+	}
+	opts := AnthropicOptions{
+		APIKey: apiKey,
+		Model:  model,
+	}
+	return NewAnthropicClient(opts)
+}
+
+// AnthropicModel predefined Anthropic Claude model constants
+const (
+	ClaudeOpus41   = "claude-opus-4-1"
+	ClaudeOpus4    = "claude-opus-4-0"
+	ClaudeSonnet45 = "claude-sonnet-4-5"
+	ClaudeSonnet4  = "claude-sonnet-4-0"
+	ClaudeHaiku45  = "claude-haiku-4-5"
+	Claude37Sonnet = "claude-3-7-sonnet-latest"
+	Claude35Haiku  = "claude-3-5-haiku-latest"
+)
+
+// DefaultAnthropicOptions default Anthropic configuration
+func DefaultAnthropicOptions() AnthropicOptions {
+	return AnthropicOptions{
+		BaseURL: "https://api.anthropic.com/v1",
+		Model:   ClaudeSonnet4,
+	}
+}
@@ -0,0 +1,85 @@
+package llm
+
+import (
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+
+	"github.com/xichan96/cortex/pkg/errors"
+	"github.com/xichan96/cortex/pkg/logger"
+)
+
+// knownAPIPaths API path required by well-known hosts when the configured base URL has none
+var knownAPIPaths = map[string]string{
+	"api.openai.com":    "/v1",
+	"api.anthropic.com": "/v1",
+	"volces.com":        "/api/v3",
+}
+
+// endpointSuffixes endpoint paths users paste by mistake, the client appends them itself
+var endpointSuffixes = []string{"/chat/completions", "/completions", "/embeddings", "/messages"}
+
+// normalizeBaseURL validates a base URL and corrects common mistakes
+// Trailing slashes, pasted endpoint paths and duplicated version segments (e.g. /v1/v1) are removed,
+// and the API path is added for well-known hosts that require one. Corrections are logged
+func normalizeBaseURL(provider, raw string) (string, error) {
+	trimmed := strings.TrimSpace(raw)
+	u, err := url.Parse(trimmed)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		if err == nil {
+			err = fmt.Errorf("expected an absolute http(s) URL")
+		}
+		return "", errors.NewError(errors.EC_INVALID_CONFIG.Code, fmt.Sprintf("invalid %s base URL %q", provider, raw)).Wrap(err)
+	}
+
+	path := strings.TrimRight(u.Path, "/")
+	for _, suffix := range endpointSuffixes {
+		path = strings.TrimSuffix(path, suffix)
+	}
+	path = dedupeVersionSegments(path)
+	if path == "" {
+		for host, apiPath := range knownAPIPaths {
+			if u.Hostname() == host || strings.HasSuffix(u.Hostname(), "."+host) {
+				path = apiPath
+				break
+			}
+		}
+	}
+	u.Path = path
+
+	normalized := u.String()
+	if normalized != raw {
+		logger.NewLogger().Info("Normalized LLM base URL",
+			slog.String("provider", provider),
+			slog.String("configured", raw),
+			slog.String("normalized", normalized))
+	}
+	return normalized, nil
+}
+
+// dedupeVersionSegments collapses repeated version segments such as /v1/v1 into one
+func dedupeVersionSegments(path string) string {
+	segments := strings.Split(path, "/")
+	result := segments[:0]
+	for _, segment := range segments {
+		if n := len(result); n > 0 && segment == result[n-1] && isVersionSegment(segment) {
+			continue
+		}
+		result = append(result, segment)
+	}
+	return strings.Join(result, "/")
+}
+
+// isVersionSegment reports whether a path segment looks like an API version (v1, v3, ...)
+func isVersionSegment(segment string) bool {
+	if len(segment) < 2 || segment[0] != 'v' {
+		return false
+	}
+	for _, c := range segment[1:] {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
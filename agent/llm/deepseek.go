@@ -1,6 +1,8 @@
 package llm
 
 import (
+	"time"
+
 	"github.com/tmc/langchaingo/llms/openai"
 	"github.com/xichan96/cortex/agent/providers"
 	"github.com/xichan96/cortex/agent/types"
@@ -9,9 +11,11 @@ import (
 
 // DeepSeekOptions DeepSeek configuration options
 type DeepSeekOptions struct {
-	APIKey  string
-	BaseURL string
-	Model   string
+	APIKey         string
+	BaseURL        string
+	Model          string
+	RequestTimeout time.Duration                   // per-request timeout, 0 uses providers.DefaultRequestTimeout
+	ConnectionPool *providers.ConnectionPoolConfig // dedicated connection pool, nil shares the global pool
 }
 
 // NewDeepSeekClient creates a new DeepSeek client and returns LLMProvider
@@ -27,8 +31,13 @@ func NewDeepSeekClient(opts DeepSeekOptions) (types.LLMProvider, error) {
 	if opts.BaseURL == "" {
 		opts.BaseURL = "https://api.deepseek.com"
 	}
+	baseURL, err := normalizeBaseURL("deepseek", opts.BaseURL)
+	if err != nil {
+		return nil, err
+	}
+	opts.BaseURL = baseURL
 
-	pooledClient := providers.GetPooledHTTPClient()
+	pooledClient := providers.NewHTTPClient(opts.RequestTimeout, opts.ConnectionPool)
 
 	client, err := openai.New(
 		openai.WithToken(opts.APIKey),
@@ -0,0 +1,172 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/tmc/langchaingo/llms/ollama"
+	"github.com/tmc/langchaingo/llms/openai"
+	"github.com/xichan96/cortex/agent/providers"
+	"github.com/xichan96/cortex/agent/types"
+	"github.com/xichan96/cortex/pkg/errors"
+	"github.com/xichan96/cortex/pkg/logger"
+)
+
+// Ollama tool calling modes
+const (
+	// OllamaToolsAuto asks the server whether the model supports tools and picks native or prompt mode
+	OllamaToolsAuto = "auto"
+	// OllamaToolsNative sends tool definitions through Ollama's OpenAI-compatible API
+	OllamaToolsNative = "native"
+	// OllamaToolsPrompt describes tools in the prompt and parses tool calls from the reply
+	OllamaToolsPrompt = "prompt"
+)
+
+// ollamaCapabilityTimeout bound of the model capability check
+const ollamaCapabilityTimeout = 10 * time.Second
+
+// OllamaOptions Ollama configuration options
+type OllamaOptions struct {
+	BaseURL string
+	Model   string
+	// ToolCalling how tools reach the model, defaults to OllamaToolsAuto
+	// langchaingo's Ollama backend does not send tool definitions, so models with function calling are
+	// served through Ollama's OpenAI-compatible API and the others fall back to prompt-based tool calling
+	ToolCalling    string
+	RequestTimeout time.Duration                   // per-request timeout, 0 uses providers.DefaultRequestTimeout
+	ConnectionPool *providers.ConnectionPoolConfig // dedicated connection pool, nil shares the global pool
+}
+
+// NewOllamaClient creates a new Ollama client for a local model and returns LLMProvider
+// No API key is needed
+func NewOllamaClient(opts OllamaOptions) (types.LLMProvider, error) {
+	if opts.Model == "" {
+		opts.Model = OllamaLlama31
+	}
+
+	if opts.BaseURL == "" {
+		opts.BaseURL = "http://localhost:11434"
+	}
+	baseURL, err := normalizeBaseURL("ollama", opts.BaseURL)
+	if err != nil {
+		return nil, err
+	}
+	// The OpenAI-compatible API path is added for native tool calling
+	opts.BaseURL = strings.TrimSuffix(baseURL, "/v1")
+
+	pooledClient := providers.NewHTTPClient(opts.RequestTimeout, opts.ConnectionPool)
+
+	mode := opts.ToolCalling
+	switch mode {
+	case "", OllamaToolsAuto:
+		mode = OllamaToolsPrompt
+		supported, err := ollamaSupportsTools(pooledClient, opts.BaseURL, opts.Model)
+		if err != nil {
+			// The server may not be up yet, prompt mode works with every model
+			logger.NewLogger().LogError("NewOllamaClient", err,
+				slog.String("model", opts.Model),
+				slog.String("phase", "capability_check"))
+		} else if supported {
+			mode = OllamaToolsNative
+		}
+	case OllamaToolsNative, OllamaToolsPrompt:
+	default:
+		return nil, errors.NewError(errors.EC_INVALID_CONFIG.Code, fmt.Sprintf("invalid ollama tool calling mode %q", opts.ToolCalling))
+	}
+
+	if mode == OllamaToolsNative {
+		client, err := openai.New(
+			openai.WithToken("ollama"), // required by the client, ignored by Ollama
+			openai.WithBaseURL(opts.BaseURL+"/v1"),
+			openai.WithModel(opts.Model),
+			openai.WithHTTPClient(pooledClient),
+		)
+		if err != nil {
+			return nil, errors.NewError(errors.EC_LLM_CLIENT_CREATE_FAILED.Code, errors.EC_LLM_CLIENT_CREATE_FAILED.Message).Wrap(err)
+		}
+		return providers.NewLangChainLLMProvider(client, opts.Model), nil
+	}
+
+	client, err := ollama.New(
+		ollama.WithServerURL(opts.BaseURL),
+		ollama.WithModel(opts.Model),
+		ollama.WithHTTPClient(pooledClient),
+	)
+	if err != nil {
+		return nil, errors.NewError(errors.EC_LLM_CLIENT_CREATE_FAILED.Code, errors.EC_LLM_CLIENT_CREATE_FAILED.Message).Wrap(err)
+	}
+	provider := providers.NewLangChainLLMProvider(client, opts.Model)
+	provider.SetPromptToolCalling(true)
+	return provider, nil
+}
+
+// ollamaSupportsTools asks the Ollama server whether a model supports function calling
+// Servers too old to report capabilities are treated as without tool support
+func ollamaSupportsTools(client *http.Client, baseURL, model string) (bool, error) {
+	body, err := json.Marshal(map[string]string{"model": model, "name": model})
+	if err != nil {
+		return false, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), ollamaCapabilityTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, baseURL+"/api/show", bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("ollama show model %s: status %d", model, resp.StatusCode)
+	}
+
+	var show struct {
+		Capabilities []string `json:"capabilities"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&show); err != nil {
+		return false, err
+	}
+	return slices.Contains(show.Capabilities, "tools"), nil
+}
+
+// QuickOllamaProvider quickly creates an Ollama provider on the default local server
+func QuickOllamaProvider(model string) (types.LLMProvider, error) {
+	if model == "" {
+		model = OllamaLlama31
+	}
+	opts := OllamaOptions{
+		Model: model,
+	}
+	return NewOllamaClient(opts)
+}
+
+// OllamaModel predefined Ollama model constants
+const (
+	OllamaLlama31  = "llama3.1"
+	OllamaLlama32  = "llama3.2"
+	OllamaLlama3   = "llama3"
+	OllamaQwen25   = "qwen2.5"
+	OllamaMistral  = "mistral"
+	OllamaGemma2   = "gemma2"
+	OllamaDeepSeek = "deepseek-r1"
+)
+
+// DefaultOllamaOptions default Ollama configuration
+func DefaultOllamaOptions() OllamaOptions {
+	return OllamaOptions{
+		BaseURL:     "http://localhost:11434",
+		Model:       OllamaLlama31,
+		ToolCalling: OllamaToolsAuto,
+	}
+}
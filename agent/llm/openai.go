@@ -1,19 +1,42 @@
 package llm
 
 import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
 	"github.com/tmc/langchaingo/llms/openai"
 	"github.com/xichan96/cortex/agent/providers"
 	"github.com/xichan96/cortex/agent/types"
 	"github.com/xichan96/cortex/pkg/errors"
 )
 
+// OpenAI API types
+const (
+	OpenAIAPITypeOpenAI = "openai"
+	// OpenAIAPITypeAzure Azure OpenAI authenticated with the resource's API key
+	OpenAIAPITypeAzure = "azure"
+	// OpenAIAPITypeAzureAD Azure OpenAI authenticated with a Microsoft Entra ID access token as APIKey
+	OpenAIAPITypeAzureAD = "azure_ad"
+)
+
+// DefaultAzureAPIVersion Azure OpenAI API version used when OpenAIOptions.APIVersion is empty
+const DefaultAzureAPIVersion = "2024-10-21"
+
 // OpenAIOptions OpenAI configuration options
+// For Azure OpenAI set APIType to "azure" (or "azure_ad"), BaseURL to the resource endpoint
+// (https://<resource>.openai.azure.com) and Deployment to the deployment name; Model is optional there
 type OpenAIOptions struct {
-	APIKey  string
-	BaseURL string
-	Model   string
-	OrgID   string
-	APIType string // "openai", "azure"
+	APIKey         string
+	BaseURL        string
+	Model          string
+	OrgID          string
+	APIType        string                          // "openai" (default), "azure" or "azure_ad"
+	APIVersion     string                          // Azure API version, defaults to DefaultAzureAPIVersion
+	Deployment     string                          // Azure deployment name, required for the Azure API types
+	RequestTimeout time.Duration                   // per-request timeout, 0 uses providers.DefaultRequestTimeout
+	ConnectionPool *providers.ConnectionPoolConfig // dedicated connection pool, nil shares the global pool
 }
 
 // NewOpenAIClient creates a new OpenAI client and returns LLMProvider
@@ -22,15 +45,28 @@ func NewOpenAIClient(opts OpenAIOptions) (types.LLMProvider, error) {
 		return nil, errors.EC_LLM_API_KEY_REQUIRED
 	}
 
+	switch strings.ToLower(opts.APIType) {
+	case "", OpenAIAPITypeOpenAI:
+	case OpenAIAPITypeAzure, OpenAIAPITypeAzureAD:
+		return newAzureOpenAIClient(opts)
+	default:
+		return nil, errors.NewError(errors.EC_INVALID_CONFIG.Code, fmt.Sprintf("invalid openai api type %q", opts.APIType))
+	}
+
 	if opts.Model == "" {
 		opts.Model = GPT4oMini.String()
 	}
 
 	if opts.BaseURL == "" {
-		opts.BaseURL = "https://api.openai.com"
+		opts.BaseURL = "https://api.openai.com/v1"
 	}
+	baseURL, err := normalizeBaseURL("openai", opts.BaseURL)
+	if err != nil {
+		return nil, err
+	}
+	opts.BaseURL = baseURL
 
-	pooledClient := providers.GetPooledHTTPClient()
+	pooledClient := providers.NewHTTPClient(opts.RequestTimeout, opts.ConnectionPool)
 
 	client, err := openai.New(
 		openai.WithToken(opts.APIKey),
@@ -47,6 +83,82 @@ func NewOpenAIClient(opts OpenAIOptions) (types.LLMProvider, error) {
 	return providers.NewLangChainLLMProvider(client, opts.Model), nil
 }
 
+// NewAzureOpenAIClient creates an Azure OpenAI client for a deployment and returns LLMProvider
+// endpoint is the resource endpoint (https://<resource>.openai.azure.com), an empty apiVersion uses DefaultAzureAPIVersion
+func NewAzureOpenAIClient(apiKey, endpoint, deployment, apiVersion string) (types.LLMProvider, error) {
+	return NewOpenAIClient(OpenAIOptions{
+		APIKey:     apiKey,
+		BaseURL:    endpoint,
+		APIType:    OpenAIAPITypeAzure,
+		Deployment: deployment,
+		APIVersion: apiVersion,
+	})
+}
+
+// newAzureOpenAIClient creates the client for the Azure API types
+// Requests go to {endpoint}/openai/deployments/{deployment}/chat/completions?api-version={version}
+func newAzureOpenAIClient(opts OpenAIOptions) (types.LLMProvider, error) {
+	if opts.BaseURL == "" {
+		return nil, errors.NewError(errors.EC_INVALID_CONFIG.Code, "azure openai requires the resource endpoint as base URL")
+	}
+	if opts.Deployment == "" {
+		return nil, errors.NewError(errors.EC_INVALID_CONFIG.Code, "azure openai requires a deployment name")
+	}
+	if opts.APIVersion == "" {
+		opts.APIVersion = DefaultAzureAPIVersion
+	}
+	endpoint, err := azureEndpoint(opts.BaseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	apiType := openai.APITypeAzure
+	if strings.ToLower(opts.APIType) == OpenAIAPITypeAzureAD {
+		apiType = openai.APITypeAzureAD
+	}
+
+	pooledClient := providers.NewHTTPClient(opts.RequestTimeout, opts.ConnectionPool)
+
+	client, err := openai.New(
+		openai.WithToken(opts.APIKey),
+		openai.WithBaseURL(endpoint),
+		// The Azure client puts the model in the URL, where the deployment name belongs
+		openai.WithModel(opts.Deployment),
+		openai.WithAPIType(apiType),
+		openai.WithAPIVersion(opts.APIVersion),
+		openai.WithHTTPClient(pooledClient),
+	)
+	if err != nil {
+		return nil, errors.NewError(errors.EC_LLM_CLIENT_CREATE_FAILED.Code, errors.EC_LLM_CLIENT_CREATE_FAILED.Message).Wrap(err)
+	}
+
+	// The model name is informational with Azure, the deployment decides the model
+	modelName := opts.Model
+	if modelName == "" {
+		modelName = opts.Deployment
+	}
+	return providers.NewLangChainLLMProvider(client, modelName), nil
+}
+
+// azureEndpoint validates an Azure OpenAI endpoint and reduces it to scheme and host
+// A pasted request URL (.../openai/deployments/<name>/chat/completions?api-version=...) is accepted
+func azureEndpoint(raw string) (string, error) {
+	normalized, err := normalizeBaseURL("azure openai", raw)
+	if err != nil {
+		return "", err
+	}
+	u, err := url.Parse(normalized)
+	if err != nil {
+		return "", errors.NewError(errors.EC_INVALID_CONFIG.Code, fmt.Sprintf("invalid azure openai base URL %q", raw)).Wrap(err)
+	}
+	if i := strings.Index(u.Path, "/openai"); i >= 0 {
+		u.Path = u.Path[:i]
+	}
+	u.RawQuery = ""
+	u.Fragment = ""
+	return strings.TrimRight(u.String(), "/"), nil
+}
+
 // OpenAIModel OpenAI model constants
 type OpenAIModel string
 
@@ -74,7 +186,7 @@ func (m OpenAIModel) String() string {
 // DefaultOpenAIOptions default OpenAI configuration
 func DefaultOpenAIOptions() OpenAIOptions {
 	return OpenAIOptions{
-		BaseURL: "https://api.openai.com",
+		BaseURL: "https://api.openai.com/v1",
 		Model:   GPT4oMini.String(),
 	}
 }
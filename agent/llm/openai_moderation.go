@@ -0,0 +1,126 @@
+package llm
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/xichan96/cortex/agent/providers"
+	"github.com/xichan96/cortex/agent/types"
+	"github.com/xichan96/cortex/pkg/errors"
+)
+
+// DefaultModerationModel OpenAI moderation model used when none is configured
+const DefaultModerationModel = "omni-moderation-latest"
+
+// OpenAIModerationOptions OpenAI moderation endpoint configuration options
+type OpenAIModerationOptions struct {
+	APIKey         string
+	BaseURL        string        // defaults to https://api.openai.com/v1
+	Model          string        // defaults to DefaultModerationModel
+	RequestTimeout time.Duration // per-request timeout, 0 uses providers.DefaultRequestTimeout
+}
+
+// OpenAIModerator checks text with OpenAI's moderation endpoint (POST /moderations)
+type OpenAIModerator struct {
+	client  *http.Client
+	apiKey  string
+	baseURL string
+	model   string
+}
+
+// NewOpenAIModerator creates a moderator backed by OpenAI's moderation endpoint
+func NewOpenAIModerator(opts OpenAIModerationOptions) (types.Moderator, error) {
+	if opts.APIKey == "" {
+		return nil, errors.EC_LLM_API_KEY_REQUIRED
+	}
+	if opts.Model == "" {
+		opts.Model = DefaultModerationModel
+	}
+	if opts.BaseURL == "" {
+		opts.BaseURL = "https://api.openai.com/v1"
+	}
+	baseURL, err := normalizeBaseURL("openai", opts.BaseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &OpenAIModerator{
+		client:  providers.NewHTTPClient(opts.RequestTimeout, nil),
+		apiKey:  opts.APIKey,
+		baseURL: baseURL,
+		model:   opts.Model,
+	}, nil
+}
+
+// moderationResponse subset of the moderation endpoint response
+type moderationResponse struct {
+	Results []struct {
+		Flagged    bool            `json:"flagged"`
+		Categories map[string]bool `json:"categories"`
+	} `json:"results"`
+}
+
+// Check implements types.Moderator, the reason lists the flagged categories
+func (m *OpenAIModerator) Check(text string) (bool, string, error) {
+	body, err := json.Marshal(map[string]string{
+		"model": m.model,
+		"input": text,
+	})
+	if err != nil {
+		return false, "", errors.NewError(errors.EC_HTTP_MARSHAL_FAILED.Code, errors.EC_HTTP_MARSHAL_FAILED.Message).Wrap(err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, m.baseURL+"/moderations", bytes.NewReader(body))
+	if err != nil {
+		return false, "", errors.NewError(errors.EC_HTTP_REQUEST_FAILED.Code, "failed to build moderation request").Wrap(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+m.apiKey)
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return false, "", errors.NewError(errors.EC_HTTP_REQUEST_FAILED.Code, "moderation request failed").Wrap(err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return false, "", errors.NewError(errors.EC_HTTP_REQUEST_FAILED.Code, "failed to read moderation response").Wrap(err)
+	}
+	if resp.StatusCode >= 400 {
+		return false, "", errors.NewError(errors.EC_HTTP_STATUS_ERROR.Code, fmt.Sprintf("moderation request failed with status %d: %s", resp.StatusCode, string(data)))
+	}
+
+	var result moderationResponse
+	if err := json.Unmarshal(data, &result); err != nil {
+		return false, "", errors.NewError(errors.EC_HTTP_REQUEST_FAILED.Code, "invalid moderation response").Wrap(err)
+	}
+
+	var categories []string
+	flagged := false
+	for _, r := range result.Results {
+		if !r.Flagged {
+			continue
+		}
+		flagged = true
+		for category, hit := range r.Categories {
+			if hit {
+				categories = append(categories, category)
+			}
+		}
+	}
+	if !flagged {
+		return true, "", nil
+	}
+	sort.Strings(categories)
+	if len(categories) == 0 {
+		return false, "flagged", nil
+	}
+	return false, "flagged for " + strings.Join(categories, ", "), nil
+}
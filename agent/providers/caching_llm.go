@@ -0,0 +1,229 @@
+package providers
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/xichan96/cortex/agent/types"
+	"github.com/xichan96/cortex/pkg/errors"
+	"github.com/xichan96/cortex/pkg/logger"
+)
+
+// Caching provider defaults
+const (
+	DefaultLLMCacheTTL        = 10 * time.Minute
+	DefaultLLMCacheMaxEntries = 1000
+)
+
+// upstreamFailurePattern provider error messages that indicate a server-side or transport failure
+var upstreamFailurePattern = regexp.MustCompile(`(?i)\b50[0-4]\b|internal server error|bad gateway|service unavailable|gateway timeout|overloaded|connection refused|connection reset|no such host|unexpected EOF`)
+
+// CachingLLMOptions response cache options
+type CachingLLMOptions struct {
+	TTL        time.Duration // how long a response is served from cache (default 10m)
+	MaxEntries int           // cached responses kept, least recently used are evicted (default 1000)
+
+	// StaleWhileError serves the last cached response for the request, even if expired,
+	// when the upstream call fails with a 5xx or transport error (opt-in)
+	StaleWhileError bool
+	MaxStale        time.Duration // oldest response served on error (0 means any age)
+}
+
+// llmCacheEntry cached response of one request
+type llmCacheEntry struct {
+	key      string
+	response types.Message
+	storedAt time.Time
+}
+
+// CachingLLMProvider wraps an LLM provider and caches Chat and ChatWithTools responses by request hash
+// Streaming calls are passed through uncached
+type CachingLLMProvider struct {
+	base   types.LLMProvider
+	opts   CachingLLMOptions
+	logger *logger.Logger
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List // front is most recently used, values are *llmCacheEntry
+}
+
+// NewCachingLLMProvider creates a caching wrapper around base
+func NewCachingLLMProvider(base types.LLMProvider, opts CachingLLMOptions) *CachingLLMProvider {
+	if opts.TTL <= 0 {
+		opts.TTL = DefaultLLMCacheTTL
+	}
+	if opts.MaxEntries <= 0 {
+		opts.MaxEntries = DefaultLLMCacheMaxEntries
+	}
+	return &CachingLLMProvider{
+		base:    base,
+		opts:    opts,
+		logger:  logger.NewLogger(),
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+// Chat returns a cached response or calls the underlying provider
+func (p *CachingLLMProvider) Chat(messages []types.Message) (types.Message, error) {
+	return p.cached(messages, nil, func() (types.Message, error) {
+		return p.base.Chat(messages)
+	})
+}
+
+// ChatStream passes through to the underlying provider
+func (p *CachingLLMProvider) ChatStream(messages []types.Message) (<-chan types.StreamMessage, error) {
+	return p.base.ChatStream(messages)
+}
+
+// ChatWithTools returns a cached response or calls the underlying provider
+func (p *CachingLLMProvider) ChatWithTools(messages []types.Message, tools []types.Tool) (types.Message, error) {
+	return p.cached(messages, tools, func() (types.Message, error) {
+		return p.base.ChatWithTools(messages, tools)
+	})
+}
+
+// ChatWithToolsStream passes through to the underlying provider
+func (p *CachingLLMProvider) ChatWithToolsStream(messages []types.Message, tools []types.Tool) (<-chan types.StreamMessage, error) {
+	return p.base.ChatWithToolsStream(messages, tools)
+}
+
+// GetModelName returns the underlying model name
+func (p *CachingLLMProvider) GetModelName() string {
+	return p.base.GetModelName()
+}
+
+// GetModelMetadata returns the underlying model metadata
+func (p *CachingLLMProvider) GetModelMetadata() types.ModelMetadata {
+	return p.base.GetModelMetadata()
+}
+
+// SupportsStreaming reports whether the underlying provider streams, providers without types.StreamingCapability do
+func (p *CachingLLMProvider) SupportsStreaming() bool {
+	if capability, ok := p.base.(types.StreamingCapability); ok {
+		return capability.SupportsStreaming()
+	}
+	return true
+}
+
+// Clear drops all cached responses
+func (p *CachingLLMProvider) Clear() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.entries = make(map[string]*list.Element)
+	p.lru.Init()
+}
+
+// cached serves a fresh cache entry, otherwise calls the upstream and falls back to a stale entry on upstream failure
+func (p *CachingLLMProvider) cached(messages []types.Message, tools []types.Tool, call func() (types.Message, error)) (types.Message, error) {
+	key, err := p.requestKey(messages, tools)
+	if err != nil {
+		// Unhashable requests are never cached
+		return call()
+	}
+
+	entry, ok := p.get(key)
+	if ok && time.Since(entry.storedAt) < p.opts.TTL {
+		return entry.response, nil
+	}
+
+	response, err := call()
+	if err == nil {
+		// Cached responses cost no tokens when served again
+		stored := response
+		stored.Usage = nil
+		p.put(key, stored)
+		return response, nil
+	}
+
+	if !ok || !p.opts.StaleWhileError || !isUpstreamFailure(err) {
+		return response, err
+	}
+	age := time.Since(entry.storedAt)
+	if p.opts.MaxStale > 0 && age > p.opts.MaxStale {
+		return response, err
+	}
+
+	p.logger.LogError("CachingLLMProvider", err,
+		slog.String("model", p.base.GetModelName()),
+		slog.Duration("stale_age", age),
+		slog.String("fallback", "stale_cache"))
+	stale := entry.response
+	stale.Warnings = append(append([]string(nil), stale.Warnings...),
+		fmt.Sprintf("model unavailable (%v), served a cached response from %s ago", err, age.Round(time.Second)))
+	return stale, nil
+}
+
+// requestKey hashes the model, messages and tool definitions of a request
+func (p *CachingLLMProvider) requestKey(messages []types.Message, tools []types.Tool) (string, error) {
+	toolDefs := make([]map[string]interface{}, 0, len(tools))
+	for _, tool := range tools {
+		toolDefs = append(toolDefs, map[string]interface{}{
+			"name":        tool.Name(),
+			"description": tool.Description(),
+			"schema":      tool.Schema(),
+		})
+	}
+	data, err := json.Marshal(map[string]interface{}{
+		"model":    p.base.GetModelName(),
+		"messages": messages,
+		"tools":    toolDefs,
+	})
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// get returns the entry for key, expired or not, and marks it recently used
+func (p *CachingLLMProvider) get(key string) (llmCacheEntry, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	elem, ok := p.entries[key]
+	if !ok {
+		return llmCacheEntry{}, false
+	}
+	p.lru.MoveToFront(elem)
+	return *elem.Value.(*llmCacheEntry), true
+}
+
+// put stores a response, evicting the least recently used entries beyond MaxEntries
+func (p *CachingLLMProvider) put(key string, response types.Message) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	entry := &llmCacheEntry{key: key, response: response, storedAt: time.Now()}
+	if elem, ok := p.entries[key]; ok {
+		elem.Value = entry
+		p.lru.MoveToFront(elem)
+		return
+	}
+	p.entries[key] = p.lru.PushFront(entry)
+	for p.lru.Len() > p.opts.MaxEntries {
+		oldest := p.lru.Back()
+		p.lru.Remove(oldest)
+		delete(p.entries, oldest.Value.(*llmCacheEntry).key)
+	}
+}
+
+// isUpstreamFailure reports whether err means the provider is unavailable rather than the request being invalid
+func isUpstreamFailure(err error) bool {
+	if errors.IsRetryable(err) {
+		return true
+	}
+	var netErr net.Error
+	if stderrors.As(err, &netErr) {
+		return true
+	}
+	return upstreamFailurePattern.MatchString(err.Error())
+}
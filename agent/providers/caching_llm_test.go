@@ -0,0 +1,106 @@
+package providers
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/xichan96/cortex/agent/types"
+)
+
+// fakeLLM returns a numbered response per call, or err when set
+type fakeLLM struct {
+	calls int
+	err   error
+}
+
+func (f *fakeLLM) Chat(messages []types.Message) (types.Message, error) {
+	f.calls++
+	if f.err != nil {
+		return types.Message{}, f.err
+	}
+	return types.Message{Role: "assistant", Content: fmt.Sprintf("answer %d", f.calls)}, nil
+}
+
+func (f *fakeLLM) ChatStream(messages []types.Message) (<-chan types.StreamMessage, error) {
+	return nil, nil
+}
+
+func (f *fakeLLM) ChatWithTools(messages []types.Message, tools []types.Tool) (types.Message, error) {
+	return f.Chat(messages)
+}
+
+func (f *fakeLLM) ChatWithToolsStream(messages []types.Message, tools []types.Tool) (<-chan types.StreamMessage, error) {
+	return nil, nil
+}
+
+func (f *fakeLLM) GetModelName() string                  { return "fake" }
+func (f *fakeLLM) GetModelMetadata() types.ModelMetadata { return types.ModelMetadata{Name: "fake"} }
+func (f *fakeLLM) SupportsStreaming() bool               { return false }
+
+func TestCachingLLMProvider(t *testing.T) {
+	base := &fakeLLM{}
+	p := NewCachingLLMProvider(base, CachingLLMOptions{})
+	messages := []types.Message{{Role: "user", Content: "hello"}}
+
+	first, err := p.Chat(messages)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	second, err := p.Chat(messages)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if base.calls != 1 || second.Content != first.Content {
+		t.Errorf("Expected the second call to be served from cache, got %d calls and %q", base.calls, second.Content)
+	}
+
+	if _, err := p.Chat([]types.Message{{Role: "user", Content: "other"}}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if base.calls != 2 {
+		t.Errorf("Expected a different request to miss the cache, got %d calls", base.calls)
+	}
+}
+
+func TestCachingLLMProviderStaleWhileError(t *testing.T) {
+	messages := []types.Message{{Role: "user", Content: "hello"}}
+	outage := fmt.Errorf("API returned unexpected status code: 503: service unavailable")
+
+	tests := []struct {
+		name      string
+		opts      CachingLLMOptions
+		err       error
+		wantStale bool
+	}{
+		{"disabled", CachingLLMOptions{TTL: time.Nanosecond}, outage, false},
+		{"server error", CachingLLMOptions{TTL: time.Nanosecond, StaleWhileError: true}, outage, true},
+		{"client error", CachingLLMOptions{TTL: time.Nanosecond, StaleWhileError: true}, fmt.Errorf("status code: 400: invalid request"), false},
+		{"too stale", CachingLLMOptions{TTL: time.Nanosecond, StaleWhileError: true, MaxStale: time.Nanosecond}, outage, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base := &fakeLLM{}
+			p := NewCachingLLMProvider(base, tt.opts)
+			if _, err := p.Chat(messages); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			time.Sleep(time.Millisecond)
+
+			base.err = tt.err
+			response, err := p.Chat(messages)
+			if !tt.wantStale {
+				if err == nil {
+					t.Errorf("Expected the upstream error, got %+v", response)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected the stale response, got %v", err)
+			}
+			if response.Content != "answer 1" || len(response.Warnings) != 1 {
+				t.Errorf("Unexpected stale response: %+v", response)
+			}
+		})
+	}
+}
@@ -0,0 +1,188 @@
+package providers
+
+import (
+	"context"
+	stderrors "errors"
+	"sync"
+	"time"
+
+	"github.com/xichan96/cortex/agent/types"
+	"github.com/xichan96/cortex/pkg/errors"
+	"github.com/xichan96/cortex/pkg/mongodb"
+	"github.com/xichan96/cortex/pkg/redis"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// DefaultCheckpointTTL default lifetime of checkpoints in Redis
+const DefaultCheckpointTTL = 24 * time.Hour
+
+// SimpleCheckpointStore in-process checkpoint store, useful for tests and single-process resumes
+type SimpleCheckpointStore struct {
+	mu          sync.RWMutex
+	checkpoints map[string]types.Checkpoint
+}
+
+// NewSimpleCheckpointStore creates a new in-memory checkpoint store
+func NewSimpleCheckpointStore() *SimpleCheckpointStore {
+	return &SimpleCheckpointStore{
+		checkpoints: make(map[string]types.Checkpoint),
+	}
+}
+
+// Save stores a copy of the checkpoint
+func (s *SimpleCheckpointStore) Save(ctx context.Context, checkpoint *types.Checkpoint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cp := *checkpoint
+	cp.Messages = append([]types.Message(nil), checkpoint.Messages...)
+	s.checkpoints[cp.ID] = cp
+	return nil
+}
+
+// Load returns a copy of the stored checkpoint
+func (s *SimpleCheckpointStore) Load(ctx context.Context, id string) (*types.Checkpoint, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	cp, ok := s.checkpoints[id]
+	if !ok {
+		return nil, errors.EC_CHECKPOINT_NOT_FOUND
+	}
+	cp.Messages = append([]types.Message(nil), cp.Messages...)
+	return &cp, nil
+}
+
+// Delete removes a checkpoint
+func (s *SimpleCheckpointStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.checkpoints, id)
+	return nil
+}
+
+// RedisCheckpointStore checkpoint store backed by Redis, checkpoints expire after the TTL
+type RedisCheckpointStore struct {
+	mu        sync.RWMutex
+	client    *redis.Client
+	keyPrefix string
+	ttl       time.Duration
+}
+
+// NewRedisCheckpointStore creates a new Redis checkpoint store
+func NewRedisCheckpointStore(client *redis.Client) *RedisCheckpointStore {
+	return &RedisCheckpointStore{
+		client:    client,
+		keyPrefix: "agent_checkpoint",
+		ttl:       DefaultCheckpointTTL,
+	}
+}
+
+// SetKeyPrefix sets the key prefix
+func (s *RedisCheckpointStore) SetKeyPrefix(prefix string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keyPrefix = prefix
+}
+
+// SetTTL sets the checkpoint lifetime (0 means no expiration)
+func (s *RedisCheckpointStore) SetTTL(ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ttl = ttl
+}
+
+func (s *RedisCheckpointStore) getKey(id string) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.keyPrefix + ":" + id
+}
+
+// Save stores the checkpoint as JSON
+func (s *RedisCheckpointStore) Save(ctx context.Context, checkpoint *types.Checkpoint) error {
+	s.mu.RLock()
+	ttl := s.ttl
+	s.mu.RUnlock()
+	return s.client.SetObjectEx(ctx, s.getKey(checkpoint.ID), persistableCheckpoint(checkpoint), ttl)
+}
+
+// Load reads the checkpoint
+func (s *RedisCheckpointStore) Load(ctx context.Context, id string) (*types.Checkpoint, error) {
+	var cp types.Checkpoint
+	if err := s.client.GetObject(ctx, s.getKey(id), &cp); err != nil {
+		return nil, checkpointLoadError(err)
+	}
+	return &cp, nil
+}
+
+// Delete removes a checkpoint
+func (s *RedisCheckpointStore) Delete(ctx context.Context, id string) error {
+	return redis.WrapRedisErr(s.client.Del(ctx, s.getKey(id)).Err())
+}
+
+// MongoDBCheckpointStore checkpoint store backed by MongoDB, one document per execution
+type MongoDBCheckpointStore struct {
+	mu             sync.RWMutex
+	client         *mongodb.Client
+	collectionName string
+}
+
+// NewMongoDBCheckpointStore creates a new MongoDB checkpoint store
+func NewMongoDBCheckpointStore(client *mongodb.Client) *MongoDBCheckpointStore {
+	return &MongoDBCheckpointStore{
+		client:         client,
+		collectionName: "agent_checkpoints",
+	}
+}
+
+// SetCollectionName sets the collection name
+func (s *MongoDBCheckpointStore) SetCollectionName(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.collectionName = name
+}
+
+func (s *MongoDBCheckpointStore) getCollection() *mongodb.Client {
+	s.mu.RLock()
+	collectionName := s.collectionName
+	s.mu.RUnlock()
+	return s.client.Collection(collectionName)
+}
+
+// Save upserts the checkpoint document
+func (s *MongoDBCheckpointStore) Save(ctx context.Context, checkpoint *types.Checkpoint) error {
+	_, err := s.getCollection().Coll.UpsertId(ctx, checkpoint.ID, persistableCheckpoint(checkpoint))
+	return mongodb.WrapErr(err)
+}
+
+// Load reads the checkpoint document
+func (s *MongoDBCheckpointStore) Load(ctx context.Context, id string) (*types.Checkpoint, error) {
+	var cp types.Checkpoint
+	if err := s.getCollection().FindOne(ctx, bson.M{"_id": id}, &cp); err != nil {
+		return nil, checkpointLoadError(err)
+	}
+	return &cp, nil
+}
+
+// Delete removes the checkpoint document
+func (s *MongoDBCheckpointStore) Delete(ctx context.Context, id string) error {
+	return s.getCollection().DeleteAll(ctx, bson.M{"_id": id})
+}
+
+// persistableCheckpoint returns a copy without multi-modal message parts, which cannot be decoded back
+func persistableCheckpoint(checkpoint *types.Checkpoint) *types.Checkpoint {
+	cp := *checkpoint
+	cp.Messages = make([]types.Message, len(checkpoint.Messages))
+	for i, msg := range checkpoint.Messages {
+		msg.Parts = nil
+		cp.Messages[i] = msg
+	}
+	return &cp
+}
+
+// checkpointLoadError maps a backend not-found error to EC_CHECKPOINT_NOT_FOUND
+func checkpointLoadError(err error) error {
+	var e *errors.Error
+	if stderrors.As(err, &e) && e.Code == errors.EC_DATA_NOT_FOUND.Code {
+		return errors.EC_CHECKPOINT_NOT_FOUND
+	}
+	return errors.NewError(errors.EC_CHECKPOINT_FAILED.Code, "failed to load checkpoint").Wrap(err)
+}
@@ -0,0 +1,60 @@
+package providers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/xichan96/cortex/agent/types"
+	"github.com/xichan96/cortex/pkg/errors"
+)
+
+func TestSimpleCheckpointStore(t *testing.T) {
+	store := NewSimpleCheckpointStore()
+	ctx := context.Background()
+
+	checkpoint := &types.Checkpoint{
+		ID:        "exec-1",
+		Input:     "hello",
+		Messages:  []types.Message{{Role: "user", Content: "hello"}},
+		Iteration: 2,
+	}
+	if err := store.Save(ctx, checkpoint); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// Later changes by the caller must not leak into the stored checkpoint
+	checkpoint.Messages[0].Content = "changed"
+
+	loaded, err := store.Load(ctx, "exec-1")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if loaded.Iteration != 2 || loaded.Messages[0].Content != "hello" {
+		t.Errorf("Unexpected checkpoint: %+v", loaded)
+	}
+
+	if err := store.Delete(ctx, "exec-1"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	_, err = store.Load(ctx, "exec-1")
+	if errObj, ok := err.(*errors.Error); !ok || errObj.Code != errors.EC_CHECKPOINT_NOT_FOUND.Code {
+		t.Errorf("Expected checkpoint not found error, got %v", err)
+	}
+}
+
+func TestPersistableCheckpoint(t *testing.T) {
+	checkpoint := &types.Checkpoint{
+		ID: "exec-2",
+		Messages: []types.Message{{
+			Role:  "user",
+			Parts: []types.MessagePart{types.TextPart{Text: "hi"}},
+		}},
+	}
+
+	cp := persistableCheckpoint(checkpoint)
+	if cp.Messages[0].Parts != nil {
+		t.Error("Expected message parts to be dropped")
+	}
+	if checkpoint.Messages[0].Parts == nil {
+		t.Error("Expected original checkpoint to be left untouched")
+	}
+}
@@ -7,48 +7,75 @@ import (
 	"time"
 )
 
+// DefaultRequestTimeout default end-to-end timeout for a single LLM request, including streamed bodies
+const DefaultRequestTimeout = 120 * time.Second
+
 var (
 	globalTransport *http.Transport
 	transportOnce   sync.Once
 )
 
 type ConnectionPoolConfig struct {
-	MaxSize     int
-	IdleTimeout time.Duration
-	DialTimeout time.Duration
-	KeepAlive   time.Duration
+	MaxSize               int           // maximum idle connections kept per host
+	MaxConnsPerHost       int           // maximum total connections per host (0 means unlimited)
+	IdleTimeout           time.Duration // how long idle connections are kept
+	DialTimeout           time.Duration // TCP connect timeout
+	KeepAlive             time.Duration // TCP keep-alive period
+	TLSHandshakeTimeout   time.Duration // TLS handshake timeout
+	ResponseHeaderTimeout time.Duration // time to wait for response headers (0 means no limit)
 }
 
 func DefaultConnectionPoolConfig() ConnectionPoolConfig {
 	return ConnectionPoolConfig{
-		MaxSize:     15,
-		IdleTimeout: 30 * time.Second,
-		DialTimeout: 5 * time.Second,
-		KeepAlive:   60 * time.Second,
+		MaxSize:             15,
+		IdleTimeout:         30 * time.Second,
+		DialTimeout:         5 * time.Second,
+		KeepAlive:           60 * time.Second,
+		TLSHandshakeTimeout: 10 * time.Second,
+	}
+}
+
+// NewTransport creates an HTTP transport from the pool configuration
+func NewTransport(config ConnectionPoolConfig) *http.Transport {
+	return &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		MaxIdleConns:          config.MaxSize,
+		MaxIdleConnsPerHost:   config.MaxSize,
+		MaxConnsPerHost:       config.MaxConnsPerHost,
+		IdleConnTimeout:       config.IdleTimeout,
+		TLSHandshakeTimeout:   config.TLSHandshakeTimeout,
+		ResponseHeaderTimeout: config.ResponseHeaderTimeout,
+		DialContext: (&net.Dialer{
+			Timeout:   config.DialTimeout,
+			KeepAlive: config.KeepAlive,
+		}).DialContext,
+		DisableKeepAlives: false,
 	}
 }
 
 func GetGlobalTransport() *http.Transport {
 	transportOnce.Do(func() {
-		config := DefaultConnectionPoolConfig()
-		globalTransport = &http.Transport{
-			MaxIdleConns:        config.MaxSize,
-			MaxIdleConnsPerHost: config.MaxSize,
-			IdleConnTimeout:     config.IdleTimeout,
-			DialContext: (&net.Dialer{
-				Timeout:   config.DialTimeout,
-				KeepAlive: config.KeepAlive,
-			}).DialContext,
-			DisableKeepAlives: false,
-		}
+		globalTransport = NewTransport(DefaultConnectionPoolConfig())
 	})
 	return globalTransport
 }
 
 func GetPooledHTTPClient() *http.Client {
+	return NewHTTPClient(0, nil)
+}
+
+// NewHTTPClient creates an HTTP client for LLM calls
+// A nil pool shares the global transport, timeout <= 0 uses DefaultRequestTimeout
+func NewHTTPClient(timeout time.Duration, pool *ConnectionPoolConfig) *http.Client {
+	if timeout <= 0 {
+		timeout = DefaultRequestTimeout
+	}
 	transport := GetGlobalTransport()
+	if pool != nil {
+		transport = NewTransport(*pool)
+	}
 	return &http.Client{
 		Transport: transport,
-		Timeout:   30 * time.Second,
+		Timeout:   timeout,
 	}
 }
@@ -5,9 +5,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"log/slog"
-	"regexp"
-	"strconv"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/tmc/langchaingo/llms"
@@ -18,74 +18,229 @@ import (
 
 // LangChainLLMProvider LangChain LLM provider
 type LangChainLLMProvider struct {
-	model      llms.Model
-	modelName  string
-	logger     *logger.Logger
-	maxRetries int
-	retryDelay time.Duration
+	model     llms.Model
+	modelName string
+	logger    *logger.Logger
+	streaming bool
+	// promptTools describes tools in the prompt for backends without native function calling
+	promptTools bool
+
+	retryMu sync.RWMutex
+	retry   retryPolicy
+
+	toolCallContentMode ToolCallContentMode
+
+	samplingMu sync.RWMutex
+	sampling   samplingOptions
+}
+
+// samplingOptions sampling parameters forwarded on every generate call, zero values are not sent
+type samplingOptions struct {
+	temperature      float64
+	temperatureSet   bool // temperature 0 is a valid setting, so it is tracked separately
+	maxTokens        int
+	topP             float64
+	frequencyPenalty float64
+	presencePenalty  float64
+	stopSequences    []string
+	responseFormat   string // types.ResponseFormatJSONObject enables JSON mode
 }
 
+// ToolCallContentMode how assistant messages that only carry tool calls are encoded
+type ToolCallContentMode string
+
+const (
+	// ToolCallContentOmit sends tool-call-only assistant messages without a text part,
+	// the representation expected by strict providers (default)
+	ToolCallContentOmit ToolCallContentMode = "omit"
+	// ToolCallContentEmptyText adds an empty text part next to the tool calls,
+	// for lenient providers that require a content part on every message
+	ToolCallContentEmptyText ToolCallContentMode = "empty_text"
+)
+
 // NewLangChainLLMProvider creates a new LangChain LLM provider
 func NewLangChainLLMProvider(model llms.Model, modelName string) *LangChainLLMProvider {
 	return &LangChainLLMProvider{
-		model:      model,
-		modelName:  modelName,
-		logger:     logger.NewLogger(),
-		maxRetries: 3,
-		retryDelay: 1 * time.Second,
+		model:     model,
+		modelName: modelName,
+		logger:    logger.NewLogger(),
+		streaming: true,
+		retry:     defaultRetryPolicy(),
+
+		toolCallContentMode: ToolCallContentOmit,
 	}
 }
 
+// SetToolCallContentMode sets how assistant messages with tool calls but no content are encoded
+func (p *LangChainLLMProvider) SetToolCallContentMode(mode ToolCallContentMode) {
+	p.toolCallContentMode = mode
+}
+
+// SetStreaming sets whether the backend supports streaming
+// Disable it for endpoints without streaming support so callers fall back to blocking calls
+func (p *LangChainLLMProvider) SetStreaming(enabled bool) {
+	p.streaming = enabled
+}
+
 // SetMaxRetries sets maximum retry attempts
 func (p *LangChainLLMProvider) SetMaxRetries(maxRetries int) {
-	p.maxRetries = maxRetries
+	p.retryMu.Lock()
+	defer p.retryMu.Unlock()
+	p.retry.maxRetries = maxRetries
 }
 
-// SetRetryDelay sets retry delay duration
+// SetRetryDelay sets the base delay of the retry backoff
 func (p *LangChainLLMProvider) SetRetryDelay(delay time.Duration) {
-	p.retryDelay = delay
+	p.retryMu.Lock()
+	defer p.retryMu.Unlock()
+	p.retry.baseDelay = delay
 }
 
-// handle429Retry handles 429 rate limit errors with retry logic
-func (p *LangChainLLMProvider) handle429Retry(err error, retryCount, maxRetries int) (shouldRetry bool, waitTime time.Duration) {
-	if retryCount >= maxRetries {
-		return false, 0
+// SetRetryPolicy sets how failed calls are retried
+// Errors with one of retryableStatuses (nil restores DefaultRetryableStatuses) and network timeouts are retried
+// up to maxRetries times. The wait doubles from baseDelay up to maxDelay (0 = uncapped) with random jitter;
+// a wait requested by a 429 response replaces the computed one.
+// Streaming calls are only retried before their first chunk, a failure mid-stream ends the stream with an error
+func (p *LangChainLLMProvider) SetRetryPolicy(maxRetries int, baseDelay time.Duration, maxDelay time.Duration, retryableStatuses []int) {
+	statuses := slices.Clone(retryableStatuses)
+	if statuses == nil {
+		statuses = slices.Clone(DefaultRetryableStatuses)
+	}
+
+	p.retryMu.Lock()
+	defer p.retryMu.Unlock()
+	p.retry = retryPolicy{
+		maxRetries: maxRetries,
+		baseDelay:  baseDelay,
+		maxDelay:   maxDelay,
+		statuses:   statuses,
+	}
+}
+
+// retryPolicy returns the current retry policy
+func (p *LangChainLLMProvider) retryPolicy() retryPolicy {
+	p.retryMu.RLock()
+	defer p.retryMu.RUnlock()
+	return p.retry
+}
+
+// SetTemperature sets the sampling temperature sent with each call
+func (p *LangChainLLMProvider) SetTemperature(temperature float32) {
+	p.samplingMu.Lock()
+	defer p.samplingMu.Unlock()
+	p.sampling.temperature = float64(temperature)
+	p.sampling.temperatureSet = true
+}
+
+// SetMaxTokens sets the maximum tokens to generate (0 uses the model default)
+func (p *LangChainLLMProvider) SetMaxTokens(maxTokens int) {
+	p.samplingMu.Lock()
+	defer p.samplingMu.Unlock()
+	p.sampling.maxTokens = maxTokens
+}
+
+// SetTopP sets Top P sampling (0 uses the model default)
+func (p *LangChainLLMProvider) SetTopP(topP float32) {
+	p.samplingMu.Lock()
+	defer p.samplingMu.Unlock()
+	p.sampling.topP = float64(topP)
+}
+
+// SetFrequencyPenalty sets the frequency penalty (0 uses the model default)
+func (p *LangChainLLMProvider) SetFrequencyPenalty(penalty float32) {
+	p.samplingMu.Lock()
+	defer p.samplingMu.Unlock()
+	p.sampling.frequencyPenalty = float64(penalty)
+}
+
+// SetPresencePenalty sets the presence penalty (0 uses the model default)
+func (p *LangChainLLMProvider) SetPresencePenalty(penalty float32) {
+	p.samplingMu.Lock()
+	defer p.samplingMu.Unlock()
+	p.sampling.presencePenalty = float64(penalty)
+}
+
+// SetStopSequences sets the sequences that stop generation (empty sends none)
+func (p *LangChainLLMProvider) SetStopSequences(sequences []string) {
+	p.samplingMu.Lock()
+	defer p.samplingMu.Unlock()
+	p.sampling.stopSequences = append([]string(nil), sequences...)
+}
+
+// SetResponseFormat sets the output format requested from the model: types.ResponseFormatText (default)
+// or types.ResponseFormatJSONObject, which enables JSON mode on models that support it
+func (p *LangChainLLMProvider) SetResponseFormat(format string) {
+	p.samplingMu.Lock()
+	defer p.samplingMu.Unlock()
+	p.sampling.responseFormat = format
+}
+
+// callOptions returns the configured sampling options followed by extra
+func (p *LangChainLLMProvider) callOptions(extra ...llms.CallOption) []llms.CallOption {
+	p.samplingMu.RLock()
+	s := p.sampling
+	p.samplingMu.RUnlock()
+
+	options := make([]llms.CallOption, 0, 7+len(extra))
+	if s.temperatureSet {
+		options = append(options, llms.WithTemperature(s.temperature))
+	}
+	if s.maxTokens > 0 {
+		options = append(options, llms.WithMaxTokens(s.maxTokens))
 	}
+	if s.topP > 0 {
+		options = append(options, llms.WithTopP(s.topP))
+	}
+	if s.frequencyPenalty != 0 {
+		options = append(options, llms.WithFrequencyPenalty(s.frequencyPenalty))
+	}
+	if s.presencePenalty != 0 {
+		options = append(options, llms.WithPresencePenalty(s.presencePenalty))
+	}
+	if len(s.stopSequences) > 0 {
+		options = append(options, llms.WithStopWords(s.stopSequences))
+	}
+	if s.responseFormat == types.ResponseFormatJSONObject {
+		options = append(options, llms.WithJSONMode())
+	}
+	return append(options, extra...)
+}
 
-	errMsg := strings.ToLower(err.Error())
-	if !strings.Contains(errMsg, "429") && !strings.Contains(errMsg, "rate limit") && !strings.Contains(errMsg, "rate_limit") && !strings.Contains(errMsg, "too many requests") {
+// retryWait decides whether a failed call is retried and how long to wait first
+func (p *LangChainLLMProvider) retryWait(err error, retryCount int) (shouldRetry bool, waitTime time.Duration) {
+	policy := p.retryPolicy()
+	if retryCount >= policy.maxRetries {
+		return false, 0
+	}
+	retryable, status := policy.retryable(err)
+	if !retryable {
 		return false, 0
 	}
 
-	retryAfterRegex := regexp.MustCompile(`(?i)(?:retry|wait|after)[\s:]+(\d+)[\s]*?(milliseconds?|ms|seconds?|s|minutes?|m)`)
-	matches := retryAfterRegex.FindStringSubmatch(err.Error())
-	waitTime = p.retryDelay
-	if len(matches) >= 3 {
-		if parsedTime, parseErr := strconv.Atoi(matches[1]); parseErr == nil {
-			unit := strings.ToLower(strings.TrimSpace(matches[2]))
-			switch {
-			case strings.HasPrefix(unit, "milli") || unit == "ms":
-				waitTime = time.Duration(parsedTime) * time.Millisecond
-			case strings.HasPrefix(unit, "second") || unit == "s":
-				waitTime = time.Duration(parsedTime) * time.Second
-			case strings.HasPrefix(unit, "minute") || unit == "m":
-				waitTime = time.Duration(parsedTime) * time.Minute
-			default:
-				waitTime = time.Duration(parsedTime) * time.Millisecond
-			}
+	waitTime = policy.backoff(retryCount)
+	if status == 429 {
+		if d, ok := retryAfter(err); ok {
+			waitTime = d
 		}
 	}
 
-	p.logger.Info("Received 429 error, will retry after wait",
+	p.logger.Info("Provider call failed, will retry after wait",
+		slog.String("error", err.Error()),
+		slog.Int("status", status),
 		slog.Duration("wait_time", waitTime),
 		slog.Int("attempt", retryCount+1),
-		slog.Int("max_retries", maxRetries))
+		slog.Int("max_retries", policy.maxRetries))
 
 	return true, waitTime
 }
 
 // Chat basic chat functionality
 func (p *LangChainLLMProvider) Chat(messages []types.Message) (types.Message, error) {
+	if p.promptTools {
+		// Earlier tool calls and results are sent as text the backend accepts
+		messages = promptToolMessages(messages, nil)
+	}
+
 	// Convert message format
 	langChainMessages := p.convertToLangChainMessages(messages)
 
@@ -93,21 +248,21 @@ func (p *LangChainLLMProvider) Chat(messages []types.Message) (types.Message, er
 
 	for {
 		// Call LLM
-		response, err := p.model.GenerateContent(context.Background(), langChainMessages)
+		response, err := p.model.GenerateContent(context.Background(), langChainMessages, p.callOptions()...)
 		if err != nil {
-			// Handle 429 retry
-			if shouldRetry, waitTime := p.handle429Retry(err, retryCount, p.maxRetries); shouldRetry {
+			// Retry rate limits, transient server errors and timeouts
+			if shouldRetry, waitTime := p.retryWait(err, retryCount); shouldRetry {
 				retryCount++
 				time.Sleep(waitTime)
 				continue
 			}
 
-			// Not a 429 error or max retries exceeded
+			// Not retryable or max retries exceeded
 			return types.Message{}, err
 		}
 
 		if len(response.Choices) > 0 {
-			return p.convertMessageFromLangChain(response.Choices[0]), nil
+			return p.convertMessageFromLangChain(mergeChoices(response.Choices)), nil
 		}
 
 		return types.Message{}, errors.EC_LLM_NO_RESPONSE
@@ -116,6 +271,11 @@ func (p *LangChainLLMProvider) Chat(messages []types.Message) (types.Message, er
 
 // ChatStream streaming chat functionality
 func (p *LangChainLLMProvider) ChatStream(messages []types.Message) (<-chan types.StreamMessage, error) {
+	if p.promptTools {
+		// Earlier tool calls and results are sent as text the backend accepts
+		messages = promptToolMessages(messages, nil)
+	}
+
 	// Convert message format
 	langChainMessages := p.convertToLangChainMessages(messages)
 
@@ -130,32 +290,35 @@ func (p *LangChainLLMProvider) ChatStream(messages []types.Message) (<-chan type
 			if retryCount > 0 {
 				outputChan <- types.StreamMessage{
 					Type:    "retry",
-					Content: fmt.Sprintf("Retrying after 429 error (attempt %d/%d)", retryCount, p.maxRetries),
+					Content: fmt.Sprintf("Retrying after error (attempt %d/%d)", retryCount, p.retryPolicy().maxRetries),
 				}
 			}
 
 			// Streaming call
-			_, err := p.model.GenerateContent(context.Background(), langChainMessages, llms.WithStreamingFunc(func(ctx context.Context, chunk []byte) error {
+			// emitted records whether this attempt already sent output, which a retry would duplicate
+			emitted := false
+			response, err := p.model.GenerateContent(context.Background(), langChainMessages, p.callOptions(llms.WithStreamingFunc(func(ctx context.Context, chunk []byte) error {
+				emitted = true
 				outputChan <- types.StreamMessage{
 					Type:    "chunk",
 					Content: string(chunk),
 				}
 				return nil
-			}))
+			}))...)
 
 			if err != nil {
-				// Handle 429 retry
-				if shouldRetry, waitTime := p.handle429Retry(err, retryCount, p.maxRetries); shouldRetry {
+				// Retry rate limits, transient server errors and timeouts, unless the failure came mid-stream
+				if shouldRetry, waitTime := p.retryWait(err, retryCount); shouldRetry && !emitted {
 					outputChan <- types.StreamMessage{
 						Type:    "info",
-						Content: fmt.Sprintf("Received 429 error, waiting %v before retry...", waitTime),
+						Content: fmt.Sprintf("Received retryable error, waiting %v before retry...", waitTime),
 					}
 					retryCount++
 					time.Sleep(waitTime)
 					continue
 				}
 
-				// Not a 429 error or max retries exceeded
+				// Not retryable or max retries exceeded
 				outputChan <- types.StreamMessage{
 					Type:  "error",
 					Error: err.Error(),
@@ -164,7 +327,12 @@ func (p *LangChainLLMProvider) ChatStream(messages []types.Message) (<-chan type
 			}
 
 			// Successfully completed, send end signal
-			outputChan <- types.StreamMessage{Type: "end"}
+			end := types.StreamMessage{Type: "end"}
+			if response != nil && len(response.Choices) > 0 {
+				end.FinishReason = normalizeFinishReason(response.Choices[0].StopReason)
+				end.Usage = usageFromGenerationInfo(response.Choices[0].GenerationInfo)
+			}
+			outputChan <- end
 			break
 		}
 	}()
@@ -174,6 +342,10 @@ func (p *LangChainLLMProvider) ChatStream(messages []types.Message) (<-chan type
 
 // ChatWithTools chat with tools functionality
 func (p *LangChainLLMProvider) ChatWithTools(messages []types.Message, tools []types.Tool) (types.Message, error) {
+	if p.promptTools {
+		return p.chatWithPromptTools(messages, tools)
+	}
+
 	// Convert message format
 	langChainMessages := p.convertToLangChainMessages(messages)
 
@@ -184,22 +356,22 @@ func (p *LangChainLLMProvider) ChatWithTools(messages []types.Message, tools []t
 
 	for {
 		// Call LLM
-		response, err := p.model.GenerateContent(context.Background(), langChainMessages, llms.WithTools(langChainTools))
+		response, err := p.model.GenerateContent(context.Background(), langChainMessages, p.callOptions(llms.WithTools(langChainTools))...)
 		if err != nil {
-			// Handle 429 retry
-			if shouldRetry, waitTime := p.handle429Retry(err, retryCount, p.maxRetries); shouldRetry {
+			// Retry rate limits, transient server errors and timeouts
+			if shouldRetry, waitTime := p.retryWait(err, retryCount); shouldRetry {
 				retryCount++
 				time.Sleep(waitTime)
 				continue
 			}
 
-			// Not a 429 error or max retries exceeded
+			// Not retryable or max retries exceeded
 			return types.Message{}, err
 		}
 
 		// Convert response
 		if len(response.Choices) > 0 {
-			return p.convertMessageFromLangChain(response.Choices[0]), nil
+			return p.convertMessageFromLangChain(mergeChoices(response.Choices)), nil
 		}
 
 		return types.Message{}, errors.EC_LLM_NO_RESPONSE
@@ -208,6 +380,10 @@ func (p *LangChainLLMProvider) ChatWithTools(messages []types.Message, tools []t
 
 // ChatWithToolsStream streaming chat with tools functionality
 func (p *LangChainLLMProvider) ChatWithToolsStream(messages []types.Message, tools []types.Tool) (<-chan types.StreamMessage, error) {
+	if p.promptTools {
+		return p.chatWithPromptToolsStream(messages, tools)
+	}
+
 	// Convert message format
 	langChainMessages := p.convertToLangChainMessages(messages)
 
@@ -225,26 +401,35 @@ func (p *LangChainLLMProvider) ChatWithToolsStream(messages []types.Message, too
 			if retryCount > 0 {
 				outputChan <- types.StreamMessage{
 					Type:    "retry",
-					Content: fmt.Sprintf("Retrying after 429 error (attempt %d/%d)", retryCount, p.maxRetries),
+					Content: fmt.Sprintf("Retrying after error (attempt %d/%d)", retryCount, p.retryPolicy().maxRetries),
 				}
 			}
 
 			var fullResponse *llms.ContentResponse
 			var contentBuffer strings.Builder
 			contentBuffer.Grow(2048)
+			accumulator := newToolCallAccumulator()
+			// emitted records whether this attempt already streamed content or tool call deltas,
+			// which a retry would duplicate
+			emitted := false
 
 			// Streaming call
-			// Note: We collect all content chunks and filter tool calls from the full response
-			// This is more reliable than trying to detect tool calls in streaming chunks
-			response, err := p.model.GenerateContent(context.Background(), langChainMessages,
+			// Tool call deltas are accumulated separately so they are not sent as content,
+			// and used when the full response does not carry the tool calls
+			response, err := p.model.GenerateContent(context.Background(), langChainMessages, p.callOptions(
 				llms.WithTools(langChainTools),
 				llms.WithStreamingFunc(func(ctx context.Context, chunk []byte) error {
+					if accumulator.add(chunk) {
+						emitted = true
+						return nil
+					}
+
 					chunkStr := string(chunk)
 					contentBuffer.WriteString(chunkStr)
 
 					// Send content chunks immediately for better user experience
-					// Tool calls will be filtered from the full response later
 					if chunkStr != "" {
+						emitted = true
 						outputChan <- types.StreamMessage{
 							Type:    "chunk",
 							Content: chunkStr,
@@ -252,7 +437,7 @@ func (p *LangChainLLMProvider) ChatWithToolsStream(messages []types.Message, too
 					}
 
 					return nil
-				}))
+				}))...)
 
 			// Save the full response to extract tool calls
 			if err == nil {
@@ -260,11 +445,11 @@ func (p *LangChainLLMProvider) ChatWithToolsStream(messages []types.Message, too
 			}
 
 			if err != nil {
-				// Handle 429 retry
-				if shouldRetry, waitTime := p.handle429Retry(err, retryCount, p.maxRetries); shouldRetry {
+				// Retry rate limits, transient server errors and timeouts, unless the failure came mid-stream
+				if shouldRetry, waitTime := p.retryWait(err, retryCount); shouldRetry && !emitted {
 					outputChan <- types.StreamMessage{
 						Type:    "info",
-						Content: fmt.Sprintf("Received 429 error, waiting %v before retry...", waitTime),
+						Content: fmt.Sprintf("Received retryable error, waiting %v before retry...", waitTime),
 					}
 					retryCount++
 					contentBuffer.Reset()
@@ -272,7 +457,7 @@ func (p *LangChainLLMProvider) ChatWithToolsStream(messages []types.Message, too
 					continue
 				}
 
-				// Not a 429 error or max retries exceeded
+				// Not retryable or max retries exceeded
 				outputChan <- types.StreamMessage{
 					Type:  "error",
 					Error: err.Error(),
@@ -280,11 +465,13 @@ func (p *LangChainLLMProvider) ChatWithToolsStream(messages []types.Message, too
 				return
 			}
 
-			// Extract tool calls from full response if available
+			// Extract tool calls from full response if available,
+			// otherwise fall back to the calls reconstructed from streamed deltas
+			var toolCalls []types.ToolCall
 			if fullResponse != nil && len(fullResponse.Choices) > 0 {
-				choice := fullResponse.Choices[0]
+				choice := mergeChoices(fullResponse.Choices)
 				if len(choice.ToolCalls) > 0 {
-					toolCalls := make([]types.ToolCall, len(choice.ToolCalls))
+					toolCalls = make([]types.ToolCall, len(choice.ToolCalls))
 					for i, tc := range choice.ToolCalls {
 						var args map[string]interface{}
 						if tc.FunctionCall.Arguments != "" {
@@ -302,15 +489,25 @@ func (p *LangChainLLMProvider) ChatWithToolsStream(messages []types.Message, too
 							},
 						}
 					}
-					outputChan <- types.StreamMessage{
-						Type:      "tool_calls",
-						ToolCalls: toolCalls,
-					}
+				}
+			}
+			if len(toolCalls) == 0 {
+				toolCalls = accumulator.toolCalls()
+			}
+			if len(toolCalls) > 0 {
+				outputChan <- types.StreamMessage{
+					Type:      "tool_calls",
+					ToolCalls: toolCalls,
 				}
 			}
 
 			// Successfully completed, send end signal
-			outputChan <- types.StreamMessage{Type: "end"}
+			end := types.StreamMessage{Type: "end"}
+			if fullResponse != nil && len(fullResponse.Choices) > 0 {
+				end.FinishReason = normalizeFinishReason(fullResponse.Choices[0].StopReason)
+				end.Usage = usageFromGenerationInfo(fullResponse.Choices[0].GenerationInfo)
+			}
+			outputChan <- end
 			break
 		}
 	}()
@@ -323,6 +520,11 @@ func (p *LangChainLLMProvider) GetModelName() string {
 	return p.modelName
 }
 
+// SupportsStreaming reports whether the backend supports streaming
+func (p *LangChainLLMProvider) SupportsStreaming() bool {
+	return p.streaming
+}
+
 // GetModelMetadata gets the model metadata
 func (p *LangChainLLMProvider) GetModelMetadata() types.ModelMetadata {
 	return types.ModelMetadata{
@@ -361,8 +563,20 @@ func (p *LangChainLLMProvider) convertToLangChainMessages(messages []types.Messa
 			parts = make([]llms.ContentPart, 0, 1)
 		}
 
-		// If there are multimodal parts, use Parts, otherwise use traditional Content field
-		if len(msg.Parts) > 0 {
+		// Tool results must be a single ToolCallResponse part
+		if msg.Role == "tool" && msg.ToolCallID != "" {
+			// Ensure Content is never null - use empty string if not provided
+			content := msg.Content
+			if content == "" {
+				content = "{}"
+			}
+			parts = append(parts, llms.ToolCallResponse{
+				ToolCallID: msg.ToolCallID,
+				Name:       msg.Name,
+				Content:    content,
+			})
+		} else if len(msg.Parts) > 0 {
+			// If there are multimodal parts, use Parts, otherwise use traditional Content field
 			for _, part := range msg.Parts {
 				switch p := part.(type) {
 				case types.TextPart:
@@ -380,30 +594,31 @@ func (p *LangChainLLMProvider) convertToLangChainMessages(messages []types.Messa
 		} else if msg.Content != "" {
 			// Backward compatibility: use traditional Content field
 			parts = append(parts, llms.TextPart(msg.Content))
-		} else if msg.Content == "" && len(msg.ToolCalls) > 0 {
-			// For assistant messages with tool calls but no content, use empty string
-			parts = append(parts, llms.TextPart(""))
+		}
+
+		// Assistant tool calls are only sent when answered by following tool messages,
+		// providers reject tool calls without matching responses
+		if msg.Role == "assistant" && len(msg.ToolCalls) > 0 {
+			answered := answeredToolCallIDs(messages[i+1:])
+			toolCallParts := make([]llms.ContentPart, 0, len(msg.ToolCalls))
+			for _, tc := range msg.ToolCalls {
+				if !answered[tc.ID] {
+					continue
+				}
+				toolCallParts = append(toolCallParts, p.convertToLangChainToolCall(tc))
+			}
+			if len(toolCallParts) > 0 {
+				if len(parts) == 0 && p.toolCallContentMode == ToolCallContentEmptyText {
+					parts = append(parts, llms.TextPart(""))
+				}
+				parts = append(parts, toolCallParts...)
+			}
 		}
 
 		// Ensure content is never null - provide empty string if no content exists
 		// This is required by some APIs that expect content to be a string, not null
 		if len(parts) == 0 {
-			// For tool messages, use ToolCallResponse if ToolCallID is present
-			if msg.Role == "tool" && msg.ToolCallID != "" {
-				// Ensure Content is never null - use empty string if not provided
-				content := msg.Content
-				if content == "" {
-					content = "{}"
-				}
-				parts = append(parts, llms.ToolCallResponse{
-					ToolCallID: msg.ToolCallID,
-					Name:       msg.Name,
-					Content:    content,
-				})
-			} else {
-				// For other messages, use empty text part
-				parts = append(parts, llms.TextPart(""))
-			}
+			parts = append(parts, llms.TextPart(""))
 		}
 
 		langChainMessages[i] = llms.MessageContent{
@@ -414,16 +629,62 @@ func (p *LangChainLLMProvider) convertToLangChainMessages(messages []types.Messa
 	return langChainMessages
 }
 
+// convertToLangChainToolCall converts a tool call requested by the assistant
+func (p *LangChainLLMProvider) convertToLangChainToolCall(tc types.ToolCall) llms.ToolCall {
+	arguments := "{}"
+	if len(tc.Function.Arguments) > 0 {
+		if data, err := json.Marshal(tc.Function.Arguments); err == nil {
+			arguments = string(data)
+		} else {
+			p.logger.LogError("convertToLangChainToolCall", err, slog.String("tool", tc.Function.Name))
+		}
+	}
+	toolType := tc.Type
+	if toolType == "" {
+		toolType = "function"
+	}
+	return llms.ToolCall{
+		ID:   tc.ID,
+		Type: toolType,
+		FunctionCall: &llms.FunctionCall{
+			Name:      tc.Function.Name,
+			Arguments: arguments,
+		},
+	}
+}
+
+// answeredToolCallIDs collects the tool call IDs answered by the tool messages at the start of messages
+func answeredToolCallIDs(messages []types.Message) map[string]bool {
+	answered := make(map[string]bool)
+	for _, msg := range messages {
+		if msg.Role != "tool" {
+			break
+		}
+		if msg.ToolCallID != "" {
+			answered[msg.ToolCallID] = true
+		}
+	}
+	return answered
+}
+
 // convertToLangChainTools converts tool format
 func (p *LangChainLLMProvider) convertToLangChainTools(tools []types.Tool) []llms.Tool {
 	langChainTools := make([]llms.Tool, len(tools))
 	for i, tool := range tools {
+		parameters := tool.Schema()
+		if len(parameters) == 0 {
+			// Tools without parameters, including compact definitions, still need an object schema for most APIs
+			parameters = map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{},
+			}
+		}
 		langChainTools[i] = llms.Tool{
 			Type: "function",
 			Function: &llms.FunctionDefinition{
 				Name:        tool.Name(),
 				Description: tool.Description(),
-				Parameters:  tool.Schema(),
+				Parameters:  parameters,
 			},
 		}
 	}
@@ -434,7 +695,9 @@ func (p *LangChainLLMProvider) convertToLangChainTools(tools []types.Tool) []llm
 func (p *LangChainLLMProvider) convertMessageFromLangChain(choice *llms.ContentChoice) types.Message {
 	// Content will be empty string if not provided (Go zero value), which is acceptable
 	msg := types.Message{
-		Content: choice.Content,
+		Content:      choice.Content,
+		FinishReason: normalizeFinishReason(choice.StopReason),
+		Usage:        usageFromGenerationInfo(choice.GenerationInfo),
 	}
 
 	// Set role if available
@@ -468,3 +731,83 @@ func (p *LangChainLLMProvider) convertMessageFromLangChain(choice *llms.ContentC
 
 	return msg
 }
+
+// mergeChoices combines the choices of a response into one
+// Anthropic returns one choice per content block, e.g. text followed by tool_use blocks,
+// while OpenAI-compatible backends return a single choice that is used as-is
+func mergeChoices(choices []*llms.ContentChoice) *llms.ContentChoice {
+	if len(choices) == 1 {
+		return choices[0]
+	}
+	merged := &llms.ContentChoice{}
+	for _, choice := range choices {
+		if choice == nil {
+			continue
+		}
+		merged.Content += choice.Content
+		merged.ToolCalls = append(merged.ToolCalls, choice.ToolCalls...)
+		if merged.FuncCall == nil {
+			merged.FuncCall = choice.FuncCall
+		}
+		if choice.StopReason != "" {
+			merged.StopReason = choice.StopReason
+		}
+		if merged.GenerationInfo == nil {
+			merged.GenerationInfo = choice.GenerationInfo
+		}
+	}
+	return merged
+}
+
+// usageFromGenerationInfo reads token counts from a choice's generation info
+// OpenAI-compatible backends report PromptTokens/CompletionTokens/TotalTokens, Anthropic InputTokens/OutputTokens;
+// returns nil when neither is present
+func usageFromGenerationInfo(info map[string]any) *types.Usage {
+	prompt, hasPrompt := generationInfoInt(info, "PromptTokens", "InputTokens")
+	completion, hasCompletion := generationInfoInt(info, "CompletionTokens", "OutputTokens")
+	if !hasPrompt && !hasCompletion {
+		return nil
+	}
+	total, ok := generationInfoInt(info, "TotalTokens")
+	if !ok || total == 0 {
+		total = prompt + completion
+	}
+	return &types.Usage{
+		PromptTokens:     prompt,
+		CompletionTokens: completion,
+		TotalTokens:      total,
+	}
+}
+
+// generationInfoInt returns the first of keys holding an integer count
+func generationInfoInt(info map[string]any, keys ...string) (int, bool) {
+	for _, key := range keys {
+		switch v := info[key].(type) {
+		case int:
+			return v, true
+		case int64:
+			return int(v), true
+		case float64:
+			return int(v), true
+		}
+	}
+	return 0, false
+}
+
+// normalizeFinishReason maps provider-specific stop reasons to the OpenAI finish reason names
+// Unknown reasons are passed through lowercased
+func normalizeFinishReason(reason string) string {
+	reason = strings.ToLower(strings.TrimSpace(reason))
+	switch reason {
+	case "end_turn", "stop_sequence", "eos":
+		return types.FinishReasonStop
+	case "max_tokens", "model_length":
+		return types.FinishReasonLength
+	case "tool_use", "function_call":
+		return types.FinishReasonToolCalls
+	case "safety", "recitation", "blocklist", "prohibited_content", "refusal":
+		return types.FinishReasonContentFilter
+	default:
+		return reason
+	}
+}
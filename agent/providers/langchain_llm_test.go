@@ -0,0 +1,205 @@
+package providers
+
+import (
+	"context"
+	stderrors "errors"
+	"reflect"
+	"testing"
+
+	"github.com/tmc/langchaingo/llms"
+	"github.com/xichan96/cortex/agent/types"
+)
+
+// optionsRecordingModel records the call options of the last generate call
+type optionsRecordingModel struct {
+	opts llms.CallOptions
+}
+
+func (m *optionsRecordingModel) GenerateContent(ctx context.Context, _ []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	m.opts = llms.CallOptions{}
+	for _, opt := range options {
+		opt(&m.opts)
+	}
+	return &llms.ContentResponse{
+		Choices: []*llms.ContentChoice{{Content: "ok"}},
+	}, nil
+}
+
+func (m *optionsRecordingModel) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return "", nil
+}
+
+func TestSamplingOptionsForwarded(t *testing.T) {
+	model := &optionsRecordingModel{}
+	p := NewLangChainLLMProvider(model, "test")
+	messages := []types.Message{{Role: "user", Content: "hi"}}
+
+	if _, err := p.Chat(messages); err != nil {
+		t.Fatal(err)
+	}
+	if model.opts.Temperature != 0 || model.opts.MaxTokens != 0 || model.opts.StopWords != nil {
+		t.Errorf("unset sampling options were sent: %+v", model.opts)
+	}
+
+	p.SetTemperature(0.2)
+	p.SetMaxTokens(256)
+	p.SetTopP(0.9)
+	p.SetFrequencyPenalty(0.5)
+	p.SetPresencePenalty(0.25)
+	p.SetStopSequences([]string{"END"})
+
+	if _, err := p.ChatWithTools(messages, nil); err != nil {
+		t.Fatal(err)
+	}
+	got := model.opts
+	if float32(got.Temperature) != 0.2 || got.MaxTokens != 256 || float32(got.TopP) != 0.9 ||
+		got.FrequencyPenalty != 0.5 || got.PresencePenalty != 0.25 || !reflect.DeepEqual(got.StopWords, []string{"END"}) {
+		t.Errorf("sampling options not forwarded: %+v", got)
+	}
+}
+
+func TestResponseFormatForwarded(t *testing.T) {
+	model := &optionsRecordingModel{}
+	p := NewLangChainLLMProvider(model, "test")
+	messages := []types.Message{{Role: "user", Content: "hi"}}
+
+	p.SetResponseFormat(types.ResponseFormatText)
+	if _, err := p.Chat(messages); err != nil {
+		t.Fatal(err)
+	}
+	if model.opts.JSONMode {
+		t.Error("JSON mode enabled for text response format")
+	}
+
+	p.SetResponseFormat(types.ResponseFormatJSONObject)
+	if _, err := p.ChatWithTools(messages, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !model.opts.JSONMode {
+		t.Error("JSON mode not enabled for json_object response format")
+	}
+}
+
+// blockChoicesModel answers with one choice per content block, like the Anthropic backend
+type blockChoicesModel struct{}
+
+func (m *blockChoicesModel) GenerateContent(ctx context.Context, _ []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	return &llms.ContentResponse{
+		Choices: []*llms.ContentChoice{
+			{Content: "Let me check.", StopReason: "tool_use"},
+			{ToolCalls: []llms.ToolCall{{ID: "toolu_1", FunctionCall: &llms.FunctionCall{Name: "weather", Arguments: `{"city":"Paris"}`}}}, StopReason: "tool_use"},
+		},
+	}, nil
+}
+
+func (m *blockChoicesModel) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return "", nil
+}
+
+func TestChatWithToolsMergesContentBlocks(t *testing.T) {
+	p := NewLangChainLLMProvider(&blockChoicesModel{}, "test")
+	msg, err := p.ChatWithTools([]types.Message{{Role: "user", Content: "weather?"}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.Content != "Let me check." || msg.FinishReason != types.FinishReasonToolCalls {
+		t.Errorf("message = %+v, want text block and tool_calls finish reason", msg)
+	}
+	if len(msg.ToolCalls) != 1 || msg.ToolCalls[0].Function.Name != "weather" || msg.ToolCalls[0].Function.Arguments["city"] != "Paris" {
+		t.Errorf("tool calls = %+v, want the tool_use block", msg.ToolCalls)
+	}
+}
+
+// failingStreamModel streams its chunks, then fails with a retryable error on the first failAttempts calls
+type failingStreamModel struct {
+	chunks       []string
+	failAttempts int
+	calls        int
+}
+
+func (m *failingStreamModel) GenerateContent(ctx context.Context, _ []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	m.calls++
+	var opts llms.CallOptions
+	for _, opt := range options {
+		opt(&opts)
+	}
+	for _, chunk := range m.chunks {
+		if err := opts.StreamingFunc(ctx, []byte(chunk)); err != nil {
+			return nil, err
+		}
+	}
+	if m.calls <= m.failAttempts {
+		return nil, stderrors.New("API returned unexpected status code: 503")
+	}
+	return &llms.ContentResponse{Choices: []*llms.ContentChoice{{Content: "done"}}}, nil
+}
+
+func (m *failingStreamModel) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return "", nil
+}
+
+func TestStreamRetriesOnlyBeforeOutput(t *testing.T) {
+	messages := []types.Message{{Role: "user", Content: "hi"}}
+	streams := map[string]func(p *LangChainLLMProvider) (<-chan types.StreamMessage, error){
+		"ChatStream": func(p *LangChainLLMProvider) (<-chan types.StreamMessage, error) {
+			return p.ChatStream(messages)
+		},
+		"ChatWithToolsStream": func(p *LangChainLLMProvider) (<-chan types.StreamMessage, error) {
+			return p.ChatWithToolsStream(messages, nil)
+		},
+	}
+	tests := []struct {
+		name      string
+		chunks    []string
+		calls     int
+		lastType  string
+		chunkSent int
+	}{
+		{"failure before output is retried", nil, 2, "end", 0},
+		{"failure mid-stream is not retried", []string{"partial"}, 1, "error", 1},
+	}
+	for streamName, stream := range streams {
+		for _, tt := range tests {
+			t.Run(streamName+"/"+tt.name, func(t *testing.T) {
+				model := &failingStreamModel{chunks: tt.chunks, failAttempts: 1}
+				p := NewLangChainLLMProvider(model, "test")
+				p.SetRetryPolicy(3, 0, 0, nil)
+				ch, err := stream(p)
+				if err != nil {
+					t.Fatal(err)
+				}
+				var last types.StreamMessage
+				chunks := 0
+				for msg := range ch {
+					if msg.Type == "chunk" {
+						chunks++
+					}
+					last = msg
+				}
+				if model.calls != tt.calls || last.Type != tt.lastType || chunks != tt.chunkSent {
+					t.Errorf("calls %d, last %q, chunks %d; want %d, %q, %d", model.calls, last.Type, chunks, tt.calls, tt.lastType, tt.chunkSent)
+				}
+			})
+		}
+	}
+}
+
+func TestUsageFromGenerationInfo(t *testing.T) {
+	tests := []struct {
+		name string
+		info map[string]any
+		want *types.Usage
+	}{
+		{"openai", map[string]any{"PromptTokens": 12, "CompletionTokens": 5, "TotalTokens": 17}, &types.Usage{PromptTokens: 12, CompletionTokens: 5, TotalTokens: 17}},
+		{"anthropic", map[string]any{"InputTokens": 30, "OutputTokens": 8}, &types.Usage{PromptTokens: 30, CompletionTokens: 8, TotalTokens: 38}},
+		{"none", map[string]any{"ThinkingContent": ""}, nil},
+		{"nil", nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := usageFromGenerationInfo(tt.info); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("usageFromGenerationInfo() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -13,6 +13,13 @@ type SimpleMemoryProvider struct {
 	mu                 sync.RWMutex
 	messages           []types.Message
 	maxHistoryMessages int
+	maxBytes           int // content byte cap (0 means unlimited)
+	metadata           map[string]string
+	historyTokenBudget
+
+	// Set when the provider belongs to a SimpleMemoryStore
+	store     *SimpleMemoryStore
+	sessionID string
 }
 
 // NewSimpleMemoryProvider creates a new simple memory provider
@@ -31,24 +38,86 @@ func NewSimpleMemoryProviderWithLimit(maxHistoryMessages int) *SimpleMemoryProvi
 	}
 }
 
+// NewSimpleMemoryProviderWithOptions creates a new simple memory provider with message and byte limits
+func NewSimpleMemoryProviderWithOptions(opts SimpleMemoryOptions) *SimpleMemoryProvider {
+	p := &SimpleMemoryProvider{
+		messages:           make([]types.Message, 0),
+		maxHistoryMessages: opts.MaxHistoryMessages,
+		maxBytes:           opts.MaxBytes,
+	}
+	p.maxHistoryTokens = opts.MaxHistoryTokens
+	return p
+}
+
 // SetMaxHistoryMessages sets the maximum history messages limit
 func (p *SimpleMemoryProvider) SetMaxHistoryMessages(limit int) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 	p.maxHistoryMessages = limit
-	if limit > 0 && len(p.messages) > limit {
-		p.messages = p.messages[len(p.messages)-limit:]
-	}
+	p.enforceLimitsLocked()
 }
 
-// AddMessage adds a message
-func (p *SimpleMemoryProvider) AddMessage(ctx context.Context, message types.Message) error {
+// SetMaxBytes sets the content byte limit (0 means unlimited)
+func (p *SimpleMemoryProvider) SetMaxBytes(limit int) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
-	p.messages = append(p.messages, message)
+	p.maxBytes = limit
+	p.enforceLimitsLocked()
+}
+
+// enforceLimitsLocked drops the oldest messages beyond the message and byte caps
+// A single message larger than the byte cap is truncated. Caller must hold p.mu
+func (p *SimpleMemoryProvider) enforceLimitsLocked() {
 	if p.maxHistoryMessages > 0 && len(p.messages) > p.maxHistoryMessages {
 		p.messages = p.messages[len(p.messages)-p.maxHistoryMessages:]
 	}
+	if p.maxBytes <= 0 {
+		return
+	}
+
+	total := 0
+	for _, msg := range p.messages {
+		total += len(msg.Content)
+	}
+	start := 0
+	for total > p.maxBytes && start < len(p.messages)-1 {
+		total -= len(p.messages[start].Content)
+		start++
+	}
+	p.messages = p.messages[start:]
+	if total > p.maxBytes && len(p.messages) == 1 {
+		p.messages[0].Content = truncateUTF8(p.messages[0].Content, p.maxBytes)
+	}
+}
+
+// Stats returns the current message count and content size
+func (p *SimpleMemoryProvider) Stats() (messages int, bytes int) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	for _, msg := range p.messages {
+		bytes += len(msg.Content)
+	}
+	return len(p.messages), bytes
+}
+
+// notifyStore reports a write to the owning store so it can track recency and global caps
+// Must be called without holding p.mu
+func (p *SimpleMemoryProvider) notifyStore() {
+	p.mu.RLock()
+	store, sessionID := p.store, p.sessionID
+	p.mu.RUnlock()
+	if store != nil {
+		store.touch(sessionID)
+	}
+}
+
+// AddMessage adds a message
+func (p *SimpleMemoryProvider) AddMessage(ctx context.Context, message types.Message) error {
+	p.mu.Lock()
+	p.messages = append(p.messages, message)
+	p.enforceLimitsLocked()
+	p.mu.Unlock()
+	p.notifyStore()
 	return nil
 }
 
@@ -77,6 +146,7 @@ func (p *SimpleMemoryProvider) LoadMemoryVariables() (map[string]interface{}, er
 	if maxHistoryMessages > 0 && len(messages) > maxHistoryMessages {
 		messages = messages[len(messages)-maxHistoryMessages:]
 	}
+	messages = p.fitHistory(messages)
 	return map[string]interface{}{
 		"history": messages,
 	}, nil
@@ -85,25 +155,21 @@ func (p *SimpleMemoryProvider) LoadMemoryVariables() (map[string]interface{}, er
 // SaveContext saves context (implements MemoryProvider interface)
 func (p *SimpleMemoryProvider) SaveContext(input, output map[string]interface{}) error {
 	p.mu.Lock()
-	defer p.mu.Unlock()
 	if inputMsg, ok := input["input"].(string); ok {
 		p.messages = append(p.messages, types.Message{
 			Role:    "user",
 			Content: inputMsg,
 		})
-		if p.maxHistoryMessages > 0 && len(p.messages) > p.maxHistoryMessages {
-			p.messages = p.messages[len(p.messages)-p.maxHistoryMessages:]
-		}
 	}
 	if outputMsg, ok := output["output"].(string); ok {
 		p.messages = append(p.messages, types.Message{
 			Role:    "assistant",
 			Content: outputMsg,
 		})
-		if p.maxHistoryMessages > 0 && len(p.messages) > p.maxHistoryMessages {
-			p.messages = p.messages[len(p.messages)-p.maxHistoryMessages:]
-		}
 	}
+	p.enforceLimitsLocked()
+	p.mu.Unlock()
+	p.notifyStore()
 	return nil
 }
 
@@ -112,6 +178,26 @@ func (p *SimpleMemoryProvider) Clear() error {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 	p.messages = make([]types.Message, 0)
+	p.metadata = nil
+	return nil
+}
+
+// GetMetadata gets a session metadata value (implements MemoryMetadataStore interface)
+func (p *SimpleMemoryProvider) GetMetadata(key string) (string, bool, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	value, ok := p.metadata[key]
+	return value, ok, nil
+}
+
+// SetMetadata sets a session metadata value (implements MemoryMetadataStore interface)
+func (p *SimpleMemoryProvider) SetMetadata(key, value string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.metadata == nil {
+		p.metadata = make(map[string]string)
+	}
+	p.metadata[key] = value
 	return nil
 }
 
@@ -130,9 +216,35 @@ func (p *SimpleMemoryProvider) GetChatHistory() ([]types.Message, error) {
 	if maxHistoryMessages > 0 && len(messages) > maxHistoryMessages {
 		messages = messages[len(messages)-maxHistoryMessages:]
 	}
+	messages = p.fitHistory(messages)
 	return messages, nil
 }
 
+// GetMessagesPaged gets a page of the stored messages (implements MemoryPager interface)
+func (p *SimpleMemoryProvider) GetMessagesPaged(offset, limit int) ([]types.Message, int, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	start, end := pageBounds(len(p.messages), offset, limit)
+	messages := make([]types.Message, end-start)
+	copy(messages, p.messages[start:end])
+	return messages, len(p.messages), nil
+}
+
+// pageBounds clamps a page of a list with total items to slice bounds
+func pageBounds(total, offset, limit int) (int, int) {
+	if offset < 0 {
+		offset = 0
+	}
+	if offset > total {
+		offset = total
+	}
+	end := total
+	if limit > 0 && offset+limit < total {
+		end = offset + limit
+	}
+	return offset, end
+}
+
 // CompressMemory compresses old messages into a summary (implements MemoryProvider interface)
 // Optimized to avoid holding lock during LLM call to prevent blocking other operations
 func (p *SimpleMemoryProvider) CompressMemory(llm types.LLMProvider, maxMessages int) error {
@@ -0,0 +1,94 @@
+package providers
+
+import (
+	"testing"
+
+	"github.com/tmc/langchaingo/llms"
+	"github.com/xichan96/cortex/agent/types"
+)
+
+func toolCallConversation() []types.Message {
+	return []types.Message{
+		{Role: "user", Content: "What is 2+3?"},
+		{Role: "assistant", ToolCalls: []types.ToolCall{{
+			ID:       "call_1",
+			Type:     "function",
+			Function: types.ToolFunction{Name: "math", Arguments: map[string]interface{}{"expression": "2+3"}},
+		}}},
+		{Role: "tool", ToolCallID: "call_1", Name: "math", Content: "5"},
+	}
+}
+
+func TestConvertToLangChainMessages_ToolCallsOmitContent(t *testing.T) {
+	p := NewLangChainLLMProvider(nil, "test")
+
+	messages := p.convertToLangChainMessages(toolCallConversation())
+	parts := messages[1].Parts
+	if len(parts) != 1 {
+		t.Fatalf("Expected only the tool call part, got %d parts: %#v", len(parts), parts)
+	}
+	toolCall, ok := parts[0].(llms.ToolCall)
+	if !ok {
+		t.Fatalf("Expected llms.ToolCall, got %T", parts[0])
+	}
+	if toolCall.ID != "call_1" || toolCall.FunctionCall.Name != "math" || toolCall.FunctionCall.Arguments != `{"expression":"2+3"}` {
+		t.Errorf("Unexpected tool call: %+v %+v", toolCall, toolCall.FunctionCall)
+	}
+
+	response, ok := messages[2].Parts[0].(llms.ToolCallResponse)
+	if !ok || response.ToolCallID != "call_1" || response.Content != "5" {
+		t.Errorf("Unexpected tool response: %#v", messages[2].Parts)
+	}
+}
+
+func TestConvertToLangChainMessages_ToolCallsEmptyText(t *testing.T) {
+	p := NewLangChainLLMProvider(nil, "test")
+	p.SetToolCallContentMode(ToolCallContentEmptyText)
+
+	parts := p.convertToLangChainMessages(toolCallConversation())[1].Parts
+	if len(parts) != 2 {
+		t.Fatalf("Expected empty text and tool call parts, got %d parts: %#v", len(parts), parts)
+	}
+	if text, ok := parts[0].(llms.TextContent); !ok || text.Text != "" {
+		t.Errorf("Expected empty text part first, got %#v", parts[0])
+	}
+	if _, ok := parts[1].(llms.ToolCall); !ok {
+		t.Errorf("Expected tool call part second, got %T", parts[1])
+	}
+}
+
+func TestConvertToLangChainMessages_UnansweredToolCalls(t *testing.T) {
+	p := NewLangChainLLMProvider(nil, "test")
+
+	// Tool results summarized as a user message do not answer the tool calls
+	conversation := toolCallConversation()
+	conversation[2] = types.Message{Role: "user", Content: "Tool math returned: 5"}
+
+	parts := p.convertToLangChainMessages(conversation)[1].Parts
+	if len(parts) != 1 {
+		t.Fatalf("Expected a single placeholder part, got %#v", parts)
+	}
+	if text, ok := parts[0].(llms.TextContent); !ok || text.Text != "" {
+		t.Errorf("Expected unanswered tool calls to be dropped, got %#v", parts[0])
+	}
+}
+
+func TestConvertMessageFromLangChain_FinishReason(t *testing.T) {
+	p := NewLangChainLLMProvider(nil, "test")
+
+	tests := map[string]string{
+		"stop":           types.FinishReasonStop,
+		"length":         types.FinishReasonLength,
+		"max_tokens":     types.FinishReasonLength,
+		"end_turn":       types.FinishReasonStop,
+		"content_filter": types.FinishReasonContentFilter,
+		"SAFETY":         types.FinishReasonContentFilter,
+		"":               "",
+	}
+	for stopReason, expected := range tests {
+		msg := p.convertMessageFromLangChain(&llms.ContentChoice{Content: "partial", StopReason: stopReason})
+		if msg.FinishReason != expected {
+			t.Errorf("StopReason %q: expected finish reason %q, got %q", stopReason, expected, msg.FinishReason)
+		}
+	}
+}
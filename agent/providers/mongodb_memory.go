@@ -3,15 +3,50 @@ package providers
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"sync"
 	"time"
 
+	"github.com/qiniu/qmgo/options"
 	"github.com/xichan96/cortex/agent/types"
+	"github.com/xichan96/cortex/pkg/logger"
 	"github.com/xichan96/cortex/pkg/mongodb"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// mongoMemoryIndexKey compound index serving the per-session queries sorted by creation time
+var mongoMemoryIndexKey = []string{"session_id", "created_at"}
+
+// mongoIndexTimeout bounds the index creation done on first use of a collection
+const mongoIndexTimeout = 30 * time.Second
+
+// mongoIndexRetryInterval minimum time between index creation attempts after a failure
+// Persistent failures, e.g. an account without the createIndex privilege, are not retried on every memory call
+var mongoIndexRetryInterval = 5 * time.Minute
+
+// mongoIndexed index state of the collections, *mongoIndexState by mongoIndexTarget
+// Providers are created per session, so the state is shared by all providers of a client
+var mongoIndexed sync.Map
+
+// mongoIndexState index creation state of one collection
+type mongoIndexState struct {
+	mu         sync.Mutex
+	done       bool
+	lastFailed time.Time // zero until an attempt fails
+}
+
+// mongoIndexTarget identifies a collection of a client
+type mongoIndexTarget struct {
+	client     *mongodb.Client
+	collection string
+}
+
+// createMongoMemoryIndexes creates the indexes of a collection, replaced in tests
+var createMongoMemoryIndexes = func(ctx context.Context, client *mongodb.Client, collectionName string) error {
+	return ensureMongoMemoryIndexes(ctx, client.Collection(collectionName))
+}
+
 type MessageDocument struct {
 	ID        primitive.ObjectID `bson:"_id,omitempty"`
 	SessionID string             `bson:"session_id"`
@@ -27,6 +62,7 @@ type MongoDBMemoryProvider struct {
 	sessionID          string
 	maxHistoryMessages int
 	collectionName     string
+	historyTokenBudget
 }
 
 func NewMongoDBMemoryProvider(client *mongodb.Client, sessionID string) *MongoDBMemoryProvider {
@@ -66,6 +102,63 @@ func (p *MongoDBMemoryProvider) getCollection() *mongodb.Client {
 	return p.client.Collection(collectionName)
 }
 
+// EnsureIndexes creates the (session_id, created_at) index on the collection and returns the error, if any
+// Creating an index that already exists is a no-op, so it is safe to call for every provider.
+// A success also stops the index creation on first use for every provider of the client and collection
+func (p *MongoDBMemoryProvider) EnsureIndexes(ctx context.Context) error {
+	target := p.indexTarget()
+	if err := createMongoMemoryIndexes(ctx, target.client, target.collection); err != nil {
+		return err
+	}
+	state := target.state()
+	state.mu.Lock()
+	state.done = true
+	state.mu.Unlock()
+	return nil
+}
+
+func (p *MongoDBMemoryProvider) indexTarget() mongoIndexTarget {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return mongoIndexTarget{client: p.client, collection: p.collectionName}
+}
+
+// state returns the shared index state of the collection
+func (t mongoIndexTarget) state() *mongoIndexState {
+	value, _ := mongoIndexed.LoadOrStore(t, &mongoIndexState{})
+	return value.(*mongoIndexState)
+}
+
+func ensureMongoMemoryIndexes(ctx context.Context, collection *mongodb.Client) error {
+	err := collection.Coll.CreateOneIndex(ctx, options.IndexModel{Key: mongoMemoryIndexKey})
+	return mongodb.WrapErr(err)
+}
+
+// ensureIndexesOnce creates the indexes on first use of the collection by any provider of the client
+// It runs detached from the caller's context, so a cancelled request does not leave the collection unindexed.
+// A failure is logged rather than returned, queries still work without the index, only slower;
+// it is retried on a later use once mongoIndexRetryInterval has passed
+func (p *MongoDBMemoryProvider) ensureIndexesOnce() {
+	target := p.indexTarget()
+	state := target.state()
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	if state.done || (!state.lastFailed.IsZero() && time.Since(state.lastFailed) < mongoIndexRetryInterval) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), mongoIndexTimeout)
+	defer cancel()
+	if err := createMongoMemoryIndexes(ctx, target.client, target.collection); err != nil {
+		state.lastFailed = time.Now()
+		logger.NewLogger().LogError("MongoDBMemoryProvider.EnsureIndexes", err,
+			slog.String("collection", target.collection),
+			slog.Duration("retry_after", mongoIndexRetryInterval))
+		return
+	}
+	state.done = true
+}
+
 func (p *MongoDBMemoryProvider) AddMessage(ctx context.Context, message types.Message) error {
 	p.mu.RLock()
 	sessionID := p.sessionID
@@ -79,6 +172,7 @@ func (p *MongoDBMemoryProvider) AddMessage(ctx context.Context, message types.Me
 		Name:      message.Name,
 		CreatedAt: time.Now(),
 	}
+	p.ensureIndexesOnce()
 	_, err := p.getCollection().InsertOne(ctx, doc)
 	if err != nil {
 		return err
@@ -107,6 +201,7 @@ func (p *MongoDBMemoryProvider) GetMessages(ctx context.Context, limit int) ([]t
 		}
 	}
 
+	p.ensureIndexesOnce()
 	sort := []string{"created_at"}
 	_, err := p.getCollection().QueryByPaging(ctx, filter, sort, 1, int64(queryLimit), &docs)
 	if err != nil {
@@ -135,7 +230,7 @@ func (p *MongoDBMemoryProvider) LoadMemoryVariables() (map[string]interface{}, e
 		return nil, err
 	}
 	return map[string]interface{}{
-		"history": messages,
+		"history": p.fitHistory(messages),
 	}, nil
 }
 
@@ -171,7 +266,48 @@ func (p *MongoDBMemoryProvider) GetChatHistory() ([]types.Message, error) {
 	p.mu.RLock()
 	maxHistoryMessages := p.maxHistoryMessages
 	p.mu.RUnlock()
-	return p.GetMessages(ctx, maxHistoryMessages)
+	messages, err := p.GetMessages(ctx, maxHistoryMessages)
+	if err != nil {
+		return nil, err
+	}
+	return p.fitHistory(messages), nil
+}
+
+// GetMessagesPaged gets a page of the stored messages (implements MemoryPager interface)
+func (p *MongoDBMemoryProvider) GetMessagesPaged(offset, limit int) ([]types.Message, int, error) {
+	ctx := context.Background()
+	p.mu.RLock()
+	sessionID := p.sessionID
+	p.mu.RUnlock()
+
+	if offset < 0 {
+		offset = 0
+	}
+	p.ensureIndexesOnce()
+	query := p.getCollection().Coll.Find(ctx, bson.M{"session_id": sessionID}).Sort("created_at")
+	total, err := query.Count()
+	if err != nil {
+		return nil, 0, mongodb.WrapErr(err)
+	}
+
+	query = query.Skip(int64(offset))
+	if limit > 0 {
+		query = query.Limit(int64(limit))
+	}
+	var docs []MessageDocument
+	if err := query.All(&docs); err != nil {
+		return nil, 0, mongodb.WrapErr(err)
+	}
+
+	messages := make([]types.Message, 0, len(docs))
+	for _, doc := range docs {
+		messages = append(messages, types.Message{
+			Role:    doc.Role,
+			Content: doc.Content,
+			Name:    doc.Name,
+		})
+	}
+	return messages, int(total), nil
 }
 
 func (p *MongoDBMemoryProvider) trimHistory(ctx context.Context) error {
@@ -214,10 +350,12 @@ func (p *MongoDBMemoryProvider) CompressMemory(llm types.LLMProvider, maxMessage
 		return fmt.Errorf("LLM provider is required for memory compression")
 	}
 
+	ctx := context.Background()
+	p.ensureIndexesOnce()
+
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	ctx := context.Background()
 	sessionID := p.sessionID
 	collectionName := p.collectionName
 	collection := p.client.Collection(collectionName)
@@ -0,0 +1,110 @@
+package providers
+
+import (
+	"context"
+	stderrors "errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/xichan96/cortex/pkg/mongodb"
+)
+
+// recordIndexCreation replaces the index creation for the test, counting calls per collection
+func recordIndexCreation(t *testing.T, fail func(call int) error) map[string]int {
+	t.Helper()
+	var mu sync.Mutex
+	calls := make(map[string]int)
+	original := createMongoMemoryIndexes
+	createMongoMemoryIndexes = func(ctx context.Context, _ *mongodb.Client, collectionName string) error {
+		mu.Lock()
+		defer mu.Unlock()
+		calls[collectionName]++
+		if _, ok := ctx.Deadline(); !ok {
+			t.Error("index creation has no timeout")
+		}
+		if fail != nil {
+			return fail(calls[collectionName])
+		}
+		return nil
+	}
+	t.Cleanup(func() {
+		createMongoMemoryIndexes = original
+		mongoIndexed.Clear()
+	})
+	return calls
+}
+
+func TestMongoDBIndexesEnsuredOncePerCollection(t *testing.T) {
+	calls := recordIndexCreation(t, nil)
+	client := &mongodb.Client{}
+
+	var wg sync.WaitGroup
+	for _, session := range []string{"a", "b", "c"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			NewMongoDBMemoryProvider(client, session).ensureIndexesOnce()
+		}()
+	}
+	wg.Wait()
+	other := NewMongoDBMemoryProvider(client, "d")
+	other.SetCollectionName("archive")
+	other.ensureIndexesOnce()
+	other.ensureIndexesOnce()
+	// Another client may point at another database
+	NewMongoDBMemoryProvider(&mongodb.Client{}, "e").ensureIndexesOnce()
+
+	if calls["chat_messages"] != 2 || calls["archive"] != 1 {
+		t.Errorf("index creations = %v, want chat_messages once per client and archive once", calls)
+	}
+}
+
+func TestMongoDBIndexFailureIsRetriedAfterInterval(t *testing.T) {
+	calls := recordIndexCreation(t, func(call int) error {
+		if call == 1 {
+			return stderrors.New("not primary")
+		}
+		return nil
+	})
+	interval := mongoIndexRetryInterval
+	mongoIndexRetryInterval = 20 * time.Millisecond
+	t.Cleanup(func() { mongoIndexRetryInterval = interval })
+	client := &mongodb.Client{}
+
+	NewMongoDBMemoryProvider(client, "a").ensureIndexesOnce()
+	NewMongoDBMemoryProvider(client, "b").ensureIndexesOnce()
+	if calls["chat_messages"] != 1 {
+		t.Errorf("index creations = %d, want no retry within the interval", calls["chat_messages"])
+	}
+
+	time.Sleep(2 * mongoIndexRetryInterval)
+	NewMongoDBMemoryProvider(client, "c").ensureIndexesOnce()
+	NewMongoDBMemoryProvider(client, "d").ensureIndexesOnce()
+	if calls["chat_messages"] != 2 {
+		t.Errorf("index creations = %d, want one retry after the interval and none after the success", calls["chat_messages"])
+	}
+}
+
+func TestMongoDBEnsureIndexesReturnsError(t *testing.T) {
+	calls := recordIndexCreation(t, func(call int) error {
+		if call == 1 {
+			return stderrors.New("not authorized")
+		}
+		return nil
+	})
+	p := NewMongoDBMemoryProvider(&mongodb.Client{}, "a")
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	if err := p.EnsureIndexes(ctx); err == nil {
+		t.Error("EnsureIndexes hid the index creation error")
+	}
+	if err := p.EnsureIndexes(ctx); err != nil {
+		t.Fatal(err)
+	}
+	p.ensureIndexesOnce()
+	if calls["chat_messages"] != 2 {
+		t.Errorf("index creations = %d, want none on use after EnsureIndexes succeeded", calls["chat_messages"])
+	}
+}
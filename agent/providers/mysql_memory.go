@@ -145,6 +145,58 @@ func (p *MySQLMemoryProvider) GetMessages(ctx context.Context, limit int) ([]typ
 	return messages, nil
 }
 
+// GetMessagesPaged gets a page of the stored messages (implements MemoryPager interface)
+func (p *MySQLMemoryProvider) GetMessagesPaged(offset, limit int) ([]types.Message, int, error) {
+	ctx := context.Background()
+	if err := p.initTable(ctx); err != nil {
+		return nil, 0, err
+	}
+
+	p.mu.RLock()
+	sessionID := p.sessionID
+	tableName := p.tableName
+	p.mu.RUnlock()
+
+	if offset < 0 {
+		offset = 0
+	}
+	var total int64
+	if err := p.getDB().WithContext(ctx).Table(tableName).
+		Where("session_id = ?", sessionID).
+		Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	// OFFSET requires a LIMIT in SQL
+	if limit <= 0 {
+		limit = int(total)
+	}
+	if offset >= int(total) {
+		return []types.Message{}, int(total), nil
+	}
+
+	var docs []MySQLMessageDocument
+	err := p.getDB().WithContext(ctx).Table(tableName).
+		Where("session_id = ?", sessionID).
+		Order("created_at ASC").
+		Order("id ASC").
+		Offset(offset).
+		Limit(limit).
+		Find(&docs).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	messages := make([]types.Message, 0, len(docs))
+	for _, doc := range docs {
+		messages = append(messages, types.Message{
+			Role:    doc.Role,
+			Content: doc.Content,
+			Name:    doc.Name,
+		})
+	}
+	return messages, int(total), nil
+}
+
 func (p *MySQLMemoryProvider) LoadMemoryVariables() (map[string]interface{}, error) {
 	ctx := context.Background()
 	p.mu.RLock()
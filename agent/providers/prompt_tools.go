@@ -0,0 +1,177 @@
+package providers
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/xichan96/cortex/agent/types"
+)
+
+// promptToolInstructions explains the tool call format to models without native function calling
+const promptToolInstructions = `You can call the following tools. To call tools, reply with only a JSON object in this format and nothing else:
+{"tool_calls": [{"name": "<tool name>", "arguments": {<arguments matching the tool parameters>}}]}
+Tool results are sent back to you in the next message. When no tool is needed, answer normally without JSON.
+
+Tools:
+`
+
+// SetPromptToolCalling sets whether tools are described in the prompt instead of sent as function definitions
+// Enable it for backends or models without native function calling: the tool definitions are added to the
+// system message, earlier tool calls and results are sent as text, and tool calls are parsed from the JSON
+// object the model replies with. Streaming tool calls are answered in one chunk in this mode
+func (p *LangChainLLMProvider) SetPromptToolCalling(enabled bool) {
+	p.promptTools = enabled
+}
+
+// PromptToolCalling reports whether tools are described in the prompt instead of sent as function definitions
+func (p *LangChainLLMProvider) PromptToolCalling() bool {
+	return p.promptTools
+}
+
+// chatWithPromptTools runs a tool-enabled chat by prompt instructions, parsing tool calls from the reply
+func (p *LangChainLLMProvider) chatWithPromptTools(messages []types.Message, tools []types.Tool) (types.Message, error) {
+	response, err := p.Chat(promptToolMessages(messages, tools))
+	if err != nil {
+		return types.Message{}, err
+	}
+	response.Role = "assistant"
+	if calls := parsePromptToolCalls(response.Content); len(calls) > 0 {
+		response.Content = ""
+		response.ToolCalls = calls
+	}
+	return response, nil
+}
+
+// chatWithPromptToolsStream answers a streaming tool-enabled chat with the blocking reply,
+// since a streamed reply can't be shown before it is known not to be a tool call
+func (p *LangChainLLMProvider) chatWithPromptToolsStream(messages []types.Message, tools []types.Tool) (<-chan types.StreamMessage, error) {
+	outputChan := make(chan types.StreamMessage, 3)
+
+	go func() {
+		defer close(outputChan)
+
+		response, err := p.chatWithPromptTools(messages, tools)
+		if err != nil {
+			outputChan <- types.StreamMessage{
+				Type:  "error",
+				Error: err.Error(),
+			}
+			return
+		}
+		if response.Content != "" {
+			outputChan <- types.StreamMessage{
+				Type:    "chunk",
+				Content: response.Content,
+			}
+		}
+		if len(response.ToolCalls) > 0 {
+			outputChan <- types.StreamMessage{
+				Type:      "tool_calls",
+				ToolCalls: response.ToolCalls,
+			}
+		}
+		outputChan <- types.StreamMessage{
+			Type:         "end",
+			FinishReason: response.FinishReason,
+			Usage:        response.Usage,
+		}
+	}()
+
+	return outputChan, nil
+}
+
+// promptToolMessages rewrites a conversation for prompt-based tool calling
+// The tool definitions are appended to the leading system message, assistant tool calls are sent
+// as the JSON object the model is asked for and tool results as user messages
+func promptToolMessages(messages []types.Message, tools []types.Tool) []types.Message {
+	var instructions strings.Builder
+	instructions.WriteString(promptToolInstructions)
+	for _, tool := range tools {
+		schema, _ := json.Marshal(tool.Schema())
+		fmt.Fprintf(&instructions, "- %s: %s\n  Parameters: %s\n", tool.Name(), tool.Description(), schema)
+	}
+
+	result := make([]types.Message, 0, len(messages)+1)
+	if len(tools) > 0 {
+		if len(messages) > 0 && messages[0].Role == "system" {
+			system := messages[0]
+			system.Content = strings.TrimRight(system.Content, "\n") + "\n\n" + instructions.String()
+			result = append(result, system)
+			messages = messages[1:]
+		} else {
+			result = append(result, types.Message{Role: "system", Content: instructions.String()})
+		}
+	}
+
+	for _, msg := range messages {
+		switch {
+		case msg.Role == "assistant" && len(msg.ToolCalls) > 0:
+			calls := make([]promptToolCall, 0, len(msg.ToolCalls))
+			for _, tc := range msg.ToolCalls {
+				calls = append(calls, promptToolCall{Name: tc.Function.Name, Arguments: tc.Function.Arguments})
+			}
+			data, _ := json.Marshal(promptToolReply{ToolCalls: calls})
+			result = append(result, types.Message{Role: "assistant", Content: string(data)})
+		case msg.Role == "tool":
+			result = append(result, types.Message{
+				Role:    "user",
+				Content: fmt.Sprintf("Result of tool %s:\n%s", msg.Name, msg.Content),
+			})
+		default:
+			result = append(result, msg)
+		}
+	}
+	return result
+}
+
+// promptToolReply JSON object a model replies with to call tools in prompt mode
+type promptToolReply struct {
+	ToolCalls []promptToolCall `json:"tool_calls"`
+}
+
+type promptToolCall struct {
+	Name      string                 `json:"name"`
+	Arguments map[string]interface{} `json:"arguments"`
+}
+
+// parsePromptToolCalls extracts the tool calls of a prompt mode reply, nil when it is a plain answer
+// The JSON object may be wrapped in a markdown code fence
+func parsePromptToolCalls(content string) []types.ToolCall {
+	text := strings.TrimSpace(content)
+	if fenced, ok := strings.CutPrefix(text, "```"); ok {
+		fenced = strings.TrimPrefix(fenced, "json")
+		text = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(fenced), "```"))
+	}
+	if !strings.HasPrefix(text, "{") {
+		return nil
+	}
+
+	var reply promptToolReply
+	if err := json.Unmarshal([]byte(text), &reply); err != nil {
+		return nil
+	}
+	calls := make([]types.ToolCall, 0, len(reply.ToolCalls))
+	for _, call := range reply.ToolCalls {
+		if call.Name == "" {
+			continue
+		}
+		args := call.Arguments
+		if args == nil {
+			args = make(map[string]interface{})
+		}
+		calls = append(calls, types.ToolCall{
+			ID:   "call_" + uuid.New().String(),
+			Type: "function",
+			Function: types.ToolFunction{
+				Name:      call.Name,
+				Arguments: args,
+			},
+		})
+	}
+	if len(calls) == 0 {
+		return nil
+	}
+	return calls
+}
@@ -0,0 +1,110 @@
+package providers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/tmc/langchaingo/llms"
+	"github.com/xichan96/cortex/agent/types"
+)
+
+// replyModel answers every call with a fixed reply, recording the messages it was sent
+type replyModel struct {
+	reply    string
+	messages []llms.MessageContent
+	opts     llms.CallOptions
+}
+
+func (m *replyModel) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	m.messages = messages
+	m.opts = llms.CallOptions{}
+	for _, opt := range options {
+		opt(&m.opts)
+	}
+	return &llms.ContentResponse{
+		Choices: []*llms.ContentChoice{{Content: m.reply}},
+	}, nil
+}
+
+func (m *replyModel) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return m.reply, nil
+}
+
+type weatherTool struct{}
+
+func (weatherTool) Name() string        { return "weather" }
+func (weatherTool) Description() string { return "current weather of a city" }
+func (weatherTool) Schema() map[string]interface{} {
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": map[string]interface{}{"city": map[string]interface{}{"type": "string"}},
+	}
+}
+func (weatherTool) Execute(input map[string]interface{}) (interface{}, error) { return "sunny", nil }
+func (weatherTool) Metadata() types.ToolMetadata                              { return types.ToolMetadata{} }
+
+func TestPromptToolCallingParsesToolCalls(t *testing.T) {
+	model := &replyModel{reply: "```json\n{\"tool_calls\": [{\"name\": \"weather\", \"arguments\": {\"city\": \"Paris\"}}]}\n```"}
+	p := NewLangChainLLMProvider(model, "test")
+	p.SetPromptToolCalling(true)
+
+	messages := []types.Message{
+		{Role: "system", Content: "You are helpful."},
+		{Role: "user", Content: "Weather in Berlin and Paris?"},
+		{Role: "assistant", ToolCalls: []types.ToolCall{{ID: "call_1", Type: "function", Function: types.ToolFunction{Name: "weather", Arguments: map[string]interface{}{"city": "Berlin"}}}}},
+		{Role: "tool", Name: "weather", ToolCallID: "call_1", Content: "rainy"},
+	}
+	response, err := p.ChatWithTools(messages, []types.Tool{weatherTool{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(response.ToolCalls) != 1 || response.ToolCalls[0].Function.Name != "weather" ||
+		response.ToolCalls[0].Function.Arguments["city"] != "Paris" || response.ToolCalls[0].ID == "" {
+		t.Fatalf("tool calls = %+v", response.ToolCalls)
+	}
+	if response.Content != "" {
+		t.Errorf("content = %q, want the tool call JSON removed", response.Content)
+	}
+
+	if model.opts.Tools != nil {
+		t.Error("tool definitions were sent in prompt mode")
+	}
+	system := model.messages[0].Parts[0].(llms.TextContent).Text
+	if !strings.HasPrefix(system, "You are helpful.") || !strings.Contains(system, "- weather: current weather of a city") {
+		t.Errorf("system message = %q", system)
+	}
+	for _, msg := range model.messages {
+		for _, part := range msg.Parts {
+			if _, ok := part.(llms.TextContent); !ok {
+				t.Errorf("%s message sent a %T part", msg.Role, part)
+			}
+		}
+	}
+	if result := model.messages[3]; result.Role != llms.ChatMessageTypeHuman || !strings.Contains(result.Parts[0].(llms.TextContent).Text, "rainy") {
+		t.Errorf("tool result sent as %+v", result)
+	}
+}
+
+func TestPromptToolCallingPlainAnswer(t *testing.T) {
+	model := &replyModel{reply: "It is sunny in Paris."}
+	p := NewLangChainLLMProvider(model, "test")
+	p.SetPromptToolCalling(true)
+
+	stream, err := p.ChatWithToolsStream([]types.Message{{Role: "user", Content: "Weather?"}}, []types.Tool{weatherTool{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var content string
+	for msg := range stream {
+		switch msg.Type {
+		case "chunk":
+			content += msg.Content
+		case "tool_calls", "error":
+			t.Errorf("unexpected %s message: %+v", msg.Type, msg)
+		}
+	}
+	if content != "It is sunny in Paris." {
+		t.Errorf("content = %q", content)
+	}
+}
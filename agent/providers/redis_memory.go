@@ -11,12 +11,57 @@ import (
 	"github.com/xichan96/cortex/pkg/redis"
 )
 
+// redisMessage stored form of a message
+// Entries written before tool calls were stored lack tool_calls and tool_call_id and decode without them
+type redisMessage struct {
+	Role       string           `json:"role"`
+	Content    string           `json:"content"`
+	Name       string           `json:"name"`
+	ToolCalls  []types.ToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string           `json:"tool_call_id,omitempty"`
+	CreatedAt  int64            `json:"created_at"`
+}
+
+// encodeRedisMessage serializes a message for storage
+func encodeRedisMessage(message types.Message) ([]byte, error) {
+	return json.Marshal(redisMessage{
+		Role:       message.Role,
+		Content:    message.Content,
+		Name:       message.Name,
+		ToolCalls:  message.ToolCalls,
+		ToolCallID: message.ToolCallID,
+		CreatedAt:  time.Now().Unix(),
+	})
+}
+
+// decodeRedisMessages restores stored entries, newest first as kept in the list, into chronological order
+// Entries that cannot be decoded are skipped
+func decodeRedisMessages(results []string) []types.Message {
+	messages := make([]types.Message, 0, len(results))
+	for i := len(results) - 1; i >= 0; i-- {
+		var stored redisMessage
+		if err := json.Unmarshal([]byte(results[i]), &stored); err != nil {
+			continue
+		}
+		messages = append(messages, types.Message{
+			Role:       stored.Role,
+			Content:    stored.Content,
+			Name:       stored.Name,
+			ToolCalls:  stored.ToolCalls,
+			ToolCallID: stored.ToolCallID,
+		})
+	}
+	return messages
+}
+
 type RedisMemoryProvider struct {
 	mu                 sync.RWMutex
 	client             *redis.Client
 	sessionID          string
 	maxHistoryMessages int
 	keyPrefix          string
+	sessionTTL         time.Duration
+	historyTokenBudget
 }
 
 func NewRedisMemoryProvider(client *redis.Client, sessionID string) *RedisMemoryProvider {
@@ -49,6 +94,14 @@ func (p *RedisMemoryProvider) SetKeyPrefix(prefix string) {
 	p.keyPrefix = prefix
 }
 
+// SetSessionTTL sets how long an idle session is kept, 0 (the default) keeps sessions until cleared
+// The expiry of the session's keys is refreshed whenever a message is added
+func (p *RedisMemoryProvider) SetSessionTTL(ttl time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.sessionTTL = ttl
+}
+
 func (p *RedisMemoryProvider) getKey() string {
 	p.mu.RLock()
 	defer p.mu.RUnlock()
@@ -56,14 +109,7 @@ func (p *RedisMemoryProvider) getKey() string {
 }
 
 func (p *RedisMemoryProvider) AddMessage(ctx context.Context, message types.Message) error {
-	msgData := map[string]interface{}{
-		"role":       message.Role,
-		"content":    message.Content,
-		"name":       message.Name,
-		"created_at": time.Now().Unix(),
-	}
-
-	msgJSON, err := json.Marshal(msgData)
+	msgJSON, err := encodeRedisMessage(message)
 	if err != nil {
 		return err
 	}
@@ -74,9 +120,27 @@ func (p *RedisMemoryProvider) AddMessage(ctx context.Context, message types.Mess
 	}
 
 	if p.maxHistoryMessages > 0 {
-		return p.trimHistory(ctx)
+		if err := p.trimHistory(ctx); err != nil {
+			return err
+		}
 	}
-	return nil
+
+	p.mu.RLock()
+	ttl := p.sessionTTL
+	p.mu.RUnlock()
+	return p.expireSession(ctx, key, ttl)
+}
+
+// expireSession sets the session TTL on the message list and metadata keys, a no-op without a TTL
+func (p *RedisMemoryProvider) expireSession(ctx context.Context, key string, ttl time.Duration) error {
+	if ttl <= 0 {
+		return nil
+	}
+	if err := p.client.Expire(ctx, key, ttl).Err(); err != nil {
+		return err
+	}
+	// Expiring a key that does not exist is a no-op, sessions without metadata have no hash
+	return p.client.Expire(ctx, key+":meta", ttl).Err()
 }
 
 func (p *RedisMemoryProvider) GetMessages(ctx context.Context, limit int) ([]types.Message, error) {
@@ -98,23 +162,7 @@ func (p *RedisMemoryProvider) GetMessages(ctx context.Context, limit int) ([]typ
 		return nil, err
 	}
 
-	messages := make([]types.Message, 0, len(results))
-	for i := len(results) - 1; i >= 0; i-- {
-		var msgData map[string]interface{}
-		if err := json.Unmarshal([]byte(results[i]), &msgData); err != nil {
-			continue
-		}
-
-		role, _ := msgData["role"].(string)
-		content, _ := msgData["content"].(string)
-		name, _ := msgData["name"].(string)
-
-		messages = append(messages, types.Message{
-			Role:    role,
-			Content: content,
-			Name:    name,
-		})
-	}
+	messages := decodeRedisMessages(results)
 
 	return messages, nil
 }
@@ -129,7 +177,7 @@ func (p *RedisMemoryProvider) LoadMemoryVariables() (map[string]interface{}, err
 		return nil, err
 	}
 	return map[string]interface{}{
-		"history": messages,
+		"history": p.fitHistory(messages),
 	}, nil
 }
 
@@ -157,7 +205,40 @@ func (p *RedisMemoryProvider) SaveContext(input, output map[string]interface{})
 func (p *RedisMemoryProvider) Clear() error {
 	ctx := context.Background()
 	key := p.getKey()
-	return p.client.Del(ctx, key).Err()
+	return p.client.Del(ctx, key, p.metadataKey()).Err()
+}
+
+// metadataKey key of the session metadata hash
+func (p *RedisMemoryProvider) metadataKey() string {
+	return p.getKey() + ":meta"
+}
+
+// GetMetadata gets a session metadata value (implements MemoryMetadataStore interface)
+func (p *RedisMemoryProvider) GetMetadata(key string) (string, bool, error) {
+	// HGetAll reports a missing hash as empty instead of redis.Nil
+	values, err := p.client.HGetAll(context.Background(), p.metadataKey()).Result()
+	if err != nil {
+		return "", false, err
+	}
+	value, ok := values[key]
+	return value, ok, nil
+}
+
+// SetMetadata sets a session metadata value (implements MemoryMetadataStore interface)
+func (p *RedisMemoryProvider) SetMetadata(key, value string) error {
+	ctx := context.Background()
+	metaKey := p.metadataKey()
+	if err := p.client.HSet(ctx, metaKey, key, value).Err(); err != nil {
+		return err
+	}
+
+	p.mu.RLock()
+	ttl := p.sessionTTL
+	p.mu.RUnlock()
+	if ttl <= 0 {
+		return nil
+	}
+	return p.client.Expire(ctx, metaKey, ttl).Err()
 }
 
 func (p *RedisMemoryProvider) GetChatHistory() ([]types.Message, error) {
@@ -165,7 +246,35 @@ func (p *RedisMemoryProvider) GetChatHistory() ([]types.Message, error) {
 	p.mu.RLock()
 	maxHistoryMessages := p.maxHistoryMessages
 	p.mu.RUnlock()
-	return p.GetMessages(ctx, maxHistoryMessages)
+	messages, err := p.GetMessages(ctx, maxHistoryMessages)
+	if err != nil {
+		return nil, err
+	}
+	return p.fitHistory(messages), nil
+}
+
+// GetMessagesPaged gets a page of the stored messages (implements MemoryPager interface)
+func (p *RedisMemoryProvider) GetMessagesPaged(offset, limit int) ([]types.Message, int, error) {
+	ctx := context.Background()
+	key := p.getKey()
+	length, err := p.client.LLen(ctx, key).Result()
+	if err != nil {
+		return nil, 0, err
+	}
+	total := int(length)
+	start, end := pageBounds(total, offset, limit)
+	if start == end {
+		return []types.Message{}, total, nil
+	}
+
+	// The list holds the newest message first
+	results, err := p.client.LRange(ctx, key, int64(total-end), int64(total-start-1)).Result()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	messages := decodeRedisMessages(results)
+	return messages, total, nil
 }
 
 func (p *RedisMemoryProvider) trimHistory(ctx context.Context) error {
@@ -197,23 +306,7 @@ func (p *RedisMemoryProvider) CompressMemory(llm types.LLMProvider, maxMessages
 		return err
 	}
 
-	messages := make([]types.Message, 0, len(results))
-	for i := len(results) - 1; i >= 0; i-- {
-		var msgData map[string]interface{}
-		if err := json.Unmarshal([]byte(results[i]), &msgData); err != nil {
-			continue
-		}
-
-		role, _ := msgData["role"].(string)
-		content, _ := msgData["content"].(string)
-		name, _ := msgData["name"].(string)
-
-		messages = append(messages, types.Message{
-			Role:    role,
-			Content: content,
-			Name:    name,
-		})
-	}
+	messages := decodeRedisMessages(results)
 
 	if len(messages) <= maxMessages {
 		return nil
@@ -267,14 +360,7 @@ func (p *RedisMemoryProvider) CompressMemory(llm types.LLMProvider, maxMessages
 	tempKey := key + ":temp:" + fmt.Sprintf("%d", time.Now().UnixNano())
 
 	for i := len(compressedMessages) - 1; i >= 0; i-- {
-		msg := compressedMessages[i]
-		msgData := map[string]interface{}{
-			"role":       msg.Role,
-			"content":    msg.Content,
-			"name":       msg.Name,
-			"created_at": time.Now().Unix(),
-		}
-		msgJSON, err := json.Marshal(msgData)
+		msgJSON, err := encodeRedisMessage(compressedMessages[i])
 		if err != nil {
 			p.client.Del(ctx, tempKey)
 			return fmt.Errorf("failed to marshal message: %w", err)
@@ -302,5 +388,10 @@ func (p *RedisMemoryProvider) CompressMemory(llm types.LLMProvider, maxMessages
 		}
 	}
 
+	// RENAME gives the key the temp key's TTL, i.e. none
+	if err := p.expireSession(ctx, key, p.sessionTTL); err != nil {
+		return fmt.Errorf("failed to set session ttl after compression: %w", err)
+	}
+
 	return nil
 }
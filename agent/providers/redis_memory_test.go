@@ -0,0 +1,52 @@
+package providers
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/xichan96/cortex/agent/types"
+)
+
+func TestRedisMessageRoundTrip(t *testing.T) {
+	messages := []types.Message{
+		{Role: "user", Content: "weather in Paris?"},
+		{Role: "assistant", ToolCalls: []types.ToolCall{{
+			ID:   "call_1",
+			Type: "function",
+			Function: types.ToolFunction{
+				Name:      "weather",
+				Arguments: map[string]interface{}{"city": "Paris"},
+			},
+		}}},
+		{Role: "tool", Content: "sunny", Name: "weather", ToolCallID: "call_1"},
+	}
+
+	// The list keeps the newest message first
+	stored := make([]string, len(messages))
+	for i, msg := range messages {
+		data, err := encodeRedisMessage(msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		stored[len(messages)-1-i] = string(data)
+	}
+
+	if got := decodeRedisMessages(stored); !reflect.DeepEqual(got, messages) {
+		t.Errorf("decoded messages = %+v, want %+v", got, messages)
+	}
+}
+
+func TestRedisMessageLegacyEntries(t *testing.T) {
+	stored := []string{
+		`{"role":"assistant","content":"hello","name":"","created_at":1700000001}`,
+		`not json`,
+		`{"role":"user","content":"hi","name":"alice","created_at":1700000000}`,
+	}
+	want := []types.Message{
+		{Role: "user", Content: "hi", Name: "alice"},
+		{Role: "assistant", Content: "hello"},
+	}
+	if got := decodeRedisMessages(stored); !reflect.DeepEqual(got, want) {
+		t.Errorf("decoded legacy messages = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,157 @@
+package providers
+
+import (
+	"context"
+	stderrors "errors"
+	"math/rand/v2"
+	"net"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// Retry policy defaults of LangChainLLMProvider
+const (
+	DefaultRetryMaxRetries = 3
+	DefaultRetryBaseDelay  = 1 * time.Second
+	DefaultRetryMaxDelay   = 30 * time.Second
+)
+
+// DefaultRetryableStatuses HTTP statuses retried by default: rate limits and transient server errors
+var DefaultRetryableStatuses = []int{429, 500, 502, 503, 504}
+
+var (
+	// statusCodePattern status codes as reported by the langchaingo clients ("unexpected status code: 503")
+	statusCodePattern = regexp.MustCompile(`(?i)status(?:\s+code)?[\s:=]+(\d{3})\b`)
+	// bareStatusPattern status codes mentioned without context, only trusted for retryable-looking codes
+	bareStatusPattern = regexp.MustCompile(`\b(429|5\d\d)\b`)
+	// timeoutPattern network timeouts, which the clients report as plain messages
+	timeoutPattern = regexp.MustCompile(`(?i)timeout|timed out|deadline exceeded`)
+	// retryAfterPattern wait hints in rate limit messages ("retry after 20s", "please wait 500ms")
+	retryAfterPattern = regexp.MustCompile(`(?i)(?:retry|wait|after)[\s:]+(\d+)[\s]*?(milliseconds?|ms|seconds?|s|minutes?|m)`)
+)
+
+// statusKeywords status codes implied by error messages without a number
+var statusKeywords = []struct {
+	keyword string
+	status  int
+}{
+	{"too many requests", 429},
+	{"rate limit", 429},
+	{"rate_limit", 429},
+	{"internal server error", 500},
+	{"bad gateway", 502},
+	{"service unavailable", 503},
+	{"overloaded", 503},
+	{"gateway timeout", 504},
+}
+
+// retryPolicy when and how long LangChainLLMProvider waits before repeating a failed call
+type retryPolicy struct {
+	maxRetries int
+	baseDelay  time.Duration
+	maxDelay   time.Duration // 0 leaves the backoff uncapped
+	statuses   []int
+}
+
+// defaultRetryPolicy returns the policy providers start with
+func defaultRetryPolicy() retryPolicy {
+	return retryPolicy{
+		maxRetries: DefaultRetryMaxRetries,
+		baseDelay:  DefaultRetryBaseDelay,
+		maxDelay:   DefaultRetryMaxDelay,
+		statuses:   slices.Clone(DefaultRetryableStatuses),
+	}
+}
+
+// retryable reports whether err is worth retrying and the HTTP status it carries, 0 if none was found
+// Errors with a status are retried when the status is in the policy, errors without one only on timeouts
+func (r retryPolicy) retryable(err error) (bool, int) {
+	if stderrors.Is(err, context.Canceled) {
+		return false, 0
+	}
+	if status := errorStatus(err); status != 0 {
+		return slices.Contains(r.statuses, status), status
+	}
+
+	var llmErr *llms.Error
+	if stderrors.As(err, &llmErr) && llmErr.Code == llms.ErrCodeTimeout {
+		return true, 0
+	}
+	var netErr net.Error
+	if stderrors.As(err, &netErr) && netErr.Timeout() {
+		return true, 0
+	}
+	return stderrors.Is(err, context.DeadlineExceeded) || timeoutPattern.MatchString(err.Error()), 0
+}
+
+// backoff returns the wait before retry number retryCount+1: baseDelay doubled per retry, capped at
+// maxDelay, with equal jitter so concurrent clients do not retry in lockstep
+func (r retryPolicy) backoff(retryCount int) time.Duration {
+	if r.baseDelay <= 0 {
+		return 0
+	}
+	delay := r.baseDelay << min(retryCount, 30)
+	if delay <= 0 || (r.maxDelay > 0 && delay > r.maxDelay) {
+		delay = r.maxDelay
+	}
+	half := delay / 2
+	return half + rand.N(delay-half+1)
+}
+
+// errorStatus extracts the HTTP status of a provider error, 0 when it has none
+func errorStatus(err error) int {
+	var llmErr *llms.Error
+	if stderrors.As(err, &llmErr) {
+		switch llmErr.Code {
+		case llms.ErrCodeRateLimit:
+			return 429
+		case llms.ErrCodeProviderUnavailable:
+			return 503
+		}
+	}
+
+	msg := err.Error()
+	if m := statusCodePattern.FindStringSubmatch(msg); m != nil {
+		status, _ := strconv.Atoi(m[1])
+		return status
+	}
+	lower := strings.ToLower(msg)
+	for _, k := range statusKeywords {
+		if strings.Contains(lower, k.keyword) {
+			return k.status
+		}
+	}
+	if m := bareStatusPattern.FindStringSubmatch(msg); m != nil {
+		status, _ := strconv.Atoi(m[1])
+		return status
+	}
+	return 0
+}
+
+// retryAfter parses the wait a rate limit error asks for
+func retryAfter(err error) (time.Duration, bool) {
+	matches := retryAfterPattern.FindStringSubmatch(err.Error())
+	if len(matches) < 3 {
+		return 0, false
+	}
+	n, parseErr := strconv.Atoi(matches[1])
+	if parseErr != nil {
+		return 0, false
+	}
+	unit := strings.ToLower(strings.TrimSpace(matches[2]))
+	switch {
+	case strings.HasPrefix(unit, "milli") || unit == "ms":
+		return time.Duration(n) * time.Millisecond, true
+	case strings.HasPrefix(unit, "second") || unit == "s":
+		return time.Duration(n) * time.Second, true
+	case strings.HasPrefix(unit, "minute") || unit == "m":
+		return time.Duration(n) * time.Minute, true
+	default:
+		return time.Duration(n) * time.Millisecond, true
+	}
+}
@@ -0,0 +1,97 @@
+package providers
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/tmc/langchaingo/llms"
+	"github.com/xichan96/cortex/agent/types"
+)
+
+func TestRetryPolicyRetryable(t *testing.T) {
+	policy := defaultRetryPolicy()
+	tests := []struct {
+		err    error
+		retry  bool
+		status int
+	}{
+		{stderrors.New("API returned unexpected status code: 429: Rate limit reached"), true, 429},
+		{stderrors.New("API returned unexpected status code: 503"), true, 503},
+		{stderrors.New("API returned unexpected status code: 400: rate limit parameter invalid"), false, 400},
+		{stderrors.New("API returned unexpected status code: 401: invalid api key"), false, 401},
+		{stderrors.New("upstream overloaded, try again"), true, 503},
+		{llms.NewError(llms.ErrCodeRateLimit, "openai", "Rate limit exceeded"), true, 429},
+		{stderrors.New("request timeout: network operation exceeded timeout"), true, 0},
+		{fmt.Errorf("call: %w", context.DeadlineExceeded), true, 0},
+		{context.Canceled, false, 0},
+		{stderrors.New("invalid tool schema"), false, 0},
+	}
+	for _, tt := range tests {
+		retry, status := policy.retryable(tt.err)
+		if retry != tt.retry || status != tt.status {
+			t.Errorf("retryable(%q) = %v, %d, want %v, %d", tt.err, retry, status, tt.retry, tt.status)
+		}
+	}
+
+	policy.statuses = []int{429}
+	if retry, _ := policy.retryable(stderrors.New("API returned unexpected status code: 503")); retry {
+		t.Error("503 retried although it is not in the retryable statuses")
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := retryPolicy{baseDelay: 100 * time.Millisecond, maxDelay: time.Second}
+	for retry, want := range []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second, time.Second} {
+		for i := 0; i < 20; i++ {
+			if got := policy.backoff(retry); got < want/2 || got > want {
+				t.Fatalf("backoff(%d) = %s, want within [%s, %s]", retry, got, want/2, want)
+			}
+		}
+	}
+	if got := policy.backoff(100); got > time.Second {
+		t.Errorf("backoff(100) = %s, want capped at 1s", got)
+	}
+}
+
+// flakyModel fails with the given errors before answering
+type flakyModel struct {
+	errs  []error
+	calls int
+}
+
+func (m *flakyModel) GenerateContent(ctx context.Context, _ []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	m.calls++
+	if m.calls <= len(m.errs) {
+		return nil, m.errs[m.calls-1]
+	}
+	return &llms.ContentResponse{Choices: []*llms.ContentChoice{{Content: "ok"}}}, nil
+}
+
+func (m *flakyModel) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return "", nil
+}
+
+func TestChatRetriesTransientErrors(t *testing.T) {
+	model := &flakyModel{errs: []error{
+		stderrors.New("API returned unexpected status code: 502"),
+		stderrors.New("request timeout: API call exceeded deadline"),
+		stderrors.New("API returned unexpected status code: 429: please retry after 1ms"),
+	}}
+	p := NewLangChainLLMProvider(model, "test")
+	p.SetRetryPolicy(3, time.Millisecond, 5*time.Millisecond, nil)
+
+	msg, err := p.Chat([]types.Message{{Role: "user", Content: "hi"}})
+	if err != nil || msg.Content != "ok" || model.calls != 4 {
+		t.Fatalf("Chat = %q, %v after %d calls, want ok after 4 calls", msg.Content, err, model.calls)
+	}
+
+	model = &flakyModel{errs: []error{stderrors.New("API returned unexpected status code: 500")}}
+	p = NewLangChainLLMProvider(model, "test")
+	p.SetRetryPolicy(3, time.Millisecond, 0, []int{429})
+	if _, err := p.Chat([]types.Message{{Role: "user", Content: "hi"}}); err == nil || model.calls != 1 {
+		t.Errorf("500 outside retryable statuses: err = %v after %d calls, want error after 1 call", err, model.calls)
+	}
+}
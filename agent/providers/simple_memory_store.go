@@ -0,0 +1,184 @@
+package providers
+
+import (
+	"container/list"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/xichan96/cortex/agent/types"
+)
+
+// SimpleMemoryOptions limits for in-memory conversation history
+type SimpleMemoryOptions struct {
+	MaxHistoryMessages int // per-session message cap (0 means unlimited)
+	MaxBytes           int // per-session content byte cap (0 means unlimited)
+	MaxHistoryTokens   int // per-session token cap of the history returned to the engine (0 means unlimited)
+	MaxSessions        int // store-wide session cap, least recently used sessions are evicted (0 means unlimited)
+	MaxTotalMessages   int // store-wide message cap across all sessions (0 means unlimited)
+}
+
+// SimpleMemoryStats memory usage snapshot of a SimpleMemoryStore
+type SimpleMemoryStats struct {
+	Sessions  int   `json:"sessions"`
+	Messages  int   `json:"messages"`
+	Bytes     int   `json:"bytes"`
+	Evictions int64 `json:"evictions"`
+}
+
+// SimpleMemoryStore keeps one SimpleMemoryProvider per session and bounds total memory growth
+// Sessions are evicted in least recently used order once MaxSessions or MaxTotalMessages is exceeded
+type SimpleMemoryStore struct {
+	mu        sync.Mutex
+	opts      SimpleMemoryOptions
+	sessions  map[string]*list.Element
+	lru       *list.List // front is most recently used, values are *SimpleMemoryProvider
+	evictions int64
+}
+
+// NewSimpleMemoryStore creates a new session-scoped simple memory store
+func NewSimpleMemoryStore(opts SimpleMemoryOptions) *SimpleMemoryStore {
+	return &SimpleMemoryStore{
+		opts:     opts,
+		sessions: make(map[string]*list.Element),
+		lru:      list.New(),
+	}
+}
+
+// Session returns the memory provider of a session, creating it if needed
+func (s *SimpleMemoryStore) Session(sessionID string) *SimpleMemoryProvider {
+	s.mu.Lock()
+	if elem, ok := s.sessions[sessionID]; ok {
+		s.lru.MoveToFront(elem)
+		s.mu.Unlock()
+		return elem.Value.(*SimpleMemoryProvider)
+	}
+
+	provider := NewSimpleMemoryProviderWithOptions(s.opts)
+	provider.store = s
+	provider.sessionID = sessionID
+	s.sessions[sessionID] = s.lru.PushFront(provider)
+	s.mu.Unlock()
+
+	s.evictIfNeeded(sessionID)
+	return provider
+}
+
+// Remove drops a session and its history from the store
+func (s *SimpleMemoryStore) Remove(sessionID string) {
+	s.mu.Lock()
+	elem, ok := s.sessions[sessionID]
+	if ok {
+		s.lru.Remove(elem)
+		delete(s.sessions, sessionID)
+	}
+	s.mu.Unlock()
+
+	if ok {
+		detach(elem.Value.(*SimpleMemoryProvider))
+	}
+}
+
+// Stats returns current session, message and byte counts
+func (s *SimpleMemoryStore) Stats() SimpleMemoryStats {
+	s.mu.Lock()
+	providers := s.providersLocked()
+	stats := SimpleMemoryStats{
+		Sessions:  len(providers),
+		Evictions: s.evictions,
+	}
+	s.mu.Unlock()
+
+	for _, p := range providers {
+		messages, bytes := p.Stats()
+		stats.Messages += messages
+		stats.Bytes += bytes
+	}
+	return stats
+}
+
+// touch marks a session as recently used and enforces store-wide caps after a write
+func (s *SimpleMemoryStore) touch(sessionID string) {
+	s.mu.Lock()
+	if elem, ok := s.sessions[sessionID]; ok {
+		s.lru.MoveToFront(elem)
+	}
+	s.mu.Unlock()
+
+	s.evictIfNeeded(sessionID)
+}
+
+// evictIfNeeded evicts least recently used sessions other than current until the caps are met
+func (s *SimpleMemoryStore) evictIfNeeded(current string) {
+	if s.opts.MaxSessions <= 0 && s.opts.MaxTotalMessages <= 0 {
+		return
+	}
+
+	// Message counts are read without holding s.mu to keep lock order provider -> store free of cycles
+	total := 0
+	if s.opts.MaxTotalMessages > 0 {
+		s.mu.Lock()
+		providers := s.providersLocked()
+		s.mu.Unlock()
+		for _, p := range providers {
+			messages, _ := p.Stats()
+			total += messages
+		}
+	}
+
+	for {
+		s.mu.Lock()
+		overSessions := s.opts.MaxSessions > 0 && s.lru.Len() > s.opts.MaxSessions
+		overMessages := s.opts.MaxTotalMessages > 0 && total > s.opts.MaxTotalMessages
+		if !overSessions && !overMessages {
+			s.mu.Unlock()
+			return
+		}
+
+		victim := s.lru.Back()
+		for victim != nil && victim.Value.(*SimpleMemoryProvider).sessionID == current {
+			victim = victim.Prev()
+		}
+		if victim == nil {
+			s.mu.Unlock()
+			return
+		}
+		provider := victim.Value.(*SimpleMemoryProvider)
+		s.lru.Remove(victim)
+		delete(s.sessions, provider.sessionID)
+		s.evictions++
+		s.mu.Unlock()
+
+		messages, _ := provider.Stats()
+		total -= messages
+		detach(provider)
+	}
+}
+
+// providersLocked returns all providers in LRU order, caller must hold s.mu
+func (s *SimpleMemoryStore) providersLocked() []*SimpleMemoryProvider {
+	providers := make([]*SimpleMemoryProvider, 0, s.lru.Len())
+	for elem := s.lru.Front(); elem != nil; elem = elem.Next() {
+		providers = append(providers, elem.Value.(*SimpleMemoryProvider))
+	}
+	return providers
+}
+
+// detach clears an evicted provider and unlinks it from its store
+func detach(p *SimpleMemoryProvider) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.messages = make([]types.Message, 0)
+	p.store = nil
+}
+
+// truncateUTF8 cuts s to at most maxBytes without splitting a rune
+func truncateUTF8(s string, maxBytes int) string {
+	if len(s) <= maxBytes {
+		return s
+	}
+	end := maxBytes
+	for end > 0 && !utf8.RuneStart(s[end]) {
+		end--
+	}
+	return s[:end]
+}
@@ -0,0 +1,125 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/xichan96/cortex/agent/types"
+)
+
+func TestSimpleMemoryProvider_MaxBytes(t *testing.T) {
+	p := NewSimpleMemoryProviderWithOptions(SimpleMemoryOptions{MaxBytes: 10})
+	ctx := context.Background()
+
+	_ = p.AddMessage(ctx, types.Message{Role: "user", Content: "12345"})
+	_ = p.AddMessage(ctx, types.Message{Role: "assistant", Content: "67890"})
+	_ = p.AddMessage(ctx, types.Message{Role: "user", Content: "abc"})
+
+	messages, bytes := p.Stats()
+	if messages != 2 || bytes != 8 {
+		t.Errorf("Expected 2 messages and 8 bytes, got %d messages and %d bytes", messages, bytes)
+	}
+
+	_ = p.AddMessage(ctx, types.Message{Role: "user", Content: strings.Repeat("x", 20)})
+	history, _ := p.GetChatHistory()
+	if len(history) != 1 || len(history[0].Content) != 10 {
+		t.Errorf("Expected single message truncated to 10 bytes, got %v", history)
+	}
+}
+
+func TestSimpleMemoryProvider_GetMessagesPaged(t *testing.T) {
+	p := NewSimpleMemoryProvider()
+	ctx := context.Background()
+	for i := 0; i < 5; i++ {
+		_ = p.AddMessage(ctx, types.Message{Role: "user", Content: fmt.Sprintf("m%d", i)})
+	}
+
+	tests := []struct {
+		offset, limit int
+		want          string
+	}{
+		{0, 2, "m0,m1"},
+		{3, 0, "m3,m4"},
+		{4, 5, "m4"},
+		{9, 2, ""},
+	}
+	for _, tt := range tests {
+		messages, total, err := p.GetMessagesPaged(tt.offset, tt.limit)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		contents := make([]string, 0, len(messages))
+		for _, msg := range messages {
+			contents = append(contents, msg.Content)
+		}
+		if got := strings.Join(contents, ","); got != tt.want || total != 5 {
+			t.Errorf("GetMessagesPaged(%d, %d) = %q (total %d), want %q (total 5)", tt.offset, tt.limit, got, total, tt.want)
+		}
+	}
+}
+
+func TestSimpleMemoryProvider_Metadata(t *testing.T) {
+	p := NewSimpleMemoryProvider()
+	if _, ok, _ := p.GetMetadata("title"); ok {
+		t.Error("Expected no metadata on a new provider")
+	}
+	if err := p.SetMetadata("title", "Password reset"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if value, ok, _ := p.GetMetadata("title"); !ok || value != "Password reset" {
+		t.Errorf("Expected stored title, got %q (%v)", value, ok)
+	}
+	if err := p.Clear(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, ok, _ := p.GetMetadata("title"); ok {
+		t.Error("Expected Clear to remove the metadata")
+	}
+}
+
+func TestSimpleMemoryStore_MaxSessions(t *testing.T) {
+	store := NewSimpleMemoryStore(SimpleMemoryOptions{MaxSessions: 2})
+	ctx := context.Background()
+
+	first := store.Session("a")
+	_ = first.AddMessage(ctx, types.Message{Role: "user", Content: "hello"})
+	_ = store.Session("b").AddMessage(ctx, types.Message{Role: "user", Content: "hello"})
+	// Using a again makes b the least recently used session
+	_ = first.AddMessage(ctx, types.Message{Role: "user", Content: "again"})
+	_ = store.Session("c").AddMessage(ctx, types.Message{Role: "user", Content: "hello"})
+
+	stats := store.Stats()
+	if stats.Sessions != 2 || stats.Evictions != 1 {
+		t.Errorf("Expected 2 sessions and 1 eviction, got %+v", stats)
+	}
+	if history, _ := store.Session("a").GetChatHistory(); len(history) != 2 {
+		t.Errorf("Expected session a to be kept, got %d messages", len(history))
+	}
+	if history, _ := store.Session("b").GetChatHistory(); len(history) != 0 {
+		t.Errorf("Expected session b to be evicted, got %d messages", len(history))
+	}
+}
+
+func TestSimpleMemoryStore_MaxTotalMessages(t *testing.T) {
+	store := NewSimpleMemoryStore(SimpleMemoryOptions{MaxTotalMessages: 5})
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		session := store.Session(fmt.Sprintf("s%d", i))
+		_ = session.AddMessage(ctx, types.Message{Role: "user", Content: "q"})
+		_ = session.AddMessage(ctx, types.Message{Role: "assistant", Content: "a"})
+	}
+
+	stats := store.Stats()
+	if stats.Messages > 5 {
+		t.Errorf("Expected at most 5 messages, got %d", stats.Messages)
+	}
+	if stats.Sessions != 2 {
+		t.Errorf("Expected oldest session to be evicted, got %d sessions", stats.Sessions)
+	}
+	if stats.Bytes != stats.Messages {
+		t.Errorf("Expected bytes %d to match single-byte messages %d", stats.Bytes, stats.Messages)
+	}
+}
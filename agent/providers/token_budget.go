@@ -0,0 +1,80 @@
+package providers
+
+import (
+	"sync"
+
+	"github.com/xichan96/cortex/agent/types"
+)
+
+// historyTokenBudget token cap of the chat history a memory provider returns, embedded by providers
+type historyTokenBudget struct {
+	tokenMu          sync.RWMutex
+	maxHistoryTokens int
+	tokenCounter     types.TokenCounter
+}
+
+// SetMaxHistoryTokens caps the estimated tokens of the returned chat history (0 means unlimited)
+// The oldest non-system messages are left out until the history fits, stored messages are kept
+func (b *historyTokenBudget) SetMaxHistoryTokens(limit int) {
+	b.tokenMu.Lock()
+	defer b.tokenMu.Unlock()
+	b.maxHistoryTokens = limit
+}
+
+// SetTokenCounter sets how message tokens are counted, nil restores types.HeuristicTokenCounter
+// AgentEngine.SetTokenCounter sets it on the engine's memory as well
+func (b *historyTokenBudget) SetTokenCounter(counter types.TokenCounter) {
+	b.tokenMu.Lock()
+	defer b.tokenMu.Unlock()
+	b.tokenCounter = counter
+}
+
+// fitHistory trims messages to the token budget
+func (b *historyTokenBudget) fitHistory(messages []types.Message) []types.Message {
+	b.tokenMu.RLock()
+	maxTokens, counter := b.maxHistoryTokens, b.tokenCounter
+	b.tokenMu.RUnlock()
+	if counter == nil {
+		counter = types.HeuristicTokenCounter{}
+	}
+	return trimToTokenBudget(messages, maxTokens, counter)
+}
+
+// trimToTokenBudget drops the oldest non-system messages until the estimated total fits maxTokens
+// Tool results whose assistant tool call was dropped go with it, so the history never starts with an
+// unanswerable tool message. System messages are always kept, maxTokens <= 0 keeps everything
+func trimToTokenBudget(messages []types.Message, maxTokens int, counter types.TokenCounter) []types.Message {
+	if maxTokens <= 0 || len(messages) == 0 {
+		return messages
+	}
+
+	counts := make([]int, len(messages))
+	total := 0
+	for i, msg := range messages {
+		counts[i] = counter.CountTokens(msg)
+		total += counts[i]
+	}
+	if total <= maxTokens {
+		return messages
+	}
+
+	dropped := make([]bool, len(messages))
+	for i, msg := range messages {
+		if total <= maxTokens && msg.Role != "tool" {
+			break
+		}
+		if msg.Role == "system" {
+			continue
+		}
+		dropped[i] = true
+		total -= counts[i]
+	}
+
+	kept := make([]types.Message, 0, len(messages))
+	for i, msg := range messages {
+		if !dropped[i] {
+			kept = append(kept, msg)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,75 @@
+package providers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/xichan96/cortex/agent/types"
+)
+
+func roles(messages []types.Message) []string {
+	out := make([]string, len(messages))
+	for i, msg := range messages {
+		out[i] = msg.Role + ":" + msg.Content
+	}
+	return out
+}
+
+func TestTrimToTokenBudget(t *testing.T) {
+	// Content length plus 2 per tool call, 16 in total
+	counter := types.TokenCounterFunc(func(m types.Message) int { return len(m.Content) + 2*len(m.ToolCalls) })
+	messages := []types.Message{
+		{Role: "system", Content: "sys"},
+		{Role: "user", Content: "aaaa"},
+		{Role: "assistant", Content: "", ToolCalls: []types.ToolCall{{ID: "1"}}},
+		{Role: "tool", Content: "r", ToolCallID: "1"},
+		{Role: "assistant", Content: "bbbb"},
+		{Role: "user", Content: "cc"},
+	}
+
+	tests := []struct {
+		maxTokens int
+		want      []string
+	}{
+		{0, roles(messages)},
+		{100, roles(messages)},
+		{12, []string{"system:sys", "assistant:", "tool:r", "assistant:bbbb", "user:cc"}},
+		// Dropping the tool call turn drops its result too
+		{10, []string{"system:sys", "assistant:bbbb", "user:cc"}},
+		{5, []string{"system:sys", "user:cc"}},
+		// System messages stay even when they alone exceed the budget
+		{1, []string{"system:sys"}},
+	}
+	for _, tt := range tests {
+		got := roles(trimToTokenBudget(messages, tt.maxTokens, counter))
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("maxTokens %d: got %v, want %v", tt.maxTokens, got, tt.want)
+		}
+	}
+}
+
+func TestSimpleMemoryMaxHistoryTokens(t *testing.T) {
+	p := NewSimpleMemoryProvider()
+	ctx := context.Background()
+	for _, content := range []string{"first message", "second message", "third"} {
+		p.AddMessage(ctx, types.Message{Role: "user", Content: content})
+	}
+
+	p.SetMaxHistoryTokens(5)
+	p.SetTokenCounter(types.TokenCounterFunc(func(m types.Message) int { return len(m.Content) }))
+	history, _ := p.GetChatHistory()
+	if got := roles(history); !reflect.DeepEqual(got, []string{"user:third"}) {
+		t.Errorf("history = %v, want only the newest message", got)
+	}
+
+	// Stored messages are kept for paging
+	if _, total, _ := p.GetMessagesPaged(0, 0); total != 3 {
+		t.Errorf("stored messages = %d, want 3", total)
+	}
+
+	p.SetMaxHistoryTokens(0)
+	if history, _ := p.GetChatHistory(); len(history) != 3 {
+		t.Errorf("history without budget = %d messages, want 3", len(history))
+	}
+}
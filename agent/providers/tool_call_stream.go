@@ -0,0 +1,130 @@
+package providers
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/xichan96/cortex/agent/types"
+)
+
+// toolCallDelta partial tool call as streamed by OpenAI-compatible providers
+// Index is only sent by some providers, continuation deltas carry neither ID nor Type
+type toolCallDelta struct {
+	Index    *int   `json:"index,omitempty"`
+	ID       string `json:"id,omitempty"`
+	Type     string `json:"type"`
+	Function *struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+// partialToolCall tool call being reconstructed from deltas
+type partialToolCall struct {
+	id        string
+	callType  string
+	name      string
+	arguments bytes.Buffer
+}
+
+// toolCallAccumulator reconstructs complete tool calls from streamed deltas
+type toolCallAccumulator struct {
+	calls   []*partialToolCall
+	indexes map[int]*partialToolCall
+}
+
+func newToolCallAccumulator() *toolCallAccumulator {
+	return &toolCallAccumulator{
+		indexes: make(map[int]*partialToolCall),
+	}
+}
+
+// add consumes a streamed chunk, returns false if the chunk is not a tool call delta
+func (a *toolCallAccumulator) add(chunk []byte) bool {
+	trimmed := bytes.TrimSpace(chunk)
+	if len(trimmed) < 2 || trimmed[0] != '[' || trimmed[1] != '{' {
+		return false
+	}
+	var deltas []toolCallDelta
+	if err := json.Unmarshal(trimmed, &deltas); err != nil || len(deltas) == 0 {
+		return false
+	}
+	for _, d := range deltas {
+		if d.Function == nil {
+			return false
+		}
+	}
+
+	for _, d := range deltas {
+		call := a.target(d)
+		if call == nil {
+			continue
+		}
+		if d.ID != "" {
+			call.id = d.ID
+		}
+		if d.Type != "" {
+			call.callType = d.Type
+		}
+		call.name += d.Function.Name
+		call.arguments.WriteString(d.Function.Arguments)
+	}
+	return true
+}
+
+// target finds the tool call a delta belongs to, starting a new one when needed
+func (a *toolCallAccumulator) target(d toolCallDelta) *partialToolCall {
+	if d.Index != nil {
+		if call, ok := a.indexes[*d.Index]; ok {
+			return call
+		}
+		call := &partialToolCall{}
+		a.indexes[*d.Index] = call
+		a.calls = append(a.calls, call)
+		return call
+	}
+
+	// Without an index, a delta carrying an ID or type starts a new call
+	// and anything else continues the most recent one
+	if d.ID != "" || d.Type != "" || len(a.calls) == 0 {
+		call := &partialToolCall{}
+		a.calls = append(a.calls, call)
+		return call
+	}
+	return a.calls[len(a.calls)-1]
+}
+
+// toolCalls returns the reconstructed tool calls
+func (a *toolCallAccumulator) toolCalls() []types.ToolCall {
+	toolCalls := make([]types.ToolCall, 0, len(a.calls))
+	for _, call := range a.calls {
+		if call.name == "" {
+			continue
+		}
+		callType := call.callType
+		if callType == "" {
+			callType = "function"
+		}
+		toolCalls = append(toolCalls, types.ToolCall{
+			ID:   call.id,
+			Type: callType,
+			Function: types.ToolFunction{
+				Name:      call.name,
+				Arguments: parseToolArguments(call.arguments.String()),
+			},
+		})
+	}
+	return toolCalls
+}
+
+// parseToolArguments decodes JSON tool arguments, returning an empty map for empty or invalid input
+func parseToolArguments(arguments string) map[string]interface{} {
+	args := make(map[string]interface{})
+	if arguments == "" {
+		return args
+	}
+	if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+		return make(map[string]interface{})
+	}
+	return args
+}
@@ -0,0 +1,116 @@
+package providers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tmc/langchaingo/llms"
+	"github.com/xichan96/cortex/agent/types"
+)
+
+// deltaStreamingModel streams preset chunks and returns a final response without tool calls
+type deltaStreamingModel struct {
+	chunks []string
+}
+
+func (m *deltaStreamingModel) GenerateContent(ctx context.Context, _ []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	opts := llms.CallOptions{}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	for _, chunk := range m.chunks {
+		if opts.StreamingFunc != nil {
+			if err := opts.StreamingFunc(ctx, []byte(chunk)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return &llms.ContentResponse{
+		Choices: []*llms.ContentChoice{{Content: ""}},
+	}, nil
+}
+
+func (m *deltaStreamingModel) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return "", nil
+}
+
+func collectStream(t *testing.T, stream <-chan types.StreamMessage) (string, []types.ToolCall) {
+	t.Helper()
+	var content string
+	var toolCalls []types.ToolCall
+	for msg := range stream {
+		switch msg.Type {
+		case "chunk":
+			content += msg.Content
+		case "tool_calls":
+			toolCalls = append(toolCalls, msg.ToolCalls...)
+		case "error":
+			t.Fatalf("Unexpected stream error: %s", msg.Error)
+		}
+	}
+	return content, toolCalls
+}
+
+func TestChatWithToolsStream_DeltaOnlyToolCalls(t *testing.T) {
+	model := &deltaStreamingModel{chunks: []string{
+		"Let me check.",
+		`[{"id":"call_1","type":"function","function":{"name":"get_time","arguments":""}}]`,
+		`[{"type":"","function":{"name":"","arguments":"{\"timezone\":"}}]`,
+		`[{"type":"","function":{"name":"","arguments":"\"UTC\"}"}}]`,
+		`[{"id":"call_2","type":"function","function":{"name":"ping","arguments":"{}"}}]`,
+	}}
+	provider := NewLangChainLLMProvider(model, "test-model")
+
+	stream, err := provider.ChatWithToolsStream([]types.Message{{Role: "user", Content: "time?"}}, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	content, toolCalls := collectStream(t, stream)
+
+	if content != "Let me check." {
+		t.Errorf("Expected tool call deltas to be excluded from content, got %q", content)
+	}
+	if len(toolCalls) != 2 {
+		t.Fatalf("Expected 2 tool calls, got %d", len(toolCalls))
+	}
+	if toolCalls[0].ID != "call_1" || toolCalls[0].Function.Name != "get_time" {
+		t.Errorf("Unexpected first tool call: %+v", toolCalls[0])
+	}
+	if toolCalls[0].Function.Arguments["timezone"] != "UTC" {
+		t.Errorf("Expected arguments to be reassembled, got %v", toolCalls[0].Function.Arguments)
+	}
+	if toolCalls[1].ID != "call_2" || toolCalls[1].Function.Name != "ping" {
+		t.Errorf("Unexpected second tool call: %+v", toolCalls[1])
+	}
+}
+
+func TestToolCallAccumulator_Indexed(t *testing.T) {
+	acc := newToolCallAccumulator()
+	chunks := []string{
+		`[{"index":0,"id":"a","type":"function","function":{"name":"first","arguments":"{\"x\":"}}]`,
+		`[{"index":1,"id":"b","type":"function","function":{"name":"second","arguments":"{}"}}]`,
+		`[{"index":0,"type":"","function":{"name":"","arguments":"1}"}}]`,
+	}
+	for _, chunk := range chunks {
+		if !acc.add([]byte(chunk)) {
+			t.Fatalf("Expected chunk to be recognized as a tool call delta: %s", chunk)
+		}
+	}
+
+	toolCalls := acc.toolCalls()
+	if len(toolCalls) != 2 {
+		t.Fatalf("Expected 2 tool calls, got %d", len(toolCalls))
+	}
+	if toolCalls[0].Function.Arguments["x"] != float64(1) {
+		t.Errorf("Expected interleaved arguments to be routed by index, got %v", toolCalls[0].Function.Arguments)
+	}
+}
+
+func TestToolCallAccumulator_IgnoresContent(t *testing.T) {
+	acc := newToolCallAccumulator()
+	for _, chunk := range []string{"hello", "[1, 2]", `[{"a":1}]`} {
+		if acc.add([]byte(chunk)) {
+			t.Errorf("Expected %q not to be treated as a tool call delta", chunk)
+		}
+	}
+}
@@ -0,0 +1,420 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/xichan96/cortex/agent/types"
+	"github.com/xichan96/cortex/pkg/logger"
+)
+
+// Vector memory defaults
+const (
+	DefaultVectorIndexBatchSize     = 32
+	DefaultVectorIndexRetryInterval = 30 * time.Second
+	DefaultVectorRecentMessages     = 10
+	DefaultVectorQueryTimeout       = 5 * time.Second
+)
+
+// VectorMemoryOptions vector memory indexing options
+type VectorMemoryOptions struct {
+	BatchSize     int           // messages embedded per request (default 32)
+	RetryInterval time.Duration // delay before retrying queued messages after an embedder failure (default 30s)
+
+	// RetrievalTopK similar messages GetChatHistory and LoadMemoryVariables add to the recent messages
+	// (0 returns the underlying history unchanged)
+	RetrievalTopK int
+	// RecentMessages most recent messages always returned when retrieval is enabled (default 10)
+	RecentMessages int
+	// QueryTimeout bound on embedding the retrieval query, after which only the recent messages are returned (default 5s)
+	QueryTimeout time.Duration
+}
+
+// VectorSearchResult message returned by semantic search
+type VectorSearchResult struct {
+	Message types.Message `json:"message"`
+	Score   float64       `json:"score"`
+}
+
+// VectorIndexStats indexing progress of a vector memory
+type VectorIndexStats struct {
+	Indexed int `json:"indexed"`
+	Pending int `json:"pending"`
+}
+
+// vectorEntry indexed message and its embedding
+type vectorEntry struct {
+	message types.Message
+	vector  []float32
+}
+
+// VectorMemoryProvider wraps a memory provider with a per-session vector index for semantic recall
+// Saved messages are queued and embedded in the background in batches, messages that fail to embed
+// stay queued and are retried, so the index stays current without re-embedding the history each turn
+type VectorMemoryProvider struct {
+	base     types.MemoryProvider
+	embedder types.Embedder
+	opts     VectorMemoryOptions
+	logger   *logger.Logger
+
+	mu         sync.RWMutex
+	entries    []vectorEntry
+	pending    []types.Message
+	generation int // bumped by Clear and Rebuild so in-flight batches are discarded
+
+	indexMu sync.Mutex // serializes embedding runs
+	notify  chan struct{}
+	cancel  context.CancelFunc
+}
+
+// NewVectorMemoryProvider creates a vector memory on top of base and starts its background indexer
+// Call Close to stop the indexer
+func NewVectorMemoryProvider(base types.MemoryProvider, embedder types.Embedder, opts VectorMemoryOptions) *VectorMemoryProvider {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultVectorIndexBatchSize
+	}
+	if opts.RetryInterval <= 0 {
+		opts.RetryInterval = DefaultVectorIndexRetryInterval
+	}
+	if opts.RecentMessages <= 0 {
+		opts.RecentMessages = DefaultVectorRecentMessages
+	}
+	if opts.QueryTimeout <= 0 {
+		opts.QueryTimeout = DefaultVectorQueryTimeout
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &VectorMemoryProvider{
+		base:     base,
+		embedder: embedder,
+		opts:     opts,
+		logger:   logger.NewLogger(),
+		notify:   make(chan struct{}, 1),
+		cancel:   cancel,
+	}
+	go p.background(ctx)
+	return p
+}
+
+// Close stops the background indexer
+func (p *VectorMemoryProvider) Close() {
+	p.cancel()
+}
+
+// LoadMemoryVariables loads memory variables from the underlying provider
+// With RetrievalTopK set, the history variable is replaced as in GetChatHistory
+func (p *VectorMemoryProvider) LoadMemoryVariables() (map[string]interface{}, error) {
+	vars, err := p.base.LoadMemoryVariables()
+	if err != nil || p.opts.RetrievalTopK <= 0 {
+		return vars, err
+	}
+	if history, ok := vars["history"].([]types.Message); ok {
+		vars["history"] = p.retrieve(history)
+	}
+	return vars, nil
+}
+
+// SaveContext saves the turn to the underlying provider and queues it for indexing
+func (p *VectorMemoryProvider) SaveContext(input, output map[string]interface{}) error {
+	if err := p.base.SaveContext(input, output); err != nil {
+		return err
+	}
+
+	messages := make([]types.Message, 0, 2)
+	if inputMsg, ok := input["input"].(string); ok && inputMsg != "" {
+		messages = append(messages, types.Message{Role: "user", Content: inputMsg})
+	}
+	if outputMsg, ok := output["output"].(string); ok && outputMsg != "" {
+		messages = append(messages, types.Message{Role: "assistant", Content: outputMsg})
+	}
+	if len(messages) == 0 {
+		return nil
+	}
+
+	p.mu.Lock()
+	p.pending = append(p.pending, messages...)
+	p.mu.Unlock()
+	p.signal()
+	return nil
+}
+
+// Clear clears the underlying provider and the index
+func (p *VectorMemoryProvider) Clear() error {
+	p.mu.Lock()
+	p.entries = nil
+	p.pending = nil
+	p.generation++
+	p.mu.Unlock()
+	return p.base.Clear()
+}
+
+// GetChatHistory gets chat history from the underlying provider
+// With RetrievalTopK set, it returns the RetrievalTopK indexed messages most similar to the latest
+// user turn, in chronological order, followed by the RecentMessages most recent messages
+func (p *VectorMemoryProvider) GetChatHistory() ([]types.Message, error) {
+	history, err := p.base.GetChatHistory()
+	if err != nil || p.opts.RetrievalTopK <= 0 {
+		return history, err
+	}
+	return p.retrieve(history), nil
+}
+
+// GetMessagesPaged gets a page of the stored messages from the underlying provider
+func (p *VectorMemoryProvider) GetMessagesPaged(offset, limit int) ([]types.Message, int, error) {
+	return types.PageMessages(p.base, offset, limit)
+}
+
+// CompressMemory compresses the underlying history
+// The index is kept, so compressed turns remain available to semantic search
+func (p *VectorMemoryProvider) CompressMemory(llm types.LLMProvider, maxMessages int) error {
+	return p.base.CompressMemory(llm, maxMessages)
+}
+
+// IndexPending embeds queued messages in batches
+// Messages that fail to embed stay queued and the embedder error is returned
+func (p *VectorMemoryProvider) IndexPending(ctx context.Context) error {
+	p.indexMu.Lock()
+	defer p.indexMu.Unlock()
+
+	for {
+		p.mu.RLock()
+		n := len(p.pending)
+		if n > p.opts.BatchSize {
+			n = p.opts.BatchSize
+		}
+		batch := append([]types.Message(nil), p.pending[:n]...)
+		generation := p.generation
+		p.mu.RUnlock()
+		if len(batch) == 0 {
+			return nil
+		}
+
+		entries, err := p.embed(ctx, batch)
+		if err != nil {
+			return err
+		}
+
+		p.mu.Lock()
+		if p.generation == generation {
+			p.pending = p.pending[len(batch):]
+			p.entries = append(p.entries, entries...)
+		}
+		p.mu.Unlock()
+	}
+}
+
+// Rebuild re-embeds the full history of the underlying provider and replaces the index
+// The existing index is kept if embedding fails
+func (p *VectorMemoryProvider) Rebuild(ctx context.Context) error {
+	p.indexMu.Lock()
+	defer p.indexMu.Unlock()
+
+	history, err := p.base.GetChatHistory()
+	if err != nil {
+		return fmt.Errorf("failed to load chat history: %w", err)
+	}
+
+	entries := make([]vectorEntry, 0, len(history))
+	for start := 0; start < len(history); start += p.opts.BatchSize {
+		end := start + p.opts.BatchSize
+		if end > len(history) {
+			end = len(history)
+		}
+		batch, err := p.embed(ctx, history[start:end])
+		if err != nil {
+			return err
+		}
+		entries = append(entries, batch...)
+	}
+
+	p.mu.Lock()
+	p.entries = entries
+	p.pending = nil
+	p.generation++
+	p.mu.Unlock()
+	return nil
+}
+
+// Search returns the k indexed messages most similar to query
+// Queued messages are left to the background indexer, call IndexPending first to include them
+func (p *VectorMemoryProvider) Search(ctx context.Context, query string, k int) ([]VectorSearchResult, error) {
+	vector, err := p.embedder.EmbedQuery(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed query: %w", err)
+	}
+
+	p.mu.RLock()
+	results := make([]VectorSearchResult, 0, len(p.entries))
+	for _, entry := range p.entries {
+		results = append(results, VectorSearchResult{
+			Message: entry.message,
+			Score:   cosineSimilarity(vector, entry.vector),
+		})
+	}
+	p.mu.RUnlock()
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	if k > 0 && len(results) > k {
+		results = results[:k]
+	}
+	return results, nil
+}
+
+// retrieve builds the history from the recent messages and the indexed messages relevant to the latest user turn
+// It runs on the prompt path, so it never waits for indexing: queued messages are found once the background
+// indexer embedded them. Without a user turn to search for, or when the query cannot be embedded within
+// QueryTimeout, only the recent messages are returned in recency order
+func (p *VectorMemoryProvider) retrieve(history []types.Message) []types.Message {
+	recent := history
+	if len(recent) > p.opts.RecentMessages {
+		recent = recent[len(recent)-p.opts.RecentMessages:]
+	}
+
+	query := ""
+	for i := len(history) - 1; i >= 0; i-- {
+		if history[i].Role == "user" && history[i].Content != "" {
+			query = history[i].Content
+			break
+		}
+	}
+	if query == "" {
+		return recent
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), p.opts.QueryTimeout)
+	defer cancel()
+	vector, err := p.embedder.EmbedQuery(ctx, query)
+	if err != nil {
+		p.logger.LogError("VectorMemoryProvider.retrieve", fmt.Errorf("failed to embed query: %w", err))
+		return recent
+	}
+
+	seen := make(map[string]bool, len(recent))
+	for _, msg := range recent {
+		seen[msg.Role+"\x00"+msg.Content] = true
+	}
+
+	type scored struct {
+		index int
+		score float64
+	}
+	p.mu.RLock()
+	candidates := make([]scored, 0, len(p.entries))
+	for i, entry := range p.entries {
+		key := entry.message.Role + "\x00" + entry.message.Content
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		if score := cosineSimilarity(vector, entry.vector); score > 0 {
+			candidates = append(candidates, scored{index: i, score: score})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].score > candidates[j].score
+	})
+	if len(candidates) > p.opts.RetrievalTopK {
+		candidates = candidates[:p.opts.RetrievalTopK]
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].index < candidates[j].index
+	})
+	messages := make([]types.Message, 0, len(candidates)+len(recent))
+	for _, c := range candidates {
+		messages = append(messages, p.entries[c.index].message)
+	}
+	p.mu.RUnlock()
+
+	return append(messages, recent...)
+}
+
+// Stats returns the number of indexed and queued messages
+func (p *VectorMemoryProvider) Stats() VectorIndexStats {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return VectorIndexStats{
+		Indexed: len(p.entries),
+		Pending: len(p.pending),
+	}
+}
+
+// embed embeds a batch of messages
+func (p *VectorMemoryProvider) embed(ctx context.Context, messages []types.Message) ([]vectorEntry, error) {
+	texts := make([]string, len(messages))
+	for i, msg := range messages {
+		texts[i] = msg.Content
+	}
+
+	vectors, err := p.embedder.EmbedDocuments(ctx, texts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed messages: %w", err)
+	}
+	if len(vectors) != len(messages) {
+		return nil, fmt.Errorf("embedder returned %d vectors for %d messages", len(vectors), len(messages))
+	}
+
+	entries := make([]vectorEntry, len(messages))
+	for i, msg := range messages {
+		entries[i] = vectorEntry{message: msg, vector: vectors[i]}
+	}
+	return entries, nil
+}
+
+// signal wakes up the background indexer without blocking
+func (p *VectorMemoryProvider) signal() {
+	select {
+	case p.notify <- struct{}{}:
+	default:
+	}
+}
+
+// background indexes queued messages as they arrive and retries after embedder failures
+func (p *VectorMemoryProvider) background(ctx context.Context) {
+	defer func() {
+		if r := recover(); r != nil {
+			p.logger.LogError("VectorMemoryProvider.background", fmt.Errorf("panic: %v", r))
+		}
+	}()
+
+	var retry <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-p.notify:
+		case <-retry:
+		}
+
+		retry = nil
+		if err := p.IndexPending(ctx); err != nil {
+			p.logger.LogError("VectorMemoryProvider.IndexPending", err,
+				slog.Int("pending", p.Stats().Pending),
+				slog.Duration("retry_in", p.opts.RetryInterval))
+			retry = time.After(p.opts.RetryInterval)
+		}
+	}
+}
+
+// cosineSimilarity returns the cosine similarity of two vectors, 0 if they are incompatible
+func cosineSimilarity(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
@@ -0,0 +1,222 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/xichan96/cortex/agent/types"
+)
+
+// keywordEmbedder embeds texts by counting a fixed vocabulary, failing while unavailable is set
+type keywordEmbedder struct {
+	mu            sync.Mutex
+	unavailable   bool
+	documentCalls int
+}
+
+var embedVocabulary = []string{"weather", "rain", "deploy", "server"}
+
+func (e *keywordEmbedder) vector(text string) []float32 {
+	v := make([]float32, len(embedVocabulary))
+	for i, word := range embedVocabulary {
+		v[i] = float32(strings.Count(strings.ToLower(text), word))
+	}
+	return v
+}
+
+func (e *keywordEmbedder) EmbedDocuments(ctx context.Context, texts []string) ([][]float32, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.documentCalls++
+	if e.unavailable {
+		return nil, errors.New("embedder unavailable")
+	}
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vectors[i] = e.vector(text)
+	}
+	return vectors, nil
+}
+
+func (e *keywordEmbedder) EmbedQuery(ctx context.Context, text string) ([]float32, error) {
+	return e.vector(text), nil
+}
+
+func (e *keywordEmbedder) setUnavailable(v bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.unavailable = v
+}
+
+func waitForIndex(t *testing.T, p *VectorMemoryProvider, indexed int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if p.Stats().Indexed == indexed {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("Expected %d indexed messages, got %+v", indexed, p.Stats())
+}
+
+func TestVectorMemoryProvider_IncrementalIndex(t *testing.T) {
+	embedder := &keywordEmbedder{}
+	p := NewVectorMemoryProvider(NewSimpleMemoryProvider(), embedder, VectorMemoryOptions{})
+	defer p.Close()
+
+	_ = p.SaveContext(map[string]interface{}{"input": "will it rain today?"}, map[string]interface{}{"output": "the weather forecast says rain"})
+	_ = p.SaveContext(map[string]interface{}{"input": "deploy the server"}, map[string]interface{}{"output": "server deploy started"})
+	waitForIndex(t, p, 4)
+
+	results, err := p.Search(context.Background(), "deploy server", 2)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(results))
+	}
+	for _, r := range results {
+		if !strings.Contains(r.Message.Content, "deploy") {
+			t.Errorf("Expected deploy-related result, got %q", r.Message.Content)
+		}
+	}
+}
+
+func TestVectorMemoryProvider_RetryWhenUnavailable(t *testing.T) {
+	embedder := &keywordEmbedder{unavailable: true}
+	p := NewVectorMemoryProvider(NewSimpleMemoryProvider(), embedder, VectorMemoryOptions{RetryInterval: 10 * time.Millisecond})
+	defer p.Close()
+
+	_ = p.SaveContext(map[string]interface{}{"input": "rain?"}, map[string]interface{}{"output": "no rain"})
+	time.Sleep(30 * time.Millisecond)
+	if stats := p.Stats(); stats.Indexed != 0 || stats.Pending != 2 {
+		t.Fatalf("Expected messages to stay queued, got %+v", stats)
+	}
+
+	embedder.setUnavailable(false)
+	waitForIndex(t, p, 2)
+	if p.Stats().Pending != 0 {
+		t.Errorf("Expected empty queue, got %+v", p.Stats())
+	}
+}
+
+func TestVectorMemoryProvider_Rebuild(t *testing.T) {
+	base := NewSimpleMemoryProvider()
+	_ = base.SaveContext(map[string]interface{}{"input": "weather?"}, map[string]interface{}{"output": "sunny weather"})
+
+	p := NewVectorMemoryProvider(base, &keywordEmbedder{}, VectorMemoryOptions{BatchSize: 1})
+	defer p.Close()
+	if p.Stats().Indexed != 0 {
+		t.Fatalf("Expected existing history not to be indexed before Rebuild")
+	}
+
+	if err := p.Rebuild(context.Background()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if stats := p.Stats(); stats.Indexed != 2 || stats.Pending != 0 {
+		t.Errorf("Expected 2 indexed messages after rebuild, got %+v", stats)
+	}
+
+	_ = p.Clear()
+	if stats := p.Stats(); stats.Indexed != 0 {
+		t.Errorf("Expected empty index after Clear, got %+v", stats)
+	}
+}
+
+func TestVectorMemoryProvider_RetrievedHistory(t *testing.T) {
+	p := NewVectorMemoryProvider(NewSimpleMemoryProvider(), &keywordEmbedder{}, VectorMemoryOptions{RetrievalTopK: 1, RecentMessages: 2})
+	defer p.Close()
+
+	_ = p.SaveContext(map[string]interface{}{"input": "deploy the server"}, map[string]interface{}{"output": "server deploy started"})
+	_ = p.SaveContext(map[string]interface{}{"input": "will it rain?"}, map[string]interface{}{"output": "rain expected"})
+	_ = p.SaveContext(map[string]interface{}{"input": "hello"}, map[string]interface{}{"output": "hi"})
+	_ = p.SaveContext(map[string]interface{}{"input": "is the server deploy done?"}, map[string]interface{}{"output": "yes"})
+	waitForIndex(t, p, 8)
+
+	history, err := p.GetChatHistory()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := []string{"deploy the server", "is the server deploy done?", "yes"}
+	if len(history) != len(want) {
+		t.Fatalf("Expected %d messages, got %+v", len(want), history)
+	}
+	for i, content := range want {
+		if history[i].Content != content {
+			t.Errorf("message %d = %q, want %q", i, history[i].Content, content)
+		}
+	}
+
+	vars, err := p.LoadMemoryVariables()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if loaded, _ := vars["history"].([]types.Message); len(loaded) != len(want) {
+		t.Errorf("Expected LoadMemoryVariables to return the retrieved history, got %+v", vars["history"])
+	}
+}
+
+func TestVectorMemoryProvider_RetrievalFallsBackToRecency(t *testing.T) {
+	base := NewSimpleMemoryProvider()
+	base.AddMessage(context.Background(), types.Message{Role: "assistant", Content: "deploy the server"})
+	base.AddMessage(context.Background(), types.Message{Role: "assistant", Content: "weather is fine"})
+	base.AddMessage(context.Background(), types.Message{Role: "assistant", Content: "rain later"})
+
+	p := NewVectorMemoryProvider(base, &keywordEmbedder{}, VectorMemoryOptions{RetrievalTopK: 2, RecentMessages: 2})
+	defer p.Close()
+
+	history, err := p.GetChatHistory()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(history) != 2 || history[0].Content != "weather is fine" || history[1].Content != "rain later" {
+		t.Errorf("Expected the 2 most recent messages without a user turn, got %+v", history)
+	}
+}
+
+func TestVectorMemoryProvider_RetrievalDoesNotIndex(t *testing.T) {
+	embedder := &keywordEmbedder{unavailable: true}
+	p := NewVectorMemoryProvider(NewSimpleMemoryProvider(), embedder, VectorMemoryOptions{RetrievalTopK: 1, RecentMessages: 2, RetryInterval: time.Hour})
+	defer p.Close()
+
+	_ = p.SaveContext(map[string]interface{}{"input": "deploy the server"}, map[string]interface{}{"output": "server deploy started"})
+	_ = p.SaveContext(map[string]interface{}{"input": "is the server deploy done?"}, map[string]interface{}{"output": "yes"})
+	// Wait for the background indexer to fail its attempt and back off
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		embedder.mu.Lock()
+		calls := embedder.documentCalls
+		embedder.mu.Unlock()
+		if calls > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("background indexer did not run")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	embedder.mu.Lock()
+	before := embedder.documentCalls
+	embedder.mu.Unlock()
+
+	for i := 0; i < 3; i++ {
+		history, err := p.GetChatHistory()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(history) != 2 || history[1].Content != "yes" {
+			t.Errorf("Expected the recent messages while nothing is indexed, got %+v", history)
+		}
+	}
+	embedder.mu.Lock()
+	defer embedder.mu.Unlock()
+	if embedder.documentCalls != before {
+		t.Errorf("GetChatHistory embedded queued messages: %d embedding calls, want %d", embedder.documentCalls, before)
+	}
+}
@@ -3,20 +3,155 @@ package builtin
 import (
 	"context"
 	"fmt"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/xichan96/cortex/agent/types"
 	"github.com/xichan96/cortex/pkg/errors"
 )
 
-type CommandTool struct{}
+// Command result statuses
+const (
+	CommandStatusExited      = "exited"       // process exited normally, exit_code is set
+	CommandStatusSignaled    = "signaled"     // process was killed by a signal (e.g. OOM killer), signal is set
+	CommandStatusTimeout     = "timeout"      // process was killed after exceeding the timeout
+	CommandStatusStartFailed = "start_failed" // process could not be started (e.g. command not found)
+)
+
+// commandDeniedEnv environment variables the model may not set, they make the loader, shell or an
+// interpreter run other code than the permitted program. Names starting with commandDeniedEnvPrefixes are denied too
+var commandDeniedEnv = []string{
+	"PATH", "IFS", "ENV", "BASH_ENV", "SHELLOPTS", "BASHOPTS", "PS4", "PROMPT_COMMAND",
+	"PYTHONPATH", "PYTHONSTARTUP", "PYTHONHOME", "PERL5LIB", "PERL5OPT", "PERLLIB", "RUBYOPT", "RUBYLIB",
+	"NODE_OPTIONS", "NODE_PATH", "JAVA_TOOL_OPTIONS", "_JAVA_OPTIONS", "CLASSPATH",
+	"GIT_SSH", "GIT_SSH_COMMAND", "GIT_EXEC_PATH", "GIT_CONFIG_GLOBAL", "GIT_CONFIG_SYSTEM", "GIT_ASKPASS", "SSH_ASKPASS", "EDITOR", "VISUAL", "PAGER",
+}
 
-func NewCommandTool() types.Tool {
+var commandDeniedEnvPrefixes = []string{"LD_", "DYLD_", "GCONV_", "MALLOC_", "GIT_CONFIG_"}
+
+type CommandTool struct {
+	mu              sync.RWMutex
+	allowedCommands []string // empty allows every command
+	deniedCommands  []string
+	allowedEnv      []string // empty allows every variable except commandDeniedEnv
+}
+
+func NewCommandTool() *CommandTool {
 	return &CommandTool{}
 }
 
+// SetAllowedCommands restricts execution to the given programs, an empty list allows every command
+// The program (the first word of the command) is resolved to an absolute path through PATH. Entries
+// with a slash match that path, bare names match the program PATH resolves them to; so "git" allows
+// /usr/bin/git but not ./git. Other commands fail with EC_PERMISSION_DENIED
+func (t *CommandTool) SetAllowedCommands(commands []string) {
+	allowed := normalizeCommandList(commands)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.allowedCommands = allowed
+}
+
+// SetDeniedCommands rejects the given programs with EC_PERMISSION_DENIED, even when they are allowed
+// Entries match like SetAllowedCommands, bare names also match any program with that file name
+func (t *CommandTool) SetDeniedCommands(commands []string) {
+	denied := normalizeCommandList(commands)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.deniedCommands = denied
+}
+
+// SetAllowedEnv restricts the env parameter to the given variable names, an empty list allows every
+// variable except those that change which code runs (PATH, LD_PRELOAD, ...), which are always rejected
+func (t *CommandTool) SetAllowedEnv(names []string) {
+	allowed := normalizeCommandList(names)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.allowedEnv = allowed
+}
+
+// normalizeCommandList trims entries and drops empty ones
+func normalizeCommandList(commands []string) []string {
+	result := make([]string, 0, len(commands))
+	for _, command := range commands {
+		command = strings.TrimSpace(command)
+		if command != "" {
+			result = append(result, command)
+		}
+	}
+	return result
+}
+
+// resolveCommand returns the absolute path of the program, looked up in PATH when it is a bare name
+// Relative paths are resolved against dir, the directory the command runs in (empty for the current one)
+func resolveCommand(program, dir string) (string, error) {
+	if strings.Contains(program, "/") && !filepath.IsAbs(program) && dir != "" {
+		program = filepath.Join(dir, program)
+	}
+	path, err := exec.LookPath(program)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Abs(path)
+}
+
+// commandEntryMatches reports whether a list entry names the resolved program
+func commandEntryMatches(entry, resolved string) bool {
+	if strings.Contains(entry, "/") {
+		path, err := filepath.Abs(entry)
+		return err == nil && path == resolved
+	}
+	path, err := resolveCommand(entry, "")
+	return err == nil && path == resolved
+}
+
+// commandPermitted reports whether a program, resolved to resolved, passes the allowlist and denylist
+// resolved is empty for programs that could not be resolved, which only pass without an allowlist
+func (t *CommandTool) commandPermitted(program, resolved string) bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	for _, entry := range t.deniedCommands {
+		if entry == filepath.Base(program) || entry == program || (resolved != "" && commandEntryMatches(entry, resolved)) {
+			return false
+		}
+	}
+	if len(t.allowedCommands) == 0 {
+		return true
+	}
+	if resolved == "" {
+		return false
+	}
+	return slices.ContainsFunc(t.allowedCommands, func(entry string) bool {
+		return commandEntryMatches(entry, resolved)
+	})
+}
+
+// envPermitted reports whether the model may set the environment variable name
+func (t *CommandTool) envPermitted(name string) bool {
+	if slices.Contains(commandDeniedEnv, name) {
+		return false
+	}
+	for _, prefix := range commandDeniedEnvPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return false
+		}
+	}
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return len(t.allowedEnv) == 0 || slices.Contains(t.allowedEnv, name)
+}
+
 func (t *CommandTool) Name() string {
 	return "command"
 }
@@ -37,6 +172,15 @@ func (t *CommandTool) Schema() map[string]interface{} {
 				"type":        "integer",
 				"description": "Command execution timeout in seconds (default: 30)",
 			},
+			"working_dir": map[string]interface{}{
+				"type":        "string",
+				"description": "Existing directory to run the command in (default: the server's working directory)",
+			},
+			"env": map[string]interface{}{
+				"type":                 "object",
+				"description":          "Environment variables added to the server's environment, e.g. {\"LANG\": \"C\"}",
+				"additionalProperties": map[string]interface{}{"type": "string"},
+			},
 		},
 		"required": []string{"command"},
 	}
@@ -58,45 +202,132 @@ func (t *CommandTool) Execute(input map[string]interface{}) (interface{}, error)
 		timeout = time.Duration(timeoutVal) * time.Second
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
-	defer cancel()
-
 	parts := strings.Fields(command)
 	if len(parts) == 0 {
 		return nil, errors.EC_TOOL_PARAMETER_INVALID.Wrap(fmt.Errorf("invalid 'command' parameter: command cannot be empty"))
 	}
+	workingDir, err := commandWorkingDir(input)
+	if err != nil {
+		return nil, err
+	}
+
+	// The resolved path is executed, so the program that was checked is the one that runs
+	program, err := resolveCommand(parts[0], workingDir)
+	if err != nil {
+		program = ""
+	}
+	if !t.commandPermitted(parts[0], program) {
+		return nil, errors.EC_PERMISSION_DENIED.Wrap(fmt.Errorf("command %q is not allowed", parts[0]))
+	}
+	if program == "" {
+		// Not found, running it reports the start failure
+		program = parts[0]
+	}
+	env, err := commandEnv(input, t.envPermitted)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
 
-	cmd := exec.CommandContext(ctx, parts[0], parts[1:]...)
+	cmd := exec.CommandContext(ctx, program, parts[1:]...)
+	cmd.Dir = workingDir
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
 
 	var stdout, stderr strings.Builder
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
 
-	err := cmd.Run()
+	err = cmd.Run()
 
-	output := stdout.String()
-	errOutput := stderr.String()
+	result := map[string]interface{}{
+		"command": command,
+		"stdout":  stdout.String(),
+		"stderr":  stderr.String(),
+	}
+	if workingDir != "" {
+		result["working_dir"] = workingDir
+	}
+	if err != nil {
+		result["error"] = err.Error()
+	}
 
-	if ctx.Err() == context.DeadlineExceeded {
-		return nil, errors.EC_TOOL_EXECUTION_TIMEOUT.Wrap(fmt.Errorf("command execution timeout after %v", timeout))
+	switch {
+	case ctx.Err() == context.DeadlineExceeded:
+		result["status"] = CommandStatusTimeout
+		result["timed_out"] = true
+		result["timeout_seconds"] = timeout.Seconds()
+	case cmd.ProcessState == nil:
+		result["status"] = CommandStatusStartFailed
+	default:
+		if ws, ok := cmd.ProcessState.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+			result["status"] = CommandStatusSignaled
+			result["signal"] = ws.Signal().String()
+			result["signal_number"] = int(ws.Signal())
+			break
+		}
+		result["status"] = CommandStatusExited
+		result["exit_code"] = cmd.ProcessState.ExitCode()
 	}
+	return result, nil
+}
 
+// commandWorkingDir returns the optional working_dir parameter, which must be an existing directory
+func commandWorkingDir(input map[string]interface{}) (string, error) {
+	value, ok := input["working_dir"]
+	if !ok || value == nil {
+		return "", nil
+	}
+	dir, ok := value.(string)
+	if !ok {
+		return "", errors.EC_TOOL_PARAMETER_INVALID.Wrap(fmt.Errorf("invalid 'working_dir' parameter: must be a string"))
+	}
+	if dir == "" {
+		return "", nil
+	}
+	info, err := os.Stat(dir)
 	if err != nil {
-		return map[string]interface{}{
-			"command":   command,
-			"exit_code": cmd.ProcessState.ExitCode(),
-			"stdout":    output,
-			"stderr":    errOutput,
-			"error":     err.Error(),
-		}, nil
+		return "", errors.EC_TOOL_PARAMETER_INVALID.Wrap(fmt.Errorf("invalid 'working_dir' parameter: %w", err))
+	}
+	if !info.IsDir() {
+		return "", errors.EC_TOOL_PARAMETER_INVALID.Wrap(fmt.Errorf("invalid 'working_dir' parameter: %s is not a directory", dir))
 	}
+	return dir, nil
+}
 
-	return map[string]interface{}{
-		"command":   command,
-		"exit_code": 0,
-		"stdout":    output,
-		"stderr":    errOutput,
-	}, nil
+// commandEnv returns the optional env parameter as sorted KEY=value entries
+// Variables rejected by permitted fail with EC_PERMISSION_DENIED
+func commandEnv(input map[string]interface{}, permitted func(string) bool) ([]string, error) {
+	value, ok := input["env"]
+	if !ok || value == nil {
+		return nil, nil
+	}
+	vars, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, errors.EC_TOOL_PARAMETER_INVALID.Wrap(fmt.Errorf("invalid 'env' parameter: must be an object"))
+	}
+	env := make([]string, 0, len(vars))
+	for key, val := range vars {
+		if key == "" || strings.ContainsAny(key, "=\x00") {
+			return nil, errors.EC_TOOL_PARAMETER_INVALID.Wrap(fmt.Errorf("invalid 'env' parameter: invalid variable name %q", key))
+		}
+		if !permitted(key) {
+			return nil, errors.EC_PERMISSION_DENIED.Wrap(fmt.Errorf("environment variable %q is not allowed", key))
+		}
+		switch v := val.(type) {
+		case string:
+			env = append(env, key+"="+v)
+		case float64, int, bool:
+			env = append(env, fmt.Sprintf("%s=%v", key, v))
+		default:
+			return nil, errors.EC_TOOL_PARAMETER_INVALID.Wrap(fmt.Errorf("invalid 'env' parameter: value of %s must be a string", key))
+		}
+	}
+	sort.Strings(env)
+	return env, nil
 }
 
 func (t *CommandTool) Metadata() types.ToolMetadata {
@@ -1,6 +1,9 @@
 package builtin
 
 import (
+	"os"
+	"os/exec"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -124,6 +127,10 @@ func TestCommandTool_Execute_CommandFailure(t *testing.T) {
 		t.Error("Expected non-zero exit code for failed command")
 	}
 
+	if resultMap["status"] != CommandStatusExited {
+		t.Errorf("Expected status %q, got %v", CommandStatusExited, resultMap["status"])
+	}
+
 	if _, ok := resultMap["error"]; !ok {
 		t.Error("Result should contain 'error' field for failed command")
 	}
@@ -227,18 +234,74 @@ func TestCommandTool_Execute_Timeout(t *testing.T) {
 		"timeout": float64(1),
 	}
 
-	_, err := tool.Execute(input)
-	if err == nil {
-		t.Fatal("Execute should return error for timeout")
+	result, err := tool.Execute(input)
+	if err != nil {
+		t.Fatalf("Execute should not return error for timeout: %v", err)
 	}
 
-	errObj, ok := err.(*errors.Error)
+	resultMap, ok := result.(map[string]interface{})
 	if !ok {
-		t.Fatalf("Expected *errors.Error, got %T", err)
+		t.Fatal("Result should be a map")
+	}
+
+	if resultMap["status"] != CommandStatusTimeout || resultMap["timed_out"] != true {
+		t.Errorf("Expected timeout status, got %v", resultMap)
+	}
+
+	if _, ok := resultMap["exit_code"]; ok {
+		t.Error("exit_code should not be set for a timed out command")
+	}
+}
+
+func TestCommandTool_Execute_Signaled(t *testing.T) {
+	tool := NewCommandTool()
+
+	input := map[string]interface{}{
+		"command": "sh -c kill${IFS}-9${IFS}$$",
+	}
+
+	result, err := tool.Execute(input)
+	if err != nil {
+		t.Fatalf("Execute should not return error for killed command: %v", err)
 	}
 
-	if errObj.Code != errors.EC_TOOL_EXECUTION_TIMEOUT.Code {
-		t.Errorf("Expected error code %d, got %d", errors.EC_TOOL_EXECUTION_TIMEOUT.Code, errObj.Code)
+	resultMap, ok := result.(map[string]interface{})
+	if !ok {
+		t.Fatal("Result should be a map")
+	}
+
+	if resultMap["status"] != CommandStatusSignaled {
+		t.Errorf("Expected status %q, got %v", CommandStatusSignaled, resultMap["status"])
+	}
+
+	if resultMap["signal"] != "killed" || resultMap["signal_number"] != 9 {
+		t.Errorf("Expected SIGKILL, got %v (%v)", resultMap["signal"], resultMap["signal_number"])
+	}
+
+	if _, ok := resultMap["exit_code"]; ok {
+		t.Error("exit_code should not be set for a signaled command")
+	}
+}
+
+func TestCommandTool_Execute_StartFailed(t *testing.T) {
+	tool := NewCommandTool()
+
+	input := map[string]interface{}{
+		"command": "cortex-command-that-does-not-exist",
+	}
+
+	result, err := tool.Execute(input)
+	if err != nil {
+		t.Fatalf("Execute should not return error for missing command: %v", err)
+	}
+
+	resultMap, ok := result.(map[string]interface{})
+	if !ok {
+		t.Fatal("Result should be a map")
+	}
+
+	if resultMap["status"] != CommandStatusStartFailed || resultMap["error"] == nil {
+		t.Errorf("Expected start_failed status with an error, got %v", resultMap)
 	}
 }
 
@@ -314,3 +377,116 @@ func TestCommandTool_Execute_Stderr(t *testing.T) {
 		t.Error("stderr should not be empty")
 	}
 }
+
+func TestCommandTool_Execute_WorkingDirAndEnv(t *testing.T) {
+	tool := NewCommandTool()
+	dir := t.TempDir()
+
+	result, err := tool.Execute(map[string]interface{}{
+		"command":     "pwd",
+		"working_dir": dir,
+	})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	resultMap := result.(map[string]interface{})
+	if resultMap["stdout"].(string) != dir+"\n" {
+		t.Errorf("Expected stdout %q, got %q", dir+"\n", resultMap["stdout"])
+	}
+	if resultMap["working_dir"] != dir {
+		t.Errorf("Expected working_dir %q, got %v", dir, resultMap["working_dir"])
+	}
+
+	result, err = tool.Execute(map[string]interface{}{
+		"command": "printenv CORTEX_TEST_VAR",
+		"env":     map[string]interface{}{"CORTEX_TEST_VAR": "hello"},
+	})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if stdout := result.(map[string]interface{})["stdout"].(string); stdout != "hello\n" {
+		t.Errorf("Expected stdout 'hello\\n', got %q", stdout)
+	}
+}
+
+func TestCommandTool_Execute_InvalidWorkingDir(t *testing.T) {
+	tool := NewCommandTool()
+
+	_, err := tool.Execute(map[string]interface{}{
+		"command":     "pwd",
+		"working_dir": "/nonexistent/cortex/dir",
+	})
+	if e, ok := err.(*errors.Error); !ok || e.Code != errors.EC_TOOL_PARAMETER_INVALID.Code {
+		t.Errorf("Expected EC_TOOL_PARAMETER_INVALID, got %v", err)
+	}
+}
+
+func TestCommandTool_AllowedAndDeniedCommands(t *testing.T) {
+	tool := NewCommandTool()
+	tool.SetAllowedCommands([]string{"echo", "/bin/ls"})
+	tool.SetDeniedCommands([]string{"ls"})
+
+	if _, err := tool.Execute(map[string]interface{}{"command": "echo ok"}); err != nil {
+		t.Errorf("allowed command failed: %v", err)
+	}
+	for _, command := range []string{"pwd", "/bin/ls", "ls -l"} {
+		_, err := tool.Execute(map[string]interface{}{"command": command})
+		if e, ok := err.(*errors.Error); !ok || e.Code != errors.EC_PERMISSION_DENIED.Code {
+			t.Errorf("%q: expected EC_PERMISSION_DENIED, got %v", command, err)
+		}
+	}
+}
+
+func TestCommandTool_AllowedCommandsResolvePath(t *testing.T) {
+	echo, err := exec.LookPath("echo")
+	if err != nil {
+		t.Skip("echo not found in PATH")
+	}
+	dir := t.TempDir()
+	data, err := os.ReadFile(echo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A binary the agent could write under an allowed name
+	if err := os.WriteFile(filepath.Join(dir, "echo"), data, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	tool := NewCommandTool()
+	tool.SetAllowedCommands([]string{"echo"})
+	if _, err := tool.Execute(map[string]interface{}{"command": "echo ok"}); err != nil {
+		t.Errorf("allowed command failed: %v", err)
+	}
+	if _, err := tool.Execute(map[string]interface{}{"command": echo + " ok"}); err != nil {
+		t.Errorf("allowed command by its resolved path failed: %v", err)
+	}
+	for _, command := range []string{filepath.Join(dir, "echo") + " ok", "./echo ok"} {
+		input := map[string]interface{}{"command": command, "working_dir": dir}
+		_, err := tool.Execute(input)
+		if e, ok := err.(*errors.Error); !ok || e.Code != errors.EC_PERMISSION_DENIED.Code {
+			t.Errorf("%q: expected EC_PERMISSION_DENIED, got %v", command, err)
+		}
+	}
+}
+
+func TestCommandTool_EnvRestrictions(t *testing.T) {
+	tool := NewCommandTool()
+	for _, name := range []string{"LD_PRELOAD", "PATH", "DYLD_INSERT_LIBRARIES", "BASH_ENV", "GIT_SSH_COMMAND"} {
+		_, err := tool.Execute(map[string]interface{}{
+			"command": "printenv",
+			"env":     map[string]interface{}{name: "/tmp/x"},
+		})
+		if e, ok := err.(*errors.Error); !ok || e.Code != errors.EC_PERMISSION_DENIED.Code {
+			t.Errorf("%s: expected EC_PERMISSION_DENIED, got %v", name, err)
+		}
+	}
+
+	tool.SetAllowedEnv([]string{"LANG"})
+	if _, err := tool.Execute(map[string]interface{}{"command": "printenv", "env": map[string]interface{}{"LANG": "C"}}); err != nil {
+		t.Errorf("allowed variable failed: %v", err)
+	}
+	_, err := tool.Execute(map[string]interface{}{"command": "printenv", "env": map[string]interface{}{"CORTEX_TEST_VAR": "x"}})
+	if e, ok := err.(*errors.Error); !ok || e.Code != errors.EC_PERMISSION_DENIED.Code {
+		t.Errorf("expected EC_PERMISSION_DENIED for a variable outside the allowlist, got %v", err)
+	}
+}
@@ -0,0 +1,259 @@
+package builtin
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/xichan96/cortex/agent/types"
+	"github.com/xichan96/cortex/pkg/errors"
+)
+
+const (
+	diffMaxInputSize = 100 * 1024 // maximum size of each input in bytes
+	diffMaxLines     = 2000       // maximum number of lines of each input
+	diffContextLines = 3          // context lines around changes in unified format
+)
+
+// diffOp single line-level edit operation
+type diffOp struct {
+	kind byte // ' ' unchanged, '-' removed, '+' added
+	line string
+}
+
+type DiffTool struct{}
+
+func NewDiffTool() types.Tool {
+	return &DiffTool{}
+}
+
+func (t *DiffTool) Name() string {
+	return "diff"
+}
+
+func (t *DiffTool) Description() string {
+	return "Compare two texts line by line and return a unified or inline diff with added/removed line counts. Useful for comparing configs or logs before and after a change."
+}
+
+func (t *DiffTool) Schema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"a": map[string]interface{}{
+				"type":        "string",
+				"description": "Original text",
+			},
+			"b": map[string]interface{}{
+				"type":        "string",
+				"description": "Modified text",
+			},
+			"format": map[string]interface{}{
+				"type":        "string",
+				"description": "Output format: 'unified' shows changed hunks with context, 'inline' shows every line",
+				"enum":        []string{"unified", "inline"},
+				"default":     "unified",
+			},
+		},
+		"required": []string{"a", "b"},
+	}
+}
+
+func (t *DiffTool) Execute(input map[string]interface{}) (interface{}, error) {
+	a, ok := input["a"].(string)
+	if !ok {
+		return nil, errors.EC_PARAMETER_MISSING.Wrap(fmt.Errorf("'a' parameter is required"))
+	}
+	b, ok := input["b"].(string)
+	if !ok {
+		return nil, errors.EC_PARAMETER_MISSING.Wrap(fmt.Errorf("'b' parameter is required"))
+	}
+	format := "unified"
+	if f, ok := input["format"].(string); ok && f != "" {
+		format = f
+	}
+	if format != "unified" && format != "inline" {
+		return nil, errors.EC_PARAMETER_INVALID.Wrap(fmt.Errorf("invalid format '%s', must be 'unified' or 'inline'", format))
+	}
+
+	if len(a) > diffMaxInputSize || len(b) > diffMaxInputSize {
+		return nil, errors.EC_PARAMETER_INVALID.Wrap(fmt.Errorf("input too large, each text must be at most %d bytes", diffMaxInputSize))
+	}
+	linesA := splitDiffLines(a)
+	linesB := splitDiffLines(b)
+	if len(linesA) > diffMaxLines || len(linesB) > diffMaxLines {
+		return nil, errors.EC_PARAMETER_INVALID.Wrap(fmt.Errorf("input too large, each text must have at most %d lines", diffMaxLines))
+	}
+
+	ops := diffLines(linesA, linesB)
+	added, removed := 0, 0
+	for _, op := range ops {
+		switch op.kind {
+		case '+':
+			added++
+		case '-':
+			removed++
+		}
+	}
+
+	var diff string
+	if added > 0 || removed > 0 {
+		if format == "inline" {
+			diff = formatInlineDiff(ops)
+		} else {
+			diff = formatUnifiedDiff(ops)
+		}
+	}
+
+	return map[string]interface{}{
+		"format":    format,
+		"diff":      diff,
+		"added":     added,
+		"removed":   removed,
+		"identical": added == 0 && removed == 0,
+	}, nil
+}
+
+func (t *DiffTool) Metadata() types.ToolMetadata {
+	return types.ToolMetadata{
+		SourceNodeName: "diff",
+		IsFromToolkit:  false,
+		ToolType:       "builtin",
+	}
+}
+
+// splitDiffLines splits text into lines, ignoring a single trailing newline
+func splitDiffLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// diffLines computes a line-level edit script using the longest common subsequence
+func diffLines(a, b []string) []diffOp {
+	n, m := len(a), len(b)
+	// lcs[i][j] is the LCS length of a[i:] and b[j:]
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	ops := make([]diffOp, 0, n+m)
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, diffOp{kind: ' ', line: a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, diffOp{kind: '-', line: a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{kind: '+', line: b[j]})
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		ops = append(ops, diffOp{kind: '-', line: a[i]})
+	}
+	for ; j < m; j++ {
+		ops = append(ops, diffOp{kind: '+', line: b[j]})
+	}
+	return ops
+}
+
+// formatInlineDiff renders every line prefixed with ' ', '-' or '+'
+func formatInlineDiff(ops []diffOp) string {
+	var builder strings.Builder
+	for _, op := range ops {
+		builder.WriteByte(op.kind)
+		builder.WriteString(op.line)
+		builder.WriteByte('\n')
+	}
+	return builder.String()
+}
+
+// formatUnifiedDiff renders changed hunks with surrounding context in unified diff format
+func formatUnifiedDiff(ops []diffOp) string {
+	var builder strings.Builder
+	builder.WriteString("--- a\n+++ b\n")
+
+	// Line numbers in a and b before each op
+	posA := make([]int, len(ops)+1)
+	posB := make([]int, len(ops)+1)
+	for k, op := range ops {
+		posA[k+1], posB[k+1] = posA[k], posB[k]
+		if op.kind != '+' {
+			posA[k+1]++
+		}
+		if op.kind != '-' {
+			posB[k+1]++
+		}
+	}
+
+	for k := 0; k < len(ops); {
+		if ops[k].kind == ' ' {
+			k++
+			continue
+		}
+
+		// Extend the hunk while changes are within 2*context lines of each other
+		start := k - diffContextLines
+		if start < 0 {
+			start = 0
+		}
+		end := k
+		for end < len(ops) {
+			if ops[end].kind != ' ' {
+				end++
+				continue
+			}
+			next := end
+			for next < len(ops) && ops[next].kind == ' ' {
+				next++
+			}
+			if next < len(ops) && next-end <= 2*diffContextLines {
+				end = next
+				continue
+			}
+			end += diffContextLines
+			if end > len(ops) {
+				end = len(ops)
+			}
+			break
+		}
+
+		lenA := posA[end] - posA[start]
+		lenB := posB[end] - posB[start]
+		fmt.Fprintf(&builder, "@@ -%s +%s @@\n", hunkRange(posA[start], lenA), hunkRange(posB[start], lenB))
+		for _, op := range ops[start:end] {
+			builder.WriteByte(op.kind)
+			builder.WriteString(op.line)
+			builder.WriteByte('\n')
+		}
+		k = end
+	}
+	return builder.String()
+}
+
+// hunkRange formats a unified diff range from a zero-based start and length
+func hunkRange(start, length int) string {
+	if length == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if length == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, length)
+}
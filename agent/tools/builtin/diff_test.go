@@ -0,0 +1,99 @@
+package builtin
+
+import (
+	"testing"
+
+	"github.com/xichan96/cortex/pkg/errors"
+)
+
+func TestDiffTool_Name(t *testing.T) {
+	tool := NewDiffTool()
+	if tool.Name() != "diff" {
+		t.Errorf("Expected name 'diff', got '%s'", tool.Name())
+	}
+}
+
+func TestDiffTool_Unified(t *testing.T) {
+	tool := NewDiffTool()
+
+	result, err := tool.Execute(map[string]interface{}{
+		"a": "one\ntwo\nthree\n",
+		"b": "one\n2\nthree\nfour\n",
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resultMap := result.(map[string]interface{})
+
+	expected := "--- a\n+++ b\n@@ -1,3 +1,4 @@\n one\n-two\n+2\n three\n+four\n"
+	if resultMap["diff"] != expected {
+		t.Errorf("Expected diff:\n%s\ngot:\n%v", expected, resultMap["diff"])
+	}
+	if resultMap["added"] != 2 {
+		t.Errorf("Expected 2 added lines, got %v", resultMap["added"])
+	}
+	if resultMap["removed"] != 1 {
+		t.Errorf("Expected 1 removed line, got %v", resultMap["removed"])
+	}
+	if resultMap["identical"] != false {
+		t.Error("Expected texts to differ")
+	}
+}
+
+func TestDiffTool_Inline(t *testing.T) {
+	tool := NewDiffTool()
+
+	result, err := tool.Execute(map[string]interface{}{
+		"a":      "a\nb",
+		"b":      "a\nc",
+		"format": "inline",
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resultMap := result.(map[string]interface{})
+
+	expected := " a\n-b\n+c\n"
+	if resultMap["diff"] != expected {
+		t.Errorf("Expected diff %q, got %q", expected, resultMap["diff"])
+	}
+}
+
+func TestDiffTool_Identical(t *testing.T) {
+	tool := NewDiffTool()
+
+	result, err := tool.Execute(map[string]interface{}{
+		"a": "same\ntext",
+		"b": "same\ntext",
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resultMap := result.(map[string]interface{})
+	if resultMap["identical"] != true {
+		t.Error("Expected texts to be identical")
+	}
+	if resultMap["diff"] != "" {
+		t.Errorf("Expected empty diff, got %q", resultMap["diff"])
+	}
+}
+
+func TestDiffTool_InvalidFormat(t *testing.T) {
+	tool := NewDiffTool()
+
+	_, err := tool.Execute(map[string]interface{}{
+		"a":      "a",
+		"b":      "b",
+		"format": "side_by_side",
+	})
+	if err == nil {
+		t.Fatal("Expected error for invalid format")
+	}
+	errObj, ok := err.(*errors.Error)
+	if !ok {
+		t.Fatalf("Expected *errors.Error, got %T", err)
+	}
+	if errObj.Code != errors.EC_PARAMETER_INVALID.Code {
+		t.Errorf("Expected error code %d, got %d", errors.EC_PARAMETER_INVALID.Code, errObj.Code)
+	}
+}
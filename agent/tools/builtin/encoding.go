@@ -0,0 +1,123 @@
+package builtin
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"github.com/xichan96/cortex/agent/types"
+	"github.com/xichan96/cortex/pkg/errors"
+)
+
+// Supported encoding operations
+var encodingOps = []string{
+	"base64_encode",
+	"base64_decode",
+	"hex",
+	"md5",
+	"sha256",
+	"url_encode",
+	"url_decode",
+	"json_pretty",
+}
+
+type EncodingTool struct{}
+
+func NewEncodingTool() types.Tool {
+	return &EncodingTool{}
+}
+
+func (t *EncodingTool) Name() string {
+	return "encoding"
+}
+
+func (t *EncodingTool) Description() string {
+	return "Encode, decode and hash text. Supports base64 encode/decode, hex encoding, md5/sha256 hashes, URL encode/decode and JSON pretty-printing."
+}
+
+func (t *EncodingTool) Schema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"op": map[string]interface{}{
+				"type":        "string",
+				"description": "Operation to perform",
+				"enum":        encodingOps,
+			},
+			"input": map[string]interface{}{
+				"type":        "string",
+				"description": "Input text to process",
+			},
+		},
+		"required": []string{"op", "input"},
+	}
+}
+
+func (t *EncodingTool) Execute(input map[string]interface{}) (interface{}, error) {
+	op, ok := input["op"].(string)
+	if !ok || op == "" {
+		return nil, errors.EC_PARAMETER_MISSING.Wrap(fmt.Errorf("'op' parameter is required"))
+	}
+	text, ok := input["input"].(string)
+	if !ok {
+		return nil, errors.EC_TOOL_PARAMETER_INVALID.Wrap(fmt.Errorf("invalid 'input' parameter: must be a string"))
+	}
+
+	var output string
+	switch op {
+	case "base64_encode":
+		output = base64.StdEncoding.EncodeToString([]byte(text))
+	case "base64_decode":
+		decoded, err := base64.StdEncoding.DecodeString(text)
+		if err != nil {
+			// Fall back to URL-safe / unpadded variants
+			decoded, err = base64.RawURLEncoding.DecodeString(text)
+			if err != nil {
+				return nil, errors.EC_PARAMETER_INVALID.Wrap(fmt.Errorf("invalid base64 input: %v", err))
+			}
+		}
+		output = string(decoded)
+	case "hex":
+		output = hex.EncodeToString([]byte(text))
+	case "md5":
+		sum := md5.Sum([]byte(text))
+		output = hex.EncodeToString(sum[:])
+	case "sha256":
+		sum := sha256.Sum256([]byte(text))
+		output = hex.EncodeToString(sum[:])
+	case "url_encode":
+		output = url.QueryEscape(text)
+	case "url_decode":
+		decoded, err := url.QueryUnescape(text)
+		if err != nil {
+			return nil, errors.EC_PARAMETER_INVALID.Wrap(fmt.Errorf("invalid URL-encoded input: %v", err))
+		}
+		output = decoded
+	case "json_pretty":
+		var buf bytes.Buffer
+		if err := json.Indent(&buf, []byte(text), "", "  "); err != nil {
+			return nil, errors.EC_PARAMETER_INVALID.Wrap(fmt.Errorf("invalid JSON input: %v", err))
+		}
+		output = buf.String()
+	default:
+		return nil, errors.EC_PARAMETER_INVALID.Wrap(fmt.Errorf("unknown op '%s', supported ops: %v", op, encodingOps))
+	}
+
+	return map[string]interface{}{
+		"op":     op,
+		"output": output,
+	}, nil
+}
+
+func (t *EncodingTool) Metadata() types.ToolMetadata {
+	return types.ToolMetadata{
+		SourceNodeName: "encoding",
+		IsFromToolkit:  false,
+		ToolType:       "builtin",
+	}
+}
@@ -0,0 +1,87 @@
+package builtin
+
+import (
+	"testing"
+
+	"github.com/xichan96/cortex/pkg/errors"
+)
+
+func TestEncodingTool_Name(t *testing.T) {
+	tool := NewEncodingTool()
+	if tool.Name() != "encoding" {
+		t.Errorf("Expected name 'encoding', got '%s'", tool.Name())
+	}
+}
+
+func TestEncodingTool_Operations(t *testing.T) {
+	tool := NewEncodingTool()
+
+	tests := []struct {
+		op       string
+		input    string
+		expected string
+	}{
+		{"base64_encode", "hello", "aGVsbG8="},
+		{"base64_decode", "aGVsbG8=", "hello"},
+		{"base64_decode", "aGVsbG8", "hello"},
+		{"hex", "hi", "6869"},
+		{"md5", "hello", "5d41402abc4b2a76b9719d911017c592"},
+		{"sha256", "hello", "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"},
+		{"url_encode", "a b&c", "a+b%26c"},
+		{"url_decode", "a+b%26c", "a b&c"},
+		{"json_pretty", `{"a":1}`, "{\n  \"a\": 1\n}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.op, func(t *testing.T) {
+			result, err := tool.Execute(map[string]interface{}{
+				"op":    tt.op,
+				"input": tt.input,
+			})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			resultMap, ok := result.(map[string]interface{})
+			if !ok {
+				t.Fatal("Result should be a map")
+			}
+			if resultMap["output"] != tt.expected {
+				t.Errorf("Expected '%s', got '%v'", tt.expected, resultMap["output"])
+			}
+		})
+	}
+}
+
+func TestEncodingTool_InvalidInput(t *testing.T) {
+	tool := NewEncodingTool()
+
+	tests := []struct {
+		name  string
+		op    string
+		input string
+	}{
+		{"unknown op", "rot13", "hello"},
+		{"bad base64", "base64_decode", "!!!"},
+		{"bad url", "url_decode", "%zz"},
+		{"bad json", "json_pretty", "{"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tool.Execute(map[string]interface{}{
+				"op":    tt.op,
+				"input": tt.input,
+			})
+			if err == nil {
+				t.Fatal("Expected error")
+			}
+			errObj, ok := err.(*errors.Error)
+			if !ok {
+				t.Fatalf("Expected *errors.Error, got %T", err)
+			}
+			if errObj.Code != errors.EC_PARAMETER_INVALID.Code {
+				t.Errorf("Expected error code %d, got %d", errors.EC_PARAMETER_INVALID.Code, errObj.Code)
+			}
+		})
+	}
+}
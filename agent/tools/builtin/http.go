@@ -0,0 +1,331 @@
+package builtin
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/xichan96/cortex/agent/types"
+	"github.com/xichan96/cortex/pkg/errors"
+)
+
+const httpToolTimeout = 30 * time.Second
+
+// DefaultHTTPToolMaxBodySize default cap on the response body returned to the model (1MB)
+const DefaultHTTPToolMaxBodySize int64 = 1024 * 1024
+
+var httpToolMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"}
+
+// errAddressNotAllowed is returned by the dialer for loopback, private and link-local addresses
+var errAddressNotAllowed = stderrors.New("address is not allowed")
+
+type HTTPTool struct {
+	client *http.Client
+
+	mu           sync.RWMutex
+	allowedHosts []string // empty allows every host
+	allowPrivate bool     // allow loopback, private and link-local addresses
+	maxBodySize  int64
+}
+
+func NewHTTPTool() *HTTPTool {
+	t := &HTTPTool{maxBodySize: DefaultHTTPToolMaxBodySize}
+	dialer := &net.Dialer{
+		Timeout: httpToolTimeout,
+		Control: t.checkAddress,
+	}
+	t.client = &http.Client{
+		Transport: &http.Transport{
+			// No proxy: the dialer must see the address of the requested host
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: 10 * time.Second,
+			MaxIdleConnsPerHost: 10,
+			IdleConnTimeout:     60 * time.Second,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	return t
+}
+
+// SetAllowedHosts restricts requests to the given hosts, an empty list allows every host
+// Entries match the URL host name case-insensitively, ignoring the port; "*.example.com" matches
+// subdomains of example.com. Requests to other hosts fail with EC_PERMISSION_DENIED
+func (t *HTTPTool) SetAllowedHosts(hosts []string) {
+	allowed := make([]string, 0, len(hosts))
+	for _, host := range hosts {
+		host = strings.ToLower(strings.TrimSpace(host))
+		if host != "" {
+			allowed = append(allowed, host)
+		}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.allowedHosts = allowed
+}
+
+// SetAllowPrivateNetworks allows requests to loopback, private, link-local, CGNAT and other non-public addresses
+// They are denied by default, since they expose internal services and cloud metadata endpoints. The check
+// runs on the resolved address when connecting, so host names resolving to such addresses are denied as well
+func (t *HTTPTool) SetAllowPrivateNetworks(allow bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.allowPrivate = allow
+}
+
+// SetMaxBodySize caps the response body returned to the model, 0 or less restores DefaultHTTPToolMaxBodySize
+// Longer bodies are cut off and the result is marked as truncated
+func (t *HTTPTool) SetMaxBodySize(size int64) {
+	if size <= 0 {
+		size = DefaultHTTPToolMaxBodySize
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.maxBodySize = size
+}
+
+func (t *HTTPTool) Name() string {
+	return "http_request"
+}
+
+func (t *HTTPTool) Description() string {
+	return "Send an HTTP request and return the response status code, headers and body. Redirects are not followed."
+}
+
+func (t *HTTPTool) Schema() map[string]interface{} {
+	methods := make([]interface{}, len(httpToolMethods))
+	for i, method := range httpToolMethods {
+		methods[i] = method
+	}
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"method": map[string]interface{}{
+				"type":        "string",
+				"description": "HTTP method (default: GET)",
+				"enum":        methods,
+			},
+			"url": map[string]interface{}{
+				"type":        "string",
+				"description": "Absolute http or https URL, including any query string",
+			},
+			"headers": map[string]interface{}{
+				"type":                 "object",
+				"description":          "Request headers, e.g. {\"Content-Type\": \"application/json\"}",
+				"additionalProperties": map[string]interface{}{"type": "string"},
+			},
+			"body": map[string]interface{}{
+				"type":        "string",
+				"description": "Raw request body",
+			},
+		},
+		"required": []string{"url"},
+	}
+}
+
+func (t *HTTPTool) Execute(input map[string]interface{}) (interface{}, error) {
+	rawURL, ok := input["url"].(string)
+	if !ok || rawURL == "" {
+		return nil, errors.NewError(errors.EC_PARAMETER_MISSING.Code, "'url' parameter is required")
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return nil, errors.NewError(errors.EC_TOOL_PARAMETER_INVALID.Code, fmt.Sprintf("invalid 'url' parameter: %q is not an absolute http or https URL", rawURL))
+	}
+	if !t.hostAllowed(u.Hostname()) {
+		return nil, errors.NewError(errors.EC_PERMISSION_DENIED.Code, fmt.Sprintf("host %q is not allowed", u.Hostname()))
+	}
+
+	method := "GET"
+	if m, ok := input["method"].(string); ok && m != "" {
+		method = strings.ToUpper(m)
+	}
+	if !slices.Contains(httpToolMethods, method) {
+		return nil, errors.NewError(errors.EC_TOOL_PARAMETER_INVALID.Code, fmt.Sprintf("invalid 'method' parameter: %s is not supported", method))
+	}
+
+	headers := make(map[string]string)
+	if raw, ok := input["headers"]; ok && raw != nil {
+		values, ok := raw.(map[string]interface{})
+		if !ok {
+			return nil, errors.NewError(errors.EC_TOOL_PARAMETER_INVALID.Code, "invalid 'headers' parameter: must be an object")
+		}
+		for key, value := range values {
+			s, ok := value.(string)
+			if !ok {
+				return nil, errors.NewError(errors.EC_TOOL_PARAMETER_INVALID.Code, fmt.Sprintf("invalid 'headers' parameter: value of %s must be a string", key))
+			}
+			headers[key] = s
+		}
+	}
+
+	body, _ := input["body"].(string)
+
+	ctx, cancel := context.WithTimeout(context.Background(), httpToolTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), strings.NewReader(body))
+	if err != nil {
+		return nil, errors.NewError(errors.EC_HTTP_REQUEST_FAILED.Code, method+" request failed").Wrap(err)
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		if stderrors.Is(err, errAddressNotAllowed) {
+			return nil, errors.NewError(errors.EC_PERMISSION_DENIED.Code, fmt.Sprintf("host %q resolves to an address that is not allowed", u.Hostname()))
+		}
+		return nil, errors.NewError(errors.EC_HTTP_REQUEST_FAILED.Code, method+" request failed").Wrap(err)
+	}
+	defer resp.Body.Close()
+
+	t.mu.RLock()
+	maxBodySize := t.maxBodySize
+	t.mu.RUnlock()
+	// One byte past the cap tells a body of exactly maxBodySize bytes from a longer one
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxBodySize+1))
+	if err != nil {
+		return nil, errors.NewError(errors.EC_HTTP_REQUEST_FAILED.Code, "failed to read response body").Wrap(err)
+	}
+
+	respHeaders := make(map[string]string, len(resp.Header))
+	for key, values := range resp.Header {
+		respHeaders[key] = strings.Join(values, ", ")
+	}
+	result := map[string]interface{}{
+		"status_code": resp.StatusCode,
+		"headers":     respHeaders,
+	}
+	if int64(len(data)) > maxBodySize {
+		data = data[:maxBodySize]
+		result["truncated"] = true
+	}
+	result["body"] = string(data)
+	return result, nil
+}
+
+func (t *HTTPTool) Metadata() types.ToolMetadata {
+	return types.ToolMetadata{
+		SourceNodeName: "http",
+		IsFromToolkit:  false,
+		ToolType:       "builtin",
+	}
+}
+
+// hostAllowed reports whether host passes the allowlist
+func (t *HTTPTool) hostAllowed(host string) bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if len(t.allowedHosts) == 0 {
+		return true
+	}
+
+	host = strings.ToLower(host)
+	for _, allowed := range t.allowedHosts {
+		if suffix, ok := strings.CutPrefix(allowed, "*."); ok {
+			if strings.HasSuffix(host, "."+suffix) {
+				return true
+			}
+			continue
+		}
+		if host == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// checkAddress is the dialer's Control hook, run with the resolved address of every connection
+// Checking after resolution also covers host names whose DNS answer changes between requests
+func (t *HTTPTool) checkAddress(network, address string, _ syscall.RawConn) error {
+	t.mu.RLock()
+	allowPrivate := t.allowPrivate
+	t.mu.RUnlock()
+	if allowPrivate {
+		return nil
+	}
+
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || internalIP(ip) {
+		return fmt.Errorf("%w: %s", errAddressNotAllowed, host)
+	}
+	return nil
+}
+
+// nonPublicPrefixes address ranges the HTTP tool never connects to unless private networks are allowed
+// IANA special-purpose ranges that are not globally reachable, plus multicast and reserved space
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),       // "this network"
+	netip.MustParsePrefix("10.0.0.0/8"),      // private (RFC 1918)
+	netip.MustParsePrefix("100.64.0.0/10"),   // shared address space / CGNAT, e.g. Alibaba Cloud metadata 100.100.100.200
+	netip.MustParsePrefix("127.0.0.0/8"),     // loopback
+	netip.MustParsePrefix("169.254.0.0/16"),  // link-local, e.g. cloud metadata 169.254.169.254
+	netip.MustParsePrefix("172.16.0.0/12"),   // private (RFC 1918)
+	netip.MustParsePrefix("192.0.0.0/24"),    // IETF protocol assignments
+	netip.MustParsePrefix("192.0.2.0/24"),    // documentation
+	netip.MustParsePrefix("192.88.99.0/24"),  // 6to4 relay anycast
+	netip.MustParsePrefix("192.168.0.0/16"),  // private (RFC 1918)
+	netip.MustParsePrefix("198.18.0.0/15"),   // benchmarking
+	netip.MustParsePrefix("198.51.100.0/24"), // documentation
+	netip.MustParsePrefix("203.0.113.0/24"),  // documentation
+	netip.MustParsePrefix("224.0.0.0/4"),     // multicast
+	netip.MustParsePrefix("240.0.0.0/4"),     // reserved and broadcast
+	netip.MustParsePrefix("::/96"),           // unspecified, loopback and IPv4-compatible
+	netip.MustParsePrefix("64:ff9b:1::/48"),  // local-use NAT64
+	netip.MustParsePrefix("100::/64"),        // discard-only
+	netip.MustParsePrefix("2001::/23"),       // IETF protocol assignments, including Teredo
+	netip.MustParsePrefix("2001:db8::/32"),   // documentation
+	netip.MustParsePrefix("fc00::/7"),        // unique local (ULA)
+	netip.MustParsePrefix("fe80::/10"),       // link-local
+	netip.MustParsePrefix("fec0::/10"),       // site-local (deprecated)
+	netip.MustParsePrefix("ff00::/8"),        // multicast
+}
+
+// IPv6 ranges that embed an IPv4 address, checked by the address they reach
+var (
+	nat64Prefix     = netip.MustParsePrefix("64:ff9b::/96") // well-known NAT64, IPv4 in the last 4 bytes
+	sixToFourPrefix = netip.MustParsePrefix("2002::/16")    // 6to4, IPv4 in bytes 2-5
+)
+
+// internalIP reports whether ip is a loopback, private, link-local or otherwise non-public address
+// IPv4-mapped, NAT64 and 6to4 addresses are checked by the IPv4 address they embed
+func internalIP(ip net.IP) bool {
+	addr, ok := netip.AddrFromSlice(ip)
+	if !ok {
+		return true
+	}
+	addr = addr.Unmap()
+	if addr.Is6() {
+		b := addr.As16()
+		switch {
+		case nat64Prefix.Contains(addr):
+			return internalIP(net.IP(b[12:16]))
+		case sixToFourPrefix.Contains(addr):
+			return internalIP(net.IP(b[2:6]))
+		}
+	}
+	for _, prefix := range nonPublicPrefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
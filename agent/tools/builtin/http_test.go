@@ -0,0 +1,194 @@
+package builtin
+
+import (
+	stderrors "errors"
+	"io"
+	"net"
+	nethttp "net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/xichan96/cortex/agent/tools"
+	"github.com/xichan96/cortex/pkg/errors"
+)
+
+func TestHTTPTool_Schema(t *testing.T) {
+	if err := tools.ValidateToolSchema(NewHTTPTool()); err != nil {
+		t.Fatalf("schema invalid: %v", err)
+	}
+}
+
+func TestHTTPTool_Request(t *testing.T) {
+	server := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("X-Method", r.Method)
+		w.WriteHeader(nethttp.StatusTeapot)
+		w.Write([]byte(r.Header.Get("X-Token") + ":" + string(body)))
+	}))
+	defer server.Close()
+
+	tool := NewHTTPTool()
+	tool.SetAllowedHosts([]string{"127.0.0.1"})
+	tool.SetAllowPrivateNetworks(true)
+	result, err := tool.Execute(map[string]interface{}{
+		"method":  "post",
+		"url":     server.URL + "/echo",
+		"headers": map[string]interface{}{"X-Token": "abc"},
+		"body":    "hello",
+	})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	resp := result.(map[string]interface{})
+	if resp["status_code"] != nethttp.StatusTeapot {
+		t.Errorf("status_code = %v, want %d", resp["status_code"], nethttp.StatusTeapot)
+	}
+	if resp["body"] != "abc:hello" {
+		t.Errorf("body = %q, want %q", resp["body"], "abc:hello")
+	}
+	if headers := resp["headers"].(map[string]string); headers["X-Method"] != "POST" {
+		t.Errorf("X-Method header = %q, want POST", headers["X-Method"])
+	}
+}
+
+func TestHTTPTool_AllowedHosts(t *testing.T) {
+	tool := NewHTTPTool()
+	tool.SetAllowedHosts([]string{"api.example.com", "*.internal.example"})
+
+	for _, host := range []string{"API.example.com", "a.internal.example", "b.a.internal.example"} {
+		if !tool.hostAllowed(host) {
+			t.Errorf("host %s should be allowed", host)
+		}
+	}
+
+	for _, rawURL := range []string{"http://169.254.169.254/latest/meta-data", "http://internal.example/", "https://example.com/"} {
+		_, err := tool.Execute(map[string]interface{}{"url": rawURL})
+		var e *errors.Error
+		if !stderrors.As(err, &e) || e.Code != errors.EC_PERMISSION_DENIED.Code {
+			t.Errorf("Execute(%s) error = %v, want EC_PERMISSION_DENIED", rawURL, err)
+		}
+	}
+}
+
+func TestHTTPTool_PrivateAddressesDenied(t *testing.T) {
+	server := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		t.Error("request to a loopback address reached the server")
+	}))
+	defer server.Close()
+
+	tool := NewHTTPTool()
+	port := server.URL[strings.LastIndex(server.URL, ":")+1:]
+	// The host name is resolved before the check, so localhost is denied like its address
+	for _, rawURL := range []string{server.URL, "http://localhost:" + port} {
+		_, err := tool.Execute(map[string]interface{}{"url": rawURL})
+		var e *errors.Error
+		if !stderrors.As(err, &e) || e.Code != errors.EC_PERMISSION_DENIED.Code {
+			t.Errorf("Execute(%s) error = %v, want EC_PERMISSION_DENIED", rawURL, err)
+		}
+	}
+}
+
+func TestInternalIP(t *testing.T) {
+	tests := []struct {
+		addr     string
+		internal bool
+	}{
+		{"0.0.0.0", true},
+		{"0.1.2.3", true},
+		{"10.1.2.3", true},
+		{"100.64.0.1", true},
+		{"100.100.100.200", true},
+		{"100.127.255.255", true},
+		{"127.0.0.1", true},
+		{"169.254.169.254", true},
+		{"172.16.0.1", true},
+		{"192.0.0.8", true},
+		{"192.0.2.1", true},
+		{"192.88.99.1", true},
+		{"192.168.1.1", true},
+		{"198.18.0.1", true},
+		{"198.19.255.255", true},
+		{"198.51.100.1", true},
+		{"203.0.113.1", true},
+		{"224.0.0.1", true},
+		{"240.0.0.1", true},
+		{"255.255.255.255", true},
+		{"::", true},
+		{"::1", true},
+		{"::127.0.0.1", true},
+		{"::ffff:127.0.0.1", true},
+		{"::ffff:100.100.100.200", true},
+		{"64:ff9b::a9fe:a9fe", true}, // NAT64 of 169.254.169.254
+		{"64:ff9b::7f00:1", true},    // NAT64 of 127.0.0.1
+		{"64:ff9b:1::1", true},
+		{"100::1", true},
+		{"2001::1", true},
+		{"2001:db8::1", true},
+		{"2002:7f00:1::", true}, // 6to4 of 127.0.0.1
+		{"2002:a00:1::", true},  // 6to4 of 10.0.0.1
+		{"fd00::1", true},
+		{"fe80::1", true},
+		{"fec0::1", true},
+		{"ff02::1", true},
+		{"8.8.8.8", false},
+		{"93.184.216.34", false},
+		{"100.63.255.255", false},
+		{"100.128.0.1", false},
+		{"198.20.0.1", false},
+		{"::ffff:8.8.8.8", false},
+		{"64:ff9b::808:808", false}, // NAT64 of 8.8.8.8
+		{"2002:808:808::", false},   // 6to4 of 8.8.8.8
+		{"2606:4700::1111", false},
+	}
+	for _, tt := range tests {
+		if got := internalIP(net.ParseIP(tt.addr)); got != tt.internal {
+			t.Errorf("internalIP(%s) = %v, want %v", tt.addr, got, tt.internal)
+		}
+	}
+}
+
+func TestHTTPTool_MaxBodySize(t *testing.T) {
+	server := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		w.Write([]byte(strings.Repeat("a", 100)))
+	}))
+	defer server.Close()
+
+	tool := NewHTTPTool()
+	tool.SetAllowPrivateNetworks(true)
+	tool.SetMaxBodySize(10)
+	result, err := tool.Execute(map[string]interface{}{"url": server.URL})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	resp := result.(map[string]interface{})
+	if resp["body"] != strings.Repeat("a", 10) || resp["truncated"] != true {
+		t.Errorf("body = %q, truncated = %v, want 10 bytes marked truncated", resp["body"], resp["truncated"])
+	}
+
+	tool.SetMaxBodySize(100)
+	result, err = tool.Execute(map[string]interface{}{"url": server.URL})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if _, ok := result.(map[string]interface{})["truncated"]; ok {
+		t.Error("body of exactly the cap should not be marked truncated")
+	}
+}
+
+func TestHTTPTool_InvalidInput(t *testing.T) {
+	tool := NewHTTPTool()
+	inputs := []map[string]interface{}{
+		{},
+		{"url": "file:///etc/passwd"},
+		{"url": "/relative"},
+		{"url": "http://example.com", "method": "TRACE"},
+		{"url": "http://example.com", "headers": map[string]interface{}{"X-Count": 1}},
+	}
+	for _, input := range inputs {
+		if _, err := tool.Execute(input); err == nil {
+			t.Errorf("Execute(%v) succeeded, want error", input)
+		}
+	}
+}
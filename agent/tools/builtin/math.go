@@ -24,7 +24,7 @@ func (t *MathTool) Name() string {
 }
 
 func (t *MathTool) Description() string {
-	return "Perform mathematical calculations. Supports basic operations (+, -, *, /), advanced operations (^, √, %, !), trigonometric functions (sin, cos, tan), and supports both degrees and radians mode."
+	return "Perform mathematical calculations. Supports basic operations (+, -, *, /), advanced operations (^, √, %, !), trigonometric functions (sin, cos, tan, asin, acos, atan), logarithms (ln, log, log(base, x)), the constants pi and e, named variables, and supports both degrees and radians mode."
 }
 
 func (t *MathTool) Schema() map[string]interface{} {
@@ -33,7 +33,14 @@ func (t *MathTool) Schema() map[string]interface{} {
 		"properties": map[string]interface{}{
 			"expression": map[string]interface{}{
 				"type":        "string",
-				"description": "Mathematical expression to evaluate (e.g., '2+3*4', 'sin(30)', 'sqrt(16)')",
+				"description": "Mathematical expression to evaluate (e.g., '2+3*4', 'sin(30)', 'sqrt(16)', 'log(2, 8)', 'r^2*pi')",
+			},
+			"variables": map[string]interface{}{
+				"type":        "object",
+				"description": "Named values usable in the expression (e.g., {\"r\": 2}), overriding the constants pi and e",
+				"additionalProperties": map[string]interface{}{
+					"type": "number",
+				},
 			},
 			"use_degrees": map[string]interface{}{
 				"type":        "boolean",
@@ -58,21 +65,80 @@ func (t *MathTool) Execute(input map[string]interface{}) (interface{}, error) {
 		useDegrees = val
 	}
 
+	variables, err := parseVariables(input["variables"])
+	if err != nil {
+		return nil, errors.EC_TOOL_PARAMETER_INVALID.Wrap(err)
+	}
+
 	expression = strings.TrimSpace(expression)
 	if expression == "" {
 		return nil, errors.EC_PARAMETER_INVALID.Wrap(fmt.Errorf("expression cannot be empty"))
 	}
 
-	result, err := t.evaluate(expression, useDegrees)
+	result, err := t.evaluate(expression, useDegrees, variables)
 	if err != nil {
 		return nil, errors.EC_TOOL_EXECUTION_FAILED.Wrap(err)
 	}
 
-	return map[string]interface{}{
+	output := map[string]interface{}{
 		"result":      result,
 		"expression":  expression,
 		"use_degrees": useDegrees,
-	}, nil
+	}
+	if len(variables) > 0 {
+		output["variables"] = variables
+	}
+	return output, nil
+}
+
+// parseVariables reads the optional 'variables' parameter into a name to value map
+func parseVariables(raw interface{}) (map[string]float64, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	values, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid 'variables' parameter: must be an object")
+	}
+
+	variables := make(map[string]float64, len(values))
+	for name, value := range values {
+		if !isIdentifier(name) {
+			return nil, fmt.Errorf("invalid variable name '%s': must start with a letter or underscore and contain only letters, digits and underscores", name)
+		}
+		switch v := value.(type) {
+		case float64:
+			variables[name] = v
+		case float32:
+			variables[name] = float64(v)
+		case int:
+			variables[name] = float64(v)
+		case int64:
+			variables[name] = float64(v)
+		default:
+			return nil, fmt.Errorf("invalid value for variable '%s': must be a number", name)
+		}
+	}
+	return variables, nil
+}
+
+func isIdentifier(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		if !isIdentifierChar(name[i], i == 0) {
+			return false
+		}
+	}
+	return true
+}
+
+func isIdentifierChar(c byte, first bool) bool {
+	if c == '_' || unicode.IsLetter(rune(c)) {
+		return true
+	}
+	return !first && unicode.IsDigit(rune(c))
 }
 
 func (t *MathTool) Metadata() types.ToolMetadata {
@@ -87,13 +153,24 @@ type parser struct {
 	expr       string
 	pos        int
 	useDegrees bool
+	vars       map[string]float64
 }
 
-func (t *MathTool) evaluate(expr string, useDegrees bool) (float64, error) {
+func (t *MathTool) evaluate(expr string, useDegrees bool, variables map[string]float64) (float64, error) {
+	// Constants are defined first so that variables of the same name take precedence
+	vars := map[string]float64{
+		"pi": math.Pi,
+		"e":  math.E,
+	}
+	for name, value := range variables {
+		vars[name] = value
+	}
+
 	p := &parser{
 		expr:       strings.ReplaceAll(strings.ReplaceAll(expr, " ", ""), "√", "sqrt"),
 		pos:        0,
 		useDegrees: useDegrees,
+		vars:       vars,
 	}
 
 	result, err := p.parseExpression()
@@ -259,7 +336,7 @@ func (p *parser) parseFactor() (float64, error) {
 			return 0, fmt.Errorf("missing closing parenthesis")
 		}
 		p.pos++
-	} else if unicode.IsLetter(rune(p.expr[p.pos])) {
+	} else if isIdentifierChar(p.expr[p.pos], true) {
 		val, err = p.parseFunction()
 		if err != nil {
 			return 0, err
@@ -294,21 +371,34 @@ func (p *parser) parseFactor() (float64, error) {
 	return val, nil
 }
 
+// parseFunction parses a function call, or a variable or constant when the name is not followed by '('
 func (p *parser) parseFunction() (float64, error) {
 	start := p.pos
-	for p.pos < len(p.expr) && unicode.IsLetter(rune(p.expr[p.pos])) {
+	for p.pos < len(p.expr) && isIdentifierChar(p.expr[p.pos], p.pos == start) {
 		p.pos++
 	}
 	funcName := p.expr[start:p.pos]
 
 	if p.pos >= len(p.expr) || p.expr[p.pos] != '(' {
-		return 0, fmt.Errorf("function '%s' must be followed by '('", funcName)
+		if val, ok := p.vars[funcName]; ok {
+			return val, nil
+		}
+		return 0, fmt.Errorf("unknown variable '%s' (functions must be followed by '(')", funcName)
 	}
 	p.pos++
 
-	arg, err := p.parseExpression()
-	if err != nil {
-		return 0, err
+	args := make([]float64, 0, 2)
+	for {
+		arg, err := p.parseExpression()
+		if err != nil {
+			return 0, err
+		}
+		args = append(args, arg)
+		if p.pos < len(p.expr) && p.expr[p.pos] == ',' {
+			p.pos++
+			continue
+		}
+		break
 	}
 
 	if p.pos >= len(p.expr) || p.expr[p.pos] != ')' {
@@ -316,6 +406,21 @@ func (p *parser) parseFunction() (float64, error) {
 	}
 	p.pos++
 
+	if funcName == "log" && len(args) == 2 {
+		base, x := args[0], args[1]
+		if base <= 0 || base == 1 {
+			return 0, fmt.Errorf("logarithm base must be positive and not equal to 1")
+		}
+		if x <= 0 {
+			return 0, fmt.Errorf("logarithm of non-positive number")
+		}
+		return math.Log(x) / math.Log(base), nil
+	}
+	if len(args) != 1 {
+		return 0, fmt.Errorf("function '%s' takes 1 argument, got %d", funcName, len(args))
+	}
+	arg := args[0]
+
 	switch funcName {
 	case "sqrt":
 		if arg < 0 {
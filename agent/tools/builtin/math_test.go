@@ -284,7 +284,7 @@ func TestMathTool_Execute_SinRadians(t *testing.T) {
 	tool := NewMathTool()
 
 	input := map[string]interface{}{
-		"expression": "sin(0)",
+		"expression":  "sin(0)",
 		"use_degrees": false,
 	}
 
@@ -304,7 +304,7 @@ func TestMathTool_Execute_SinDegrees(t *testing.T) {
 	tool := NewMathTool()
 
 	input := map[string]interface{}{
-		"expression": "sin(90)",
+		"expression":  "sin(90)",
 		"use_degrees": true,
 	}
 
@@ -324,7 +324,7 @@ func TestMathTool_Execute_CosRadians(t *testing.T) {
 	tool := NewMathTool()
 
 	input := map[string]interface{}{
-		"expression": "cos(0)",
+		"expression":  "cos(0)",
 		"use_degrees": false,
 	}
 
@@ -344,7 +344,7 @@ func TestMathTool_Execute_TanRadians(t *testing.T) {
 	tool := NewMathTool()
 
 	input := map[string]interface{}{
-		"expression": "tan(0)",
+		"expression":  "tan(0)",
 		"use_degrees": false,
 	}
 
@@ -672,3 +672,233 @@ func TestMathTool_Execute_NestedExpressions(t *testing.T) {
 	}
 }
 
+func TestMathTool_Execute_Variables(t *testing.T) {
+	tool := NewMathTool()
+
+	input := map[string]interface{}{
+		"expression": "rate * hours + base_fee",
+		"variables": map[string]interface{}{
+			"rate":     25.5,
+			"hours":    4,
+			"base_fee": 10.0,
+		},
+	}
+
+	result, err := tool.Execute(input)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	resultMap := result.(map[string]interface{})
+	if resultMap["result"].(float64) != 112.0 {
+		t.Errorf("Expected result 112.0, got %f", resultMap["result"])
+	}
+	if _, ok := resultMap["variables"]; !ok {
+		t.Error("Result should include the variables")
+	}
+}
+
+func TestMathTool_Execute_VariableFactorial(t *testing.T) {
+	tool := NewMathTool()
+
+	input := map[string]interface{}{
+		"expression": "n!",
+		"variables":  map[string]interface{}{"n": 5.0},
+	}
+
+	result, err := tool.Execute(input)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	resultMap := result.(map[string]interface{})
+	if resultMap["result"].(float64) != 120.0 {
+		t.Errorf("Expected result 120.0, got %f", resultMap["result"])
+	}
+}
+
+func TestMathTool_Execute_Constants(t *testing.T) {
+	tool := NewMathTool()
+
+	tests := []struct {
+		expression string
+		variables  map[string]interface{}
+		expected   float64
+	}{
+		{"pi", nil, math.Pi},
+		{"2*pi*r", map[string]interface{}{"r": 3.0}, 2 * math.Pi * 3},
+		{"e^2", nil, math.E * math.E},
+		{"ln(e)", nil, 1},
+		{"e", map[string]interface{}{"e": 7.0}, 7},
+	}
+
+	for _, tt := range tests {
+		input := map[string]interface{}{"expression": tt.expression}
+		if tt.variables != nil {
+			input["variables"] = tt.variables
+		}
+		result, err := tool.Execute(input)
+		if err != nil {
+			t.Fatalf("Execute(%q) failed: %v", tt.expression, err)
+		}
+		got := result.(map[string]interface{})["result"].(float64)
+		if math.Abs(got-tt.expected) > 1e-10 {
+			t.Errorf("Execute(%q): expected %f, got %f", tt.expression, tt.expected, got)
+		}
+	}
+}
+
+func TestMathTool_Execute_UnknownVariable(t *testing.T) {
+	tool := NewMathTool()
+
+	input := map[string]interface{}{
+		"expression": "x + 1",
+	}
+
+	_, err := tool.Execute(input)
+	if err == nil {
+		t.Fatal("Execute should return error for unknown variable")
+	}
+
+	errObj, ok := err.(*errors.Error)
+	if !ok {
+		t.Fatalf("Expected *errors.Error, got %T", err)
+	}
+
+	if errObj.Code != errors.EC_TOOL_EXECUTION_FAILED.Code {
+		t.Errorf("Expected error code %d, got %d", errors.EC_TOOL_EXECUTION_FAILED.Code, errObj.Code)
+	}
+}
+
+func TestMathTool_Execute_InvalidVariables(t *testing.T) {
+	tool := NewMathTool()
+
+	tests := []struct {
+		name      string
+		variables interface{}
+	}{
+		{"not an object", "x=1"},
+		{"non-number value", map[string]interface{}{"x": "1"}},
+		{"invalid name", map[string]interface{}{"1x": 1.0}},
+	}
+
+	for _, tt := range tests {
+		input := map[string]interface{}{
+			"expression": "1",
+			"variables":  tt.variables,
+		}
+		_, err := tool.Execute(input)
+		if err == nil {
+			t.Fatalf("%s: Execute should return error", tt.name)
+		}
+
+		errObj, ok := err.(*errors.Error)
+		if !ok {
+			t.Fatalf("%s: expected *errors.Error, got %T", tt.name, err)
+		}
+		if errObj.Code != errors.EC_TOOL_PARAMETER_INVALID.Code {
+			t.Errorf("%s: expected error code %d, got %d", tt.name, errors.EC_TOOL_PARAMETER_INVALID.Code, errObj.Code)
+		}
+	}
+}
+
+func TestMathTool_Execute_InverseTrig(t *testing.T) {
+	tool := NewMathTool()
+
+	tests := []struct {
+		expression string
+		useDegrees bool
+		expected   float64
+	}{
+		{"asin(1)", false, math.Pi / 2},
+		{"acos(0)", false, math.Pi / 2},
+		{"atan(1)", false, math.Pi / 4},
+		{"asin(0.5)", true, 30},
+		{"acos(0.5)", true, 60},
+		{"atan(1)", true, 45},
+	}
+
+	for _, tt := range tests {
+		result, err := tool.Execute(map[string]interface{}{
+			"expression":  tt.expression,
+			"use_degrees": tt.useDegrees,
+		})
+		if err != nil {
+			t.Fatalf("Execute(%q) failed: %v", tt.expression, err)
+		}
+		got := result.(map[string]interface{})["result"].(float64)
+		if math.Abs(got-tt.expected) > 1e-10 {
+			t.Errorf("Execute(%q, degrees=%v): expected %f, got %f", tt.expression, tt.useDegrees, tt.expected, got)
+		}
+	}
+}
+
+func TestMathTool_Execute_InverseTrigOutOfRange(t *testing.T) {
+	tool := NewMathTool()
+
+	_, err := tool.Execute(map[string]interface{}{
+		"expression": "asin(2)",
+	})
+	if err == nil {
+		t.Fatal("Execute should return error for asin out of range")
+	}
+
+	errObj, ok := err.(*errors.Error)
+	if !ok {
+		t.Fatalf("Expected *errors.Error, got %T", err)
+	}
+
+	if errObj.Code != errors.EC_TOOL_EXECUTION_FAILED.Code {
+		t.Errorf("Expected error code %d, got %d", errors.EC_TOOL_EXECUTION_FAILED.Code, errObj.Code)
+	}
+}
+
+func TestMathTool_Execute_Logarithms(t *testing.T) {
+	tool := NewMathTool()
+
+	tests := []struct {
+		expression string
+		expected   float64
+	}{
+		{"ln(100)", math.Log(100)},
+		{"log(100)", 2},
+		{"log10(1000)", 3},
+		{"log(2, 8)", 3},
+		{"log(b, x)", 4},
+	}
+
+	for _, tt := range tests {
+		result, err := tool.Execute(map[string]interface{}{
+			"expression": tt.expression,
+			"variables":  map[string]interface{}{"b": 3.0, "x": 81.0},
+		})
+		if err != nil {
+			t.Fatalf("Execute(%q) failed: %v", tt.expression, err)
+		}
+		got := result.(map[string]interface{})["result"].(float64)
+		if math.Abs(got-tt.expected) > 1e-10 {
+			t.Errorf("Execute(%q): expected %f, got %f", tt.expression, tt.expected, got)
+		}
+	}
+}
+
+func TestMathTool_Execute_InvalidLogarithm(t *testing.T) {
+	tool := NewMathTool()
+
+	for _, expression := range []string{"log(1, 5)", "log(-2, 8)", "log(2, 0)", "ln(2, 8)", "sqrt(4, 9)"} {
+		_, err := tool.Execute(map[string]interface{}{
+			"expression": expression,
+		})
+		if err == nil {
+			t.Fatalf("Execute(%q) should return error", expression)
+		}
+
+		errObj, ok := err.(*errors.Error)
+		if !ok {
+			t.Fatalf("Execute(%q): expected *errors.Error, got %T", expression, err)
+		}
+		if errObj.Code != errors.EC_TOOL_EXECUTION_FAILED.Code {
+			t.Errorf("Execute(%q): expected error code %d, got %d", expression, errors.EC_TOOL_EXECUTION_FAILED.Code, errObj.Code)
+		}
+	}
+}
@@ -1,7 +1,10 @@
 package builtin
 
 import (
+	"encoding/base64"
+	stderrors "errors"
 	"fmt"
+	"path/filepath"
 
 	"github.com/xichan96/cortex/agent/types"
 	"github.com/xichan96/cortex/pkg/email"
@@ -21,10 +24,36 @@ func (t *EmailTool) Name() string {
 }
 
 func (t *EmailTool) Description() string {
-	return "Send an email to one or more recipients. Supports HTML, plain text, and markdown content types."
+	return "Send an email to one or more recipients. Supports HTML, plain text, and markdown content types, an optional HTML alternative body, and file attachments."
 }
 
 func (t *EmailTool) Schema() map[string]interface{} {
+	attachment := map[string]interface{}{
+		"content": map[string]interface{}{
+			"type":        "string",
+			"description": "Base64 encoded file content",
+		},
+		"filename": map[string]interface{}{
+			"type":        "string",
+			"description": "File name shown to the recipient (required with 'content')",
+		},
+		"content_type": map[string]interface{}{
+			"type":        "string",
+			"description": "MIME type of the file (e.g., 'application/pdf'), detected from the file name when omitted",
+		},
+	}
+	attachmentsDescription := "Optional files to attach, each given by base64 encoded content with a filename"
+	// Path attachments are only advertised when the operator configured a directory to read them from
+	if t.attachmentDir() != "" {
+		attachment["path"] = map[string]interface{}{
+			"type":        "string",
+			"description": "Path of a file in the attachment directory, relative paths are resolved against it",
+		}
+		attachment["content"].(map[string]interface{})["description"] = "Base64 encoded file content, used when 'path' is not provided"
+		attachment["filename"].(map[string]interface{})["description"] = "File name shown to the recipient (required with 'content', defaults to the base name of 'path')"
+		attachmentsDescription = "Optional files to attach, each given by a file path or by base64 encoded content with a filename"
+	}
+
 	return map[string]interface{}{
 		"type": "object",
 		"properties": map[string]interface{}{
@@ -48,6 +77,18 @@ func (t *EmailTool) Schema() map[string]interface{} {
 				"type":        "string",
 				"description": "Email message content",
 			},
+			"html_body": map[string]interface{}{
+				"type":        "string",
+				"description": "Optional HTML version of the message, shown instead of 'message' by clients that render HTML",
+			},
+			"attachments": map[string]interface{}{
+				"type":        "array",
+				"description": attachmentsDescription,
+				"items": map[string]interface{}{
+					"type":       "object",
+					"properties": attachment,
+				},
+			},
 		},
 		"required": []string{"to", "subject", "type", "message"},
 	}
@@ -103,18 +144,106 @@ func (t *EmailTool) Execute(input map[string]interface{}) (interface{}, error) {
 		return nil, errors.EC_PARAMETER_MISSING.Wrap(fmt.Errorf("'message' parameter cannot be empty"))
 	}
 
-	err := email.Do(t.cfg, toEmails, &email.Content{
+	content := &email.Content{
 		Title:   subject,
 		Type:    contentType,
 		Message: message,
-	})
+	}
+
+	if htmlBody, ok := input["html_body"]; ok && htmlBody != nil {
+		content.HTMLBody, ok = htmlBody.(string)
+		if !ok {
+			return nil, errors.EC_TOOL_PARAMETER_INVALID.Wrap(fmt.Errorf("invalid 'html_body' parameter: must be a string"))
+		}
+	}
+
+	attachments, err := t.parseAttachments(input["attachments"])
+	if err != nil {
+		return nil, err
+	}
+	content.Attachments = attachments
+
+	maxSize := email.DefaultMaxAttachmentSize
+	if t.cfg != nil && t.cfg.MaxAttachmentSize > 0 {
+		maxSize = t.cfg.MaxAttachmentSize
+	}
+	if err := email.CheckAttachments(attachments, maxSize); err != nil {
+		if stderrors.Is(err, email.ErrAttachmentTooLarge) {
+			return nil, errors.EC_DATA_SIZE_EXCEEDED.Wrap(err)
+		}
+		return nil, errors.EC_TOOL_PARAMETER_INVALID.Wrap(err)
+	}
+
+	err = email.Do(t.cfg, toEmails, content)
 	if err != nil {
 		return nil, errors.EC_EMAIL_SEND_FAILED.Wrap(err)
 	}
 
+	if len(attachments) > 0 {
+		return fmt.Sprintf("Email sent successfully to %d recipient(s) with %d attachment(s)", len(toEmails), len(attachments)), nil
+	}
 	return fmt.Sprintf("Email sent successfully to %d recipient(s)", len(toEmails)), nil
 }
 
+// attachmentDir returns the directory path attachments are read from, empty when they are disabled
+func (t *EmailTool) attachmentDir() string {
+	if t.cfg == nil {
+		return ""
+	}
+	return t.cfg.AttachmentDir
+}
+
+// parseAttachments reads the optional 'attachments' parameter
+// Paths are resolved inside the configured attachment directory, anything else is rejected
+func (t *EmailTool) parseAttachments(raw interface{}) ([]email.Attachment, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	items, ok := raw.([]interface{})
+	if !ok {
+		return nil, errors.EC_TOOL_PARAMETER_INVALID.Wrap(fmt.Errorf("invalid 'attachments' parameter: must be an array"))
+	}
+
+	attachments := make([]email.Attachment, 0, len(items))
+	for i, item := range items {
+		obj, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, errors.EC_TOOL_PARAMETER_INVALID.Wrap(fmt.Errorf("invalid 'attachments' parameter at index %d: must be an object", i))
+		}
+
+		var attachment email.Attachment
+		attachment.Path, _ = obj["path"].(string)
+		attachment.Filename, _ = obj["filename"].(string)
+		attachment.ContentType, _ = obj["content_type"].(string)
+		encoded, _ := obj["content"].(string)
+
+		switch {
+		case attachment.Path != "":
+			resolved, err := email.ResolveAttachmentPath(t.attachmentDir(), attachment.Path)
+			if err != nil {
+				return nil, errors.EC_PERMISSION_DENIED.Wrap(fmt.Errorf("invalid 'attachments' parameter at index %d: %w", i, err))
+			}
+			if attachment.Filename == "" {
+				attachment.Filename = filepath.Base(attachment.Path)
+			}
+			attachment.Path = resolved
+		case encoded != "":
+			if attachment.Filename == "" {
+				return nil, errors.EC_PARAMETER_MISSING.Wrap(fmt.Errorf("invalid 'attachments' parameter at index %d: 'filename' is required with 'content'", i))
+			}
+			data, err := base64.StdEncoding.DecodeString(encoded)
+			if err != nil {
+				return nil, errors.EC_TOOL_PARAMETER_INVALID.Wrap(fmt.Errorf("invalid 'attachments' parameter at index %d: 'content' must be base64 encoded: %w", i, err))
+			}
+			attachment.Data = data
+		default:
+			return nil, errors.EC_PARAMETER_MISSING.Wrap(fmt.Errorf("invalid 'attachments' parameter at index %d: 'path' or 'content' is required", i))
+		}
+		attachments = append(attachments, attachment)
+	}
+	return attachments, nil
+}
+
 func (t *EmailTool) Metadata() types.ToolMetadata {
 	return types.ToolMetadata{
 		SourceNodeName: "email",
@@ -0,0 +1,126 @@
+package builtin
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/xichan96/cortex/agent/types"
+	"github.com/xichan96/cortex/pkg/email"
+	"github.com/xichan96/cortex/pkg/errors"
+)
+
+func emailInput(extra map[string]interface{}) map[string]interface{} {
+	input := map[string]interface{}{
+		"to":      []interface{}{"user@example.com"},
+		"subject": "Report",
+		"type":    "text/plain",
+		"message": "hello",
+	}
+	for k, v := range extra {
+		input[k] = v
+	}
+	return input
+}
+
+func TestEmailTool_Schema(t *testing.T) {
+	tool := NewEmailTool(&email.Config{})
+	properties := tool.Schema()["properties"].(map[string]interface{})
+	for _, name := range []string{"html_body", "attachments"} {
+		if _, ok := properties[name]; !ok {
+			t.Errorf("Schema should have '%s' property", name)
+		}
+	}
+}
+
+func TestEmailTool_Execute_AttachmentTooLarge(t *testing.T) {
+	tool := NewEmailTool(&email.Config{MaxAttachmentSize: 8})
+
+	_, err := tool.Execute(emailInput(map[string]interface{}{
+		"attachments": []interface{}{
+			map[string]interface{}{
+				"filename": "big.txt",
+				"content":  base64.StdEncoding.EncodeToString([]byte("more than eight bytes")),
+			},
+		},
+	}))
+	if err == nil {
+		t.Fatal("Execute should fail when attachments exceed the cap")
+	}
+
+	errObj, ok := err.(*errors.Error)
+	if !ok {
+		t.Fatalf("Expected *errors.Error, got %T", err)
+	}
+	if errObj.Code != errors.EC_DATA_SIZE_EXCEEDED.Code {
+		t.Errorf("Expected error code %d, got %d", errors.EC_DATA_SIZE_EXCEEDED.Code, errObj.Code)
+	}
+}
+
+func TestEmailTool_Execute_InvalidAttachments(t *testing.T) {
+	tool := NewEmailTool(&email.Config{})
+
+	tests := []struct {
+		name        string
+		attachments interface{}
+		code        int
+	}{
+		{"not an array", "file.txt", errors.EC_TOOL_PARAMETER_INVALID.Code},
+		{"missing source", []interface{}{map[string]interface{}{"filename": "a.txt"}}, errors.EC_PARAMETER_MISSING.Code},
+		{"content without filename", []interface{}{map[string]interface{}{"content": "aGk="}}, errors.EC_PARAMETER_MISSING.Code},
+		{"invalid base64", []interface{}{map[string]interface{}{"filename": "a.txt", "content": "%%%"}}, errors.EC_TOOL_PARAMETER_INVALID.Code},
+	}
+
+	for _, tt := range tests {
+		_, err := tool.Execute(emailInput(map[string]interface{}{"attachments": tt.attachments}))
+		if err == nil {
+			t.Fatalf("%s: Execute should return error", tt.name)
+		}
+		errObj, ok := err.(*errors.Error)
+		if !ok {
+			t.Fatalf("%s: expected *errors.Error, got %T", tt.name, err)
+		}
+		if errObj.Code != tt.code {
+			t.Errorf("%s: expected error code %d, got %d", tt.name, tt.code, errObj.Code)
+		}
+	}
+}
+
+func TestEmailTool_PathAttachments(t *testing.T) {
+	dir := t.TempDir()
+	secret := filepath.Join(t.TempDir(), "cortex.yaml")
+	if err := os.WriteFile(secret, []byte("api_key: x"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(secret, filepath.Join(dir, "config.yaml")); err != nil {
+		t.Fatal(err)
+	}
+
+	disabled := NewEmailTool(&email.Config{})
+	items := disabled.Schema()["properties"].(map[string]interface{})["attachments"].(map[string]interface{})["items"].(map[string]interface{})
+	if _, ok := items["properties"].(map[string]interface{})["path"]; ok {
+		t.Error("Schema should not offer 'path' without an attachment directory")
+	}
+
+	enabled := NewEmailTool(&email.Config{AttachmentDir: dir})
+	tests := []struct {
+		name string
+		tool types.Tool
+		path string
+	}{
+		{"disabled by default", disabled, secret},
+		{"outside the directory", enabled, secret},
+		{"symlink out of the directory", enabled, "config.yaml"},
+		{"missing file", enabled, "missing.txt"},
+	}
+	for _, tt := range tests {
+		_, err := tt.tool.Execute(emailInput(map[string]interface{}{
+			"attachments": []interface{}{map[string]interface{}{"path": tt.path}},
+		}))
+		errObj, ok := err.(*errors.Error)
+		if !ok || errObj.Code != errors.EC_PERMISSION_DENIED.Code {
+			t.Errorf("%s: expected EC_PERMISSION_DENIED, got %v", tt.name, err)
+		}
+	}
+}
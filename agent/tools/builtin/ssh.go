@@ -2,6 +2,7 @@ package builtin
 
 import (
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/xichan96/cortex/agent/types"
@@ -9,18 +10,43 @@ import (
 	"github.com/xichan96/cortex/pkg/ssh"
 )
 
-type SSHTool struct{}
+type SSHTool struct {
+	mu             sync.RWMutex
+	hostKeyCheck   string // empty uses ssh.HostKeyStrict
+	knownHostsFile string // empty uses ~/.ssh/known_hosts
+}
 
-func NewSSHTool() types.Tool {
+func NewSSHTool() *SSHTool {
 	return &SSHTool{}
 }
 
+// SetHostKeyCheck sets how host keys are verified: ssh.HostKeyStrict (default), ssh.HostKeyAcceptNew
+// or ssh.HostKeyInsecure. It is an operator setting and not exposed to the model
+func (t *SSHTool) SetHostKeyCheck(mode string) error {
+	switch mode {
+	case "", ssh.HostKeyStrict, ssh.HostKeyAcceptNew, ssh.HostKeyInsecure:
+	default:
+		return errors.EC_INVALID_CONFIG.Wrap(fmt.Errorf("invalid host key check %q: must be one of %s, %s or %s", mode, ssh.HostKeyStrict, ssh.HostKeyAcceptNew, ssh.HostKeyInsecure))
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.hostKeyCheck = mode
+	return nil
+}
+
+// SetKnownHostsFile sets the known_hosts file used to verify host keys, empty uses ~/.ssh/known_hosts
+func (t *SSHTool) SetKnownHostsFile(path string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.knownHostsFile = path
+}
+
 func (t *SSHTool) Name() string {
 	return "ssh"
 }
 
 func (t *SSHTool) Description() string {
-	return "Execute commands on a remote server via SSH. Supports password, private key, and SSH agent authentication."
+	return "Execute commands on a remote server via SSH. Supports password, private key (with optional passphrase), and SSH agent authentication, verifying the host key against the configured known_hosts file."
 }
 
 func (t *SSHTool) Schema() map[string]interface{} {
@@ -45,7 +71,15 @@ func (t *SSHTool) Schema() map[string]interface{} {
 			},
 			"private_key": map[string]interface{}{
 				"type":        "string",
-				"description": "SSH private key content (optional if password or agent_socket is provided)",
+				"description": "SSH private key content in PEM format (optional if password, key_file or agent_socket is provided)",
+			},
+			"key_file": map[string]interface{}{
+				"type":        "string",
+				"description": "Path of an SSH private key file, used when private_key is not provided (optional)",
+			},
+			"passphrase": map[string]interface{}{
+				"type":        "string",
+				"description": "Passphrase of an encrypted private key (optional)",
 			},
 			"agent_socket": map[string]interface{}{
 				"type":        "string",
@@ -114,6 +148,19 @@ func (t *SSHTool) Execute(input map[string]interface{}) (interface{}, error) {
 		cfg.PrivateKey = privateKey
 	}
 
+	if keyFile, ok := input["key_file"].(string); ok && keyFile != "" {
+		cfg.KeyFile = keyFile
+	}
+
+	if passphrase, ok := input["passphrase"].(string); ok && passphrase != "" {
+		cfg.Passphrase = passphrase
+	}
+
+	t.mu.RLock()
+	cfg.HostKeyCheck = t.hostKeyCheck
+	cfg.KnownHostsFile = t.knownHostsFile
+	t.mu.RUnlock()
+
 	if agentSocket, ok := input["agent_socket"].(string); ok && agentSocket != "" {
 		cfg.AgentSocket = agentSocket
 	}
@@ -0,0 +1,33 @@
+package builtin
+
+import (
+	"testing"
+
+	"github.com/xichan96/cortex/agent/tools"
+	"github.com/xichan96/cortex/pkg/ssh"
+)
+
+func TestSSHTool_Schema(t *testing.T) {
+	tool := NewSSHTool()
+	if err := tools.ValidateToolSchema(tool); err != nil {
+		t.Fatalf("schema invalid: %v", err)
+	}
+	props := tool.Schema()["properties"].(map[string]interface{})
+	for _, name := range []string{"host_key_check", "known_hosts_file"} {
+		if _, ok := props[name]; ok {
+			t.Errorf("%s is an operator setting and must not be in the schema", name)
+		}
+	}
+}
+
+func TestSSHTool_SetHostKeyCheck(t *testing.T) {
+	tool := NewSSHTool()
+	for _, mode := range []string{"", ssh.HostKeyStrict, ssh.HostKeyAcceptNew, ssh.HostKeyInsecure} {
+		if err := tool.SetHostKeyCheck(mode); err != nil {
+			t.Errorf("SetHostKeyCheck(%q) failed: %v", mode, err)
+		}
+	}
+	if err := tool.SetHostKeyCheck("none"); err == nil {
+		t.Error("SetHostKeyCheck should reject an unknown mode")
+	}
+}
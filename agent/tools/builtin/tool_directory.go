@@ -0,0 +1,132 @@
+package builtin
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/xichan96/cortex/agent/engine"
+	"github.com/xichan96/cortex/agent/types"
+	"github.com/xichan96/cortex/pkg/errors"
+)
+
+const (
+	toolDirectoryName         = "tool_directory"
+	toolDirectoryDefaultLimit = 10
+	toolDirectoryMaxLimit     = 50
+)
+
+type ToolDirectoryTool struct {
+	engine *engine.AgentEngine
+}
+
+func NewToolDirectoryTool(engine *engine.AgentEngine) types.Tool {
+	return &ToolDirectoryTool{engine: engine}
+}
+
+func (t *ToolDirectoryTool) Name() string {
+	return toolDirectoryName
+}
+
+func (t *ToolDirectoryTool) Description() string {
+	return "Search the available tools by keyword and return their names, descriptions and parameter schemas. Use it to find a tool that can help with a task before calling it."
+}
+
+func (t *ToolDirectoryTool) Schema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"query": map[string]interface{}{
+				"type":        "string",
+				"description": "Keywords describing the task, e.g. 'send email' or 'read file'. Empty lists all tools",
+			},
+			"limit": map[string]interface{}{
+				"type":        "integer",
+				"description": fmt.Sprintf("Maximum number of tools to return (default %d, max %d)", toolDirectoryDefaultLimit, toolDirectoryMaxLimit),
+			},
+		},
+		"required": []string{"query"},
+	}
+}
+
+func (t *ToolDirectoryTool) Execute(input map[string]interface{}) (interface{}, error) {
+	if t.engine == nil {
+		return nil, errors.EC_INVALID_STATE.Wrap(fmt.Errorf("tool directory is not bound to an engine"))
+	}
+	query, ok := input["query"].(string)
+	if !ok {
+		return nil, errors.EC_PARAMETER_MISSING.Wrap(fmt.Errorf("'query' parameter is required"))
+	}
+	limit := toolDirectoryDefaultLimit
+	if l, ok := input["limit"].(float64); ok && l > 0 {
+		limit = int(l)
+	}
+	if limit > toolDirectoryMaxLimit {
+		limit = toolDirectoryMaxLimit
+	}
+
+	terms := strings.Fields(strings.ToLower(query))
+
+	type match struct {
+		tool  types.Tool
+		score int
+	}
+	var matches []match
+	for _, tool := range t.engine.EnabledTools() {
+		if tool.Name() == toolDirectoryName {
+			continue
+		}
+		score := toolMatchScore(tool, terms)
+		if len(terms) > 0 && score == 0 {
+			continue
+		}
+		matches = append(matches, match{tool: tool, score: score})
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].score > matches[j].score
+	})
+
+	total := len(matches)
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+
+	tools := make([]map[string]interface{}, 0, len(matches))
+	for _, m := range matches {
+		tools = append(tools, map[string]interface{}{
+			"name":        m.tool.Name(),
+			"description": m.tool.Description(),
+			"schema":      m.tool.Schema(),
+		})
+	}
+
+	return map[string]interface{}{
+		"query": query,
+		"total": total,
+		"tools": tools,
+	}, nil
+}
+
+func (t *ToolDirectoryTool) Metadata() types.ToolMetadata {
+	return types.ToolMetadata{
+		SourceNodeName: toolDirectoryName,
+		IsFromToolkit:  false,
+		ToolType:       "builtin",
+	}
+}
+
+// toolMatchScore scores a tool against query terms, name hits weigh more than description hits
+func toolMatchScore(tool types.Tool, terms []string) int {
+	name := strings.ToLower(tool.Name())
+	description := strings.ToLower(tool.Description())
+	score := 0
+	for _, term := range terms {
+		if strings.Contains(name, term) {
+			score += 2
+		}
+		if strings.Contains(description, term) {
+			score++
+		}
+	}
+	return score
+}
@@ -0,0 +1,57 @@
+package builtin
+
+import (
+	"testing"
+
+	"github.com/xichan96/cortex/agent/engine"
+	"github.com/xichan96/cortex/agent/types"
+)
+
+func TestToolDirectoryTool_Query(t *testing.T) {
+	agentEngine := engine.NewAgentEngine(nil, types.NewAgentConfig())
+	directory := NewToolDirectoryTool(agentEngine)
+	agentEngine.AddTools([]types.Tool{directory, NewTimeTool(), NewEncodingTool(), NewDiffTool()})
+
+	result, err := directory.Execute(map[string]interface{}{"query": "base64"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resultMap := result.(map[string]interface{})
+	tools := resultMap["tools"].([]map[string]interface{})
+	if len(tools) != 1 || tools[0]["name"] != "encoding" {
+		t.Errorf("Expected only the encoding tool, got %v", tools)
+	}
+}
+
+func TestToolDirectoryTool_ListAll(t *testing.T) {
+	agentEngine := engine.NewAgentEngine(nil, types.NewAgentConfig())
+	directory := NewToolDirectoryTool(agentEngine)
+	agentEngine.AddTools([]types.Tool{directory, NewTimeTool(), NewEncodingTool()})
+
+	result, err := directory.Execute(map[string]interface{}{"query": ""})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resultMap := result.(map[string]interface{})
+	if resultMap["total"] != 2 {
+		t.Errorf("Expected 2 tools excluding the directory itself, got %v", resultMap["total"])
+	}
+}
+
+func TestToolDirectoryTool_SkipsDisabledTools(t *testing.T) {
+	agentEngine := engine.NewAgentEngine(nil, types.NewAgentConfig())
+	directory := NewToolDirectoryTool(agentEngine)
+	agentEngine.AddTools([]types.Tool{directory, NewTimeTool(), NewEncodingTool()})
+	if err := agentEngine.DisableTool("encoding"); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := directory.Execute(map[string]interface{}{"query": "base64"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resultMap := result.(map[string]interface{})
+	if resultMap["total"] != 0 {
+		t.Errorf("Expected the disabled encoding tool to be hidden, got %v", resultMap["tools"])
+	}
+}
@@ -0,0 +1,83 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/xichan96/cortex/agent/types"
+	"github.com/xichan96/cortex/pkg/errors"
+)
+
+// FuncTool tool backed by a typed Go function
+// The schema is derived from Input with SchemaFromStruct and arguments are decoded into Input via JSON
+type FuncTool[Input any, Output any] struct {
+	name        string
+	description string
+	schema      map[string]interface{}
+	fn          func(Input) (Output, error)
+}
+
+// NewFuncTool creates a tool from a typed function
+func NewFuncTool[Input any, Output any](name, description string, fn func(Input) (Output, error)) types.Tool {
+	var zero Input
+	return &FuncTool[Input, Output]{
+		name:        name,
+		description: description,
+		schema:      SchemaFromStruct(zero),
+		fn:          fn,
+	}
+}
+
+// Name returns the tool name
+func (t *FuncTool[Input, Output]) Name() string {
+	return t.name
+}
+
+// Description returns the tool description
+func (t *FuncTool[Input, Output]) Description() string {
+	return t.description
+}
+
+// Schema returns the schema derived from Input
+func (t *FuncTool[Input, Output]) Schema() map[string]interface{} {
+	return t.schema
+}
+
+// Execute decodes the arguments into Input and calls the function
+func (t *FuncTool[Input, Output]) Execute(input map[string]interface{}) (interface{}, error) {
+	if required, ok := t.schema["required"].([]string); ok {
+		for _, name := range required {
+			if _, exists := input[name]; !exists {
+				return nil, errors.EC_PARAMETER_MISSING.Wrap(fmt.Errorf("'%s' parameter is required", name))
+			}
+		}
+	}
+
+	data, err := json.Marshal(input)
+	if err != nil {
+		return nil, errors.EC_PARAMETER_INVALID.Wrap(err)
+	}
+	var args Input
+	// Decode into the pointed-to value when Input is a pointer type
+	if rv := reflect.ValueOf(&args).Elem(); rv.Kind() == reflect.Ptr {
+		rv.Set(reflect.New(rv.Type().Elem()))
+		err = json.Unmarshal(data, rv.Interface())
+	} else {
+		err = json.Unmarshal(data, &args)
+	}
+	if err != nil {
+		return nil, errors.EC_PARAMETER_INVALID.Wrap(fmt.Errorf("invalid arguments for tool %s: %w", t.name, err))
+	}
+
+	return t.fn(args)
+}
+
+// Metadata returns the tool metadata
+func (t *FuncTool[Input, Output]) Metadata() types.ToolMetadata {
+	return types.ToolMetadata{
+		SourceNodeName: t.name,
+		IsFromToolkit:  false,
+		ToolType:       "function",
+	}
+}
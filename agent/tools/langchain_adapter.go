@@ -6,6 +6,7 @@ import (
 	"fmt"
 
 	"github.com/xichan96/cortex/agent/types"
+	"github.com/xichan96/cortex/pkg/errors"
 )
 
 // LangChainToolAdapter LangChain tool adapter
@@ -46,3 +47,70 @@ func (a *LangChainToolAdapter) Call(ctx context.Context, input string) (string,
 	// Convert result to string
 	return fmt.Sprintf("%v", result), nil
 }
+
+// LangChainTool the langchaingo tools.Tool interface, any langchaingo tool satisfies it
+type LangChainTool interface {
+	Name() string
+	Description() string
+	Call(ctx context.Context, input string) (string, error)
+}
+
+// langChainTool wraps a langchaingo tool as a cortex tool
+type langChainTool struct {
+	lcTool LangChainTool
+}
+
+// NewToolFromLangChain wraps a langchaingo tool so it can be added to the engine
+// langchaingo tools take a single string, so the tool exposes one "input" parameter
+func NewToolFromLangChain(lcTool LangChainTool) types.Tool {
+	return &langChainTool{lcTool: lcTool}
+}
+
+// Name returns the tool name
+func (t *langChainTool) Name() string {
+	return t.lcTool.Name()
+}
+
+// Description returns the tool description
+func (t *langChainTool) Description() string {
+	return t.lcTool.Description()
+}
+
+// Schema returns the single string parameter of a langchaingo tool
+func (t *langChainTool) Schema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"input": map[string]interface{}{
+				"type":        "string",
+				"description": "Input passed to the tool, as described by the tool description",
+			},
+		},
+		"required": []string{"input"},
+	}
+}
+
+// Execute passes the "input" argument to the langchaingo tool
+// Any other arguments are marshaled to a JSON string instead, as NewLangChainToolAdapter expects
+func (t *langChainTool) Execute(input map[string]interface{}) (interface{}, error) {
+	var text string
+	if value, ok := input["input"].(string); ok && len(input) == 1 {
+		text = value
+	} else {
+		data, err := json.Marshal(input)
+		if err != nil {
+			return nil, errors.EC_PARAMETER_INVALID.Wrap(err)
+		}
+		text = string(data)
+	}
+	return t.lcTool.Call(context.Background(), text)
+}
+
+// Metadata returns the tool metadata
+func (t *langChainTool) Metadata() types.ToolMetadata {
+	return types.ToolMetadata{
+		SourceNodeName: t.lcTool.Name(),
+		IsFromToolkit:  false,
+		ToolType:       "langchain",
+	}
+}
@@ -0,0 +1,53 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// upperTool mimics a langchaingo tool, upper-casing its input
+type upperTool struct{}
+
+func (upperTool) Name() string        { return "upper" }
+func (upperTool) Description() string { return "Upper-cases the input" }
+func (upperTool) Call(ctx context.Context, input string) (string, error) {
+	return strings.ToUpper(input), nil
+}
+
+func TestNewToolFromLangChain(t *testing.T) {
+	tool := NewToolFromLangChain(upperTool{})
+	if tool.Name() != "upper" || tool.Description() != "Upper-cases the input" {
+		t.Errorf("Expected name and description of the langchaingo tool, got %q, %q", tool.Name(), tool.Description())
+	}
+	if err := ValidateInput(tool.Schema(), map[string]interface{}{"input": "hello"}); err != nil {
+		t.Errorf("Expected input argument to match the schema: %v", err)
+	}
+
+	result, err := tool.Execute(map[string]interface{}{"input": "hello"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result != "HELLO" {
+		t.Errorf("Expected input passed as-is, got %v", result)
+	}
+
+	result, err = tool.Execute(map[string]interface{}{"a": 1, "b": "x"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result != `{"A":1,"B":"X"}` {
+		t.Errorf("Expected arguments marshaled to JSON, got %v", result)
+	}
+}
+
+func TestLangChainToolRoundTrip(t *testing.T) {
+	tool := NewToolFromLangChain(NewLangChainToolAdapter(NewToolFromLangChain(upperTool{})))
+	result, err := tool.Execute(map[string]interface{}{"input": "hello"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result != "HELLO" {
+		t.Errorf("Expected wrapped tool to receive the input, got %v", result)
+	}
+}
@@ -2,26 +2,43 @@ package tools
 
 import (
 	"fmt"
+	"log/slog"
 	"sync"
 
 	"github.com/xichan96/cortex/agent/types"
 	"github.com/xichan96/cortex/pkg/errors"
+	"github.com/xichan96/cortex/pkg/logger"
 )
 
 // Registry tool registry
 type Registry struct {
-	tools map[string]types.Tool
-	mu    sync.RWMutex
+	tools            map[string]types.Tool
+	disabled         map[string]bool // names of registered tools left out of GetAll and GetByType
+	schemaValidation string
+	logger           *logger.Logger
+	mu               sync.RWMutex
 }
 
 // NewRegistry creates a new tool registry
 func NewRegistry() *Registry {
 	return &Registry{
-		tools: make(map[string]types.Tool),
+		tools:            make(map[string]types.Tool),
+		disabled:         make(map[string]bool),
+		schemaValidation: SchemaValidationWarn,
+		logger:           logger.NewLogger(),
 	}
 }
 
+// SetSchemaValidation sets how malformed tool schemas are handled by Register
+// (SchemaValidationWarn, SchemaValidationError or SchemaValidationOff)
+func (r *Registry) SetSchemaValidation(mode string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.schemaValidation = mode
+}
+
 // Register registers a tool
+// In SchemaValidationError mode a malformed schema fails with EC_TOOL_VALIDATION_FAILED
 func (r *Registry) Register(tool types.Tool) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
@@ -31,6 +48,15 @@ func (r *Registry) Register(tool types.Tool) error {
 		return errors.NewError(errors.EC_TOOL_ALREADY_REGISTERED.Code, fmt.Sprintf("tool %s already registered", name))
 	}
 
+	if r.schemaValidation != SchemaValidationOff {
+		if err := ValidateToolSchema(tool); err != nil {
+			if r.schemaValidation == SchemaValidationError {
+				return err
+			}
+			r.logger.LogError("Registry.Register", err, slog.String("tool_name", name))
+		}
+	}
+
 	r.tools[name] = tool
 	return nil
 }
@@ -45,6 +71,41 @@ func (r *Registry) RegisterMultiple(tools []types.Tool) error {
 	return nil
 }
 
+// Enable re-enables a disabled tool
+func (r *Registry) Enable(name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.tools[name]; !exists {
+		return errors.NewError(errors.EC_TOOL_NOT_FOUND.Code, fmt.Sprintf("tool %s not found", name))
+	}
+
+	delete(r.disabled, name)
+	return nil
+}
+
+// Disable hides a tool from GetAll and GetByType without removing it, Get still returns it
+func (r *Registry) Disable(name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.tools[name]; !exists {
+		return errors.NewError(errors.EC_TOOL_NOT_FOUND.Code, fmt.Sprintf("tool %s not found", name))
+	}
+
+	r.disabled[name] = true
+	return nil
+}
+
+// IsEnabled reports whether a tool is registered and not disabled
+func (r *Registry) IsEnabled(name string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	_, exists := r.tools[name]
+	return exists && !r.disabled[name]
+}
+
 // Get gets a tool
 func (r *Registry) Get(name string) (types.Tool, error) {
 	r.mu.RLock()
@@ -58,26 +119,31 @@ func (r *Registry) Get(name string) (types.Tool, error) {
 	return tool, nil
 }
 
-// GetAll gets all tools
+// GetAll gets all enabled tools
 func (r *Registry) GetAll() []types.Tool {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
 	tools := make([]types.Tool, 0, len(r.tools))
-	for _, tool := range r.tools {
-		tools = append(tools, tool)
+	for name, tool := range r.tools {
+		if !r.disabled[name] {
+			tools = append(tools, tool)
+		}
 	}
 
 	return tools
 }
 
-// GetByType gets tools by type
+// GetByType gets enabled tools by type
 func (r *Registry) GetByType(toolType string) []types.Tool {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
 	tools := make([]types.Tool, 0)
-	for _, tool := range r.tools {
+	for name, tool := range r.tools {
+		if r.disabled[name] {
+			continue
+		}
 		metadata := tool.Metadata()
 		if metadata.ToolType == toolType {
 			tools = append(tools, tool)
@@ -97,6 +163,7 @@ func (r *Registry) Remove(name string) error {
 	}
 
 	delete(r.tools, name)
+	delete(r.disabled, name)
 	return nil
 }
 
@@ -106,9 +173,10 @@ func (r *Registry) Clear() {
 	defer r.mu.Unlock()
 
 	r.tools = make(map[string]types.Tool)
+	r.disabled = make(map[string]bool)
 }
 
-// Size gets the number of tools
+// Size gets the number of tools, including disabled ones
 func (r *Registry) Size() int {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
@@ -153,6 +221,21 @@ func (m *Manager) GetByType(toolType string) []types.Tool {
 	return m.registry.GetByType(toolType)
 }
 
+// Enable re-enables a disabled tool
+func (m *Manager) Enable(name string) error {
+	return m.registry.Enable(name)
+}
+
+// Disable hides a tool from GetAll and GetByType
+func (m *Manager) Disable(name string) error {
+	return m.registry.Disable(name)
+}
+
+// IsEnabled reports whether a tool is registered and not disabled
+func (m *Manager) IsEnabled(name string) bool {
+	return m.registry.IsEnabled(name)
+}
+
 // Remove removes a tool
 func (m *Manager) Remove(name string) error {
 	return m.registry.Remove(name)
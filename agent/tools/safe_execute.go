@@ -0,0 +1,61 @@
+package tools
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"time"
+
+	"github.com/xichan96/cortex/agent/types"
+	"github.com/xichan96/cortex/pkg/errors"
+)
+
+// ExecutionOutcome result of a sandboxed tool execution
+type ExecutionOutcome struct {
+	Result   interface{}
+	Err      error
+	Duration time.Duration
+	TimedOut bool // the context deadline passed before the tool returned
+	Panicked bool // the tool panicked, Err holds the recovered value
+}
+
+// SafeExecute runs a tool in its own goroutine, recovering panics into errors and enforcing the context deadline
+// The Tool interface has no cancellation, so a tool that outlives the deadline keeps running in the background
+// and its result is discarded
+func SafeExecute(ctx context.Context, tool types.Tool, args map[string]interface{}) ExecutionOutcome {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	start := time.Now()
+
+	// Buffered so the goroutine can always deliver its outcome and exit, even after a timeout
+	done := make(chan ExecutionOutcome, 1)
+	go func() {
+		var outcome ExecutionOutcome
+		defer func() {
+			if r := recover(); r != nil {
+				outcome = ExecutionOutcome{
+					Err:      errors.NewError(errors.EC_TOOL_EXECUTION_FAILED.Code, fmt.Sprintf("tool '%s' panicked", tool.Name())).Wrap(fmt.Errorf("tool execution panic: %v", r)),
+					Panicked: true,
+				}
+			}
+			done <- outcome
+		}()
+		outcome.Result, outcome.Err = tool.Execute(args)
+	}()
+
+	select {
+	case outcome := <-done:
+		outcome.Duration = time.Since(start)
+		return outcome
+	case <-ctx.Done():
+		outcome := ExecutionOutcome{Duration: time.Since(start)}
+		if stderrors.Is(ctx.Err(), context.DeadlineExceeded) {
+			outcome.TimedOut = true
+			outcome.Err = errors.NewError(errors.EC_TOOL_EXECUTION_TIMEOUT.Code, errors.EC_TOOL_EXECUTION_TIMEOUT.Message).Wrap(fmt.Errorf("tool execution timeout after %v", outcome.Duration.Round(time.Millisecond)))
+		} else {
+			outcome.Err = errors.NewError(errors.EC_TOOL_EXECUTION_FAILED.Code, "tool execution cancelled").Wrap(ctx.Err())
+		}
+		return outcome
+	}
+}
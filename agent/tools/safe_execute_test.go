@@ -0,0 +1,118 @@
+package tools
+
+import (
+	"context"
+	stderrors "errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xichan96/cortex/agent/types"
+	"github.com/xichan96/cortex/pkg/errors"
+)
+
+type safeExecuteTestTool struct {
+	execute func(map[string]interface{}) (interface{}, error)
+}
+
+func (t *safeExecuteTestTool) Name() string                   { return "test_tool" }
+func (t *safeExecuteTestTool) Description() string            { return "test tool" }
+func (t *safeExecuteTestTool) Schema() map[string]interface{} { return nil }
+func (t *safeExecuteTestTool) Metadata() types.ToolMetadata   { return types.ToolMetadata{} }
+func (t *safeExecuteTestTool) Execute(input map[string]interface{}) (interface{}, error) {
+	return t.execute(input)
+}
+
+func TestSafeExecuteCompletes(t *testing.T) {
+	tool := &safeExecuteTestTool{execute: func(input map[string]interface{}) (interface{}, error) {
+		return input["value"], nil
+	}}
+
+	outcome := SafeExecute(context.Background(), tool, map[string]interface{}{"value": 42})
+	if outcome.Err != nil {
+		t.Fatalf("Unexpected error: %v", outcome.Err)
+	}
+	if outcome.Result != 42 {
+		t.Errorf("Expected result 42, got %v", outcome.Result)
+	}
+	if outcome.TimedOut || outcome.Panicked {
+		t.Errorf("Expected clean outcome, got %+v", outcome)
+	}
+}
+
+func TestSafeExecuteReturnsToolError(t *testing.T) {
+	toolErr := stderrors.New("upstream unavailable")
+	tool := &safeExecuteTestTool{execute: func(map[string]interface{}) (interface{}, error) {
+		return nil, toolErr
+	}}
+
+	outcome := SafeExecute(context.Background(), tool, nil)
+	if outcome.Err != toolErr {
+		t.Errorf("Expected tool error to be returned unchanged, got %v", outcome.Err)
+	}
+	if outcome.Panicked {
+		t.Error("Expected Panicked to be false")
+	}
+}
+
+func TestSafeExecuteRecoversPanic(t *testing.T) {
+	tool := &safeExecuteTestTool{execute: func(map[string]interface{}) (interface{}, error) {
+		panic("boom")
+	}}
+
+	outcome := SafeExecute(context.Background(), tool, nil)
+	if !outcome.Panicked {
+		t.Fatal("Expected Panicked to be true")
+	}
+	var e *errors.Error
+	if !stderrors.As(outcome.Err, &e) || e.Code != errors.EC_TOOL_EXECUTION_FAILED.Code {
+		t.Errorf("Expected EC_TOOL_EXECUTION_FAILED, got %v", outcome.Err)
+	}
+	if !strings.Contains(outcome.Err.Error(), "boom") {
+		t.Errorf("Expected panic value in error, got %v", outcome.Err)
+	}
+}
+
+func TestSafeExecuteTimeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	tool := &safeExecuteTestTool{execute: func(map[string]interface{}) (interface{}, error) {
+		<-release
+		return "late", nil
+	}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	outcome := SafeExecute(ctx, tool, nil)
+	if !outcome.TimedOut {
+		t.Fatal("Expected TimedOut to be true")
+	}
+	if outcome.Result != nil {
+		t.Errorf("Expected no result, got %v", outcome.Result)
+	}
+	var e *errors.Error
+	if !stderrors.As(outcome.Err, &e) || e.Code != errors.EC_TOOL_EXECUTION_TIMEOUT.Code {
+		t.Errorf("Expected EC_TOOL_EXECUTION_TIMEOUT, got %v", outcome.Err)
+	}
+}
+
+func TestSafeExecuteCancelled(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	tool := &safeExecuteTestTool{execute: func(map[string]interface{}) (interface{}, error) {
+		<-release
+		return nil, nil
+	}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	outcome := SafeExecute(ctx, tool, nil)
+	if outcome.TimedOut {
+		t.Error("Expected cancellation not to be reported as a timeout")
+	}
+	if outcome.Err == nil || !stderrors.Is(outcome.Err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", outcome.Err)
+	}
+}
@@ -0,0 +1,190 @@
+package tools
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// SchemaFromStruct generates a JSON schema from a Go struct (or pointer to struct)
+// Supported field tags:
+//   - json: property name, "-" skips the field, omitempty makes it optional
+//   - description: property description
+//   - default: default value, parsed according to the field type
+//   - validate: "required" forces a field to be required, "min=N"/"max=N" map to
+//     minimum/maximum (numbers), minLength/maxLength (strings) or minItems/maxItems (slices),
+//     "oneof=a b c" maps to enum
+//
+// Fields without omitempty that are not pointers are required
+func SchemaFromStruct(v interface{}) map[string]interface{} {
+	t := reflect.TypeOf(v)
+	if t == nil {
+		return map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}
+	}
+	return schemaForType(t, make(map[reflect.Type]bool))
+}
+
+// schemaForType generates the JSON schema of a Go type
+// visiting holds the structs being generated, a recursive reference to one of them becomes a plain object
+func schemaForType(t reflect.Type, visiting map[reflect.Type]bool) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": schemaForType(t.Elem(), visiting)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": schemaForType(t.Elem(), visiting)}
+	case reflect.Struct:
+		if visiting[t] {
+			return map[string]interface{}{"type": "object"}
+		}
+		return schemaForStruct(t, visiting)
+	default:
+		// interface{} and other kinds accept any value
+		return map[string]interface{}{}
+	}
+}
+
+// schemaForStruct generates an object schema from exported struct fields
+func schemaForStruct(t reflect.Type, visiting map[reflect.Type]bool) map[string]interface{} {
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	properties := make(map[string]interface{})
+	required := make([]string, 0)
+	collectFields(t, properties, &required, visiting)
+
+	schema := map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// collectFields adds the properties of t to properties, flattening embedded structs like encoding/json
+func collectFields(t reflect.Type, properties map[string]interface{}, required *[]string, visiting map[reflect.Type]bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && name == "" {
+			ft := field.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				// A struct embedding itself through a pointer adds no further fields
+				if !visiting[ft] {
+					visiting[ft] = true
+					collectFields(ft, properties, required, visiting)
+					delete(visiting, ft)
+				}
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		prop := schemaForType(field.Type, visiting)
+		if desc := field.Tag.Get("description"); desc != "" {
+			prop["description"] = desc
+		}
+		if def, ok := field.Tag.Lookup("default"); ok {
+			prop["default"] = parseTagValue(field.Type, def)
+		}
+
+		isRequired := !strings.Contains(opts, "omitempty") && field.Type.Kind() != reflect.Ptr
+		for _, rule := range strings.Split(field.Tag.Get("validate"), ",") {
+			key, value, _ := strings.Cut(strings.TrimSpace(rule), "=")
+			switch key {
+			case "required":
+				isRequired = true
+			case "min", "max":
+				applyBound(prop, key, value)
+			case "oneof":
+				values := strings.Fields(value)
+				enum := make([]interface{}, 0, len(values))
+				for _, v := range values {
+					enum = append(enum, parseTagValue(field.Type, v))
+				}
+				prop["enum"] = enum
+			}
+		}
+
+		properties[name] = prop
+		if isRequired {
+			*required = append(*required, name)
+		}
+	}
+}
+
+// applyBound maps a min/max validation rule to the keyword matching the property type
+func applyBound(prop map[string]interface{}, key, value string) {
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return
+	}
+	var keyword string
+	switch prop["type"] {
+	case "string":
+		keyword = key + "Length"
+	case "array":
+		keyword = key + "Items"
+	case "integer", "number":
+		keyword = map[string]string{"min": "minimum", "max": "maximum"}[key]
+	default:
+		return
+	}
+	prop[keyword] = n
+}
+
+// parseTagValue converts a tag string into a value of the field's JSON type
+func parseTagValue(t reflect.Type, value string) interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return n
+		}
+	case reflect.Float32, reflect.Float64:
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	}
+	return value
+}
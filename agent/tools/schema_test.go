@@ -0,0 +1,136 @@
+package tools
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/xichan96/cortex/pkg/errors"
+)
+
+type schemaTestBase struct {
+	Verbose bool `json:"verbose,omitempty" description:"Verbose output"`
+}
+
+type schemaTestInput struct {
+	schemaTestBase
+	Query  string            `json:"query" description:"Search query" validate:"min=1"`
+	Limit  int               `json:"limit,omitempty" default:"10" validate:"min=1,max=50"`
+	Mode   string            `json:"mode,omitempty" validate:"required,oneof=fast exact"`
+	Tags   []string          `json:"tags,omitempty"`
+	Filter *string           `json:"filter"`
+	Labels map[string]string `json:"labels,omitempty"`
+	Hidden string            `json:"-"`
+	secret string
+}
+
+func TestSchemaFromStruct(t *testing.T) {
+	schema := SchemaFromStruct(schemaTestInput{})
+	if schema["type"] != "object" {
+		t.Fatalf("Expected object schema, got %v", schema["type"])
+	}
+
+	props := schema["properties"].(map[string]interface{})
+	if len(props) != 7 {
+		t.Errorf("Expected 7 properties, got %d: %v", len(props), props)
+	}
+	for _, name := range []string{"Hidden", "secret"} {
+		if _, ok := props[name]; ok {
+			t.Errorf("Expected %s to be skipped", name)
+		}
+	}
+
+	query := props["query"].(map[string]interface{})
+	if query["type"] != "string" || query["description"] != "Search query" || query["minLength"] != 1.0 {
+		t.Errorf("Unexpected query schema: %v", query)
+	}
+	limit := props["limit"].(map[string]interface{})
+	if limit["type"] != "integer" || limit["default"] != int64(10) || limit["maximum"] != 50.0 {
+		t.Errorf("Unexpected limit schema: %v", limit)
+	}
+	mode := props["mode"].(map[string]interface{})
+	if !reflect.DeepEqual(mode["enum"], []interface{}{"fast", "exact"}) {
+		t.Errorf("Unexpected mode enum: %v", mode["enum"])
+	}
+	tags := props["tags"].(map[string]interface{})
+	if tags["type"] != "array" || tags["items"].(map[string]interface{})["type"] != "string" {
+		t.Errorf("Unexpected tags schema: %v", tags)
+	}
+	if props["verbose"].(map[string]interface{})["type"] != "boolean" {
+		t.Error("Expected embedded struct fields to be flattened")
+	}
+
+	expected := []string{"query", "mode"}
+	if !reflect.DeepEqual(schema["required"], expected) {
+		t.Errorf("Expected required %v, got %v", expected, schema["required"])
+	}
+}
+
+type treeNode struct {
+	Name     string     `json:"name"`
+	Children []treeNode `json:"children,omitempty"`
+	Parent   *treeNode  `json:"parent,omitempty"`
+	*treeNode
+}
+
+func TestSchemaFromStructRecursive(t *testing.T) {
+	schema := SchemaFromStruct(treeNode{})
+
+	props := schema["properties"].(map[string]interface{})
+	children := props["children"].(map[string]interface{})
+	if !reflect.DeepEqual(children["items"], map[string]interface{}{"type": "object"}) {
+		t.Errorf("Expected recursive items to be a plain object, got %v", children["items"])
+	}
+	if !reflect.DeepEqual(props["parent"], map[string]interface{}{"type": "object"}) {
+		t.Errorf("Expected recursive pointer to be a plain object, got %v", props["parent"])
+	}
+	if len(props) != 3 {
+		t.Errorf("Expected 3 properties, got %d: %v", len(props), props)
+	}
+}
+
+type addInput struct {
+	A int `json:"a"`
+	B int `json:"b"`
+}
+
+func TestFuncTool(t *testing.T) {
+	tool := NewFuncTool("add", "Add two numbers", func(in addInput) (int, error) {
+		return in.A + in.B, nil
+	})
+
+	if tool.Name() != "add" || tool.Metadata().ToolType != "function" {
+		t.Errorf("Unexpected tool identity: %s %s", tool.Name(), tool.Metadata().ToolType)
+	}
+
+	result, err := tool.Execute(map[string]interface{}{"a": 2, "b": 3.0})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result != 5 {
+		t.Errorf("Expected 5, got %v", result)
+	}
+
+	_, err = tool.Execute(map[string]interface{}{"a": 1})
+	if errObj, ok := err.(*errors.Error); !ok || errObj.Code != errors.EC_PARAMETER_MISSING.Code {
+		t.Errorf("Expected missing parameter error, got %v", err)
+	}
+
+	_, err = tool.Execute(map[string]interface{}{"a": "one", "b": 2})
+	if errObj, ok := err.(*errors.Error); !ok || errObj.Code != errors.EC_PARAMETER_INVALID.Code {
+		t.Errorf("Expected invalid parameter error, got %v", err)
+	}
+}
+
+func TestFuncTool_PointerInput(t *testing.T) {
+	tool := NewFuncTool("echo", "Echo input", func(in *addInput) (*addInput, error) {
+		return in, nil
+	})
+
+	result, err := tool.Execute(map[string]interface{}{"a": 1, "b": 2})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if out := result.(*addInput); out.A != 1 || out.B != 2 {
+		t.Errorf("Unexpected result: %+v", out)
+	}
+}
@@ -0,0 +1,343 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/xichan96/cortex/agent/types"
+	"github.com/xichan96/cortex/pkg/errors"
+)
+
+// Schema validation modes applied when tools are registered
+const (
+	SchemaValidationWarn  = "warn"  // log malformed schemas and register the tool anyway (default)
+	SchemaValidationError = "error" // reject tools with malformed schemas
+	SchemaValidationOff   = "off"   // skip validation
+)
+
+// validSchemaTypes JSON Schema type names
+var validSchemaTypes = map[string]bool{
+	"object":  true,
+	"array":   true,
+	"string":  true,
+	"number":  true,
+	"integer": true,
+	"boolean": true,
+	"null":    true,
+}
+
+// ValidateSchema checks that a tool schema is a well-formed JSON Schema object
+// The root must be an object schema (an empty schema declares no parameters), every type must be
+// a JSON Schema type and every required field must be declared in properties
+// Problems are reported together as EC_TOOL_VALIDATION_FAILED
+func ValidateSchema(schema map[string]interface{}) error {
+	if problems := schemaProblems(schema); len(problems) > 0 {
+		return errors.NewError(errors.EC_TOOL_VALIDATION_FAILED.Code, "invalid tool schema: "+strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// ValidateToolSchema checks the schema of a tool like ValidateSchema, naming the tool in the error
+func ValidateToolSchema(tool types.Tool) error {
+	if problems := schemaProblems(tool.Schema()); len(problems) > 0 {
+		return errors.NewError(errors.EC_TOOL_VALIDATION_FAILED.Code,
+			fmt.Sprintf("tool %s has an invalid schema: %s", tool.Name(), strings.Join(problems, "; ")))
+	}
+	return nil
+}
+
+// schemaProblems lists everything wrong with a schema
+func schemaProblems(schema map[string]interface{}) []string {
+	if len(schema) == 0 {
+		return nil
+	}
+
+	// Round-trip through JSON so typed maps and slices are checked like the schema providers receive
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return []string{fmt.Sprintf("not JSON-serializable: %v", err)}
+	}
+	var normalized map[string]interface{}
+	if err := json.Unmarshal(data, &normalized); err != nil {
+		return []string{err.Error()}
+	}
+
+	var problems []string
+	if t, ok := normalized["type"]; ok && t != "object" {
+		problems = append(problems, fmt.Sprintf("root type must be \"object\", got %v", t))
+	}
+	validateSchemaNode(normalized, "", &problems)
+	return problems
+}
+
+// validateSchemaNode appends the problems of one schema node and its subschemas
+func validateSchemaNode(node map[string]interface{}, path string, problems *[]string) {
+	at := func(format string, args ...interface{}) {
+		msg := fmt.Sprintf(format, args...)
+		if path != "" {
+			msg = path + ": " + msg
+		}
+		*problems = append(*problems, msg)
+	}
+
+	if t, ok := node["type"]; ok {
+		switch v := t.(type) {
+		case string:
+			if !validSchemaTypes[v] {
+				at("invalid type %q", v)
+			}
+		case []interface{}:
+			for _, item := range v {
+				if s, ok := item.(string); !ok || !validSchemaTypes[s] {
+					at("invalid type %v", item)
+				}
+			}
+		default:
+			at("type must be a string or an array of strings")
+		}
+	}
+
+	var properties map[string]interface{}
+	if raw, ok := node["properties"]; ok {
+		properties, ok = raw.(map[string]interface{})
+		if !ok {
+			at("properties must be an object")
+		}
+		names := make([]string, 0, len(properties))
+		for name := range properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			child, ok := properties[name].(map[string]interface{})
+			if !ok {
+				at("property %q must be a schema object", name)
+				continue
+			}
+			validateSchemaNode(child, joinSchemaPath(path, name), problems)
+		}
+	}
+
+	if raw, ok := node["required"]; ok {
+		required, ok := raw.([]interface{})
+		if !ok {
+			at("required must be an array of strings")
+		}
+		for _, item := range required {
+			name, ok := item.(string)
+			if !ok {
+				at("required entry %v must be a string", item)
+				continue
+			}
+			if _, declared := properties[name]; !declared {
+				at("required field %q is not declared in properties", name)
+			}
+		}
+	}
+
+	switch items := node["items"].(type) {
+	case nil:
+	case map[string]interface{}:
+		validateSchemaNode(items, joinSchemaPath(path, "items"), problems)
+	case []interface{}:
+		for i, item := range items {
+			if child, ok := item.(map[string]interface{}); ok {
+				validateSchemaNode(child, joinSchemaPath(path, fmt.Sprintf("items[%d]", i)), problems)
+			} else {
+				at("items[%d] must be a schema object", i)
+			}
+		}
+	default:
+		at("items must be a schema object or an array of schema objects")
+	}
+
+	switch additional := node["additionalProperties"].(type) {
+	case nil, bool:
+	case map[string]interface{}:
+		validateSchemaNode(additional, joinSchemaPath(path, "additionalProperties"), problems)
+	default:
+		at("additionalProperties must be a boolean or a schema object")
+	}
+
+	for _, keyword := range []string{"anyOf", "oneOf", "allOf"} {
+		raw, ok := node[keyword]
+		if !ok {
+			continue
+		}
+		subschemas, ok := raw.([]interface{})
+		if !ok {
+			at("%s must be an array of schema objects", keyword)
+			continue
+		}
+		for i, item := range subschemas {
+			if child, ok := item.(map[string]interface{}); ok {
+				validateSchemaNode(child, joinSchemaPath(path, fmt.Sprintf("%s[%d]", keyword, i)), problems)
+			} else {
+				at("%s[%d] must be a schema object", keyword, i)
+			}
+		}
+	}
+
+	if raw, ok := node["enum"]; ok {
+		if _, ok := raw.([]interface{}); !ok {
+			at("enum must be an array")
+		}
+	}
+}
+
+// ValidateInput checks tool arguments against the tool schema before the tool runs
+// Required fields must be present and non-null, and values must match the declared types, nested objects
+// and array items are checked the same way. Unknown types and keywords are ignored, so malformed schemas
+// registered in warn mode do not reject every call. Problems are reported together as EC_TOOL_VALIDATION_FAILED
+func ValidateInput(schema map[string]interface{}, args map[string]interface{}) error {
+	if len(schema) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return nil
+	}
+	var normalized map[string]interface{}
+	if err := json.Unmarshal(data, &normalized); err != nil {
+		return nil
+	}
+
+	var problems []string
+	validateObjectInput(normalized, args, "", &problems)
+	if len(problems) > 0 {
+		return errors.NewError(errors.EC_TOOL_VALIDATION_FAILED.Code, "invalid tool input: "+strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// validateObjectInput appends the problems of an object value against an object schema node
+func validateObjectInput(node map[string]interface{}, args map[string]interface{}, path string, problems *[]string) {
+	required, _ := node["required"].([]interface{})
+	for _, item := range required {
+		name, ok := item.(string)
+		if !ok {
+			continue
+		}
+		if value, present := args[name]; !present || value == nil {
+			*problems = append(*problems, fmt.Sprintf("missing required field %q", joinSchemaPath(path, name)))
+		}
+	}
+
+	properties, _ := node["properties"].(map[string]interface{})
+	names := make([]string, 0, len(args))
+	for name := range args {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		child, ok := properties[name].(map[string]interface{})
+		if !ok || args[name] == nil {
+			continue
+		}
+		validateValueInput(child, args[name], joinSchemaPath(path, name), problems)
+	}
+}
+
+// validateValueInput appends the problems of one value against its schema node
+func validateValueInput(node map[string]interface{}, value interface{}, path string, problems *[]string) {
+	var allowed []string
+	switch t := node["type"].(type) {
+	case string:
+		allowed = []string{t}
+	case []interface{}:
+		for _, item := range t {
+			if s, ok := item.(string); ok {
+				allowed = append(allowed, s)
+			}
+		}
+	}
+	if len(allowed) > 0 {
+		matched, known := false, false
+		for _, t := range allowed {
+			if validSchemaTypes[t] {
+				known = true
+				if matchesSchemaType(t, value) {
+					matched = true
+					break
+				}
+			}
+		}
+		if known && !matched {
+			*problems = append(*problems, fmt.Sprintf("field %q must be %s, got %s", path, strings.Join(allowed, " or "), inputTypeName(value)))
+			return
+		}
+	}
+
+	if object, ok := value.(map[string]interface{}); ok {
+		validateObjectInput(node, object, path, problems)
+		return
+	}
+	items, ok := node["items"].(map[string]interface{})
+	if !ok {
+		return
+	}
+	rv := reflect.ValueOf(value)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return
+	}
+	for i := 0; i < rv.Len(); i++ {
+		if item := rv.Index(i).Interface(); item != nil {
+			validateValueInput(items, item, fmt.Sprintf("%s[%d]", path, i), problems)
+		}
+	}
+}
+
+// matchesSchemaType reports whether a decoded JSON or Go value has the JSON Schema type t
+func matchesSchemaType(t string, value interface{}) bool {
+	if value == nil {
+		return t == "null"
+	}
+	rv := reflect.ValueOf(value)
+	switch t {
+	case "string":
+		return rv.Kind() == reflect.String
+	case "boolean":
+		return rv.Kind() == reflect.Bool
+	case "number":
+		return isNumberKind(rv.Kind())
+	case "integer":
+		switch rv.Kind() {
+		case reflect.Float32, reflect.Float64:
+			f := rv.Float()
+			return f == math.Trunc(f) && !math.IsInf(f, 0)
+		}
+		return isNumberKind(rv.Kind())
+	case "array":
+		return rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array
+	case "object":
+		return rv.Kind() == reflect.Map || rv.Kind() == reflect.Struct
+	}
+	return false
+}
+
+// isNumberKind reports whether k is a Go integer or float kind
+func isNumberKind(k reflect.Kind) bool {
+	return (k >= reflect.Int && k <= reflect.Uint64) || k == reflect.Float32 || k == reflect.Float64
+}
+
+// inputTypeName names the JSON type of a value for error messages
+func inputTypeName(value interface{}) string {
+	for _, t := range []string{"null", "string", "boolean", "integer", "number", "array", "object"} {
+		if matchesSchemaType(t, value) {
+			return t
+		}
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// joinSchemaPath appends a segment to a dotted schema path
+func joinSchemaPath(path, segment string) string {
+	if path == "" {
+		return segment
+	}
+	return path + "." + segment
+}
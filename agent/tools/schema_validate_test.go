@@ -0,0 +1,177 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/xichan96/cortex/agent/types"
+	"github.com/xichan96/cortex/pkg/errors"
+)
+
+// schemaTool tool with a fixed schema
+type schemaTool struct {
+	schema map[string]interface{}
+}
+
+func (t schemaTool) Name() string                   { return "schema_tool" }
+func (t schemaTool) Description() string            { return "Tool with a fixed schema" }
+func (t schemaTool) Schema() map[string]interface{} { return t.schema }
+func (t schemaTool) Metadata() types.ToolMetadata   { return types.ToolMetadata{} }
+func (t schemaTool) Execute(input map[string]interface{}) (interface{}, error) {
+	return nil, nil
+}
+
+func TestValidateSchema(t *testing.T) {
+	tests := []struct {
+		name    string
+		schema  map[string]interface{}
+		problem string
+	}{
+		{"empty", nil, ""},
+		{"generated", SchemaFromStruct(schemaTestInput{}), ""},
+		{"typed maps", map[string]interface{}{
+			"type":       "object",
+			"properties": map[string]map[string]interface{}{"q": {"type": "string"}},
+			"required":   []string{"q"},
+		}, ""},
+		{"root not object", map[string]interface{}{"type": "string"}, `root type must be "object"`},
+		{"invalid type", map[string]interface{}{
+			"type":       "object",
+			"properties": map[string]interface{}{"n": map[string]interface{}{"type": "int"}},
+		}, `n: invalid type "int"`},
+		{"undeclared required", map[string]interface{}{
+			"type":       "object",
+			"properties": map[string]interface{}{"q": map[string]interface{}{"type": "string"}},
+			"required":   []interface{}{"query"},
+		}, `required field "query" is not declared`},
+		{"nested items", map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"tags": map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "text"}},
+			},
+		}, `tags.items: invalid type "text"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateSchema(tt.schema)
+			if tt.problem == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			errObj, ok := err.(*errors.Error)
+			if !ok || errObj.Code != errors.EC_TOOL_VALIDATION_FAILED.Code {
+				t.Fatalf("Expected tool validation error, got %v", err)
+			}
+			if !strings.Contains(errObj.Message, tt.problem) {
+				t.Errorf("Expected %q in %q", tt.problem, errObj.Message)
+			}
+		})
+	}
+}
+
+func TestRegistry_SchemaValidation(t *testing.T) {
+	malformed := schemaTool{schema: map[string]interface{}{"type": "object", "required": []interface{}{"q"}}}
+
+	r := NewRegistry()
+	if err := r.Register(malformed); err != nil {
+		t.Errorf("Expected warn mode to register the tool, got %v", err)
+	}
+
+	r = NewRegistry()
+	r.SetSchemaValidation(SchemaValidationError)
+	err := r.Register(malformed)
+	if errObj, ok := err.(*errors.Error); !ok || errObj.Code != errors.EC_TOOL_VALIDATION_FAILED.Code {
+		t.Errorf("Expected tool validation error, got %v", err)
+	}
+	if r.Size() != 0 {
+		t.Errorf("Expected the tool not to be registered, got %d tools", r.Size())
+	}
+}
+
+func TestRegistry_EnableDisable(t *testing.T) {
+	r := NewRegistry()
+	if err := r.Register(schemaTool{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.Disable("schema_tool"); err != nil {
+		t.Fatalf("Disable failed: %v", err)
+	}
+	if r.IsEnabled("schema_tool") || len(r.GetAll()) != 0 || len(r.GetByType("")) != 0 {
+		t.Error("Expected the disabled tool to be left out of GetAll and GetByType")
+	}
+	if _, err := r.Get("schema_tool"); err != nil {
+		t.Errorf("Expected Get to return the disabled tool, got %v", err)
+	}
+
+	if err := r.Enable("schema_tool"); err != nil {
+		t.Fatalf("Enable failed: %v", err)
+	}
+	if !r.IsEnabled("schema_tool") || len(r.GetAll()) != 1 {
+		t.Error("Expected the tool to be enabled again")
+	}
+
+	err := r.Disable("missing")
+	if errObj, ok := err.(*errors.Error); !ok || errObj.Code != errors.EC_TOOL_NOT_FOUND.Code {
+		t.Errorf("Expected tool not found error, got %v", err)
+	}
+}
+
+func TestValidateInput(t *testing.T) {
+	schema := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"query": map[string]interface{}{"type": "string"},
+			"limit": map[string]interface{}{"type": "integer"},
+			"tags":  map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+			"filter": map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{"field": map[string]interface{}{"type": "string"}},
+				"required":   []string{"field"},
+			},
+			"custom": map[string]interface{}{"type": "any"},
+		},
+		"required": []string{"query"},
+	}
+
+	tests := []struct {
+		name    string
+		args    map[string]interface{}
+		problem string
+	}{
+		{"valid", map[string]interface{}{"query": "go", "limit": float64(5), "tags": []interface{}{"a"}}, ""},
+		{"go ints", map[string]interface{}{"query": "go", "limit": 5}, ""},
+		{"null optional", map[string]interface{}{"query": "go", "limit": nil}, ""},
+		{"unknown type ignored", map[string]interface{}{"query": "go", "custom": 1}, ""},
+		{"missing required", map[string]interface{}{"limit": float64(5)}, `missing required field "query"`},
+		{"null required", map[string]interface{}{"query": nil}, `missing required field "query"`},
+		{"wrong type", map[string]interface{}{"query": 1}, `field "query" must be string, got integer`},
+		{"fractional integer", map[string]interface{}{"query": "go", "limit": 2.5}, `field "limit" must be integer, got number`},
+		{"array item", map[string]interface{}{"query": "go", "tags": []interface{}{"a", true}}, `field "tags[1]" must be string, got boolean`},
+		{"nested required", map[string]interface{}{"query": "go", "filter": map[string]interface{}{}}, `missing required field "filter.field"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateInput(schema, tt.args)
+			if tt.problem == "" {
+				if err != nil {
+					t.Errorf("Expected valid input, got %v", err)
+				}
+				return
+			}
+			errObj, ok := err.(*errors.Error)
+			if !ok || errObj.Code != errors.EC_TOOL_VALIDATION_FAILED.Code {
+				t.Fatalf("Expected tool validation error, got %v", err)
+			}
+			if !strings.Contains(errObj.Message, tt.problem) {
+				t.Errorf("Expected %q in %q", tt.problem, errObj.Message)
+			}
+		})
+	}
+
+	if err := ValidateInput(nil, map[string]interface{}{"x": 1}); err != nil {
+		t.Errorf("Expected no validation without a schema, got %v", err)
+	}
+}
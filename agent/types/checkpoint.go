@@ -0,0 +1,33 @@
+package types
+
+import (
+	"context"
+	"time"
+)
+
+// Checkpoint persisted progress of an execution, written after each iteration
+type Checkpoint struct {
+	ID                string            `json:"id" bson:"_id"`
+	Input             string            `json:"input" bson:"input"`
+	Messages          []Message         `json:"messages" bson:"messages"`                    // conversation to send on the next iteration
+	Iteration         int               `json:"iteration" bson:"iteration"`                  // next iteration to run
+	ToolCallCount     int               `json:"toolCallCount" bson:"tool_call_count"`        // tool calls consumed from the budget
+	Output            string            `json:"output" bson:"output"`                        // output of the last completed iteration
+	ToolCalls         []ToolCallRequest `json:"toolCalls,omitempty" bson:"tool_calls"`       // tool calls of the last completed iteration
+	IntermediateSteps []ToolCallData    `json:"intermediateSteps,omitempty" bson:"steps"`    // steps of the last completed iteration
+	Warnings          []string          `json:"warnings,omitempty" bson:"warnings"`          // warnings accumulated so far
+	AllowedTools      []string          `json:"allowedTools,omitempty" bson:"allowed_tools"` // per-request tool allowlist
+	UpdatedAt         time.Time         `json:"updatedAt" bson:"updated_at"`
+}
+
+// CheckpointStore persists execution checkpoints so long-running executions can be resumed
+type CheckpointStore interface {
+	// Save creates or replaces the checkpoint with the same ID
+	Save(ctx context.Context, checkpoint *Checkpoint) error
+
+	// Load returns the checkpoint with the given ID, or errors.EC_CHECKPOINT_NOT_FOUND
+	Load(ctx context.Context, id string) (*Checkpoint, error)
+
+	// Delete removes a checkpoint, deleting a missing checkpoint is not an error
+	Delete(ctx context.Context, id string) error
+}
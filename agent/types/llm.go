@@ -1,5 +1,7 @@
 package types
 
+import "context"
+
 // LLMProvider defines LLM provider interface
 type LLMProvider interface {
 	// Basic chat functionality
@@ -15,6 +17,12 @@ type LLMProvider interface {
 	GetModelMetadata() ModelMetadata
 }
 
+// StreamingCapability optional LLMProvider extension reporting whether the streaming methods are supported by the backend
+// Providers without it are treated as streaming-capable
+type StreamingCapability interface {
+	SupportsStreaming() bool
+}
+
 // ModelMetadata model metadata
 type ModelMetadata struct {
 	Name      string                 `json:"name"`
@@ -32,8 +40,43 @@ type Message struct {
 	ToolCalls  []ToolCall    `json:"tool_calls,omitempty"`
 	ToolCallID string        `json:"tool_call_id,omitempty"`
 	Parts      []MessagePart `json:"parts,omitempty"` // Multi-modal content support
+
+	// FinishReason why the model stopped generating, set on model responses (see FinishReason constants)
+	FinishReason string `json:"finish_reason,omitempty"`
+
+	// Warnings non-fatal notes on how a model response was produced (e.g. served from a stale cache),
+	// reported in AgentResult.Warnings and never sent to providers
+	Warnings []string `json:"-"`
+
+	// Usage token counts of the call that produced a model response, nil when the provider reports none
+	Usage *Usage `json:"-"`
 }
 
+// Usage token counts reported by a provider
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// Add accumulates the counts of other, nil is ignored
+func (u *Usage) Add(other *Usage) {
+	if other == nil {
+		return
+	}
+	u.PromptTokens += other.PromptTokens
+	u.CompletionTokens += other.CompletionTokens
+	u.TotalTokens += other.TotalTokens
+}
+
+// Finish reasons reported by providers, normalized to the OpenAI names
+const (
+	FinishReasonStop          = "stop"           // natural end or stop sequence
+	FinishReasonLength        = "length"         // output token limit reached, the response is truncated
+	FinishReasonToolCalls     = "tool_calls"     // the model requested tool calls
+	FinishReasonContentFilter = "content_filter" // the response was blocked or cut by a content filter
+)
+
 // MessagePart message part interface
 type MessagePart interface {
 	isMessagePart()
@@ -81,6 +124,12 @@ type StreamMessage struct {
 	Content   string     `json:"content,omitempty"`
 	Error     string     `json:"error,omitempty"`
 	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+
+	// FinishReason is set on the "end" message when the provider reports one
+	FinishReason string `json:"finish_reason,omitempty"`
+
+	// Usage is set on the "end" message when the provider reports token counts
+	Usage *Usage `json:"usage,omitempty"`
 }
 
 // MemoryProvider memory system interface
@@ -101,6 +150,52 @@ type MemoryProvider interface {
 	CompressMemory(llm LLMProvider, maxMessages int) error
 }
 
+// MemoryPager optional MemoryProvider extension reading the stored messages page by page
+type MemoryPager interface {
+	// GetMessagesPaged gets a page of the stored messages in chronological order, starting at offset
+	// limit <= 0 returns all remaining messages, total is the number of stored messages
+	GetMessagesPaged(offset, limit int) (messages []Message, total int, err error)
+}
+
+// PageMessages gets a page of the messages of memory, through MemoryPager when the provider implements it
+// Other providers are paged over GetChatHistory, so the page is limited to the history they return
+func PageMessages(memory MemoryProvider, offset, limit int) ([]Message, int, error) {
+	if pager, ok := memory.(MemoryPager); ok {
+		return pager.GetMessagesPaged(offset, limit)
+	}
+	history, err := memory.GetChatHistory()
+	if err != nil {
+		return nil, 0, err
+	}
+	total := len(history)
+	offset = min(max(offset, 0), total)
+	end := total
+	if limit > 0 && offset+limit < total {
+		end = offset + limit
+	}
+	return append([]Message{}, history[offset:end]...), total, nil
+}
+
+// MemoryMetadataStore optional MemoryProvider extension storing session metadata such as the title
+// Clear removes the metadata together with the messages
+type MemoryMetadataStore interface {
+	GetMetadata(key string) (value string, ok bool, err error)
+	SetMetadata(key, value string) error
+}
+
+// Embedder converts texts into embedding vectors
+// The method set matches langchaingo's embeddings.Embedder so its implementations can be used directly
+type Embedder interface {
+	EmbedDocuments(ctx context.Context, texts []string) ([][]float32, error)
+	EmbedQuery(ctx context.Context, text string) ([]float32, error)
+}
+
+// Moderator safety check run on text before it reaches the model (and optionally on model output)
+// allowed false blocks the text, reason explains why (e.g. the flagged categories)
+type Moderator interface {
+	Check(text string) (allowed bool, reason string, err error)
+}
+
 // OutputParser output parser interface
 type OutputParser interface {
 	Parse(output string) (interface{}, error)
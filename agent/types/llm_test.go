@@ -0,0 +1,50 @@
+package types
+
+import (
+	"fmt"
+	"testing"
+)
+
+// historyMemory implements only MemoryProvider, without MemoryPager
+type historyMemory struct {
+	history []Message
+}
+
+func (m *historyMemory) LoadMemoryVariables() (map[string]interface{}, error)   { return nil, nil }
+func (m *historyMemory) SaveContext(input, output map[string]interface{}) error { return nil }
+func (m *historyMemory) Clear() error                                           { return nil }
+func (m *historyMemory) GetChatHistory() ([]Message, error)                     { return m.history, nil }
+func (m *historyMemory) CompressMemory(llm LLMProvider, maxMessages int) error {
+	return nil
+}
+
+func TestPageMessagesFallsBackToHistory(t *testing.T) {
+	memory := &historyMemory{}
+	for i := 0; i < 5; i++ {
+		memory.history = append(memory.history, Message{Role: "user", Content: fmt.Sprint(i)})
+	}
+
+	tests := []struct {
+		offset, limit int
+		want          string
+	}{
+		{0, 0, "01234"},
+		{1, 2, "12"},
+		{3, 10, "34"},
+		{7, 2, ""},
+		{-1, 1, "0"},
+	}
+	for _, tt := range tests {
+		messages, total, err := PageMessages(memory, tt.offset, tt.limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := ""
+		for _, msg := range messages {
+			got += msg.Content
+		}
+		if got != tt.want || total != 5 {
+			t.Errorf("PageMessages(%d, %d) = %q (total %d), want %q (total 5)", tt.offset, tt.limit, got, total, tt.want)
+		}
+	}
+}
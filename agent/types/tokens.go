@@ -0,0 +1,62 @@
+package types
+
+import (
+	"encoding/json"
+	"unicode/utf8"
+)
+
+// TokenCounter counts the tokens of a message
+// The engine's context compaction and the memory providers' history limits share one counter,
+// inject a model-specific tokenizer for exact counts
+type TokenCounter interface {
+	CountTokens(message Message) int
+}
+
+// TokenCounterFunc adapts a function to TokenCounter
+type TokenCounterFunc func(message Message) int
+
+// CountTokens implements TokenCounter
+func (f TokenCounterFunc) CountTokens(message Message) int {
+	return f(message)
+}
+
+// HeuristicTokenCounter default TokenCounter without a model-specific tokenizer
+// Counts the text of the content and the JSON of tool calls, plus a per-message overhead for role and formatting
+type HeuristicTokenCounter struct{}
+
+// CountTokens implements TokenCounter
+func (HeuristicTokenCounter) CountTokens(message Message) int {
+	tokens := 4 + EstimateTextTokens(message.Content)
+	if len(message.ToolCalls) > 0 {
+		if data, err := json.Marshal(message.ToolCalls); err == nil {
+			tokens += EstimateTextTokens(string(data))
+		}
+	}
+	return tokens
+}
+
+// EstimateTextTokens roughly estimates the token count of a text
+// ASCII text averages about 4 characters per token, other scripts (e.g. CJK) about one rune per token
+func EstimateTextTokens(text string) int {
+	ascii, other := 0, 0
+	for _, r := range text {
+		if r < utf8.RuneSelf {
+			ascii++
+		} else {
+			other++
+		}
+	}
+	return (ascii+3)/4 + other
+}
+
+// CountMessageTokens sums the tokens of messages, a nil counter uses HeuristicTokenCounter
+func CountMessageTokens(counter TokenCounter, messages []Message) int {
+	if counter == nil {
+		counter = HeuristicTokenCounter{}
+	}
+	tokens := 0
+	for _, msg := range messages {
+		tokens += counter.CountTokens(msg)
+	}
+	return tokens
+}
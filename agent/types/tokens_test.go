@@ -0,0 +1,20 @@
+package types
+
+import "testing"
+
+func TestHeuristicTokenCounter(t *testing.T) {
+	counter := HeuristicTokenCounter{}
+	if got := counter.CountTokens(Message{Content: "12345678"}); got != 6 {
+		t.Errorf("ASCII CountTokens = %d, want 6", got)
+	}
+	if got := counter.CountTokens(Message{Content: "你好"}); got != 6 {
+		t.Errorf("CJK CountTokens = %d, want 6", got)
+	}
+	withCall := Message{ToolCalls: []ToolCall{{ID: "call_1", Function: ToolFunction{Name: "search"}}}}
+	if got := counter.CountTokens(withCall); got <= 4 {
+		t.Error("tool calls are not counted")
+	}
+	if got := CountMessageTokens(nil, []Message{{Content: "1234"}, {Content: "5678"}}); got != 10 {
+		t.Errorf("CountMessageTokens = %d, want 10", got)
+	}
+}
@@ -1,6 +1,9 @@
 package types
 
-import "time"
+import (
+	"fmt"
+	"time"
+)
 
 // Tool defines tool interface
 type Tool interface {
@@ -10,6 +13,7 @@ type Tool interface {
 	Schema() map[string]interface{}
 
 	// Tool execution
+	// The result is formatted as a JSON observation, a []Message result is spliced into the conversation instead
 	Execute(input map[string]interface{}) (interface{}, error)
 
 	// Tool metadata
@@ -20,13 +24,23 @@ type Tool interface {
 type ToolMetadata struct {
 	SourceNodeName      string                 `json:"sourceNodeName"`
 	IsFromToolkit       bool                   `json:"isFromToolkit"`
-	ToolType            string                 `json:"toolType"`                      // "mcp","http","builtin"
+	ToolType            string                 `json:"toolType"`                      // "mcp","http","builtin","function","langchain"
 	Priority            int                    `json:"priority,omitempty"`            // 优先级，数字越大优先级越高
 	Dependencies        []string               `json:"dependencies,omitempty"`        // 依赖的工具名称列表
 	MaxTruncationLength int                    `json:"maxTruncationLength,omitempty"` // 工具结果截断长度，0表示使用默认值
+	RateLimit           *ToolRateLimit         `json:"rateLimit,omitempty"`           // 工具调用限流，nil表示不限流
+	InvalidatesCaches   []string               `json:"invalidatesCaches,omitempty"`   // 执行后需清除缓存结果的工具名称列表，"*"表示清除全部
+	Timeout             time.Duration          `json:"timeout,omitempty"`             // 单次执行超时时间，0表示使用AgentConfig.ToolCallTimeout
 	Extra               map[string]interface{} `json:"extra,omitempty"`
 }
 
+// ToolRateLimit per-tool rate limit enforced by the engine before execution
+type ToolRateLimit struct {
+	Requests int           `json:"requests"`          // 每个时间窗口允许的调用次数
+	Interval time.Duration `json:"interval"`          // 时间窗口
+	MaxWait  time.Duration `json:"maxWait,omitempty"` // 超限时最长等待时间，超过则返回限流提示给模型，0表示不等待
+}
+
 // ToolCallRequest tool call request
 type ToolCallRequest struct {
 	Tool       string                 `json:"tool"`
@@ -37,6 +51,28 @@ type ToolCallRequest struct {
 	MessageLog []interface{}          `json:"messageLog,omitempty"`
 }
 
+// ToolCallRequestToToolCall converts an executed tool call back into the form the model requested it in
+func ToolCallRequestToToolCall(req ToolCallRequest) ToolCall {
+	return ToolCall{
+		ID:   req.ToolCallID,
+		Type: req.Type,
+		Function: ToolFunction{
+			Name:      req.Tool,
+			Arguments: req.ToolInput,
+		},
+	}
+}
+
+// ToolCallToRequest converts a tool call requested by the model into a tool call request
+func ToolCallToRequest(call ToolCall) ToolCallRequest {
+	return ToolCallRequest{
+		Tool:       call.Function.Name,
+		ToolInput:  call.Function.Arguments,
+		ToolCallID: call.ID,
+		Type:       call.Type,
+	}
+}
+
 // ToolAction tool action
 type ToolAction struct {
 	NodeName string                 `json:"nodeName"`
@@ -81,6 +117,7 @@ type RequestResponseMetadata struct {
 type ToolCallData struct {
 	Action      ToolActionStep `json:"action"`
 	Observation string         `json:"observation"`
+	Messages    []Message      `json:"messages,omitempty"` // set when the tool returned []Message, spliced into the conversation
 }
 
 // ToolActionStep tool action step
@@ -94,25 +131,63 @@ type ToolActionStep struct {
 
 // AgentConfig agent configuration
 type AgentConfig struct {
-	MaxIterations           int           `json:"maxIterations"`
-	SystemMessage           string        `json:"systemMessage"`
-	Temperature             float32       `json:"temperature"`             // 温度参数 (0.0-1.0)
-	MaxTokens               int           `json:"maxTokens"`               // 最大token数
-	TopP                    float32       `json:"topP"`                    // Top P采样
-	FrequencyPenalty        float32       `json:"frequencyPenalty"`        // 频率惩罚
-	PresencePenalty         float32       `json:"presencePenalty"`         // 存在惩罚
-	StopSequences           []string      `json:"stopSequences"`           // 停止序列
-	Timeout                 time.Duration `json:"timeout"`                 // 超时时间
-	ToolExecutionTimeout    time.Duration `json:"toolExecutionTimeout"`    // 工具执行超时时间
-	RetryAttempts           int           `json:"retryAttempts"`           // 重试次数
-	RetryDelay              time.Duration `json:"retryDelay"`              // 重试延迟
-	EnableToolRetry         bool          `json:"enableToolRetry"`         // 启用工具重试
-	MaxHistoryMessages      int           `json:"maxHistoryMessages"`      // 最大历史消息数
-	EnableMemoryCompress    bool          `json:"enableMemoryCompress"`    // 启用记忆压缩
-	MemoryCompressThreshold int           `json:"memoryCompressThreshold"` // 记忆压缩阈值（消息数量）
-	MemoryCompressRatio     float32       `json:"memoryCompressRatio"`     // 记忆压缩比例（0.0-1.0）
+	MaxIterations             int            `json:"maxIterations"`
+	SystemMessage             string         `json:"systemMessage"`
+	Temperature               float32        `json:"temperature"`               // 温度参数 (0.0-1.0)
+	MaxTokens                 int            `json:"maxTokens"`                 // 最大token数
+	TopP                      float32        `json:"topP"`                      // Top P采样
+	FrequencyPenalty          float32        `json:"frequencyPenalty"`          // 频率惩罚
+	PresencePenalty           float32        `json:"presencePenalty"`           // 存在惩罚
+	StopSequences             []string       `json:"stopSequences"`             // 停止序列
+	Timeout                   time.Duration  `json:"timeout"`                   // 超时时间
+	ToolExecutionTimeout      time.Duration  `json:"toolExecutionTimeout"`      // 工具执行超时时间
+	RetryAttempts             int            `json:"retryAttempts"`             // 重试次数
+	RetryDelay                time.Duration  `json:"retryDelay"`                // 重试延迟
+	EnableToolRetry           bool           `json:"enableToolRetry"`           // 启用工具重试
+	MaxHistoryMessages        int            `json:"maxHistoryMessages"`        // 最大历史消息数
+	EnableMemoryCompress      bool           `json:"enableMemoryCompress"`      // 启用记忆压缩
+	MemoryCompressThreshold   int            `json:"memoryCompressThreshold"`   // 记忆压缩阈值（消息数量）
+	MemoryCompressRatio       float32        `json:"memoryCompressRatio"`       // 记忆压缩比例（0.0-1.0）
+	MaxToolCalls              int            `json:"maxToolCalls"`              // 单次执行工具调用总数上限（0表示不限制）
+	MaxRepairAttempts         int            `json:"maxRepairAttempts"`         // 输出解析失败时的自动修复次数
+	ApprovalTimeout           time.Duration  `json:"approvalTimeout"`           // 工具调用审批等待超时时间
+	ContextWindow             int            `json:"contextWindow"`             // 模型上下文窗口大小（token数）
+	CompactAtContextFraction  float32        `json:"compactAtContextFraction"`  // 历史估算token数超过上下文窗口该比例时压缩（0表示不启用）
+	DocumentTokenBudget       int            `json:"documentTokenBudget"`       // 请求附带文档的token预算，超出时自动摘要
+	ToolPriorityOverrides     map[string]int `json:"toolPriorityOverrides"`     // 工具优先级覆盖（优先于工具元数据中的优先级）
+	AsyncMemorySave           bool           `json:"asyncMemorySave"`           // 流式执行时在发送end事件之后再保存/压缩记忆
+	ToolNameMaxEditDistance   int            `json:"toolNameMaxEditDistance"`   // 工具名称模糊匹配的最大编辑距离（0表示仅做大小写/空白规范化匹配）
+	EnableCheckpoints         bool           `json:"enableCheckpoints"`         // 每轮迭代后保存执行检查点（需设置CheckpointStore，仅非流式执行）
+	IncludeStreamMetrics      bool           `json:"includeStreamMetrics"`      // 在流式执行的end事件结果中附带首字延迟等流式指标
+	EnableCitations           bool           `json:"enableCitations"`           // 为工具结果分配引用编号，并在结果中返回输出引用的工具调用
+	StreamPartialJSON         bool           `json:"streamPartialJSON"`         // 流式输出JSON时增量解析，并发送partial_json事件
+	MergeSystemMessages       bool           `json:"mergeSystemMessages"`       // 将多条system消息按顺序合并为一条置于开头
+	LengthContinuations       int            `json:"lengthContinuations"`       // 输出因长度截断时自动续写的最大次数，0表示仅告警
+	InjectBudgetHints         bool           `json:"injectBudgetHints"`         // 每轮迭代向模型提示剩余迭代次数和工具调用次数
+	BudgetHintTemplate        string         `json:"budgetHintTemplate"`        // 预算提示模板，为空时使用默认模板
+	GenerateSummary           bool           `json:"generateSummary"`           // 为最终回答额外生成简短摘要（用于通知）
+	SummaryMaxTokens          int            `json:"summaryMaxTokens"`          // 摘要的最大token数，0表示使用默认值
+	StrictToolArgs            bool           `json:"strictToolArgs"`            // 拒绝包含工具schema未声明参数的调用（默认移除未声明参数后执行）
+	ToolSchemaValidation      string         `json:"toolSchemaValidation"`      // 注册工具时的schema校验模式：warn（记录日志，默认）、error（拒绝注册）、off
+	MaxRepeatedResponses      int            `json:"maxRepeatedResponses"`      // 模型连续返回相同内容达到该次数时停止执行（小于2表示不检测）
+	GenerateTitles            bool           `json:"generateTitles"`            // 首条消息过长时使用模型生成会话标题（默认截断首条消息）
+	ToolResultFormat          string         `json:"toolResultFormat"`          // 工具结果回传模型的格式：prose（汇总为一条用户消息，默认）、native（每个调用一条tool消息）
+	CompactToolDefsAfterFirst bool           `json:"compactToolDefsAfterFirst"` // 首轮之后只发送工具名称和单行描述（省略参数schema）以减少token
+	ModerateOutput            bool           `json:"moderateOutput"`            // 设置Moderator时同时审核最终输出（默认只审核用户输入）
+	ParallelToolCalls         bool           `json:"parallelToolCalls"`         // 并发执行同一轮中互不依赖的工具调用
+	ToolCacheSize             int            `json:"toolCacheSize"`             // 工具结果缓存的最大条目数（0表示使用默认值）
+	ToolCacheTTL              time.Duration  `json:"toolCacheTTL"`              // 工具结果缓存的有效期（0表示使用默认值）
+	IterationDelay            time.Duration  `json:"iterationDelay"`            // 相邻迭代之间的等待时间（默认0，不等待）
+	ResponseFormat            string         `json:"responseFormat"`            // 模型输出格式：text（默认）、json_object（要求模型返回JSON对象，需模型支持）
+	ToolCallTimeout           time.Duration  `json:"toolCallTimeout"`           // 未设置ToolMetadata.Timeout的工具单次执行超时时间（0表示使用ToolExecutionTimeout）
 }
 
+// Response formats of AgentConfig.ResponseFormat
+const (
+	ResponseFormatText       = "text"
+	ResponseFormatJSONObject = "json_object"
+)
+
 // NewAgentConfig creates a new agent configuration with reasonable defaults
 func NewAgentConfig() *AgentConfig {
 	return &AgentConfig{
@@ -133,7 +208,34 @@ func NewAgentConfig() *AgentConfig {
 		EnableMemoryCompress:    false,
 		MemoryCompressThreshold: 50,
 		MemoryCompressRatio:     0.5,
+		MaxRepairAttempts:       2,
+		ApprovalTimeout:         5 * time.Minute,
+		ContextWindow:           128000,
+		DocumentTokenBudget:     8000,
+		ToolNameMaxEditDistance: 2,
+		MergeSystemMessages:     true,
+		ToolSchemaValidation:    "warn",
+		ToolCacheSize:           100,
+		ToolCacheTTL:            5 * time.Minute,
+	}
+}
+
+// Validate reports configuration values the engine cannot run with
+func (c *AgentConfig) Validate() error {
+	if c.MaxIterations <= 0 {
+		return fmt.Errorf("MaxIterations must be greater than 0, got %d", c.MaxIterations)
+	}
+	switch c.ToolResultFormat {
+	case "", "prose", "native":
+	default:
+		return fmt.Errorf("ToolResultFormat must be prose or native, got %q", c.ToolResultFormat)
+	}
+	switch c.ResponseFormat {
+	case "", ResponseFormatText, ResponseFormatJSONObject:
+	default:
+		return fmt.Errorf("ResponseFormat must be text or json_object, got %q", c.ResponseFormat)
 	}
+	return nil
 }
 
 // StreamEvent stream event
@@ -0,0 +1,43 @@
+package types
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestToolCallConversionRoundTrip(t *testing.T) {
+	call := ToolCall{
+		ID:   "call_1",
+		Type: "function",
+		Function: ToolFunction{
+			Name:      "search",
+			Arguments: map[string]interface{}{"query": "cortex"},
+		},
+	}
+
+	req := ToolCallToRequest(call)
+	if req.Tool != "search" || req.ToolCallID != "call_1" || req.Type != "function" {
+		t.Fatalf("unexpected request: %+v", req)
+	}
+	if got := ToolCallRequestToToolCall(req); !reflect.DeepEqual(got, call) {
+		t.Errorf("round trip = %+v, want %+v", got, call)
+	}
+}
+
+func TestAgentConfigValidate(t *testing.T) {
+	if err := NewAgentConfig().Validate(); err != nil {
+		t.Fatalf("default config invalid: %v", err)
+	}
+
+	config := NewAgentConfig()
+	config.MaxIterations = 0
+	if err := config.Validate(); err == nil {
+		t.Error("expected error for MaxIterations 0")
+	}
+
+	config = NewAgentConfig()
+	config.ToolResultFormat = "xml"
+	if err := config.Validate(); err == nil {
+		t.Error("expected error for unknown ToolResultFormat")
+	}
+}
@@ -1,15 +1,29 @@
 package main
 
 import (
+	"context"
+	stderrors "errors"
 	"flag"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/xichan96/cortex/internal/app"
 	"github.com/xichan96/cortex/internal/config"
+	"github.com/xichan96/cortex/pkg/errors"
+	mcptrigger "github.com/xichan96/cortex/trigger/mcp"
 )
 
+// toolInvokeSessionID session of the engine serving direct tool invocations
+const toolInvokeSessionID = "tool-invoke"
+
+// shutdownTimeout how long in-flight requests may run after a shutdown signal
+const shutdownTimeout = 30 * time.Second
+
 func chatHandler(c *gin.Context) {
 	agent := app.NewAgent()
 	httpTrigger := agent.HttpTrigger()
@@ -21,7 +35,7 @@ func chatHandler(c *gin.Context) {
 
 	engine, err := agent.Engine(req.SessionID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		engineError(c, err)
 		return
 	}
 	httpTrigger.ChatAPI(c, engine, req)
@@ -37,25 +51,107 @@ func streamChatHandler(c *gin.Context) {
 	}
 	engine, err := agent.Engine(req.SessionID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		engineError(c, err)
 		return
 	}
 	httpTrigger.StreamChatAPI(c, engine, req)
 }
 
-func mcpHandler(c *gin.Context) {
+func toolInvokeHandler(c *gin.Context) {
+	agent := app.NewAgent()
+	httpTrigger := agent.HttpTrigger()
+	req, err := httpTrigger.GetToolInvokeRequest(c)
+	if err != nil {
+		return
+	}
+	engine, err := agent.Engine(toolInvokeSessionID)
+	if err != nil {
+		engineError(c, err)
+		return
+	}
+	httpTrigger.InvokeToolAPI(c, engine, req)
+}
+
+func messagesHandler(c *gin.Context) {
 	agent := app.NewAgent()
-	mcpTrigger, err := agent.McpTrigger()
+	httpTrigger := agent.HttpTrigger()
+	req, err := httpTrigger.GetMessagesRequest(c)
+	if err != nil {
+		return
+	}
+	httpTrigger.MessagesAPI(c, agent.History(req.SessionID), req)
+}
+
+func historyHandler(c *gin.Context) {
+	agent := app.NewAgent()
+	httpTrigger := agent.HttpTrigger()
+	req, err := httpTrigger.GetHistoryRequest(c)
 	if err != nil {
 		return
 	}
-	mcpTrigger.Agent()(c)
+	httpTrigger.HistoryAPI(c, agent.History(req.SessionID), req)
 }
 
-func router(r *gin.Engine) {
+func clearHistoryHandler(c *gin.Context) {
+	agent := app.NewAgent()
+	httpTrigger := agent.HttpTrigger()
+	req, err := httpTrigger.GetClearHistoryRequest(c)
+	if err != nil {
+		return
+	}
+	httpTrigger.ClearHistoryAPI(c, agent.History(req.SessionID), req)
+}
+
+func titleHandler(c *gin.Context) {
+	agent := app.NewAgent()
+	httpTrigger := agent.HttpTrigger()
+	req, err := httpTrigger.GetTitleRequest(c)
+	if err != nil {
+		return
+	}
+	httpTrigger.TitleAPI(c, agent.History(req.SessionID), req)
+}
+
+func cancelHandler(c *gin.Context) {
+	httpTrigger := app.NewAgent().HttpTrigger()
+	req, err := httpTrigger.GetCancelRequest(c)
+	if err != nil {
+		return
+	}
+	httpTrigger.CancelAPI(c, req)
+}
+
+// mcpHandler serves the MCP trigger built at startup, or the error that prevented building it
+func mcpHandler(mcpTrigger mcptrigger.Handler, err error) gin.HandlerFunc {
+	if err != nil {
+		return func(c *gin.Context) {
+			engineError(c, err)
+		}
+	}
+	return mcpTrigger.Agent()
+}
+
+// engineError responds to a failure to get the session engine
+// 429 when the session cap is reached and every session is running
+func engineError(c *gin.Context, err error) {
+	var ec *errors.Error
+	if stderrors.As(err, &ec) && ec.Code == errors.EC_RESOURCE_EXHAUSTED.Code {
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+func router(r *gin.Engine, mcp gin.HandlerFunc) {
 	r.POST("/chat", chatHandler)
 	r.POST("/chat/stream", streamChatHandler)
-	r.Any("/mcp", mcpHandler)
+	r.POST("/chat/cancel", cancelHandler)
+	r.GET("/chat/history", historyHandler)
+	r.DELETE("/chat/history", clearHistoryHandler)
+	r.POST("/tool/invoke", toolInvokeHandler)
+	r.GET("/sessions/:id/messages", messagesHandler)
+	r.GET("/sessions/:id/title", titleHandler)
+	r.Any("/mcp", mcp)
 }
 
 func main() {
@@ -66,7 +162,33 @@ func main() {
 		panic(err)
 	}
 	log.Println("Starting Cortex...")
+	// The MCP trigger and its engine live for the whole process, outside the session pool
+	mcpTrigger, err := app.NewAgent().McpTrigger()
+	if err != nil {
+		log.Printf("MCP trigger unavailable: %v", err)
+	}
 	r := gin.Default()
-	router(r)
-	r.Run(":5678")
+	router(r, mcpHandler(mcpTrigger, err))
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	srv := &http.Server{Addr: ":5678", Handler: r}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !stderrors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Server failed: %v", err)
+		}
+	}()
+	<-ctx.Done()
+
+	log.Println("Shutting down Cortex...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("HTTP server shutdown: %v", err)
+	}
+	if mcpTrigger != nil {
+		if err := mcpTrigger.Shutdown(shutdownCtx); err != nil {
+			log.Printf("MCP trigger shutdown: %v", err)
+		}
+	}
 }
@@ -21,6 +21,9 @@ class AIChatAssistant {
         useStream: this.elements.useStream.checked
     };
     
+    // Session ID assigned by the server on the first message, keeps the conversation's memory
+    this.sessionId = null;
+    
     // Initialize application
     this.init();
     }
@@ -174,12 +177,13 @@ class AIChatAssistant {
                 headers: {
                     'Content-Type': 'application/json',
                 },
-                body: JSON.stringify({ message }),
+                body: JSON.stringify({ session_id: this.sessionId, message }),
             });
             
             if (!response.ok) {
                 throw new Error(`HTTP error! status: ${response.status}`);
             }
+            this.sessionId = response.headers.get('X-Session-ID') || this.sessionId;
             
             const data = await response.json();
             
@@ -226,11 +230,16 @@ class AIChatAssistant {
                                 hasNonJSONContent = true;
                                 this.updateMessage(messageId, data.content);
                             }
+                        } else if (data.type === 'tool_start') {
+                            this.setToolStatus(messageId, `Calling tool ${data.content}...`);
+                        } else if (data.type === 'tool_end') {
+                            this.setToolStatus(messageId, null);
                         } else if (data.type === 'error') {
                             hasNonJSONContent = true;
                             this.updateMessage(messageId, `Error: ${data.error}`);
                             eventSource.close();
                         } else if (data.type === 'end') {
+                            this.setToolStatus(messageId, null);
                             // Streaming ended, remove entire message if no non-JSON content
                             if (!hasNonJSONContent) {
                                 const messageDiv = document.getElementById(messageId);
@@ -385,6 +394,27 @@ class AIChatAssistant {
         }
     }
     
+    // Show the running tool call below the message content, null clears it
+    setToolStatus(messageId, text) {
+        const messageDiv = document.getElementById(messageId);
+        if (!messageDiv) return;
+        
+        let statusDiv = messageDiv.querySelector('.tool-status');
+        if (!text) {
+            if (statusDiv) {
+                statusDiv.remove();
+            }
+            return;
+        }
+        if (!statusDiv) {
+            statusDiv = document.createElement('div');
+            statusDiv.className = 'tool-status';
+            messageDiv.querySelector('.message-content').after(statusDiv);
+        }
+        statusDiv.textContent = text;
+        this.scrollToBottom();
+    }
+    
     // Remove typing indicator
     removeTypingIndicator(messageId) {
         const messageDiv = document.getElementById(messageId);
@@ -434,6 +464,9 @@ class AIChatAssistant {
     
     // Clear chat history
     clearChatHistory() {
+        // Start a new session so the cleared messages are not remembered
+        this.sessionId = null;
+        
         // Remove all messages except the initial welcome message
         const messages = this.elements.chatMessages.querySelectorAll('.message');
         messages.forEach((message, index) => {
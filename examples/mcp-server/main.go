@@ -1,7 +1,13 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
+	"net/http"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/xichan96/cortex/trigger/mcp"
@@ -26,6 +32,28 @@ func main() {
 	r := gin.Default()
 	mcpGroup := r.Group("/mcp")
 	mcpGroup.Any("", mcpHandler.Agent())
-	log.Println("Starting MCP server...")
-	r.Run()
+
+	srv := &http.Server{Addr: ":8080", Handler: r}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	go func() {
+		log.Println("Starting MCP server...")
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("MCP server failed: %v", err)
+		}
+	}()
+
+	<-ctx.Done()
+	log.Println("Shutting down MCP server...")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := mcpHandler.Shutdown(shutdownCtx); err != nil {
+		log.Printf("MCP handler shutdown: %v", err)
+	}
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("HTTP server shutdown: %v", err)
+	}
 }
@@ -1,19 +1,18 @@
 package app
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
-	"time"
 
-	"github.com/google/uuid"
 	"github.com/jinzhu/copier"
 	"github.com/xichan96/cortex/agent/engine"
 	"github.com/xichan96/cortex/agent/types"
 	"github.com/xichan96/cortex/internal/config"
-	"github.com/xichan96/cortex/pkg/cache"
 	"github.com/xichan96/cortex/pkg/logger"
+	"github.com/xichan96/cortex/pkg/mcp"
 	"github.com/xichan96/cortex/trigger/http"
-	"github.com/xichan96/cortex/trigger/mcp"
+	mcptrigger "github.com/xichan96/cortex/trigger/mcp"
 )
 
 type Agent interface {
@@ -23,15 +22,17 @@ type Agent interface {
 	setupTools() ([]types.Tool, error)
 	build(sessionID string) (*engine.AgentEngine, error)
 	Engine(sessionID string) (*engine.AgentEngine, error)
+	History(sessionID string) http.History
 
 	// trigger methods
 	HttpTrigger() http.Handler
-	McpTrigger() (mcp.Handler, error)
+	McpTrigger() (mcptrigger.Handler, error)
 }
 
 type agent struct {
-	config *config.Config
-	logger *logger.Logger
+	config     *config.Config
+	logger     *logger.Logger
+	mcpClients []*mcp.Client // clients connected by the last build, owned by its session
 }
 
 func NewAgent() Agent {
@@ -51,6 +52,7 @@ func (a *agent) build(sessionID string) (*engine.AgentEngine, error) {
 	}
 
 	memoryProvider := a.setupMemory(sessionID)
+	a.mcpClients = nil
 	tools, err := a.setupTools()
 	if err != nil {
 		return nil, fmt.Errorf("failed to setup tools: %w", err)
@@ -73,18 +75,46 @@ func (a *agent) build(sessionID string) (*engine.AgentEngine, error) {
 		agentConfig.Timeout = timeout
 	}
 
+	if a.config.Agent.ToolCacheTTL != "" {
+		ttl, err := a.config.Agent.ToolCacheTTLDuration()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse tool cache ttl: %w", err)
+		}
+		agentConfig.ToolCacheTTL = ttl
+	}
+
+	if a.config.Agent.IterationDelay != "" {
+		delay, err := a.config.Agent.IterationDelayDuration()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse iteration delay: %w", err)
+		}
+		agentConfig.IterationDelay = delay
+	}
+
+	if a.config.Agent.ToolCallTimeout != "" {
+		timeout, err := a.config.Agent.ToolCallTimeoutDuration()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse tool call timeout: %w", err)
+		}
+		agentConfig.ToolCallTimeout = timeout
+	}
+
 	engine := engine.NewAgentEngine(llmProvider, agentConfig)
 	engine.SetMemory(memoryProvider)
 	engine.AddTools(tools)
 	return engine, nil
 }
 
+// Engine returns the engine of a session, building it on first use
+// Fails with EC_RESOURCE_EXHAUSTED when MaxSessions sessions are all running
 func (a *agent) Engine(sessionID string) (*engine.AgentEngine, error) {
-	var v interface{}
-	if err := cache.Local.Get(sessionID, &v); err == nil {
-		if eng, ok := v.(*engine.AgentEngine); ok {
-			return eng, nil
-		}
+	if eng, ok := sessions.get(sessionID); ok {
+		return eng, nil
+	}
+
+	maxSessions := a.config.Agent.MaxSessions
+	if err := sessions.reserve(maxSessions); err != nil {
+		return nil, err
 	}
 
 	agentEngine, err := a.build(sessionID)
@@ -92,28 +122,96 @@ func (a *agent) Engine(sessionID string) (*engine.AgentEngine, error) {
 		return nil, err
 	}
 
-	cache.Local.Set(sessionID, agentEngine, 10*time.Minute)
-	return agentEngine, nil
+	return sessions.add(&session{
+		id:         sessionID,
+		engine:     agentEngine,
+		mcpClients: a.mcpClients,
+	}, maxSessions)
+}
+
+// History returns the stored conversation of a session without building an engine for it
+// Live sessions answer through their engine, others read the memory provider (and its metadata for the title) directly
+func (a *agent) History(sessionID string) http.History {
+	if eng, ok := sessions.get(sessionID); ok {
+		return eng
+	}
+	return &memoryHistory{memory: a.setupMemory(sessionID)}
 }
 
 func (a *agent) HttpTrigger() http.Handler {
-	return http.NewHandler()
+	return http.NewHandlerWithOptions(http.Options{
+		ToolInvoke: http.ToolInvokeOptions{
+			Enabled: a.config.Agent.HTTP.ToolInvoke.Enabled,
+			Secret:  a.config.Agent.HTTP.ToolInvoke.Secret,
+		},
+		StreamCancel: http.StreamCancelOptions{
+			Enabled: a.config.Agent.HTTP.StreamCancel.Enabled,
+		},
+		SystemPromptOverride: http.SystemPromptOverrideOptions{
+			Enabled: a.config.Agent.HTTP.SystemPromptOverride.Enabled,
+			Secret:  a.config.Agent.HTTP.SystemPromptOverride.Secret,
+		},
+		HistoryDelete: http.HistoryDeleteOptions{
+			Secret: a.config.Agent.HTTP.HistoryDelete.Secret,
+		},
+	})
+}
+
+// mcpSessionID memory session of the engine serving the MCP trigger
+const mcpSessionID = "mcp"
+
+// mcpHandler MCP trigger that also disconnects the MCP clients of its engine on shutdown
+type mcpHandler struct {
+	mcptrigger.Handler
+	mcpClients []*mcp.Client
+	logger     *logger.Logger
 }
 
-func (a *agent) McpTrigger() (mcp.Handler, error) {
-	engine, err := a.Engine(uuid.New().String())
+// Shutdown drains the trigger and its engine, then disconnects the engine's MCP clients
+func (h *mcpHandler) Shutdown(ctx context.Context) error {
+	err := h.Handler.Shutdown(ctx)
+	for _, client := range h.mcpClients {
+		if dErr := client.Disconnect(ctx); dErr != nil {
+			h.logger.LogError("mcpHandler.Shutdown", dErr, slog.String("phase", "mcp_disconnect"))
+		}
+	}
+	return err
+}
+
+// McpTrigger builds the MCP trigger with its own engine, outside the session pool
+// Call it once at startup and Shutdown the handler on exit, every MCP request shares the engine
+func (a *agent) McpTrigger() (mcptrigger.Handler, error) {
+	auth := mcptrigger.AuthOptions{
+		Enabled:     a.config.Agent.MCP.Auth.Enabled,
+		Secret:      a.config.Agent.MCP.Auth.Secret,
+		ExemptTools: a.config.Agent.MCP.Auth.ExemptTools,
+	}
+	if err := auth.Validate(); err != nil {
+		return nil, err
+	}
+	engine, err := a.build(mcpSessionID)
 	if err != nil {
 		return nil, err
 	}
-	mcpHandler := mcp.NewHandler(engine, mcp.Options{
-		Server: mcp.Metadata{
+	tools := make([]mcptrigger.ToolMetadata, 0, len(a.config.Agent.MCP.Tools))
+	for _, tool := range a.config.Agent.MCP.Tools {
+		tools = append(tools, mcptrigger.ToolMetadata{
+			Name:         tool.Name,
+			Description:  tool.Description,
+			SystemPrompt: tool.SystemPrompt,
+		})
+	}
+	handler := mcptrigger.NewHandler(engine, mcptrigger.Options{
+		Server: mcptrigger.Metadata{
 			Name:    a.config.Agent.MCP.Server.Name,
 			Version: a.config.Agent.MCP.Server.Version,
 		},
-		Tool: mcp.Metadata{
+		Tool: mcptrigger.Metadata{
 			Name:        a.config.Agent.MCP.Tool.Name,
 			Description: a.config.Agent.MCP.Tool.Description,
 		},
+		Tools: tools,
+		Auth:  auth,
 	})
-	return mcpHandler, nil
+	return &mcpHandler{Handler: handler, mcpClients: a.mcpClients, logger: a.logger}, nil
 }
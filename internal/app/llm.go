@@ -25,11 +25,13 @@ func (a *agent) setupLLM() (types.LLMProvider, error) {
 func (a *agent) initOpenAI() (types.LLMProvider, error) {
 	cfg := a.config.LLM.OpenAI
 	opts := llm.OpenAIOptions{
-		APIKey:  cfg.APIKey,
-		BaseURL: cfg.BaseURL,
-		Model:   cfg.Model,
-		OrgID:   cfg.OrgID,
-		APIType: cfg.APIType,
+		APIKey:     cfg.APIKey,
+		BaseURL:    cfg.BaseURL,
+		Model:      cfg.Model,
+		OrgID:      cfg.OrgID,
+		APIType:    cfg.APIType,
+		APIVersion: cfg.APIVersion,
+		Deployment: cfg.Deployment,
 	}
 
 	provider, err := llm.NewOpenAIClient(opts)
@@ -2,15 +2,66 @@ package app
 
 import (
 	"log/slog"
+	"sync"
 
+	"github.com/xichan96/cortex/agent/engine"
 	"github.com/xichan96/cortex/agent/providers"
 	"github.com/xichan96/cortex/agent/types"
+	"github.com/xichan96/cortex/internal/config"
+	"github.com/xichan96/cortex/pkg/errors"
 	"github.com/xichan96/cortex/pkg/mongodb"
 	"github.com/xichan96/cortex/pkg/redis"
 	"github.com/xichan96/cortex/pkg/sql/mysql"
 	"github.com/xichan96/cortex/pkg/sql/sqlite"
 )
 
+// memoryClients storage clients of the memory backends, shared by all sessions
+// Clients are pooled, so every session and history request reuses the same connections
+var memoryClients = &memoryClientPool{}
+
+// memoryClientPool lazily connects one client per memory backend
+// A failed connection is not cached, the next session retries it
+type memoryClientPool struct {
+	mu      sync.Mutex
+	redis   *redis.Client
+	mongodb *mongodb.Client
+	mysql   *mysql.Client
+	sqlite  *sqlite.Client
+}
+
+// memoryHistory history of a session without a live engine, backed by its memory provider
+type memoryHistory struct {
+	memory types.MemoryProvider
+}
+
+func (h *memoryHistory) GetChatHistory() ([]types.Message, error) {
+	history, err := h.memory.GetChatHistory()
+	if err != nil {
+		return nil, errors.NewError(errors.EC_MEMORY_HISTORY_FAILED.Code, errors.EC_MEMORY_HISTORY_FAILED.Message).Wrap(err)
+	}
+	return history, nil
+}
+
+func (h *memoryHistory) GetMessagesPaged(offset, limit int) ([]types.Message, int, error) {
+	messages, total, err := types.PageMessages(h.memory, offset, limit)
+	if err != nil {
+		return nil, 0, errors.NewError(errors.EC_MEMORY_HISTORY_FAILED.Code, errors.EC_MEMORY_HISTORY_FAILED.Message).Wrap(err)
+	}
+	return messages, total, nil
+}
+
+// Title reads the stored title, or derives one without a model until a live session stores it
+func (h *memoryHistory) Title() (string, error) {
+	return engine.StoredTitle(h.memory)
+}
+
+func (h *memoryHistory) ClearMemory() error {
+	if err := h.memory.Clear(); err != nil {
+		return errors.NewError(errors.EC_MEMORY_ERROR.Code, errors.EC_MEMORY_ERROR.Message).Wrap(err)
+	}
+	return nil
+}
+
 func (a *agent) setupMemory(sessionID string) types.MemoryProvider {
 	memCfg := a.config.Memory
 	maxHistory := memCfg.MaxHistoryMessages
@@ -36,15 +87,7 @@ func (a *agent) setupMemory(sessionID string) types.MemoryProvider {
 
 func (a *agent) initRedisMemory(sessionID string, maxHistory int) types.MemoryProvider {
 	cfg := a.config.Memory.Redis
-	redisCfg := &redis.Config{
-		Host:     cfg.Host,
-		Port:     cfg.Port,
-		DB:       cfg.DB,
-		Username: cfg.Username,
-		Password: cfg.Password,
-	}
-
-	client, err := redis.NewClient(redisCfg)
+	client, err := memoryClients.redisClient(cfg)
 	if err != nil {
 		a.logger.LogError("initRedisMemory", err,
 			slog.String("fallback", "simple_memory"),
@@ -56,29 +99,22 @@ func (a *agent) initRedisMemory(sessionID string, maxHistory int) types.MemoryPr
 	if cfg.KeyPrefix != "" {
 		provider.SetKeyPrefix(cfg.KeyPrefix)
 	}
+	if cfg.SessionTTL != "" {
+		ttl, err := cfg.SessionTTLDuration()
+		if err != nil {
+			a.logger.LogError("initRedisMemory", err,
+				slog.String("phase", "parse_session_ttl"),
+				slog.String("session_id", sessionID))
+		} else {
+			provider.SetSessionTTL(ttl)
+		}
+	}
 	return provider
 }
 
 func (a *agent) initMongoDBMemory(sessionID string, maxHistory int) types.MemoryProvider {
 	cfg := a.config.Memory.MongoDB
-	opts := []mongodb.ClientOptionFunc{
-		mongodb.SetURI(cfg.URI),
-		mongodb.SetDatabase(cfg.Database),
-	}
-
-	if cfg.Username != "" && cfg.Password != "" {
-		opts = append(opts, mongodb.SetBasicAuth(cfg.Username, cfg.Password))
-	}
-
-	if cfg.MaxPoolSize > 0 {
-		opts = append(opts, mongodb.SetMaxPoolSize(cfg.MaxPoolSize))
-	}
-
-	if cfg.MinPoolSize > 0 {
-		opts = append(opts, mongodb.SetMinPoolSize(cfg.MinPoolSize))
-	}
-
-	client, err := mongodb.NewClient(opts...)
+	client, err := memoryClients.mongodbClient(cfg)
 	if err != nil {
 		a.logger.LogError("initMongoDBMemory", err,
 			slog.String("fallback", "simple_memory"),
@@ -95,19 +131,7 @@ func (a *agent) initMongoDBMemory(sessionID string, maxHistory int) types.Memory
 
 func (a *agent) initMySQLMemory(sessionID string, maxHistory int) types.MemoryProvider {
 	cfg := a.config.Memory.MySQL
-	mysqlCfg := &mysql.Config{
-		Host:             cfg.Host,
-		Port:             cfg.Port,
-		User:             cfg.User,
-		Password:         cfg.Password,
-		Database:         cfg.Database,
-		MaxOpenConn:      cfg.MaxOpenConn,
-		MaxIdleConn:      cfg.MaxIdleConn,
-		MaxIdleTimeSec:   cfg.MaxIdleTimeSec,
-		DisableErrorHook: cfg.DisableErrorHook,
-	}
-
-	client, err := mysql.NewClient(mysqlCfg)
+	client, err := memoryClients.mysqlClient(cfg)
 	if err != nil {
 		a.logger.LogError("initMySQLMemory", err,
 			slog.String("fallback", "simple_memory"),
@@ -124,15 +148,7 @@ func (a *agent) initMySQLMemory(sessionID string, maxHistory int) types.MemoryPr
 
 func (a *agent) initSQLiteMemory(sessionID string, maxHistory int) types.MemoryProvider {
 	cfg := a.config.Memory.SQLite
-	sqliteCfg := &sqlite.Config{
-		Path:             cfg.Path,
-		MaxOpenConn:      cfg.MaxOpenConn,
-		MaxIdleConn:      cfg.MaxIdleConn,
-		MaxIdleTimeSec:   cfg.MaxIdleTimeSec,
-		DisableErrorHook: cfg.DisableErrorHook,
-	}
-
-	client, err := sqlite.NewClient(sqliteCfg)
+	client, err := memoryClients.sqliteClient(cfg)
 	if err != nil {
 		a.logger.LogError("initSQLiteMemory", err,
 			slog.String("fallback", "simple_memory"),
@@ -146,3 +162,98 @@ func (a *agent) initSQLiteMemory(sessionID string, maxHistory int) types.MemoryP
 	}
 	return provider
 }
+
+func (p *memoryClientPool) redisClient(cfg config.RedisConfig) (*redis.Client, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.redis != nil {
+		return p.redis, nil
+	}
+	client, err := redis.NewClient(&redis.Config{
+		Host:     cfg.Host,
+		Port:     cfg.Port,
+		DB:       cfg.DB,
+		Username: cfg.Username,
+		Password: cfg.Password,
+	})
+	if err != nil {
+		return nil, err
+	}
+	p.redis = client
+	return client, nil
+}
+
+func (p *memoryClientPool) mongodbClient(cfg config.MongoDBConfig) (*mongodb.Client, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.mongodb != nil {
+		return p.mongodb, nil
+	}
+	opts := []mongodb.ClientOptionFunc{
+		mongodb.SetURI(cfg.URI),
+		mongodb.SetDatabase(cfg.Database),
+	}
+
+	if cfg.Username != "" && cfg.Password != "" {
+		opts = append(opts, mongodb.SetBasicAuth(cfg.Username, cfg.Password))
+	}
+
+	if cfg.MaxPoolSize > 0 {
+		opts = append(opts, mongodb.SetMaxPoolSize(cfg.MaxPoolSize))
+	}
+
+	if cfg.MinPoolSize > 0 {
+		opts = append(opts, mongodb.SetMinPoolSize(cfg.MinPoolSize))
+	}
+
+	client, err := mongodb.NewClient(opts...)
+	if err != nil {
+		return nil, err
+	}
+	p.mongodb = client
+	return client, nil
+}
+
+func (p *memoryClientPool) mysqlClient(cfg config.MySQLConfig) (*mysql.Client, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.mysql != nil {
+		return p.mysql, nil
+	}
+	client, err := mysql.NewClient(&mysql.Config{
+		Host:             cfg.Host,
+		Port:             cfg.Port,
+		User:             cfg.User,
+		Password:         cfg.Password,
+		Database:         cfg.Database,
+		MaxOpenConn:      cfg.MaxOpenConn,
+		MaxIdleConn:      cfg.MaxIdleConn,
+		MaxIdleTimeSec:   cfg.MaxIdleTimeSec,
+		DisableErrorHook: cfg.DisableErrorHook,
+	})
+	if err != nil {
+		return nil, err
+	}
+	p.mysql = client
+	return client, nil
+}
+
+func (p *memoryClientPool) sqliteClient(cfg config.SQLiteConfig) (*sqlite.Client, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.sqlite != nil {
+		return p.sqlite, nil
+	}
+	client, err := sqlite.NewClient(&sqlite.Config{
+		Path:             cfg.Path,
+		MaxOpenConn:      cfg.MaxOpenConn,
+		MaxIdleConn:      cfg.MaxIdleConn,
+		MaxIdleTimeSec:   cfg.MaxIdleTimeSec,
+		DisableErrorHook: cfg.DisableErrorHook,
+	})
+	if err != nil {
+		return nil, err
+	}
+	p.sqlite = client
+	return client, nil
+}
@@ -0,0 +1,152 @@
+package app
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/xichan96/cortex/agent/engine"
+	"github.com/xichan96/cortex/pkg/errors"
+	"github.com/xichan96/cortex/pkg/logger"
+	"github.com/xichan96/cortex/pkg/mcp"
+)
+
+// Session pool defaults
+const (
+	sessionIdleTTL         = 10 * time.Minute
+	sessionShutdownTimeout = 30 * time.Second
+)
+
+// sessions engines of the server, shared by all requests
+var sessions = newSessionPool()
+
+// session engine of one session and the MCP clients it owns
+type session struct {
+	id         string
+	engine     *engine.AgentEngine
+	mcpClients []*mcp.Client
+	lastUsed   time.Time
+}
+
+// close shuts down the engine, letting a running execution finish, then disconnects its MCP clients
+func (s *session) close(log *logger.Logger) {
+	ctx, cancel := context.WithTimeout(context.Background(), sessionShutdownTimeout)
+	defer cancel()
+	if err := s.engine.Shutdown(ctx); err != nil {
+		log.LogError("session.close", err, slog.String("session_id", s.id))
+	}
+	for _, client := range s.mcpClients {
+		if err := client.Disconnect(ctx); err != nil {
+			log.LogError("session.close", err, slog.String("session_id", s.id), slog.String("phase", "mcp_disconnect"))
+		}
+	}
+}
+
+// sessionPool caches one engine per session
+// Idle sessions expire after sessionIdleTTL, and once the cap is reached the least recently used
+// idle session is evicted; sessions with a running execution are never evicted
+type sessionPool struct {
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List // front is most recently used, values are *session
+	logger  *logger.Logger
+}
+
+func newSessionPool() *sessionPool {
+	return &sessionPool{
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+		logger:  logger.NewLogger(),
+	}
+}
+
+// get returns the engine of a live session and marks it recently used
+func (p *sessionPool) get(sessionID string) (*engine.AgentEngine, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.expireLocked()
+	elem, ok := p.entries[sessionID]
+	if !ok {
+		return nil, false
+	}
+	s := elem.Value.(*session)
+	s.lastUsed = time.Now()
+	p.lru.MoveToFront(elem)
+	return s.engine, true
+}
+
+// reserve makes room for a new session, evicting idle sessions as needed
+// maxSessions <= 0 means unlimited
+func (p *sessionPool) reserve(maxSessions int) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.expireLocked()
+	return p.makeRoomLocked(maxSessions)
+}
+
+// add stores a newly built session and returns the engine to use
+// If another request created the session meanwhile, the new one is closed and the existing engine returned
+func (p *sessionPool) add(s *session, maxSessions int) (*engine.AgentEngine, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if elem, ok := p.entries[s.id]; ok {
+		go s.close(p.logger)
+		existing := elem.Value.(*session)
+		existing.lastUsed = time.Now()
+		p.lru.MoveToFront(elem)
+		return existing.engine, nil
+	}
+	if err := p.makeRoomLocked(maxSessions); err != nil {
+		go s.close(p.logger)
+		return nil, err
+	}
+	s.lastUsed = time.Now()
+	p.entries[s.id] = p.lru.PushFront(s)
+	return s.engine, nil
+}
+
+// expireLocked removes sessions idle for longer than sessionIdleTTL
+func (p *sessionPool) expireLocked() {
+	for elem := p.lru.Back(); elem != nil; {
+		prev := elem.Prev()
+		s := elem.Value.(*session)
+		if time.Since(s.lastUsed) < sessionIdleTTL {
+			// The list is ordered by use, everything in front is newer
+			return
+		}
+		if !s.engine.IsRunning() {
+			p.removeLocked(elem)
+		}
+		elem = prev
+	}
+}
+
+// makeRoomLocked evicts least recently used idle sessions until one more fits
+func (p *sessionPool) makeRoomLocked(maxSessions int) error {
+	if maxSessions <= 0 {
+		return nil
+	}
+	for elem := p.lru.Back(); elem != nil && p.lru.Len() >= maxSessions; {
+		prev := elem.Prev()
+		if !elem.Value.(*session).engine.IsRunning() {
+			p.removeLocked(elem)
+		}
+		elem = prev
+	}
+	if p.lru.Len() >= maxSessions {
+		return errors.NewError(errors.EC_RESOURCE_EXHAUSTED.Code, fmt.Sprintf("all %d sessions are running, try again later", maxSessions))
+	}
+	return nil
+}
+
+// removeLocked unlinks a session and closes it in the background
+func (p *sessionPool) removeLocked(elem *list.Element) {
+	s := elem.Value.(*session)
+	p.lru.Remove(elem)
+	delete(p.entries, s.id)
+	p.logger.Info("Session evicted", slog.String("session_id", s.id))
+	go s.close(p.logger)
+}
@@ -17,7 +17,11 @@ func (a *agent) setupTools() ([]types.Tool, error) {
 	toolsCfg := a.config.Tools
 
 	if toolsCfg.Builtin.Enabled {
-		tools = append(tools, a.initBuiltinTools()...)
+		builtinTools, err := a.initBuiltinTools()
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize builtin tools: %w", err)
+		}
+		tools = append(tools, builtinTools...)
 	}
 
 	for _, mcpCfg := range toolsCfg.MCP {
@@ -33,12 +37,17 @@ func (a *agent) setupTools() ([]types.Tool, error) {
 	return tools, nil
 }
 
-func (a *agent) initBuiltinTools() []types.Tool {
+func (a *agent) initBuiltinTools() ([]types.Tool, error) {
 	var tools []types.Tool
 	cfg := a.config.Tools.Builtin
 
 	if cfg.SSH.Enabled {
-		tools = append(tools, builtin.NewSSHTool())
+		sshTool := builtin.NewSSHTool()
+		if err := sshTool.SetHostKeyCheck(cfg.SSH.HostKeyCheck); err != nil {
+			return nil, err
+		}
+		sshTool.SetKnownHostsFile(cfg.SSH.KnownHostsFile)
+		tools = append(tools, sshTool)
 	}
 
 	if cfg.File.Enabled {
@@ -46,7 +55,11 @@ func (a *agent) initBuiltinTools() []types.Tool {
 	}
 
 	if cfg.Command.Enabled {
-		tools = append(tools, builtin.NewCommandTool())
+		commandTool := builtin.NewCommandTool()
+		commandTool.SetAllowedCommands(cfg.Command.AllowedCommands)
+		commandTool.SetDeniedCommands(cfg.Command.DeniedCommands)
+		commandTool.SetAllowedEnv(cfg.Command.AllowedEnv)
+		tools = append(tools, commandTool)
 	}
 
 	if cfg.Math.Enabled {
@@ -61,32 +74,58 @@ func (a *agent) initBuiltinTools() []types.Tool {
 		tools = append(tools, builtin.NewTimeTool())
 	}
 
+	if cfg.Encoding.Enabled {
+		tools = append(tools, builtin.NewEncodingTool())
+	}
+
+	if cfg.Diff.Enabled {
+		tools = append(tools, builtin.NewDiffTool())
+	}
+
+	if cfg.HTTP.Enabled {
+		httpTool := builtin.NewHTTPTool()
+		httpTool.SetAllowedHosts(cfg.HTTP.AllowedHosts)
+		httpTool.SetAllowPrivateNetworks(cfg.HTTP.AllowPrivateNetworks)
+		httpTool.SetMaxBodySize(cfg.HTTP.MaxBodySize)
+		tools = append(tools, httpTool)
+	}
+
 	if cfg.Email.Enabled {
 		emailCfg := &email.Config{
-			Address: cfg.Email.Config.Address,
-			Name:    cfg.Email.Config.Name,
-			Pwd:     cfg.Email.Config.Pwd,
-			Host:    cfg.Email.Config.Host,
-			Port:    cfg.Email.Config.Port,
+			Address:           cfg.Email.Config.Address,
+			Name:              cfg.Email.Config.Name,
+			Pwd:               cfg.Email.Config.Pwd,
+			Host:              cfg.Email.Config.Host,
+			Port:              cfg.Email.Config.Port,
+			MaxAttachmentSize: cfg.Email.Config.MaxAttachmentSize,
+			AttachmentDir:     cfg.Email.Config.AttachmentDir,
 		}
 		tools = append(tools, builtin.NewEmailTool(emailCfg))
 	}
 
-	return tools
+	return tools, nil
 }
 
 func (a *agent) initMCPTools(cfg config.MCPConfig) ([]types.Tool, error) {
-	if cfg.URL == "" {
-		return nil, fmt.Errorf("MCP URL is required")
+	var mcpClient *mcp.Client
+	if cfg.Transport == "stdio" {
+		if cfg.Command == "" {
+			return nil, fmt.Errorf("MCP command is required for the stdio transport")
+		}
+		mcpClient = mcp.NewStdioClient(cfg.Command, cfg.Args, cfg.Env)
+	} else {
+		if cfg.URL == "" {
+			return nil, fmt.Errorf("MCP URL is required")
+		}
+		mcpClient = mcp.NewClient(cfg.URL, cfg.Transport, cfg.Headers)
 	}
 
-	mcpClient := mcp.NewClient(cfg.URL, cfg.Transport, cfg.Headers)
-
 	ctx := context.Background()
 	if err := mcpClient.Connect(ctx); err != nil {
 		return nil, fmt.Errorf("failed to connect to MCP server: %w", err)
 	}
 
+	a.mcpClients = append(a.mcpClients, mcpClient)
 	tools := mcpClient.GetTools()
 	return tools, nil
 }
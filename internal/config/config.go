@@ -23,6 +23,12 @@ func Load(path string) error {
 	if err := yaml.Unmarshal(data, &cfg); err != nil {
 		return fmt.Errorf("failed to parse config file: %w", err)
 	}
+	if cfg.Agent.MaxIterations <= 0 {
+		return fmt.Errorf("invalid config: agent.max_iterations must be greater than 0, got %d", cfg.Agent.MaxIterations)
+	}
+	if cfg.Agent.MCP.Auth.Enabled && cfg.Agent.MCP.Auth.Secret == "" {
+		return fmt.Errorf("invalid config: agent.mcp.auth.secret is required when agent.mcp.auth.enabled is true")
+	}
 
 	configMu.Lock()
 	globalConfig = &cfg
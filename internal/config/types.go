@@ -17,11 +17,13 @@ type LLMConfig struct {
 }
 
 type OpenAIConfig struct {
-	APIKey  string `yaml:"api_key"`
-	BaseURL string `yaml:"base_url"`
-	Model   string `yaml:"model"`
-	OrgID   string `yaml:"org_id"`
-	APIType string `yaml:"api_type"`
+	APIKey     string `yaml:"api_key"`
+	BaseURL    string `yaml:"base_url"`
+	Model      string `yaml:"model"`
+	OrgID      string `yaml:"org_id"`
+	APIType    string `yaml:"api_type"`
+	APIVersion string `yaml:"api_version"`
+	Deployment string `yaml:"deployment"`
 }
 
 type DeepSeekConfig struct {
@@ -47,6 +49,9 @@ type MCPConfig struct {
 	URL       string            `yaml:"url"`
 	Transport string            `yaml:"transport"`
 	Headers   map[string]string `yaml:"headers"`
+	Command   string            `yaml:"command"` // stdio transport only
+	Args      []string          `yaml:"args"`    // stdio transport only
+	Env       []string          `yaml:"env"`     // stdio transport only, "KEY=value"
 }
 
 type HTTPConfig struct {
@@ -54,31 +59,56 @@ type HTTPConfig struct {
 }
 
 type BuiltinConfig struct {
-	Enabled bool            `yaml:"enabled"`
-	SSH     ToolConfig      `yaml:"ssh"`
-	File    ToolConfig      `yaml:"file"`
-	Email   EmailToolConfig `yaml:"email"`
-	Command ToolConfig      `yaml:"command"`
-	Math    ToolConfig      `yaml:"math"`
-	Ping    ToolConfig      `yaml:"ping"`
-	Time    ToolConfig      `yaml:"time"`
+	Enabled  bool              `yaml:"enabled"`
+	SSH      SSHToolConfig     `yaml:"ssh"`
+	File     ToolConfig        `yaml:"file"`
+	Email    EmailToolConfig   `yaml:"email"`
+	Command  CommandToolConfig `yaml:"command"`
+	Math     ToolConfig        `yaml:"math"`
+	Ping     ToolConfig        `yaml:"ping"`
+	Time     ToolConfig        `yaml:"time"`
+	Encoding ToolConfig        `yaml:"encoding"`
+	Diff     ToolConfig        `yaml:"diff"`
+	HTTP     HTTPToolConfig    `yaml:"http"`
 }
 
 type ToolConfig struct {
 	Enabled bool `yaml:"enabled"`
 }
 
+type SSHToolConfig struct {
+	Enabled        bool   `yaml:"enabled"`
+	HostKeyCheck   string `yaml:"host_key_check"`
+	KnownHostsFile string `yaml:"known_hosts_file"`
+}
+
+type CommandToolConfig struct {
+	Enabled         bool     `yaml:"enabled"`
+	AllowedCommands []string `yaml:"allowed_commands"`
+	DeniedCommands  []string `yaml:"denied_commands"`
+	AllowedEnv      []string `yaml:"allowed_env"`
+}
+
+type HTTPToolConfig struct {
+	Enabled              bool     `yaml:"enabled"`
+	AllowedHosts         []string `yaml:"allowed_hosts"`
+	AllowPrivateNetworks bool     `yaml:"allow_private_networks"`
+	MaxBodySize          int64    `yaml:"max_body_size"`
+}
+
 type EmailToolConfig struct {
 	Enabled bool        `yaml:"enabled"`
 	Config  EmailConfig `yaml:"config"`
 }
 
 type EmailConfig struct {
-	Address string `yaml:"address"`
-	Name    string `yaml:"name"`
-	Pwd     string `yaml:"pwd"`
-	Host    string `yaml:"host"`
-	Port    int    `yaml:"port"`
+	Address           string `yaml:"address"`
+	Name              string `yaml:"name"`
+	Pwd               string `yaml:"pwd"`
+	Host              string `yaml:"host"`
+	Port              int    `yaml:"port"`
+	MaxAttachmentSize int64  `yaml:"max_attachment_size"`
+	AttachmentDir     string `yaml:"attachment_dir"`
 }
 
 type MemoryConfig struct {
@@ -98,6 +128,8 @@ type RedisConfig struct {
 	Username  string `yaml:"username"`
 	Password  string `yaml:"password"`
 	KeyPrefix string `yaml:"key_prefix"`
+	// SessionTTL expiry of idle sessions (e.g. "168h"), empty or 0 keeps them
+	SessionTTL string `yaml:"session_ttl"`
 }
 
 type MongoDBConfig struct {
@@ -133,23 +165,68 @@ type SQLiteConfig struct {
 }
 
 type AgentConfig struct {
-	MaxIterations      int         `yaml:"max_iterations"`
-	SystemMessage      string      `yaml:"system_message"`
-	Temperature        float64     `yaml:"temperature"`
-	MaxTokens          int         `yaml:"max_tokens"`
-	TopP               float64     `yaml:"top_p"`
-	FrequencyPenalty   float64     `yaml:"frequency_penalty"`
-	PresencePenalty    float64     `yaml:"presence_penalty"`
-	Timeout            string      `yaml:"timeout"`
-	RetryAttempts      int         `yaml:"retry_attempts"`
-	EnableToolRetry    bool        `yaml:"enable_tool_retry"`
-	MaxHistoryMessages int         `yaml:"max_history_messages"`
-	MCP                MCPMetadata `yaml:"mcp"`
+	MaxIterations             int         `yaml:"max_iterations"`
+	MaxToolCalls              int         `yaml:"max_tool_calls"`
+	SystemMessage             string      `yaml:"system_message"`
+	Temperature               float64     `yaml:"temperature"`
+	MaxTokens                 int         `yaml:"max_tokens"`
+	TopP                      float64     `yaml:"top_p"`
+	FrequencyPenalty          float64     `yaml:"frequency_penalty"`
+	PresencePenalty           float64     `yaml:"presence_penalty"`
+	Timeout                   string      `yaml:"timeout"`
+	RetryAttempts             int         `yaml:"retry_attempts"`
+	EnableToolRetry           bool        `yaml:"enable_tool_retry"`
+	MaxHistoryMessages        int         `yaml:"max_history_messages"`
+	MaxSessions               int         `yaml:"max_sessions"`
+	GenerateTitles            bool        `yaml:"generate_titles"`
+	ToolResultFormat          string      `yaml:"tool_result_format"`
+	CompactToolDefsAfterFirst bool        `yaml:"compact_tool_defs_after_first"`
+	ParallelToolCalls         bool        `yaml:"parallel_tool_calls"`
+	ToolCacheSize             int         `yaml:"tool_cache_size"`
+	ToolCacheTTL              string      `yaml:"tool_cache_ttl"`
+	IterationDelay            string      `yaml:"iteration_delay"`
+	ResponseFormat            string      `yaml:"response_format"`
+	ToolCallTimeout           string      `yaml:"tool_call_timeout"`
+	MCP                       MCPMetadata `yaml:"mcp"`
+	HTTP                      HTTPTrigger `yaml:"http"`
+}
+
+type HTTPTrigger struct {
+	ToolInvoke           ToolInvokeConfig           `yaml:"tool_invoke"`
+	StreamCancel         StreamCancelConfig         `yaml:"stream_cancel"`
+	SystemPromptOverride SystemPromptOverrideConfig `yaml:"system_prompt_override"`
+	HistoryDelete        HistoryDeleteConfig        `yaml:"history_delete"`
+}
+
+type HistoryDeleteConfig struct {
+	Secret string `yaml:"secret"`
+}
+
+type SystemPromptOverrideConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Secret  string `yaml:"secret"`
+}
+
+type StreamCancelConfig struct {
+	Enabled bool `yaml:"enabled"`
+}
+
+type ToolInvokeConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Secret  string `yaml:"secret"`
 }
 
 type MCPMetadata struct {
 	Server MCPServerMetadata `yaml:"server"`
 	Tool   MCPToolMetadata   `yaml:"tool"`
+	Tools  []MCPToolMetadata `yaml:"tools,omitempty"`
+	Auth   MCPAuthConfig     `yaml:"auth"`
+}
+
+type MCPAuthConfig struct {
+	Enabled     bool     `yaml:"enabled"`
+	Secret      string   `yaml:"secret"`
+	ExemptTools []string `yaml:"exempt_tools,omitempty"`
 }
 
 type MCPServerMetadata struct {
@@ -158,10 +235,27 @@ type MCPServerMetadata struct {
 }
 
 type MCPToolMetadata struct {
-	Name        string `yaml:"name"`
-	Description string `yaml:"description"`
+	Name         string `yaml:"name"`
+	Description  string `yaml:"description"`
+	SystemPrompt string `yaml:"system_prompt,omitempty"`
 }
 
 func (a *AgentConfig) TimeoutDuration() (time.Duration, error) {
 	return time.ParseDuration(a.Timeout)
 }
+
+func (a *AgentConfig) ToolCacheTTLDuration() (time.Duration, error) {
+	return time.ParseDuration(a.ToolCacheTTL)
+}
+
+func (a *AgentConfig) IterationDelayDuration() (time.Duration, error) {
+	return time.ParseDuration(a.IterationDelay)
+}
+
+func (a *AgentConfig) ToolCallTimeoutDuration() (time.Duration, error) {
+	return time.ParseDuration(a.ToolCallTimeout)
+}
+
+func (r *RedisConfig) SessionTTLDuration() (time.Duration, error) {
+	return time.ParseDuration(r.SessionTTL)
+}
@@ -1,6 +1,7 @@
 package cache
 
 import (
+	"container/list"
 	"context"
 	"errors"
 	"fmt"
@@ -29,18 +30,83 @@ type LocalCache struct {
 	db     map[string]Value
 	lock   sync.RWMutex
 	cancel context.CancelFunc
+
+	// capacity maximum number of entries, 0 means unbounded and no recency tracking
+	capacity int
+	// lruLock guards recency, taken after lock so readers holding the read lock can update it
+	lruLock sync.Mutex
+	recency *list.List               // keys, most recently used first
+	elems   map[string]*list.Element // recency element of each key
 }
 
 func NewLocalCache() LocalCacheIer {
+	return NewLocalCacheWithCapacity(0)
+}
+
+// NewLocalCacheWithCapacity creates a local cache holding at most max entries
+// Once full, setting a new key evicts the least recently used entry; max <= 0 means unbounded
+func NewLocalCacheWithCapacity(max int) LocalCacheIer {
 	ctx, cancel := context.WithCancel(context.Background())
 	ca := &LocalCache{
 		db:     make(map[string]Value),
 		cancel: cancel,
 	}
+	if max > 0 {
+		ca.capacity = max
+		ca.recency = list.New()
+		ca.elems = make(map[string]*list.Element)
+	}
 	go ca.background(ctx)
 	return ca
 }
 
+// touch marks key as most recently used, adding it when new
+func (c *LocalCache) touch(key string) {
+	if c.capacity <= 0 {
+		return
+	}
+	c.lruLock.Lock()
+	defer c.lruLock.Unlock()
+	if elem, ok := c.elems[key]; ok {
+		c.recency.MoveToFront(elem)
+		return
+	}
+	c.elems[key] = c.recency.PushFront(key)
+}
+
+// forget removes key from the recency list, the caller holds the write lock
+func (c *LocalCache) forget(key string) {
+	if c.capacity <= 0 {
+		return
+	}
+	c.lruLock.Lock()
+	defer c.lruLock.Unlock()
+	if elem, ok := c.elems[key]; ok {
+		c.recency.Remove(elem)
+		delete(c.elems, key)
+	}
+}
+
+// evictLocked removes least recently used entries until the cache is within capacity
+// The caller holds the write lock
+func (c *LocalCache) evictLocked() {
+	if c.capacity <= 0 {
+		return
+	}
+	c.lruLock.Lock()
+	defer c.lruLock.Unlock()
+	for len(c.db) > c.capacity {
+		oldest := c.recency.Back()
+		if oldest == nil {
+			return
+		}
+		key := oldest.Value.(string)
+		c.recency.Remove(oldest)
+		delete(c.elems, key)
+		delete(c.db, key)
+	}
+}
+
 func (c *LocalCache) Close() {
 	c.cancel()
 }
@@ -58,6 +124,8 @@ func (c *LocalCache) Set(key string, data interface{}, expire time.Duration) err
 		Data:   data,
 		Expire: expireTime,
 	}
+	c.touch(key)
+	c.evictLocked()
 	return nil
 }
 
@@ -93,6 +161,7 @@ func (c *LocalCache) Get(key string, result interface{}) error {
 		return fmt.Errorf("cannot assign %s to %s", dataVal.Type(), elem.Type())
 	}
 	elem.Set(dataVal)
+	c.touch(key)
 	return nil
 }
 
@@ -108,6 +177,7 @@ func (c *LocalCache) GetValue(key string) (value any, err error) {
 	if data.Expire > 0 && time.Now().Unix() >= data.Expire {
 		return value, errors.New("not found")
 	}
+	c.touch(key)
 	return data.Data, nil
 }
 
@@ -119,6 +189,7 @@ func (c *LocalCache) Del(key string) error {
 		return errors.New("not found")
 	}
 	delete(c.db, key)
+	c.forget(key)
 	return nil
 }
 
@@ -164,6 +235,7 @@ func (c *LocalCache) background(ctx context.Context) {
 				}
 				if nowTime >= val.Expire {
 					delete(c.db, key)
+					c.forget(key)
 				}
 			}
 		}()
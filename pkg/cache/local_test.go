@@ -0,0 +1,66 @@
+package cache
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestLocalCacheWithCapacityEvictsLeastRecentlyUsed(t *testing.T) {
+	c := NewLocalCacheWithCapacity(2).(*LocalCache)
+	defer c.Close()
+
+	_ = c.Set("a", 1, 0)
+	_ = c.Set("b", 2, 0)
+	// Reading a makes b the least recently used entry
+	var v int
+	if err := c.Get("a", &v); err != nil || v != 1 {
+		t.Fatalf("Get(a) = %d, %v", v, err)
+	}
+	_ = c.Set("c", 3, 0)
+
+	if c.IsExists("b") {
+		t.Error("b was not evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if !c.IsExists(key) {
+			t.Errorf("%s was evicted", key)
+		}
+	}
+
+	if _, err := c.GetValue("c"); err != nil {
+		t.Fatal(err)
+	}
+	_ = c.Set("d", 4, 0)
+	if c.IsExists("a") {
+		t.Error("a was not evicted after GetValue(c)")
+	}
+}
+
+func TestLocalCacheWithCapacityDelFreesSlot(t *testing.T) {
+	c := NewLocalCacheWithCapacity(2).(*LocalCache)
+	defer c.Close()
+
+	_ = c.Set("a", 1, time.Minute)
+	_ = c.Set("b", 2, time.Minute)
+	_ = c.Del("a")
+	_ = c.Set("c", 3, time.Minute)
+	if !c.IsExists("b") || !c.IsExists("c") {
+		t.Error("entries evicted although the cache was within capacity")
+	}
+	if len(c.elems) != 2 || c.recency.Len() != 2 {
+		t.Errorf("recency tracks %d keys, want 2", c.recency.Len())
+	}
+}
+
+func TestLocalCacheUnbounded(t *testing.T) {
+	c := NewLocalCache().(*LocalCache)
+	defer c.Close()
+
+	for i := 0; i < 100; i++ {
+		_ = c.Set(fmt.Sprintf("key-%d", i), i, 0)
+	}
+	if len(c.db) != 100 {
+		t.Errorf("len = %d, want 100", len(c.db))
+	}
+}
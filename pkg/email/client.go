@@ -2,9 +2,25 @@
 package email
 
 import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
 	"gopkg.in/gomail.v2"
 )
 
+// DefaultMaxAttachmentSize default cap on the combined size of a message's attachments (10MB)
+const DefaultMaxAttachmentSize int64 = 10 * 1024 * 1024
+
+// ErrAttachmentTooLarge is returned when the attachments exceed the configured size cap
+var ErrAttachmentTooLarge = errors.New("attachments exceed the maximum size")
+
+// ErrAttachmentPathDenied is returned when an attachment path is outside the attachment directory
+var ErrAttachmentPathDenied = errors.New("attachment path is not allowed")
+
 // Config ...
 type Config struct {
 	Address string `json:"address"`
@@ -12,22 +28,156 @@ type Config struct {
 	Pwd     string `json:"pwd"`
 	Host    string `json:"host"`
 	Port    int    `json:"port"`
+	// MaxAttachmentSize cap in bytes on the combined size of a message's attachments, 0 uses DefaultMaxAttachmentSize
+	MaxAttachmentSize int64 `json:"max_attachment_size"`
+	// AttachmentDir directory the send_email tool may attach files from by path, empty disables path attachments
+	AttachmentDir string `json:"attachment_dir"`
 }
 
 type Content struct {
 	Title   string `json:"title"`
 	Type    string `json:"type"` // text/html, text/plain, text/markdown
 	Message string `json:"message"`
+	// HTMLBody optional HTML alternative of Message, clients able to render HTML show it instead
+	HTMLBody    string       `json:"html_body"`
+	Attachments []Attachment `json:"attachments"`
+}
+
+// Attachment file attached to a message, read from Path or given as Data
+type Attachment struct {
+	Filename    string `json:"filename"`     // name shown to the recipient, defaults to the base name of Path
+	ContentType string `json:"content_type"` // MIME type, detected from the file name when empty
+	Path        string `json:"path"`
+	Data        []byte `json:"data"`
 }
 
 // Do 发送
 func Do(cfg *Config, recvUser []string, content *Content) error {
+	m, err := newMessage(cfg, recvUser, content)
+	if err != nil {
+		return err
+	}
+	d := gomail.NewDialer(cfg.Host, cfg.Port, cfg.Address, cfg.Pwd)
+	err = d.DialAndSend(m)
+	return err
+}
+
+// newMessage builds the message, multipart when it has an HTML alternative or attachments
+func newMessage(cfg *Config, recvUser []string, content *Content) (*gomail.Message, error) {
+	maxSize := cfg.MaxAttachmentSize
+	if maxSize <= 0 {
+		maxSize = DefaultMaxAttachmentSize
+	}
+	if err := CheckAttachments(content.Attachments, maxSize); err != nil {
+		return nil, err
+	}
+
 	m := gomail.NewMessage()
 	m.SetHeader("From", m.FormatAddress(cfg.Address, cfg.Name))
 	m.SetHeader("To", recvUser...)
 	m.SetHeader("Subject", content.Title)
-	m.SetBody(content.Type, content.Message)
-	d := gomail.NewDialer(cfg.Host, cfg.Port, cfg.Address, cfg.Pwd)
-	err := d.DialAndSend(m)
-	return err
+	switch {
+	case content.HTMLBody == "":
+		m.SetBody(content.Type, content.Message)
+	case content.Message == "":
+		m.SetBody("text/html", content.HTMLBody)
+	default:
+		m.SetBody(content.Type, content.Message)
+		m.AddAlternative("text/html", content.HTMLBody)
+	}
+
+	for _, a := range content.Attachments {
+		m.Attach(attachmentName(a), attachmentSettings(a)...)
+	}
+	return m, nil
+}
+
+// CheckAttachments validates the attachments and that their combined size is within maxSize bytes
+func CheckAttachments(attachments []Attachment, maxSize int64) error {
+	var total int64
+	for i, a := range attachments {
+		var size int64
+		switch {
+		case a.Path != "":
+			info, err := os.Stat(a.Path)
+			if err != nil {
+				return fmt.Errorf("attachment %d: %w", i, err)
+			}
+			if info.IsDir() {
+				return fmt.Errorf("attachment %d: %s is a directory", i, a.Path)
+			}
+			size = info.Size()
+		case a.Filename == "":
+			return fmt.Errorf("attachment %d: filename is required for attachment data", i)
+		default:
+			size = int64(len(a.Data))
+		}
+
+		total += size
+		if total > maxSize {
+			return fmt.Errorf("%w: %d bytes allowed", ErrAttachmentTooLarge, maxSize)
+		}
+	}
+	return nil
+}
+
+// ResolveAttachmentPath resolves path, relative paths against dir, following symlinks
+// Paths outside dir, missing files and an empty dir all fail with ErrAttachmentPathDenied so callers learn nothing about the file system
+func ResolveAttachmentPath(dir, path string) (string, error) {
+	if dir == "" {
+		return "", fmt.Errorf("%w: path attachments are disabled", ErrAttachmentPathDenied)
+	}
+	root, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return "", fmt.Errorf("%w: attachment directory is unavailable", ErrAttachmentPathDenied)
+	}
+	root, err = filepath.Abs(root)
+	if err != nil {
+		return "", fmt.Errorf("%w: attachment directory is unavailable", ErrAttachmentPathDenied)
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(root, path)
+	}
+	resolved, err := filepath.EvalSymlinks(path)
+	if err == nil {
+		resolved, err = filepath.Abs(resolved)
+	}
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrAttachmentPathDenied, path)
+	}
+	if rel, err := filepath.Rel(root, resolved); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%w: %s", ErrAttachmentPathDenied, path)
+	}
+	return resolved, nil
+}
+
+func attachmentName(a Attachment) string {
+	if a.Path == "" {
+		return a.Filename
+	}
+	return a.Path
+}
+
+func attachmentSettings(a Attachment) []gomail.FileSetting {
+	var settings []gomail.FileSetting
+	name := a.Filename
+	if name == "" {
+		name = filepath.Base(a.Path)
+	}
+	if a.Path != "" {
+		// gomail reads the file named by the first Attach argument, the recipient sees the renamed one
+		settings = append(settings, gomail.Rename(name))
+	} else {
+		data := a.Data
+		settings = append(settings, gomail.SetCopyFunc(func(w io.Writer) error {
+			_, err := w.Write(data)
+			return err
+		}))
+	}
+	if a.ContentType != "" {
+		settings = append(settings, gomail.SetHeader(map[string][]string{
+			"Content-Type": {a.ContentType + `; name="` + name + `"`},
+		}))
+	}
+	return settings
 }
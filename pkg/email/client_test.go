@@ -0,0 +1,127 @@
+package email
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewMessagePlainText(t *testing.T) {
+	m, err := newMessage(&Config{Address: "bot@example.com"}, []string{"user@example.com"}, &Content{
+		Title:   "Report",
+		Type:    "text/plain",
+		Message: "hello",
+	})
+	if err != nil {
+		t.Fatalf("newMessage failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if _, err := m.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	raw := buf.String()
+	if strings.Contains(raw, "multipart/") {
+		t.Error("plain text message should not be multipart")
+	}
+	if !strings.Contains(raw, "Content-Type: text/plain") {
+		t.Error("message should have a text/plain body")
+	}
+}
+
+func TestNewMessageHTMLAndAttachments(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.csv")
+	if err := os.WriteFile(path, []byte("a,b\n1,2\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := newMessage(&Config{Address: "bot@example.com"}, []string{"user@example.com"}, &Content{
+		Title:    "Report",
+		Type:     "text/plain",
+		Message:  "hello",
+		HTMLBody: "<p>hello</p>",
+		Attachments: []Attachment{
+			{Path: path},
+			{Filename: "notes.bin", ContentType: "application/x-notes", Data: []byte("notes")},
+		},
+	})
+	if err != nil {
+		t.Fatalf("newMessage failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if _, err := m.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	raw := buf.String()
+	for _, want := range []string{
+		"multipart/mixed",
+		"multipart/alternative",
+		"Content-Type: text/html",
+		`filename="report.csv"`,
+		`Content-Type: application/x-notes; name="notes.bin"`,
+	} {
+		if !strings.Contains(raw, want) {
+			t.Errorf("message should contain %q", want)
+		}
+	}
+}
+
+func TestCheckAttachments(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.txt")
+	if err := os.WriteFile(path, make([]byte, 6), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := CheckAttachments([]Attachment{{Path: path}, {Filename: "a.txt", Data: make([]byte, 4)}}, 10); err != nil {
+		t.Errorf("attachments within the cap should pass: %v", err)
+	}
+
+	err := CheckAttachments([]Attachment{{Path: path}, {Filename: "a.txt", Data: make([]byte, 5)}}, 10)
+	if !errors.Is(err, ErrAttachmentTooLarge) {
+		t.Errorf("Expected ErrAttachmentTooLarge, got %v", err)
+	}
+
+	if err := CheckAttachments([]Attachment{{Data: []byte("x")}}, 10); err == nil {
+		t.Error("attachment data without a filename should fail")
+	}
+	if err := CheckAttachments([]Attachment{{Path: filepath.Join(t.TempDir(), "missing")}}, 10); err == nil {
+		t.Error("missing attachment file should fail")
+	}
+}
+
+func TestResolveAttachmentPath(t *testing.T) {
+	dir := t.TempDir()
+	inside := filepath.Join(dir, "report.csv")
+	outside := filepath.Join(t.TempDir(), "secret.txt")
+	for _, path := range []string{inside, outside} {
+		if err := os.WriteFile(path, []byte("x"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(outside, filepath.Join(dir, "link.txt")); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ResolveAttachmentPath("", inside); !errors.Is(err, ErrAttachmentPathDenied) {
+		t.Errorf("path attachments without a directory should be denied, got %v", err)
+	}
+	for _, path := range []string{inside, "report.csv"} {
+		resolved, err := ResolveAttachmentPath(dir, path)
+		if err != nil {
+			t.Errorf("%s should resolve: %v", path, err)
+			continue
+		}
+		if want, _ := filepath.EvalSymlinks(inside); resolved != want {
+			t.Errorf("%s resolved to %s, want %s", path, resolved, want)
+		}
+	}
+	for _, path := range []string{outside, "../secret.txt", "link.txt", "missing.txt", dir + "-other/report.csv"} {
+		if _, err := ResolveAttachmentPath(dir, path); !errors.Is(err, ErrAttachmentPathDenied) {
+			t.Errorf("%s should be denied, got %v", path, err)
+		}
+	}
+}
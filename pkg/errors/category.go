@@ -0,0 +1,139 @@
+package errors
+
+import (
+	stderrors "errors"
+	"fmt"
+	"sync"
+)
+
+// Category error category used to group codes and drive retry decisions
+type Category string
+
+const (
+	CategoryGeneric    Category = "generic"    // 1xxx
+	CategoryTool       Category = "tool"       // 2xxx
+	CategoryConfig     Category = "config"     // 3xxx
+	CategoryMemory     Category = "memory"     // 4xxx
+	CategoryNetwork    Category = "network"    // 5xxx
+	CategoryValidation Category = "validation" // 6xxx
+	CategorySystem     Category = "system"     // 7xxx
+	CategoryData       Category = "data"       // 8xxx
+	CategoryPermission Category = "permission" // 9xxx
+	CategoryLLM        Category = "llm"        // 10xxx
+	CategoryMCP        Category = "mcp"        // 11xxx
+	CategoryHTTP       Category = "http"       // 12xxx
+	CategoryEmail      Category = "email"      // 13xxx
+	CategoryCache      Category = "cache"      // 14xxx
+	CategorySQL        Category = "sql"        // 15xxx
+	CategoryTransient  Category = "transient"  // custom codes only, always retryable
+	CategoryUnknown    Category = "unknown"
+)
+
+// CustomCodeBase first code available to RegisterCode, lower codes are reserved for built-in errors
+const CustomCodeBase = 100000
+
+// builtinCategories maps the thousand-range of built-in codes to their category
+var builtinCategories = map[int]Category{
+	1:  CategoryGeneric,
+	2:  CategoryTool,
+	3:  CategoryConfig,
+	4:  CategoryMemory,
+	5:  CategoryNetwork,
+	6:  CategoryValidation,
+	7:  CategorySystem,
+	8:  CategoryData,
+	9:  CategoryPermission,
+	10: CategoryLLM,
+	11: CategoryMCP,
+	12: CategoryHTTP,
+	13: CategoryEmail,
+	14: CategoryCache,
+	15: CategorySQL,
+}
+
+// retryableCodes built-in codes outside retryable categories that are still worth retrying
+var retryableCodes = map[int]bool{
+	EC_TOOL_EXECUTION_TIMEOUT.Code: true,
+	EC_SYSTEM_OVERLOAD.Code:        true,
+	ErrRateLimitExceeded.Code:      true,
+	EC_LLM_NO_RESPONSE.Code:        true,
+	EC_LLM_CALL_FAILED.Code:        true,
+}
+
+// retryableCategories categories whose errors are considered transient
+var retryableCategories = map[Category]bool{
+	CategoryNetwork:   true,
+	CategoryTransient: true,
+}
+
+// customCode code registered by third-party code
+type customCode struct {
+	message  string
+	category Category
+}
+
+var (
+	customCodesMu sync.RWMutex
+	customCodes   = make(map[int]customCode)
+)
+
+// RegisterCode registers a custom error code for use by third-party tools
+// Codes must be >= CustomCodeBase and unique, otherwise RegisterCode panics,
+// so it is intended to be called from package-level var declarations or init
+// The returned error participates in Category and IsRetryable like built-in codes
+func RegisterCode(code int, message string, category Category) *Error {
+	if code < CustomCodeBase {
+		panic(fmt.Sprintf("errors: custom code %d collides with the built-in range, use codes >= %d", code, CustomCodeBase))
+	}
+
+	customCodesMu.Lock()
+	defer customCodesMu.Unlock()
+	if existing, ok := customCodes[code]; ok {
+		panic(fmt.Sprintf("errors: code %d already registered as %q", code, existing.message))
+	}
+	customCodes[code] = customCode{message: message, category: category}
+
+	return NewError(code, message)
+}
+
+// Category returns the category of the error code
+func (e *Error) Category() Category {
+	return categoryOf(e.Code)
+}
+
+// categoryOf resolves the category of a built-in or registered code
+func categoryOf(code int) Category {
+	if code >= CustomCodeBase {
+		customCodesMu.RLock()
+		defer customCodesMu.RUnlock()
+		if c, ok := customCodes[code]; ok {
+			return c.category
+		}
+		return CategoryUnknown
+	}
+	if category, ok := builtinCategories[code/1000]; ok {
+		return category
+	}
+	return CategoryUnknown
+}
+
+// GetCategory returns the category of the first *Error in err's chain
+func GetCategory(err error) Category {
+	var e *Error
+	if stderrors.As(err, &e) {
+		return e.Category()
+	}
+	return CategoryUnknown
+}
+
+// IsRetryable reports whether err is a transient error worth retrying
+func IsRetryable(err error) bool {
+	var e *Error
+	if !stderrors.As(err, &e) {
+		return false
+	}
+	if retryableCodes[e.Code] {
+		return true
+	}
+	return retryableCategories[e.Category()]
+}
@@ -0,0 +1,82 @@
+package errors
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestCategory_Builtin(t *testing.T) {
+	tests := []struct {
+		err      *Error
+		expected Category
+	}{
+		{EC_AGENT_BUSY, CategoryGeneric},
+		{EC_TOOL_NOT_FOUND, CategoryTool},
+		{EC_TIMEOUT, CategoryNetwork},
+		{EC_LLM_CALL_FAILED, CategoryLLM},
+		{EC_SQL_ERROR, CategorySQL},
+	}
+
+	for _, tt := range tests {
+		if got := tt.err.Category(); got != tt.expected {
+			t.Errorf("Code %d: expected category %s, got %s", tt.err.Code, tt.expected, got)
+		}
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	if !IsRetryable(NewError(EC_CONNECTION_FAILED.Code, "dial failed")) {
+		t.Error("Expected network errors to be retryable")
+	}
+	if !IsRetryable(fmt.Errorf("wrapped: %w", NewError(ErrRateLimitExceeded.Code, "slow down"))) {
+		t.Error("Expected wrapped rate limit error to be retryable")
+	}
+	if IsRetryable(NewError(EC_PARAMETER_INVALID.Code, "bad input")) {
+		t.Error("Expected validation errors not to be retryable")
+	}
+	if IsRetryable(fmt.Errorf("plain error")) {
+		t.Error("Expected plain errors not to be retryable")
+	}
+}
+
+func TestRegisterCode(t *testing.T) {
+	ec := RegisterCode(CustomCodeBase+1, "upstream flaky", CategoryTransient)
+	if ec.Code != CustomCodeBase+1 || ec.Message != "upstream flaky" {
+		t.Errorf("Unexpected registered error: %v", ec)
+	}
+	if ec.Category() != CategoryTransient {
+		t.Errorf("Expected category %s, got %s", CategoryTransient, ec.Category())
+	}
+	if !IsRetryable(ec) {
+		t.Error("Expected transient custom error to be retryable")
+	}
+
+	denied := RegisterCode(CustomCodeBase+2, "quota denied", CategoryPermission)
+	if IsRetryable(denied) {
+		t.Error("Expected permission custom error not to be retryable")
+	}
+	if GetCategory(fmt.Errorf("wrapped: %w", denied)) != CategoryPermission {
+		t.Error("Expected category to be resolved through wrapping")
+	}
+}
+
+func TestRegisterCode_Collisions(t *testing.T) {
+	assertPanics := func(name string, fn func()) {
+		t.Helper()
+		defer func() {
+			if recover() == nil {
+				t.Errorf("%s: expected panic", name)
+			}
+		}()
+		fn()
+	}
+
+	assertPanics("builtin range", func() {
+		RegisterCode(EC_TOOL_NOT_FOUND.Code, "shadow", CategoryTool)
+	})
+
+	RegisterCode(CustomCodeBase+10, "first", CategoryTool)
+	assertPanics("duplicate", func() {
+		RegisterCode(CustomCodeBase+10, "second", CategoryTool)
+	})
+}
@@ -13,6 +13,7 @@ var (
 	EC_ITERATION_FAILED        = NewError(1008, "iteration failed")                          // 1008
 	EC_BLOCKING_CHAT_FAILED    = NewError(1009, "failed to get tool calls in blocking mode") // 1009
 	EC_MEMORY_HISTORY_FAILED   = NewError(1010, "failed to get chat history")                // 1010
+	EC_AGENT_SHUTTING_DOWN     = NewError(1011, "agent is shutting down")                    // 1011
 
 	// Tool-related errors (2xxx)
 	EC_TOOL_EXECUTION_FAILED   = NewError(2001, "tool execution failed")   // 2001
@@ -33,6 +34,8 @@ var (
 	EC_CACHE_ERROR              = NewError(4002, "cache error")              // 4002
 	EC_CACHE_FULL               = NewError(4003, "cache full")               // 4003
 	EC_MEMORY_ALLOCATION_FAILED = NewError(4004, "memory allocation failed") // 4004
+	EC_CHECKPOINT_NOT_FOUND     = NewError(4005, "checkpoint not found")     // 4005
+	EC_CHECKPOINT_FAILED        = NewError(4006, "checkpoint failed")        // 4006
 
 	// Network/connection errors (5xxx)
 	EC_NETWORK_ERROR       = NewError(5001, "network error")       // 5001
@@ -73,6 +76,7 @@ var (
 	EC_LLM_CALL_FAILED          = NewError(10002, "LLM call failed")             // 10002
 	EC_LLM_API_KEY_REQUIRED     = NewError(10003, "API key is required")         // 10003
 	EC_LLM_CLIENT_CREATE_FAILED = NewError(10004, "failed to create LLM client") // 10004
+	EC_LLM_CONTENT_FILTERED     = NewError(10005, "response content filtered")   // 10005
 
 	// MCP client errors (11xxx)
 	EC_MCP_UNSUPPORTED_TRANSPORT = NewError(11001, "unsupported transport")           // 11001
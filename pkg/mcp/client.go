@@ -1,31 +1,72 @@
 package mcp
 
 import (
+	"bufio"
 	"context"
+	stderrors "errors"
 	"fmt"
+	"io"
 	"log/slog"
+	"os"
+	"os/exec"
 	"sync"
 	"time"
 
 	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/client/transport"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/xichan96/cortex/agent/types"
 	"github.com/xichan96/cortex/pkg/errors"
 	"github.com/xichan96/cortex/pkg/logger"
 )
 
+// CallTool retry defaults
+const (
+	DefaultCallToolAttempts   = 3
+	DefaultCallToolBackoff    = 500 * time.Millisecond
+	DefaultCallToolMaxBackoff = 5 * time.Second
+)
+
+// Reconnect defaults
+const (
+	DefaultReconnectAttempts = 3
+	DefaultReconnectBackoff  = 1 * time.Second
+)
+
+// StdioShutdownTimeout how long Disconnect waits for a stdio server to exit after its stdin is closed
+// before killing it
+const StdioShutdownTimeout = 5 * time.Second
+
+// CallRetryOptions retry policy for transient transport failures in CallTool
+// Errors reported by the tool itself (IsError results) and JSON-RPC errors are never retried
+type CallRetryOptions struct {
+	MaxAttempts int           // total attempts including the first, 1 disables retries (default 3)
+	Backoff     time.Duration // delay before the first retry, doubled after each attempt (default 500ms)
+	MaxBackoff  time.Duration // upper bound of the delay (default 5s)
+}
+
 // Client MCP client - using official SDK
 
 type Client struct {
 	serverURL string
-	transport string // "httpStreamable" or "sse"
+	transport string // "httpStreamable", "sse" or "stdio"
 	headers   map[string]string
-	mcpClient *client.Client
+	command   string         // stdio only, server command
+	args      []string       // stdio only, command arguments
+	env       []string       // stdio only, "KEY=value" entries added to the current environment
+	cmd       *exec.Cmd      // stdio only, running server process
+	mcpClient *client.Client // nil while a dropped connection could not be re-established
 	tools     []types.Tool
 	toolsMu   sync.RWMutex
-	connected bool
+	connected bool   // between Connect and Disconnect, also while reconnecting
+	epoch     uint64 // bumped by every established connection
 	connectMu sync.RWMutex
+	retry     CallRetryOptions
 	logger    *logger.Logger
+
+	reconnectMu       sync.Mutex // serializes reconnection so concurrent calls share one attempt
+	reconnectAttempts int
+	reconnectBackoff  time.Duration
 }
 
 // NewClient creates a new MCP client
@@ -42,41 +83,114 @@ func NewClient(url string, transport string, headers map[string]string) *Client
 		transport: transport,
 		headers:   headers,
 		tools:     make([]types.Tool, 0),
-		logger:    logger.NewLogger(),
+		retry: CallRetryOptions{
+			MaxAttempts: DefaultCallToolAttempts,
+			Backoff:     DefaultCallToolBackoff,
+			MaxBackoff:  DefaultCallToolMaxBackoff,
+		},
+		logger:            logger.NewLogger(),
+		reconnectAttempts: DefaultReconnectAttempts,
+		reconnectBackoff:  DefaultReconnectBackoff,
 	}
 }
 
+// NewStdioClient creates an MCP client that launches command as a subprocess on Connect and talks to it
+// over stdin/stdout. env entries ("KEY=value") are added to the current environment, the server's stderr
+// is logged. Disconnect closes the server's stdin and kills it if it has not exited after StdioShutdownTimeout
+func NewStdioClient(command string, args []string, env []string) *Client {
+	c := NewClient("", "stdio", nil)
+	c.command = command
+	c.args = args
+	c.env = env
+	return c
+}
+
+// SetCallRetry sets the CallTool retry policy, zero fields keep their defaults
+func (c *Client) SetCallRetry(opts CallRetryOptions) {
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = DefaultCallToolAttempts
+	}
+	if opts.Backoff <= 0 {
+		opts.Backoff = DefaultCallToolBackoff
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = DefaultCallToolMaxBackoff
+	}
+	c.connectMu.Lock()
+	c.retry = opts
+	c.connectMu.Unlock()
+}
+
+// SetReconnectPolicy sets how CallTool re-establishes a dropped connection
+// When a call still fails in the transport after its retries, the client reconnects up to maxAttempts
+// times, waiting backoff (doubled after each attempt) in between, refreshes the tool list and repeats
+// the call once. maxAttempts <= 0 disables reconnection, backoff <= 0 uses DefaultReconnectBackoff
+func (c *Client) SetReconnectPolicy(maxAttempts int, backoff time.Duration) {
+	if backoff <= 0 {
+		backoff = DefaultReconnectBackoff
+	}
+	c.connectMu.Lock()
+	c.reconnectAttempts = maxAttempts
+	c.reconnectBackoff = backoff
+	c.connectMu.Unlock()
+}
+
 // Connect connects to MCP server
+// Connecting a client whose connection dropped and could not be re-established tries again
 func (c *Client) Connect(ctx context.Context) error {
 	c.connectMu.Lock()
 	defer c.connectMu.Unlock()
 
-	if c.connected {
+	if c.connected && c.mcpClient != nil {
 		return nil
 	}
+	if err := c.connectLocked(ctx); err != nil {
+		return err
+	}
+	c.connected = true
+	return nil
+}
 
+// connectLocked establishes the connection and fetches the tool list, callers must hold connectMu
+// On failure the partial connection is closed and mcpClient left nil
+func (c *Client) connectLocked(ctx context.Context) error {
 	c.logger.Info("Connecting to MCP server",
 		slog.String("server_url", c.serverURL),
+		slog.String("command", c.command),
 		slog.String("transport", c.transport))
 
 	var err error
+	startCtx := ctx
 
 	switch c.transport {
 	case "http", "httpStreamable":
 		c.mcpClient, err = client.NewStreamableHttpClient(c.serverURL)
 	case "sse":
 		c.mcpClient, err = client.NewSSEMCPClient(c.serverURL, client.WithHeaders(c.headers))
+	case "stdio":
+		if c.command == "" {
+			return errors.NewError(errors.EC_MCP_CLIENT_CREATE_FAILED.Code, "stdio transport requires a command")
+		}
+		c.mcpClient = client.NewClient(transport.NewStdioWithOptions(c.command, c.env, c.args,
+			transport.WithCommandFunc(c.stdioCommand)))
+		// The server process outlives Connect, it is stopped by Disconnect
+		startCtx = context.WithoutCancel(ctx)
 	default:
 		return errors.NewError(errors.EC_MCP_UNSUPPORTED_TRANSPORT.Code, fmt.Sprintf("unsupported transport: %s", c.transport))
 	}
 
 	if err != nil {
+		c.mcpClient = nil
 		return errors.NewError(errors.EC_MCP_CLIENT_CREATE_FAILED.Code, errors.EC_MCP_CLIENT_CREATE_FAILED.Message).Wrap(err)
 	}
 
-	if err := c.mcpClient.Start(ctx); err != nil {
+	if err := c.mcpClient.Start(startCtx); err != nil {
+		c.closeClient(ctx)
 		return errors.NewError(errors.EC_MCP_CLIENT_START_FAILED.Code, errors.EC_MCP_CLIENT_START_FAILED.Message).Wrap(err)
 	}
+	if stderr, ok := client.GetStderr(c.mcpClient); ok {
+		go c.logStderr(stderr)
+	}
 
 	// Initialize client
 	initRequest := mcp.InitializeRequest{
@@ -95,19 +209,17 @@ func (c *Client) Connect(ctx context.Context) error {
 
 	_, err = c.mcpClient.Initialize(ctx, initRequest)
 	if err != nil {
-		c.mcpClient.Close()
+		c.closeClient(ctx)
 		return errors.NewError(errors.EC_MCP_CLIENT_INIT_FAILED.Code, errors.EC_MCP_CLIENT_INIT_FAILED.Message).Wrap(err)
 	}
 
-	c.connected = true
-
 	// Get available tool list
 	if err := c.refreshTools(ctx); err != nil {
-		c.connected = false
-		c.mcpClient.Close()
+		c.closeClient(ctx)
 		return errors.NewError(errors.EC_MCP_REFRESH_TOOLS_FAILED.Code, errors.EC_MCP_REFRESH_TOOLS_FAILED.Message).Wrap(err)
 	}
 
+	c.epoch++
 	return nil
 }
 
@@ -120,22 +232,72 @@ func (c *Client) Disconnect(ctx context.Context) error {
 		return nil
 	}
 
-	if c.mcpClient != nil {
-		c.mcpClient.Close()
-		c.mcpClient = nil
-	}
-
+	c.closeClient(ctx)
 	c.connected = false
 	c.tools = make([]types.Tool, 0)
 
 	return nil
 }
 
+// stdioCommand builds the stdio server process, keeping it so Disconnect can kill a server that hangs
+// Called by the stdio transport during Connect, with connectMu held
+func (c *Client) stdioCommand(ctx context.Context, command string, env []string, args []string) (*exec.Cmd, error) {
+	cmd := exec.Command(command, args...)
+	cmd.Env = append(os.Environ(), env...)
+	c.cmd = cmd
+	return cmd, nil
+}
+
+// closeClient closes the MCP client, callers must hold connectMu
+// A stdio server is asked to exit by closing its stdin and killed if it is still running after
+// StdioShutdownTimeout or once ctx is done
+func (c *Client) closeClient(ctx context.Context) {
+	mcpClient, cmd := c.mcpClient, c.cmd
+	c.mcpClient, c.cmd = nil, nil
+	if mcpClient == nil {
+		return
+	}
+	if cmd == nil || cmd.Process == nil {
+		mcpClient.Close()
+		return
+	}
+
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		mcpClient.Close()
+	}()
+
+	timer := time.NewTimer(StdioShutdownTimeout)
+	defer timer.Stop()
+	select {
+	case <-closed:
+		return
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+	c.logger.Info("MCP stdio server did not exit, killing it",
+		slog.String("command", c.command),
+		slog.Int("pid", cmd.Process.Pid))
+	_ = cmd.Process.Kill()
+	<-closed
+}
+
+// logStderr logs the stderr output of a stdio server until it exits
+func (c *Client) logStderr(stderr io.Reader) {
+	scanner := bufio.NewScanner(stderr)
+	for scanner.Scan() {
+		c.logger.Info("MCP stdio server stderr",
+			slog.String("command", c.command),
+			slog.String("line", scanner.Text()))
+	}
+}
+
 // IsConnected checks if connected
 func (c *Client) IsConnected() bool {
 	c.connectMu.RLock()
 	defer c.connectMu.RUnlock()
-	return c.connected
+	return c.connected && c.mcpClient != nil
 }
 
 // GetTools gets available tools
@@ -156,6 +318,8 @@ func (c *Client) CallTool(ctx context.Context, toolName string, arguments map[st
 		return nil, errors.EC_MCP_NOT_CONNECTED
 	}
 	mcpClient := c.mcpClient
+	retry := c.retry
+	epoch := c.epoch
 	c.connectMu.RUnlock()
 
 	params := mcp.CallToolRequest{
@@ -168,9 +332,27 @@ func (c *Client) CallTool(ctx context.Context, toolName string, arguments map[st
 		},
 	}
 
-	result, err := mcpClient.CallTool(ctx, params)
+	var result *mcp.CallToolResult
+	var err error
+	attempts := 0
+	if mcpClient != nil {
+		result, attempts, err = c.callWithRetry(ctx, mcpClient, params, retry)
+	}
+	if mcpClient == nil || (err != nil && isTransientCallError(ctx, err)) {
+		// The connection dropped, reconnect and repeat the call once on the new connection
+		reconnected, reconnectErr := c.reconnect(ctx, epoch)
+		if reconnectErr != nil {
+			if err == nil {
+				return nil, errors.NewError(errors.EC_MCP_NOT_CONNECTED.Code, errors.EC_MCP_NOT_CONNECTED.Message).Wrap(reconnectErr)
+			}
+			c.logger.LogError("CallTool", reconnectErr, slog.String("tool", toolName), slog.String("phase", "reconnect"))
+		} else {
+			attempts++
+			result, err = reconnected.CallTool(ctx, params)
+		}
+	}
 	if err != nil {
-		return nil, errors.NewError(errors.EC_MCP_CALL_TOOL_FAILED.Code, fmt.Sprintf("failed to call tool %s", toolName)).Wrap(err)
+		return nil, errors.NewError(errors.EC_MCP_CALL_TOOL_FAILED.Code, fmt.Sprintf("failed to call tool %s after %d attempt(s)", toolName, attempts)).Wrap(err)
 	}
 
 	if result.IsError {
@@ -184,6 +366,91 @@ func (c *Client) CallTool(ctx context.Context, toolName string, arguments map[st
 	}, nil
 }
 
+// callWithRetry calls the tool, retrying transient transport failures on the same connection
+// Returns the number of attempts made
+func (c *Client) callWithRetry(ctx context.Context, mcpClient *client.Client, params mcp.CallToolRequest, retry CallRetryOptions) (*mcp.CallToolResult, int, error) {
+	backoff := retry.Backoff
+	for attempt := 1; ; attempt++ {
+		result, err := mcpClient.CallTool(ctx, params)
+		if err == nil || attempt >= retry.MaxAttempts || !isTransientCallError(ctx, err) {
+			return result, attempt, err
+		}
+
+		c.logger.Info("Transient MCP call failure, retrying",
+			slog.String("tool", params.Params.Name),
+			slog.Int("attempt", attempt),
+			slog.Duration("backoff", backoff),
+			slog.String("error", err.Error()))
+		select {
+		case <-ctx.Done():
+			return nil, attempt, err
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, retry.MaxBackoff)
+	}
+}
+
+// reconnect replaces the connection established at epoch, which dropped, and refreshes the tool list
+// Calls that saw the same connection drop wait for a single reconnection and share its result. Gives up
+// after the reconnect policy's attempts, when ctx is done or when the client is disconnected meanwhile
+func (c *Client) reconnect(ctx context.Context, epoch uint64) (*client.Client, error) {
+	c.reconnectMu.Lock()
+	defer c.reconnectMu.Unlock()
+
+	c.connectMu.RLock()
+	maxAttempts, backoff := c.reconnectAttempts, c.reconnectBackoff
+	c.connectMu.RUnlock()
+	if maxAttempts <= 0 {
+		return nil, fmt.Errorf("reconnection is disabled")
+	}
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		c.connectMu.Lock()
+		if !c.connected {
+			c.connectMu.Unlock()
+			return nil, errors.EC_MCP_NOT_CONNECTED
+		}
+		if c.epoch != epoch && c.mcpClient != nil {
+			// Reconnected by a concurrent call or Connect
+			mcpClient := c.mcpClient
+			c.connectMu.Unlock()
+			return mcpClient, nil
+		}
+
+		c.logger.Info("MCP connection dropped, reconnecting",
+			slog.String("transport", c.transport),
+			slog.Int("attempt", attempt),
+			slog.Int("max_attempts", maxAttempts))
+		c.closeClient(ctx)
+		err = c.connectLocked(ctx)
+		mcpClient := c.mcpClient
+		c.connectMu.Unlock()
+		if err == nil {
+			return mcpClient, nil
+		}
+
+		if attempt >= maxAttempts {
+			return nil, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// isTransientCallError reports whether a CallTool failure came from the transport and may succeed on retry
+func isTransientCallError(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var transportErr *transport.Error
+	return stderrors.As(err, &transportErr)
+}
+
 // refreshTools refreshes tool list
 func (c *Client) refreshTools(ctx context.Context) error {
 	if c.mcpClient == nil {
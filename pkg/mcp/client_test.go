@@ -0,0 +1,179 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	mcpgo "github.com/mark3labs/mcp-go/mcp"
+	mcpsrv "github.com/mark3labs/mcp-go/server"
+)
+
+// stdioServerEnv makes the test binary run as a stdio MCP server, "hang" keeps it running after stdin closes
+const stdioServerEnv = "CORTEX_TEST_MCP_STDIO_SERVER"
+
+func TestMain(m *testing.M) {
+	if mode := os.Getenv(stdioServerEnv); mode != "" {
+		runStdioServer(mode)
+		return
+	}
+	os.Exit(m.Run())
+}
+
+// runStdioServer serves an echo tool over stdin/stdout
+func runStdioServer(mode string) {
+	server := mcpsrv.NewMCPServer("test", "1.0.0")
+	server.AddTool(
+		mcpgo.NewTool("echo", mcpgo.WithString("text", mcpgo.Required())),
+		func(ctx context.Context, request mcpgo.CallToolRequest) (*mcpgo.CallToolResult, error) {
+			return mcpgo.NewToolResultText(request.GetString("text", "")), nil
+		},
+	)
+	_ = mcpsrv.NewStdioServer(server).Listen(context.Background(), os.Stdin, os.Stdout)
+	if mode == "hang" {
+		select {}
+	}
+}
+
+func newTestStdioClient(t *testing.T, mode string) *Client {
+	t.Helper()
+	executable, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewStdioClient(executable, []string{"-test.run=^$"}, []string{stdioServerEnv + "=" + mode})
+}
+
+func TestStdioClient(t *testing.T) {
+	c := newTestStdioClient(t, "serve")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := c.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+
+	tools := c.GetTools()
+	if len(tools) != 1 || tools[0].Name() != "echo" {
+		t.Fatalf("tools = %v, want the echo tool", tools)
+	}
+	result, err := tools[0].Execute(map[string]interface{}{"text": "hello"})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if !strings.Contains(fmt.Sprint(result), "hello") {
+		t.Errorf("result = %v, want echoed text", result)
+	}
+
+	cmd := c.cmd
+	if err := c.Disconnect(ctx); err != nil {
+		t.Fatalf("Disconnect failed: %v", err)
+	}
+	if cmd.ProcessState == nil || !cmd.ProcessState.Exited() {
+		t.Errorf("server process still running after Disconnect: %v", cmd.ProcessState)
+	}
+	if _, err := c.CallTool(ctx, "echo", nil); err == nil {
+		t.Error("CallTool succeeded after Disconnect")
+	}
+}
+
+func TestStdioClientKillsHangingServer(t *testing.T) {
+	c := newTestStdioClient(t, "hang")
+	if err := c.Connect(context.Background()); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+
+	cmd := c.cmd
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	start := time.Now()
+	if err := c.Disconnect(ctx); err != nil {
+		t.Fatalf("Disconnect failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed >= StdioShutdownTimeout {
+		t.Errorf("Disconnect took %s, want the server killed once ctx is done", elapsed)
+	}
+	if cmd.ProcessState == nil {
+		t.Error("server process was not reaped")
+	}
+}
+
+func TestStdioClientRequiresCommand(t *testing.T) {
+	if err := NewStdioClient("", nil, nil).Connect(context.Background()); err == nil {
+		t.Error("Connect succeeded without a command")
+	}
+}
+
+// killServer kills the stdio server process and waits until writes to it fail
+func killServer(t *testing.T, c *Client) {
+	t.Helper()
+	c.connectMu.RLock()
+	cmd := c.cmd
+	c.connectMu.RUnlock()
+	if err := cmd.Process.Kill(); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+}
+
+func TestReconnectAfterServerRestart(t *testing.T) {
+	c := newTestStdioClient(t, "serve")
+	c.SetCallRetry(CallRetryOptions{MaxAttempts: 1})
+	c.SetReconnectPolicy(2, 10*time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := c.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer c.Disconnect(ctx)
+
+	killServer(t, c)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 5)
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := c.CallTool(ctx, "echo", map[string]interface{}{"text": "hi"})
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("CallTool after server restart failed: %v", err)
+		}
+	}
+
+	c.connectMu.RLock()
+	epoch := c.epoch
+	c.connectMu.RUnlock()
+	if epoch != 2 {
+		t.Errorf("connection established %d times, want one reconnection shared by all calls", epoch)
+	}
+	if !c.IsConnected() || len(c.GetTools()) != 1 {
+		t.Errorf("client not reconnected with refreshed tools: connected=%v tools=%v", c.IsConnected(), c.GetTools())
+	}
+}
+
+func TestReconnectDisabled(t *testing.T) {
+	c := newTestStdioClient(t, "serve")
+	c.SetCallRetry(CallRetryOptions{MaxAttempts: 1})
+	c.SetReconnectPolicy(0, 0)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := c.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer c.Disconnect(ctx)
+
+	killServer(t, c)
+	if _, err := c.CallTool(ctx, "echo", map[string]interface{}{"text": "hi"}); err == nil {
+		t.Error("CallTool succeeded without reconnecting")
+	}
+}
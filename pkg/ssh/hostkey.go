@@ -0,0 +1,103 @@
+package ssh
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// Host key verification modes
+const (
+	// HostKeyStrict only accepts hosts whose key is listed in the known_hosts file
+	HostKeyStrict = "strict"
+	// HostKeyAcceptNew records the key of hosts missing from the known_hosts file and rejects changed keys
+	HostKeyAcceptNew = "accept-new"
+	// HostKeyInsecure accepts any host key, vulnerable to man-in-the-middle attacks
+	HostKeyInsecure = "insecure"
+)
+
+// knownHostsMu serializes appends to known_hosts files
+var knownHostsMu sync.Mutex
+
+// defaultKnownHostsFile returns the current user's ~/.ssh/known_hosts
+func defaultKnownHostsFile() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", errors.Wrap(err, "could not locate the known_hosts file, set a known hosts file explicitly")
+	}
+	return filepath.Join(home, ".ssh", "known_hosts"), nil
+}
+
+// hostKeyCallback builds the host key verification for the configured mode
+func hostKeyCallback(cfg Cfg) (ssh.HostKeyCallback, error) {
+	if cfg.HostKeyCheck == HostKeyInsecure {
+		return ssh.InsecureIgnoreHostKey(), nil
+	}
+
+	path := cfg.KnownHostsFile
+	if cfg.HostKeyCheck == HostKeyAcceptNew {
+		// The file is created on first use so that new hosts can be recorded
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			return nil, errors.Wrapf(err, "could not create directory for known_hosts file %q", path)
+		}
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_RDONLY, 0o600)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not create known_hosts file %q", path)
+		}
+		_ = f.Close()
+	}
+
+	callback, err := knownhosts.New(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not load known_hosts file %q", path)
+	}
+
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		err := callback(hostname, remote, key)
+		if err == nil {
+			return nil
+		}
+
+		var keyErr *knownhosts.KeyError
+		if !errors.As(err, &keyErr) {
+			return err
+		}
+		if len(keyErr.Want) > 0 {
+			return fmt.Errorf("host key mismatch for %s, the key in %s has changed (possible man-in-the-middle attack): %w", hostname, path, err)
+		}
+		if cfg.HostKeyCheck != HostKeyAcceptNew {
+			return fmt.Errorf("host %s is not in known_hosts file %s, add its key or use the %q host key check: %w", hostname, path, HostKeyAcceptNew, err)
+		}
+		return appendKnownHost(path, hostname, remote, key)
+	}, nil
+}
+
+// appendKnownHost records a newly seen host key in the known_hosts file
+func appendKnownHost(path, hostname string, remote net.Addr, key ssh.PublicKey) error {
+	knownHostsMu.Lock()
+	defer knownHostsMu.Unlock()
+
+	addresses := []string{knownhosts.Normalize(hostname)}
+	if remote != nil {
+		if addr := knownhosts.Normalize(remote.String()); addr != addresses[0] {
+			addresses = append(addresses, addr)
+		}
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return errors.Wrapf(err, "could not open known_hosts file %q", path)
+	}
+	defer f.Close()
+
+	if _, err := f.WriteString(knownhosts.Line(addresses, key) + "\n"); err != nil {
+		return errors.Wrapf(err, "could not record host key in %q", path)
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package ssh
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+func newTestHostKey(t *testing.T) ssh.PublicKey {
+	t.Helper()
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestHostKeyCallbackStrict(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "known_hosts")
+	known := newTestHostKey(t)
+	if err := os.WriteFile(path, []byte(knownhosts.Line([]string{knownhosts.Normalize("example.com:2222")}, known)+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	callback, err := hostKeyCallback(Cfg{HostKeyCheck: HostKeyStrict, KnownHostsFile: path})
+	if err != nil {
+		t.Fatalf("hostKeyCallback failed: %v", err)
+	}
+	remote := &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 2222}
+
+	if err := callback("example.com:2222", remote, known); err != nil {
+		t.Errorf("known host should be accepted: %v", err)
+	}
+	if err := callback("example.com:2222", remote, newTestHostKey(t)); err == nil || !strings.Contains(err.Error(), "mismatch") {
+		t.Errorf("changed host key should be rejected as a mismatch, got %v", err)
+	}
+	if err := callback("other.com:22", remote, known); err == nil {
+		t.Error("unknown host should be rejected in strict mode")
+	}
+}
+
+func TestHostKeyCallbackStrictMissingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "known_hosts")
+	if _, err := hostKeyCallback(Cfg{HostKeyCheck: HostKeyStrict, KnownHostsFile: path}); err == nil {
+		t.Error("strict mode should fail without a known_hosts file")
+	}
+}
+
+func TestHostKeyCallbackAcceptNew(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".ssh", "known_hosts")
+	callback, err := hostKeyCallback(Cfg{HostKeyCheck: HostKeyAcceptNew, KnownHostsFile: path})
+	if err != nil {
+		t.Fatalf("hostKeyCallback failed: %v", err)
+	}
+	remote := &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 22}
+	key := newTestHostKey(t)
+
+	if err := callback("example.com:22", remote, key); err != nil {
+		t.Fatalf("new host should be accepted: %v", err)
+	}
+
+	// A fresh callback reads the recorded key back from the file
+	callback, err = hostKeyCallback(Cfg{HostKeyCheck: HostKeyStrict, KnownHostsFile: path})
+	if err != nil {
+		t.Fatalf("hostKeyCallback failed: %v", err)
+	}
+	if err := callback("example.com:22", remote, key); err != nil {
+		t.Errorf("recorded host should be accepted: %v", err)
+	}
+	if err := callback("example.com:22", remote, newTestHostKey(t)); err == nil {
+		t.Error("changed host key should be rejected after it was recorded")
+	}
+}
+
+func TestValidateOptionsHostKeyCheck(t *testing.T) {
+	base := Cfg{Username: "root", Address: "127.0.0.1", Password: "secret"}
+
+	cfg, err := validateOptions(base)
+	if err != nil {
+		t.Fatalf("validateOptions failed: %v", err)
+	}
+	if cfg.HostKeyCheck != HostKeyStrict {
+		t.Errorf("Expected default host key check %q, got %q", HostKeyStrict, cfg.HostKeyCheck)
+	}
+
+	invalid := base
+	invalid.HostKeyCheck = "none"
+	if _, err := validateOptions(invalid); err == nil {
+		t.Error("validateOptions should reject an unknown host key check")
+	}
+}
+
+func TestParsePrivateKeyPassphrase(t *testing.T) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := ssh.MarshalPrivateKeyWithPassphrase(priv, "", []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	encrypted := string(pem.EncodeToMemory(block))
+
+	if _, err := parsePrivateKey(encrypted, ""); err == nil || !strings.Contains(err.Error(), "passphrase is required") {
+		t.Errorf("encrypted key without passphrase should ask for one, got %v", err)
+	}
+	if _, err := parsePrivateKey(encrypted, "wrong"); err == nil {
+		t.Error("encrypted key with a wrong passphrase should fail")
+	}
+	if _, err := parsePrivateKey(encrypted, "secret"); err != nil {
+		t.Errorf("encrypted key with the passphrase should parse: %v", err)
+	}
+}
@@ -25,13 +25,18 @@ type Cfg struct {
 	Password    string        `json:"password"`
 	Address     string        `json:"address"`
 	Port        int           `json:"port"`
-	PrivateKey  string        `json:"-"`
-	KeyFile     string        `json:"-"`
+	PrivateKey  string        `json:"-"` // PEM encoded private key
+	KeyFile     string        `json:"-"` // path of a PEM encoded private key, used when PrivateKey is empty
+	Passphrase  string        `json:"-"` // passphrase of an encrypted private key
 	AgentSocket string        `json:"-"`
 	Timeout     time.Duration `json:"timeout"`
 	Bastion     string        `json:"-"`
 	BastionPort int           `json:"-"`
 	BastionUser string        `json:"-"`
+	// HostKeyCheck host key verification mode, one of HostKeyStrict (default), HostKeyAcceptNew or HostKeyInsecure
+	HostKeyCheck string `json:"-"`
+	// KnownHostsFile known_hosts file used to verify host keys, defaults to ~/.ssh/known_hosts
+	KnownHostsFile string `json:"-"`
 }
 
 const socketEnvPrefix = "env:"
@@ -58,9 +63,9 @@ func NewConnection(cfg Cfg) (Connection, error) {
 	}
 
 	if len(cfg.PrivateKey) > 0 {
-		signer, parseErr := ssh.ParsePrivateKey([]byte(cfg.PrivateKey))
+		signer, parseErr := parsePrivateKey(cfg.PrivateKey, cfg.Passphrase)
 		if parseErr != nil {
-			return nil, errors.Wrap(parseErr, "The given SSH key could not be parsed")
+			return nil, parseErr
 		}
 		authMethods = append(authMethods, ssh.PublicKeys(signer))
 	}
@@ -92,11 +97,16 @@ func NewConnection(cfg Cfg) (Connection, error) {
 		authMethods = append(authMethods, ssh.PublicKeys(signers...))
 	}
 
+	hostKeyCb, err := hostKeyCallback(cfg)
+	if err != nil {
+		return nil, err
+	}
+
 	sshConfig := &ssh.ClientConfig{
 		User:            cfg.Username,
 		Timeout:         cfg.Timeout,
 		Auth:            authMethods,
-		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		HostKeyCallback: hostKeyCb,
 	}
 
 	targetHost := cfg.Address
@@ -193,9 +203,46 @@ func validateOptions(cfg Cfg) (Cfg, error) {
 		cfg.Timeout = 15 * time.Second
 	}
 
+	switch cfg.HostKeyCheck {
+	case "":
+		cfg.HostKeyCheck = HostKeyStrict
+	case HostKeyStrict, HostKeyAcceptNew, HostKeyInsecure:
+	default:
+		return cfg, errors.Errorf("Invalid host key check %q, must be one of %s, %s or %s", cfg.HostKeyCheck, HostKeyStrict, HostKeyAcceptNew, HostKeyInsecure)
+	}
+
+	if cfg.HostKeyCheck != HostKeyInsecure && len(cfg.KnownHostsFile) == 0 {
+		path, err := defaultKnownHostsFile()
+		if err != nil {
+			return cfg, err
+		}
+		cfg.KnownHostsFile = path
+	}
+
 	return cfg, nil
 }
 
+// parsePrivateKey parses a PEM encoded private key, decrypting it with the passphrase when one is given
+func parsePrivateKey(privateKey, passphrase string) (ssh.Signer, error) {
+	if len(passphrase) > 0 {
+		signer, err := ssh.ParsePrivateKeyWithPassphrase([]byte(privateKey), []byte(passphrase))
+		if err != nil {
+			return nil, errors.Wrap(err, "The given SSH key could not be decrypted with the passphrase")
+		}
+		return signer, nil
+	}
+
+	signer, err := ssh.ParsePrivateKey([]byte(privateKey))
+	if err != nil {
+		var missing *ssh.PassphraseMissingError
+		if errors.As(err, &missing) {
+			return nil, errors.New("The given SSH key is encrypted, a passphrase is required")
+		}
+		return nil, errors.Wrap(err, "The given SSH key could not be parsed")
+	}
+	return signer, nil
+}
+
 func (c *connection) Close() {
 	c.mu.Lock()
 	defer c.mu.Unlock()
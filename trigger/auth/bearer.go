@@ -0,0 +1,14 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// BearerAuthorized reports whether the request carries "Authorization: Bearer <secret>"
+// The token is compared in constant time, an empty secret never authorizes
+func BearerAuthorized(r *http.Request, secret string) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return secret != "" && ok && subtle.ConstantTimeCompare([]byte(token), []byte(secret)) == 1
+}
@@ -0,0 +1,32 @@
+package auth
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBearerAuthorized(t *testing.T) {
+	tests := []struct {
+		name          string
+		secret        string
+		authorization string
+		want          bool
+	}{
+		{"matching token", "s3cret", "Bearer s3cret", true},
+		{"wrong token", "s3cret", "Bearer nope", false},
+		{"missing header", "s3cret", "", false},
+		{"other scheme", "s3cret", "Basic s3cret", false},
+		{"empty secret", "", "Bearer ", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			if tt.authorization != "" {
+				r.Header.Set("Authorization", tt.authorization)
+			}
+			if got := BearerAuthorized(r, tt.secret); got != tt.want {
+				t.Errorf("BearerAuthorized = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
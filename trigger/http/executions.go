@@ -0,0 +1,48 @@
+package http
+
+import (
+	"context"
+	"sync"
+)
+
+// executions streams in flight on this server, shared by all handlers so POST /chat/cancel
+// reaches streams started by other requests
+var executions = newExecutionRegistry()
+
+// executionRegistry maps execution IDs of running streams to the functions cancelling them
+type executionRegistry struct {
+	mu      sync.Mutex
+	cancels map[string]context.CancelFunc
+}
+
+func newExecutionRegistry() *executionRegistry {
+	return &executionRegistry{
+		cancels: make(map[string]context.CancelFunc),
+	}
+}
+
+// register stores the cancel function of a starting execution
+func (r *executionRegistry) register(id string, cancel context.CancelFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cancels[id] = cancel
+}
+
+// unregister removes a finished execution
+func (r *executionRegistry) unregister(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.cancels, id)
+}
+
+// cancel cancels a running execution, reporting false when it is unknown or already finished
+func (r *executionRegistry) cancel(id string) bool {
+	r.mu.Lock()
+	cancel, ok := r.cancels[id]
+	delete(r.cancels, id)
+	r.mu.Unlock()
+	if ok {
+		cancel()
+	}
+	return ok
+}
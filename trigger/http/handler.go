@@ -1,30 +1,54 @@
 package http
 
 import (
+	"context"
 	"encoding/json"
+	stderrors "errors"
 	"fmt"
 	"log/slog"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/xichan96/cortex/agent/engine"
+	"github.com/xichan96/cortex/agent/types"
 	"github.com/xichan96/cortex/pkg/errors"
 	"github.com/xichan96/cortex/pkg/logger"
+	"github.com/xichan96/cortex/trigger/auth"
 )
 
 type Handler interface {
 	GetMessageRequest(c *gin.Context) (*MessageRequest, error)
 	ChatAPI(c *gin.Context, engine *engine.AgentEngine, req *MessageRequest)
 	StreamChatAPI(c *gin.Context, engine *engine.AgentEngine, req *MessageRequest)
+	GetToolInvokeRequest(c *gin.Context) (*ToolInvokeRequest, error)
+	InvokeToolAPI(c *gin.Context, engine *engine.AgentEngine, req *ToolInvokeRequest)
+	GetMessagesRequest(c *gin.Context) (*MessagesRequest, error)
+	MessagesAPI(c *gin.Context, history History, req *MessagesRequest)
+	GetHistoryRequest(c *gin.Context) (*HistoryRequest, error)
+	HistoryAPI(c *gin.Context, history History, req *HistoryRequest)
+	GetClearHistoryRequest(c *gin.Context) (*HistoryRequest, error)
+	ClearHistoryAPI(c *gin.Context, history History, req *HistoryRequest)
+	GetTitleRequest(c *gin.Context) (*TitleRequest, error)
+	TitleAPI(c *gin.Context, history History, req *TitleRequest)
+	GetCancelRequest(c *gin.Context) (*CancelRequest, error)
+	CancelAPI(c *gin.Context, req *CancelRequest)
 }
 
 type handler struct {
 	logger *logger.Logger
+	opt    Options
 }
 
 func NewHandler() Handler {
+	return NewHandlerWithOptions(Options{})
+}
+
+// NewHandlerWithOptions creates a handler with trigger options
+func NewHandlerWithOptions(opt Options) Handler {
 	return &handler{
 		logger: logger.NewLogger(),
+		opt:    opt,
 	}
 }
 
@@ -79,9 +103,65 @@ func (h *handler) GetMessageRequest(c *gin.Context) (*MessageRequest, error) {
 		})
 		return nil, errors.EC_HTTP_INVALID_METHOD
 	}
+	if req.SystemPrompt != "" && !h.authorizeSystemPrompt(c) {
+		return nil, errors.EC_UNAUTHORIZED
+	}
+	// Requests without a session start a new one, the client keeps it by sending the returned ID back
+	if req.SessionID == "" {
+		req.SessionID = uuid.New().String()
+	}
+	c.Header(SessionIDHeader, req.SessionID)
 	return &req, nil
 }
 
+// authorizeSystemPrompt checks that the caller may override the system prompt
+// Writes the error response itself when overrides are disabled or the caller is not authorized
+func (h *handler) authorizeSystemPrompt(c *gin.Context) bool {
+	if !h.opt.SystemPromptOverride.Enabled {
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Status: errors.EC_FORBIDDEN.Code,
+			Msg:    "system prompt override is disabled",
+		})
+		return false
+	}
+	if !auth.BearerAuthorized(c.Request, h.opt.SystemPromptOverride.Secret) {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Status: errors.EC_UNAUTHORIZED.Code,
+			Msg:    errors.EC_UNAUTHORIZED.Message,
+		})
+		return false
+	}
+	return true
+}
+
+// executeOptions builds per-request engine options from the message request
+func (h *handler) executeOptions(req *MessageRequest) *engine.ExecuteOptions {
+	if len(req.Documents) == 0 && len(req.Tools) == 0 && req.SystemPrompt == "" {
+		return nil
+	}
+	opts := &engine.ExecuteOptions{
+		Documents:    make([]engine.Document, 0, len(req.Documents)),
+		Tools:        req.Tools,
+		SystemPrompt: req.SystemPrompt,
+	}
+	for _, doc := range req.Documents {
+		opts.Documents = append(opts.Documents, engine.Document{
+			Name:    doc.Name,
+			Content: doc.Content,
+		})
+	}
+	return opts
+}
+
+// withExecutionContext sets the context cancelling the execution on the request options
+func withExecutionContext(opts *engine.ExecuteOptions, ctx context.Context) *engine.ExecuteOptions {
+	if opts == nil {
+		opts = &engine.ExecuteOptions{}
+	}
+	opts.Context = ctx
+	return opts
+}
+
 func (h *handler) ChatAPI(c *gin.Context, engine *engine.AgentEngine, req *MessageRequest) {
 	if engine == nil {
 		h.logger.LogError("ChatAPI", fmt.Errorf("agent engine is nil"))
@@ -92,13 +172,19 @@ func (h *handler) ChatAPI(c *gin.Context, engine *engine.AgentEngine, req *Messa
 		return
 	}
 
-	result, err := engine.Execute(req.Message, nil)
+	// The execution stops once the client disconnects
+	result, err := engine.ExecuteWithOptions(req.Message, nil, withExecutionContext(h.executeOptions(req), c.Request.Context()))
 	if err != nil {
 		ec := h.handleError(err)
 		h.logger.LogError("ChatAPI", err,
 			slog.String("session_id", req.SessionID),
 			slog.Int("error_code", ec.Code))
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
+		status := http.StatusInternalServerError
+		if ec.Code == errors.EC_FORBIDDEN.Code {
+			// Rejected by moderation
+			status = http.StatusForbidden
+		}
+		c.JSON(status, ErrorResponse{
 			Status: ec.Code,
 			Msg:    ec.Message,
 		})
@@ -124,8 +210,21 @@ func (h *handler) StreamChatAPI(c *gin.Context, engine *engine.AgentEngine, req
 	c.Header("Cache-Control", "no-cache")
 	c.Header("Connection", "keep-alive")
 
+	// The execution stops once the client disconnects or, with stream cancel enabled, is cancelled by ID
 	ctx := c.Request.Context()
-	stream, err := engine.ExecuteStream(req.Message, nil)
+	executionCtx := ctx
+	executionID := ""
+	if h.opt.StreamCancel.Enabled {
+		var cancel context.CancelFunc
+		executionCtx, cancel = context.WithCancel(ctx)
+		defer cancel()
+		executionID = uuid.New().String()
+		executions.register(executionID, cancel)
+		defer executions.unregister(executionID)
+	}
+	opts := withExecutionContext(h.executeOptions(req), executionCtx)
+
+	stream, err := engine.ExecuteStreamWithOptions(req.Message, nil, opts)
 	if err != nil {
 		ec := h.handleError(err)
 		h.logger.LogError("StreamChatAPI", err,
@@ -140,12 +239,24 @@ func (h *handler) StreamChatAPI(c *gin.Context, engine *engine.AgentEngine, req
 		return
 	}
 
+	if executionID != "" && !h.sendSSEvent(c, SSEvent{
+		Type:        "start",
+		ExecutionID: executionID,
+	}) {
+		return
+	}
+
 	for result := range stream {
 		select {
 		case <-ctx.Done():
 			h.logger.Info("Stream context cancelled",
 				slog.String("session_id", req.SessionID),
 				slog.String("reason", ctx.Err().Error()))
+			// The execution is cancelled with the request, let it finish without blocking on the stream
+			go func() {
+				for range stream {
+				}
+			}()
 			return
 		default:
 			switch result.Type {
@@ -156,6 +267,43 @@ func (h *handler) StreamChatAPI(c *gin.Context, engine *engine.AgentEngine, req
 				}) {
 					return
 				}
+			case "approval_required":
+				if !h.sendSSEvent(c, SSEvent{
+					Type:    "approval_required",
+					Content: result.Content,
+					Data:    result.ToolCall,
+				}) {
+					return
+				}
+			case "warning":
+				if !h.sendSSEvent(c, SSEvent{
+					Type:    "warning",
+					Content: result.Content,
+				}) {
+					return
+				}
+			case "partial_json":
+				if !h.sendSSEvent(c, SSEvent{
+					Type: "partial_json",
+					Data: result.JSON,
+				}) {
+					return
+				}
+			case "plan":
+				if !h.sendSSEvent(c, SSEvent{
+					Type: "plan",
+					Data: result.Plan,
+				}) {
+					return
+				}
+			case "tool_start", "tool_end":
+				if !h.sendSSEvent(c, SSEvent{
+					Type:    result.Type,
+					Content: result.Content,
+					Data:    result.ToolEvent,
+				}) {
+					return
+				}
 			case "error":
 				errorMsg := ""
 				if result.Error != nil {
@@ -168,14 +316,337 @@ func (h *handler) StreamChatAPI(c *gin.Context, engine *engine.AgentEngine, req
 					return
 				}
 			case "end":
-				if !h.sendSSEvent(c, SSEvent{
+				event := SSEvent{
 					Type: "end",
 					End:  true,
 					Data: result.Result,
-				}) {
-					return
 				}
+				if result.Result != nil {
+					event.StoppedReason = result.Result.StoppedReason
+				}
+				event.FinishReason = result.FinishReason
+				// Memory may still be persisted after the end event, don't hold the response open for it
+				h.sendSSEvent(c, event)
+				return
 			}
 		}
 	}
 }
+
+// GetToolInvokeRequest authenticates and binds a direct tool invocation request
+// Writes the error response itself when the endpoint is disabled, the caller is not authorized or the body is invalid
+func (h *handler) GetToolInvokeRequest(c *gin.Context) (*ToolInvokeRequest, error) {
+	if !h.opt.ToolInvoke.Enabled {
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Status: errors.EC_FORBIDDEN.Code,
+			Msg:    "tool invocation is disabled",
+		})
+		return nil, errors.EC_FORBIDDEN
+	}
+
+	if !auth.BearerAuthorized(c.Request, h.opt.ToolInvoke.Secret) {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Status: errors.EC_UNAUTHORIZED.Code,
+			Msg:    errors.EC_UNAUTHORIZED.Message,
+		})
+		return nil, errors.EC_UNAUTHORIZED
+	}
+
+	var req ToolInvokeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Status: errors.EC_HTTP_INVALID_REQUEST.Code,
+			Msg:    errors.EC_HTTP_INVALID_REQUEST.Message,
+		})
+		return nil, errors.EC_HTTP_INVALID_REQUEST.Wrap(err)
+	}
+	return &req, nil
+}
+
+// InvokeToolAPI executes a registered tool directly, bypassing the model
+func (h *handler) InvokeToolAPI(c *gin.Context, engine *engine.AgentEngine, req *ToolInvokeRequest) {
+	if engine == nil {
+		h.logger.LogError("InvokeToolAPI", fmt.Errorf("agent engine is nil"))
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Status: errors.EC_HTTP_EXECUTE_FAILED.Code,
+			Msg:    "agent engine is not available",
+		})
+		return
+	}
+
+	result, err := engine.InvokeTool(c.Request.Context(), req.Name, req.Arguments)
+	if err != nil {
+		ec := h.handleError(err)
+		h.logger.LogError("InvokeToolAPI", err,
+			slog.String("tool_name", req.Name),
+			slog.Int("error_code", ec.Code))
+		c.JSON(toolErrorStatus(ec), ErrorResponse{
+			Status: ec.Code,
+			Msg:    ec.Message,
+		})
+		return
+	}
+	c.JSON(http.StatusOK, ToolInvokeResponse{
+		Name:   req.Name,
+		Result: result,
+	})
+}
+
+// GetMessagesRequest binds a session message history request
+// Writes the error response itself when the parameters are invalid
+func (h *handler) GetMessagesRequest(c *gin.Context) (*MessagesRequest, error) {
+	var req MessagesRequest
+	if err := c.ShouldBindUri(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Status: errors.EC_HTTP_INVALID_REQUEST.Code,
+			Msg:    errors.EC_HTTP_INVALID_REQUEST.Message,
+		})
+		return nil, errors.EC_HTTP_INVALID_REQUEST.Wrap(err)
+	}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Status: errors.EC_HTTP_INVALID_REQUEST.Code,
+			Msg:    errors.EC_HTTP_INVALID_REQUEST.Message,
+		})
+		return nil, errors.EC_HTTP_INVALID_REQUEST.Wrap(err)
+	}
+	if req.Limit == 0 {
+		req.Limit = DefaultMessagesPageSize
+	}
+	return &req, nil
+}
+
+// MessagesAPI returns a page of the session's stored conversation, oldest first
+func (h *handler) MessagesAPI(c *gin.Context, history History, req *MessagesRequest) {
+	if history == nil {
+		h.logger.LogError("MessagesAPI", fmt.Errorf("session history is nil"))
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Status: errors.EC_HTTP_EXECUTE_FAILED.Code,
+			Msg:    "session history is not available",
+		})
+		return
+	}
+
+	messages, total, err := history.GetMessagesPaged(req.Offset, req.Limit)
+	if err != nil {
+		ec := h.handleError(err)
+		h.logger.LogError("MessagesAPI", err,
+			slog.String("session_id", req.SessionID),
+			slog.Int("error_code", ec.Code))
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Status: ec.Code,
+			Msg:    ec.Message,
+		})
+		return
+	}
+	c.JSON(http.StatusOK, MessagesResponse{
+		SessionID: req.SessionID,
+		Messages:  messages,
+		Offset:    req.Offset,
+		Limit:     req.Limit,
+		Total:     total,
+	})
+}
+
+// GetHistoryRequest binds a chat history request
+// Writes the error response itself when the parameters are invalid
+func (h *handler) GetHistoryRequest(c *gin.Context) (*HistoryRequest, error) {
+	var req HistoryRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Status: errors.EC_HTTP_INVALID_REQUEST.Code,
+			Msg:    errors.EC_HTTP_INVALID_REQUEST.Message,
+		})
+		return nil, errors.EC_HTTP_INVALID_REQUEST.Wrap(err)
+	}
+	return &req, nil
+}
+
+// GetClearHistoryRequest authenticates and binds a chat history deletion request
+// When Options.HistoryDelete.Secret is set it is required as a bearer token
+// Writes the error response itself when the caller is not authorized or the parameters are invalid
+func (h *handler) GetClearHistoryRequest(c *gin.Context) (*HistoryRequest, error) {
+	if secret := h.opt.HistoryDelete.Secret; secret != "" && !auth.BearerAuthorized(c.Request, secret) {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Status: errors.EC_UNAUTHORIZED.Code,
+			Msg:    errors.EC_UNAUTHORIZED.Message,
+		})
+		return nil, errors.EC_UNAUTHORIZED
+	}
+	return h.GetHistoryRequest(c)
+}
+
+// HistoryAPI returns the session's chat history as sent with prompts, oldest first
+// Unknown sessions have no stored messages and get an empty list
+func (h *handler) HistoryAPI(c *gin.Context, history History, req *HistoryRequest) {
+	if history == nil {
+		h.logger.LogError("HistoryAPI", fmt.Errorf("session history is nil"))
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Status: errors.EC_HTTP_EXECUTE_FAILED.Code,
+			Msg:    "session history is not available",
+		})
+		return
+	}
+
+	messages, err := history.GetChatHistory()
+	if err != nil && !isNotFound(err) {
+		ec := h.handleError(err)
+		h.logger.LogError("HistoryAPI", err,
+			slog.String("session_id", req.SessionID),
+			slog.Int("error_code", ec.Code))
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Status: ec.Code,
+			Msg:    ec.Message,
+		})
+		return
+	}
+	if messages == nil {
+		messages = []types.Message{}
+	}
+	c.JSON(http.StatusOK, HistoryResponse{
+		SessionID: req.SessionID,
+		Messages:  messages,
+	})
+}
+
+// ClearHistoryAPI deletes the session's stored conversation, unknown sessions are already empty
+func (h *handler) ClearHistoryAPI(c *gin.Context, history History, req *HistoryRequest) {
+	if history == nil {
+		h.logger.LogError("ClearHistoryAPI", fmt.Errorf("session history is nil"))
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Status: errors.EC_HTTP_EXECUTE_FAILED.Code,
+			Msg:    "session history is not available",
+		})
+		return
+	}
+
+	if err := history.ClearMemory(); err != nil && !isNotFound(err) {
+		ec := h.handleError(err)
+		h.logger.LogError("ClearHistoryAPI", err,
+			slog.String("session_id", req.SessionID),
+			slog.Int("error_code", ec.Code))
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Status: ec.Code,
+			Msg:    ec.Message,
+		})
+		return
+	}
+	c.JSON(http.StatusOK, ClearHistoryResponse{
+		SessionID: req.SessionID,
+		Cleared:   true,
+	})
+}
+
+// GetTitleRequest binds a session title request
+// Writes the error response itself when the parameters are invalid
+func (h *handler) GetTitleRequest(c *gin.Context) (*TitleRequest, error) {
+	var req TitleRequest
+	if err := c.ShouldBindUri(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Status: errors.EC_HTTP_INVALID_REQUEST.Code,
+			Msg:    errors.EC_HTTP_INVALID_REQUEST.Message,
+		})
+		return nil, errors.EC_HTTP_INVALID_REQUEST.Wrap(err)
+	}
+	return &req, nil
+}
+
+// TitleAPI returns the session's conversation title
+func (h *handler) TitleAPI(c *gin.Context, history History, req *TitleRequest) {
+	if history == nil {
+		h.logger.LogError("TitleAPI", fmt.Errorf("session history is nil"))
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Status: errors.EC_HTTP_EXECUTE_FAILED.Code,
+			Msg:    "session history is not available",
+		})
+		return
+	}
+
+	title, err := history.Title()
+	if err != nil {
+		ec := h.handleError(err)
+		h.logger.LogError("TitleAPI", err,
+			slog.String("session_id", req.SessionID),
+			slog.Int("error_code", ec.Code))
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Status: ec.Code,
+			Msg:    ec.Message,
+		})
+		return
+	}
+	c.JSON(http.StatusOK, TitleResponse{
+		SessionID: req.SessionID,
+		Title:     title,
+	})
+}
+
+// GetCancelRequest binds a stream cancellation request
+// Writes the error response itself when the endpoint is disabled or the body is invalid
+func (h *handler) GetCancelRequest(c *gin.Context) (*CancelRequest, error) {
+	if !h.opt.StreamCancel.Enabled {
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Status: errors.EC_FORBIDDEN.Code,
+			Msg:    "stream cancellation is disabled",
+		})
+		return nil, errors.EC_FORBIDDEN
+	}
+
+	var req CancelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Status: errors.EC_HTTP_INVALID_REQUEST.Code,
+			Msg:    errors.EC_HTTP_INVALID_REQUEST.Message,
+		})
+		return nil, errors.EC_HTTP_INVALID_REQUEST.Wrap(err)
+	}
+	return &req, nil
+}
+
+// CancelAPI cancels a running stream by the execution ID of its start event
+// The stream ends with an end event whose stopped_reason is "cancelled"; unknown or finished executions get 404
+func (h *handler) CancelAPI(c *gin.Context, req *CancelRequest) {
+	if !executions.cancel(req.ExecutionID) {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Status: errors.EC_DATA_NOT_FOUND.Code,
+			Msg:    "execution not found or already finished",
+		})
+		return
+	}
+	h.logger.Info("Stream cancelled", slog.String("execution_id", req.ExecutionID))
+	c.JSON(http.StatusOK, CancelResponse{
+		ExecutionID: req.ExecutionID,
+		Cancelled:   true,
+	})
+}
+
+// isNotFound reports whether a memory error only means the session has no stored messages
+func isNotFound(err error) bool {
+	var ec *errors.Error
+	for stderrors.As(err, &ec) {
+		if ec.Code == errors.EC_DATA_NOT_FOUND.Code || ec.Code == errors.EC_SQL_NOT_FOUND.Code || ec.Code == errors.EC_HTTP_SESSION_NOT_FOUND.Code {
+			return true
+		}
+		if err = ec.Unwrap(); err == nil {
+			return false
+		}
+	}
+	return false
+}
+
+// toolErrorStatus maps a tool invocation error to its HTTP status
+func toolErrorStatus(ec *errors.Error) int {
+	switch ec.Code {
+	case errors.EC_TOOL_NOT_FOUND.Code:
+		return http.StatusNotFound
+	case errors.EC_PERMISSION_DENIED.Code:
+		return http.StatusForbidden
+	case errors.ErrRateLimitExceeded.Code:
+		return http.StatusTooManyRequests
+	case errors.EC_TOOL_EXECUTION_TIMEOUT.Code:
+		return http.StatusGatewayTimeout
+	case errors.EC_PARAMETER_MISSING.Code, errors.EC_PARAMETER_INVALID.Code, errors.EC_TOOL_PARAMETER_INVALID.Code:
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -1,9 +1,143 @@
 package http
 
+import "github.com/xichan96/cortex/agent/types"
+
+// DefaultMessagesPageSize page size of message history requests without a limit
+const DefaultMessagesPageSize = 50
+
+// SessionIDHeader response header carrying the session ID of a message request
+const SessionIDHeader = "X-Session-ID"
+
 // MessageRequest defines the structure for message requests
 type MessageRequest struct {
-	SessionID string `json:"session_id" binding:"required,min=1"`
-	Message   string `json:"message" binding:"required,min=1"`
+	// SessionID selects the conversation, a new UUID is generated when empty (see SessionIDHeader)
+	SessionID string          `json:"session_id" binding:"omitempty,max=256"`
+	Message   string          `json:"message" binding:"required,min=1"`
+	Documents []DocumentInput `json:"documents,omitempty" binding:"omitempty,max=10,dive"`
+	Tools     []string        `json:"tools,omitempty" binding:"omitempty,max=100,dive,min=1"`
+	// SystemPrompt replaces the engine system prompt for this request, e.g. per tenant
+	// Rejected unless SystemPromptOverrideOptions is enabled and the request carries its secret
+	SystemPrompt string `json:"system_prompt,omitempty" binding:"omitempty,max=32000"`
+}
+
+// DocumentInput defines a document attached to a message request as context
+type DocumentInput struct {
+	Name    string `json:"name" binding:"max=256"`
+	Content string `json:"content" binding:"required,max=1048576"`
+}
+
+// MessagesRequest defines the path and query parameters of session message history requests
+type MessagesRequest struct {
+	SessionID string `uri:"id" binding:"required,min=1"`
+	Offset    int    `form:"offset" binding:"min=0"`
+	Limit     int    `form:"limit" binding:"omitempty,min=1,max=200"` // defaults to DefaultMessagesPageSize
+}
+
+// MessagesResponse defines the structure for session message history responses
+type MessagesResponse struct {
+	SessionID string          `json:"session_id"`
+	Messages  []types.Message `json:"messages"`
+	Offset    int             `json:"offset"`
+	Limit     int             `json:"limit"`
+	Total     int             `json:"total"`
+}
+
+// History stored conversation of one session, implemented by *engine.AgentEngine
+// Lets the history, messages and title endpoints serve a session without building an engine for it
+type History interface {
+	GetChatHistory() ([]types.Message, error)
+	GetMessagesPaged(offset, limit int) ([]types.Message, int, error)
+	Title() (string, error)
+	ClearMemory() error
+}
+
+// HistoryRequest defines the query parameters of chat history requests
+type HistoryRequest struct {
+	SessionID string `form:"session_id" binding:"required,min=1,max=256"`
+}
+
+// HistoryResponse defines the structure for chat history responses
+type HistoryResponse struct {
+	SessionID string          `json:"session_id"`
+	Messages  []types.Message `json:"messages"` // empty for unknown sessions
+}
+
+// ClearHistoryResponse defines the structure for chat history deletion responses
+type ClearHistoryResponse struct {
+	SessionID string `json:"session_id"`
+	Cleared   bool   `json:"cleared"`
+}
+
+// TitleRequest defines the path parameters of session title requests
+type TitleRequest struct {
+	SessionID string `uri:"id" binding:"required,min=1"`
+}
+
+// TitleResponse defines the structure for session title responses
+type TitleResponse struct {
+	SessionID string `json:"session_id"`
+	Title     string `json:"title"` // empty until the session has a user message
+}
+
+// CancelRequest defines the structure for stream cancellation requests
+type CancelRequest struct {
+	ExecutionID string `json:"execution_id" binding:"required,min=1"`
+}
+
+// CancelResponse defines the structure for stream cancellation responses
+type CancelResponse struct {
+	ExecutionID string `json:"execution_id"`
+	Cancelled   bool   `json:"cancelled"`
+}
+
+// ToolInvokeRequest defines the structure for direct tool invocation requests
+type ToolInvokeRequest struct {
+	Name      string                 `json:"name" binding:"required,min=1"`
+	Arguments map[string]interface{} `json:"arguments"`
+}
+
+// ToolInvokeResponse defines the structure for direct tool invocation responses
+type ToolInvokeResponse struct {
+	Name   string      `json:"name"`
+	Result interface{} `json:"result"`
+}
+
+// Options HTTP trigger options
+type Options struct {
+	ToolInvoke   ToolInvokeOptions   `json:"toolInvoke"`
+	StreamCancel StreamCancelOptions `json:"streamCancel"`
+	// SystemPromptOverride gates the system_prompt field of message requests
+	SystemPromptOverride SystemPromptOverrideOptions `json:"systemPromptOverride"`
+	// HistoryDelete protects DELETE /chat/history
+	HistoryDelete HistoryDeleteOptions `json:"historyDelete"`
+}
+
+// HistoryDeleteOptions authentication of chat history deletion
+type HistoryDeleteOptions struct {
+	// Secret when set is required as "Authorization: Bearer <secret>"
+	// When empty, knowing the session ID is enough, as for reading the history
+	Secret string `json:"secret"`
+}
+
+// ToolInvokeOptions direct tool invocation over POST /tool/invoke (disabled by default)
+type ToolInvokeOptions struct {
+	Enabled bool `json:"enabled"`
+	// Secret is required as "Authorization: Bearer <secret>", requests are rejected when it is empty
+	Secret string `json:"secret"`
+}
+
+// SystemPromptOverrideOptions per-request system prompts (disabled by default)
+// The prompt replaces the operator's, including its guardrails, so only trusted callers may send one
+type SystemPromptOverrideOptions struct {
+	Enabled bool `json:"enabled"`
+	// Secret is required as "Authorization: Bearer <secret>", requests are rejected when it is empty
+	Secret string `json:"secret"`
+}
+
+// StreamCancelOptions out-of-band cancellation of streams over POST /chat/cancel (disabled by default)
+// When enabled, every stream starts with a start event carrying the execution ID to cancel
+type StreamCancelOptions struct {
+	Enabled bool `json:"enabled"`
 }
 
 // ErrorResponse defines the structure for error responses
@@ -19,4 +153,15 @@ type SSEvent struct {
 	Error   string      `json:"error,omitempty"`
 	End     bool        `json:"end,omitempty"`
 	Data    interface{} `json:"data,omitempty"`
+
+	// StoppedReason is set on the end event when the run was cut short
+	// (e.g. "max_iterations", "max_tool_calls", "cancelled"), empty for complete responses
+	StoppedReason string `json:"stopped_reason,omitempty"`
+
+	// FinishReason is set on the end event, why the run ended: "stop", "length" (response truncated
+	// by the output token limit), "tool_calls", "max_iterations" or "tool_budget_exhausted"
+	FinishReason string `json:"finish_reason,omitempty"`
+
+	// ExecutionID is set on the start event when stream cancellation is enabled, pass it to POST /chat/cancel
+	ExecutionID string `json:"execution_id,omitempty"`
 }
@@ -0,0 +1,105 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xichan96/cortex/pkg/errors"
+	"github.com/xichan96/cortex/trigger/auth"
+)
+
+const (
+	// SignatureHeader carries "sha256=<hex HMAC-SHA256 of timestamp + "." + body>"
+	SignatureHeader = "X-Cortex-Signature"
+	// TimestampHeader carries the unix timestamp (seconds) used in the signature
+	TimestampHeader = "X-Cortex-Timestamp"
+
+	defaultMaxClockSkew = 5 * time.Minute
+	maxSignedBodySize   = 10 << 20 // 10MB
+)
+
+// authContextKey context key holding the authentication result of the HTTP request
+type authContextKey struct{}
+
+// authenticate validates the request against the configured shared secret
+// Accepts either "Authorization: Bearer <secret>" or an HMAC signature over the timestamp and body.
+// The body is restored so the MCP server can read it afterwards.
+// A signed body over maxSignedBodySize is an error rather than a failed check, so it can be answered with 413
+func (h *handler) authenticate(r *http.Request) (bool, error) {
+	secret := []byte(h.opt.Auth.Secret)
+	if len(secret) == 0 {
+		return false, nil
+	}
+
+	if strings.HasPrefix(r.Header.Get("Authorization"), "Bearer ") {
+		return auth.BearerAuthorized(r, h.opt.Auth.Secret), nil
+	}
+
+	signature, ok := strings.CutPrefix(r.Header.Get(SignatureHeader), "sha256=")
+	if !ok || r.Body == nil {
+		return false, nil
+	}
+	timestamp := r.Header.Get(TimestampHeader)
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false, nil
+	}
+	maxSkew := h.opt.Auth.MaxClockSkew
+	if maxSkew <= 0 {
+		maxSkew = defaultMaxClockSkew
+	}
+	if skew := time.Since(time.Unix(unix, 0)); skew > maxSkew || skew < -maxSkew {
+		return false, nil
+	}
+
+	// One byte past the cap tells a body of exactly maxSignedBodySize bytes from a longer one
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxSignedBodySize+1))
+	r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return false, nil
+	}
+	if len(body) > maxSignedBodySize {
+		return false, errors.NewError(errors.EC_PARAMETER_INVALID.Code, "signed request body too large")
+	}
+
+	expected, err := hex.DecodeString(signature)
+	if err != nil {
+		return false, nil
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return hmac.Equal(mac.Sum(nil), expected), nil
+}
+
+// authorized reports whether a tool call may proceed
+// Always true when auth is disabled or the tool is exempt
+func (h *handler) authorized(ctx context.Context, toolName string) bool {
+	if !h.opt.Auth.Enabled {
+		return true
+	}
+	for _, exempt := range h.exemptTools() {
+		if exempt == toolName {
+			return true
+		}
+	}
+	ok, _ := ctx.Value(authContextKey{}).(bool)
+	return ok
+}
+
+// exemptTools returns the tools callable without authentication, "ping" by default
+func (h *handler) exemptTools() []string {
+	if h.opt.Auth.ExemptTools != nil {
+		return h.opt.Auth.ExemptTools
+	}
+	return []string{"ping"}
+}
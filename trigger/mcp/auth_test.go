@@ -0,0 +1,133 @@
+package mcp
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const testSecret = "s3cret"
+
+// sign returns the X-Cortex-Signature value of a body signed at timestamp
+func sign(secret, timestamp, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "." + body))
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestAuthenticate(t *testing.T) {
+	h := &handler{opt: Options{Auth: AuthOptions{Enabled: true, Secret: testSecret}}}
+	body := `{"jsonrpc":"2.0","method":"tools/call"}`
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	stale := strconv.FormatInt(time.Now().Add(-10*time.Minute).Unix(), 10)
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    bool
+	}{
+		{"valid bearer", map[string]string{"Authorization": "Bearer " + testSecret}, true},
+		{"bad bearer", map[string]string{"Authorization": "Bearer nope"}, false},
+		{"valid signature", map[string]string{
+			TimestampHeader: now,
+			SignatureHeader: sign(testSecret, now, body),
+		}, true},
+		{"tampered body", map[string]string{
+			TimestampHeader: now,
+			SignatureHeader: sign(testSecret, now, body+" "),
+		}, false},
+		{"wrong key", map[string]string{
+			TimestampHeader: now,
+			SignatureHeader: sign("other", now, body),
+		}, false},
+		{"stale timestamp", map[string]string{
+			TimestampHeader: stale,
+			SignatureHeader: sign(testSecret, stale, body),
+		}, false},
+		{"no credentials", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(body))
+			for key, value := range tt.headers {
+				r.Header.Set(key, value)
+			}
+			if got, err := h.authenticate(r); got != tt.want || err != nil {
+				t.Errorf("authenticate = %v, %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthenticateRestoresBody(t *testing.T) {
+	h := &handler{opt: Options{Auth: AuthOptions{Enabled: true, Secret: testSecret}}}
+	body := `{"jsonrpc":"2.0","method":"tools/call"}`
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	r := httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(body))
+	r.Header.Set(TimestampHeader, now)
+	r.Header.Set(SignatureHeader, sign(testSecret, now, body))
+
+	if ok, err := h.authenticate(r); !ok || err != nil {
+		t.Fatalf("signed request rejected: %v", err)
+	}
+	restored, err := io.ReadAll(r.Body)
+	if err != nil || string(restored) != body {
+		t.Errorf("body after authentication = %q, %v, want the original body", restored, err)
+	}
+}
+
+func TestAuthenticateRejectsOversizedBody(t *testing.T) {
+	h := &handler{opt: Options{Auth: AuthOptions{Enabled: true, Secret: testSecret}}}
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+
+	// A body of exactly the cap is still verified
+	fits := strings.Repeat("a", maxSignedBodySize)
+	r := httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(fits))
+	r.Header.Set(TimestampHeader, now)
+	r.Header.Set(SignatureHeader, sign(testSecret, now, fits))
+	if ok, err := h.authenticate(r); !ok || err != nil {
+		t.Fatalf("body at the cap = %v, %v, want accepted", ok, err)
+	}
+
+	// One byte more is refused even though the signature covers the whole body
+	tooLarge := fits + "a"
+	r = httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(tooLarge))
+	r.Header.Set(TimestampHeader, now)
+	r.Header.Set(SignatureHeader, sign(testSecret, now, tooLarge))
+	if ok, err := h.authenticate(r); ok || err == nil {
+		t.Fatalf("oversized body = %v, %v, want an error", ok, err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(tooLarge))
+	r.Header.Set(TimestampHeader, now)
+	r.Header.Set(SignatureHeader, sign(testSecret, now, tooLarge))
+	c, _ := gin.CreateTestContext(w)
+	c.Request = r
+	h.Agent()(c)
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusRequestEntityTooLarge)
+	}
+}
+
+func TestAuthOptionsValidate(t *testing.T) {
+	if err := (AuthOptions{Enabled: true}).Validate(); err == nil {
+		t.Error("enabled auth without a secret accepted")
+	}
+	if err := (AuthOptions{Enabled: true, Secret: testSecret}).Validate(); err != nil {
+		t.Errorf("valid auth rejected: %v", err)
+	}
+	if err := (AuthOptions{}).Validate(); err != nil {
+		t.Errorf("disabled auth rejected: %v", err)
+	}
+}
@@ -4,6 +4,9 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
 
 	"github.com/gin-gonic/gin"
 	mcpgo "github.com/mark3labs/mcp-go/mcp"
@@ -15,6 +18,7 @@ import (
 
 type Handler interface {
 	Agent() gin.HandlerFunc
+	Shutdown(ctx context.Context) error
 }
 
 type handler struct {
@@ -22,6 +26,11 @@ type handler struct {
 	opt       Options
 	mcpServer *mcpsrv.MCPServer
 	logger    *logger.Logger
+
+	// graceful shutdown
+	closeMu  sync.Mutex
+	closing  bool
+	inflight sync.WaitGroup
 }
 
 func NewHandler(engine *engine.AgentEngine, opt Options) Handler {
@@ -30,9 +39,13 @@ func NewHandler(engine *engine.AgentEngine, opt Options) Handler {
 		logger.NewLogger().LogError("NewHandler", fmt.Errorf("agent engine is nil"))
 	}
 
-	if opt.Tool.Name == "" {
+	if opt.Tool.Name == "" && len(opt.Tools) == 0 {
 		logger.NewLogger().LogError("NewHandler", fmt.Errorf("tool name is required"))
 	}
+	if err := opt.Auth.Validate(); err != nil {
+		// Fails closed: every call except the exempt tools is rejected
+		logger.NewLogger().LogError("NewHandler", err)
+	}
 
 	mcp := mcpsrv.NewMCPServer(
 		opt.Server.Name,
@@ -54,17 +67,108 @@ func (h *handler) Agent() gin.HandlerFunc {
 		h.mcpServer,
 		mcpsrv.WithEndpointPath("/mcp"),
 	)
-	return gin.WrapH(mcpHandler)
+	wrapped := gin.WrapH(mcpHandler)
+	return func(c *gin.Context) {
+		if h.isClosing() {
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
+				"status": errors.EC_AGENT_SHUTTING_DOWN.Code,
+				"msg":    errors.EC_AGENT_SHUTTING_DOWN.Message,
+			})
+			return
+		}
+		if h.opt.Auth.Enabled {
+			ok, err := h.authenticate(c.Request)
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{
+					"status": errors.EC_PARAMETER_INVALID.Code,
+					"msg":    err.Error(),
+				})
+				return
+			}
+			ctx := context.WithValue(c.Request.Context(), authContextKey{}, ok)
+			c.Request = c.Request.WithContext(ctx)
+		}
+		wrapped(c)
+	}
+}
+
+// Shutdown stops accepting new MCP requests and waits for in-flight tool calls to finish
+// (bounded by ctx), then shuts down the agent engine so both layers drain cleanly
+func (h *handler) Shutdown(ctx context.Context) error {
+	h.closeMu.Lock()
+	h.closing = true
+	h.closeMu.Unlock()
+
+	h.logger.Info("Shutting down MCP handler, waiting for in-flight calls")
+
+	done := make(chan struct{})
+	go func() {
+		h.inflight.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		h.logger.LogError("Shutdown", ctx.Err(), slog.String("phase", "wait_inflight"))
+		if h.engine != nil {
+			h.engine.Stop()
+		}
+		return errors.NewError(errors.EC_TIMEOUT.Code, "timed out waiting for in-flight MCP calls").Wrap(ctx.Err())
+	}
+
+	if h.engine != nil {
+		return h.engine.Shutdown(ctx)
+	}
+	return nil
+}
+
+// isClosing reports whether the handler is shutting down
+func (h *handler) isClosing() bool {
+	h.closeMu.Lock()
+	defer h.closeMu.Unlock()
+	return h.closing
+}
+
+// beginCall registers an in-flight call, returns false if the handler is shutting down
+func (h *handler) beginCall() bool {
+	h.closeMu.Lock()
+	defer h.closeMu.Unlock()
+	if h.closing {
+		return false
+	}
+	h.inflight.Add(1)
+	return true
+}
+
+// chatTools returns the configured chat tools, Tool first
+func (h *handler) chatTools() []ToolMetadata {
+	tools := make([]ToolMetadata, 0, len(h.opt.Tools)+1)
+	if h.opt.Tool.Name != "" {
+		tools = append(tools, ToolMetadata{
+			Name:        h.opt.Tool.Name,
+			Description: h.opt.Tool.Description,
+		})
+	}
+	return append(tools, h.opt.Tools...)
 }
 
 func (h *handler) registerTools(mcp *mcpsrv.MCPServer) {
+	tools := h.chatTools()
+	names := make([]string, 0, len(tools))
+	for _, tool := range tools {
+		names = append(names, tool.Name)
+	}
 	h.logger.Info("Registering MCP tools",
-		slog.String("tool_name", h.opt.Tool.Name),
+		slog.String("tool_names", strings.Join(names, ",")),
 		slog.String("server_name", h.opt.Server.Name))
 
 	mcp.AddTool(
 		mcpgo.NewTool("ping", mcpgo.WithDescription("health check")),
 		func(ctx context.Context, _ mcpgo.CallToolRequest) (*mcpgo.CallToolResult, error) {
+			if !h.authorized(ctx, "ping") {
+				return nil, errors.EC_UNAUTHORIZED
+			}
 			select {
 			case <-ctx.Done():
 				h.logger.Info("Ping tool context cancelled",
@@ -76,51 +180,161 @@ func (h *handler) registerTools(mcp *mcpsrv.MCPServer) {
 		},
 	)
 
-	if h.opt.Tool.Name == "" {
+	if len(tools) == 0 {
 		h.logger.LogError("registerTools", fmt.Errorf("tool name is required"))
 		return
 	}
 
-	chatTool := mcpgo.NewTool(h.opt.Tool.Name, mcpgo.WithDescription(h.opt.Tool.Description),
-		mcpgo.WithString("message", func(prop map[string]any) {
-			prop["description"] = "message to send to the agent"
-		}, mcpgo.Required()),
-	)
-	mcp.AddTool(
-		chatTool,
-		func(ctx context.Context, request mcpgo.CallToolRequest) (*mcpgo.CallToolResult, error) {
-			select {
-			case <-ctx.Done():
-				h.logger.Info("Chat tool context cancelled",
-					slog.String("reason", ctx.Err().Error()))
-				return nil, ctx.Err()
-			default:
-			}
+	registered := map[string]bool{"ping": true}
+	for _, tool := range tools {
+		if tool.Name == "" || registered[tool.Name] {
+			h.logger.LogError("registerTools", fmt.Errorf("tool name is empty or already registered"),
+				slog.String("tool_name", tool.Name))
+			continue
+		}
+		registered[tool.Name] = true
 
-			if h.engine == nil {
-				h.logger.LogError("Chat tool", fmt.Errorf("agent engine is nil"))
-				return mcpgo.NewToolResultError("agent engine is not available"), nil
-			}
+		chatTool := mcpgo.NewTool(tool.Name, mcpgo.WithDescription(tool.Description),
+			mcpgo.WithString("message", func(prop map[string]any) {
+				prop["description"] = "message to send to the agent"
+			}, mcpgo.Required()),
+		)
+		mcp.AddTool(chatTool, h.chatHandler(tool))
+	}
+}
+
+// chatHandler executes the agent for calls of a chat tool, with the tool's system prompt when set
+func (h *handler) chatHandler(tool ToolMetadata) mcpsrv.ToolHandlerFunc {
+	return func(ctx context.Context, request mcpgo.CallToolRequest) (*mcpgo.CallToolResult, error) {
+		select {
+		case <-ctx.Done():
+			h.logger.Info("Chat tool context cancelled",
+				slog.String("tool_name", tool.Name),
+				slog.String("reason", ctx.Err().Error()))
+			return nil, ctx.Err()
+		default:
+		}
+
+		if !h.authorized(ctx, tool.Name) {
+			h.logger.Info("Rejected unauthorized MCP tool call",
+				slog.String("tool_name", tool.Name))
+			return nil, errors.EC_UNAUTHORIZED
+		}
+
+		if h.engine == nil {
+			h.logger.LogError("Chat tool", fmt.Errorf("agent engine is nil"))
+			return mcpgo.NewToolResultError("agent engine is not available"), nil
+		}
 
-			message := request.GetString("message", "")
-			if message == "" {
-				return mcpgo.NewToolResultError("message parameter is required"), nil
+		if !h.beginCall() {
+			return mcpgo.NewToolResultError(errors.EC_AGENT_SHUTTING_DOWN.Message), nil
+		}
+		defer h.inflight.Done()
+
+		message := request.GetString("message", "")
+		if message == "" {
+			return mcpgo.NewToolResultError("message parameter is required"), nil
+		}
+
+		// Clients asking for progress get the output streamed as progress notifications
+		if token := progressToken(request); token != nil {
+			return h.streamChat(ctx, tool, message, token)
+		}
+
+		// The execution is cancelled with the call
+		result, err := h.engine.ExecuteWithOptions(message, nil, &engine.ExecuteOptions{
+			Context:      ctx,
+			SystemPrompt: tool.SystemPrompt,
+		})
+		if err != nil {
+			return h.executionError(tool, err), nil
+		}
+		return mcpgo.NewToolResultText(result.Output), nil
+	}
+}
+
+// streamChat runs the agent in stream mode, sending each chunk as a progress notification
+// and returning the final output as the tool result; the execution is cancelled when the client cancels the call
+func (h *handler) streamChat(ctx context.Context, tool ToolMetadata, message string, token mcpgo.ProgressToken) (*mcpgo.CallToolResult, error) {
+	stream, err := h.engine.ExecuteStreamWithOptions(message, nil, &engine.ExecuteOptions{
+		Context:      ctx,
+		SystemPrompt: tool.SystemPrompt,
+	})
+	if err != nil {
+		return h.executionError(tool, err), nil
+	}
+	// The execution is cancelled with the call, let it finish without blocking on the stream
+	defer func() {
+		go func() {
+			for range stream {
 			}
+		}()
+	}()
 
-			result, err := h.engine.Execute(message, nil)
-			if err != nil {
-				var errorMsg string
-				if e, ok := err.(*errors.Error); ok {
-					errorMsg = fmt.Sprintf("%d: %s", e.Code, e.Message)
-					h.logger.LogError("Chat tool execution", err,
-						slog.Int("error_code", e.Code))
-				} else {
-					errorMsg = err.Error()
-					h.logger.LogError("Chat tool execution", err)
+	server := mcpsrv.ServerFromContext(ctx)
+	var output strings.Builder
+	progress := 0
+	for {
+		select {
+		case <-ctx.Done():
+			h.logger.Info("Chat tool context cancelled",
+				slog.String("tool_name", tool.Name),
+				slog.String("reason", ctx.Err().Error()))
+			return nil, ctx.Err()
+		case result, ok := <-stream:
+			if !ok {
+				return mcpgo.NewToolResultText(output.String()), nil
+			}
+			switch result.Type {
+			case "chunk":
+				output.WriteString(result.Content)
+				progress++
+				if server == nil {
+					continue
+				}
+				if err := server.SendNotificationToClient(ctx, "notifications/progress", map[string]any{
+					"progressToken": token,
+					"progress":      progress,
+					"message":       result.Content,
+				}); err != nil {
+					h.logger.LogError("Chat tool progress", err,
+						slog.String("tool_name", tool.Name))
+				}
+			case "error":
+				if result.Error != nil {
+					return h.executionError(tool, result.Error), nil
 				}
-				return mcpgo.NewToolResultError(errorMsg), nil
+			case "end":
+				// The result output is final, e.g. without text dropped by output parsing
+				if result.Result != nil && result.Result.Output != "" {
+					return mcpgo.NewToolResultText(result.Result.Output), nil
+				}
+				return mcpgo.NewToolResultText(output.String()), nil
 			}
-			return mcpgo.NewToolResultText(result.Output), nil
-		},
-	)
+		}
+	}
+}
+
+// executionError logs a failed execution and converts it to an MCP tool error result
+func (h *handler) executionError(tool ToolMetadata, err error) *mcpgo.CallToolResult {
+	var errorMsg string
+	if e, ok := err.(*errors.Error); ok {
+		errorMsg = fmt.Sprintf("%d: %s", e.Code, e.Message)
+		h.logger.LogError("Chat tool execution", err,
+			slog.String("tool_name", tool.Name),
+			slog.Int("error_code", e.Code))
+	} else {
+		errorMsg = err.Error()
+		h.logger.LogError("Chat tool execution", err,
+			slog.String("tool_name", tool.Name))
+	}
+	return mcpgo.NewToolResultError(errorMsg)
+}
+
+// progressToken returns the progress token the client sent with the call, nil when it asked for no progress
+func progressToken(request mcpgo.CallToolRequest) mcpgo.ProgressToken {
+	if request.Params.Meta == nil {
+		return nil
+	}
+	return request.Params.Meta.ProgressToken
 }
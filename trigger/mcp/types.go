@@ -1,12 +1,49 @@
 package mcp
 
+import (
+	"fmt"
+	"time"
+)
+
 type Metadata struct {
 	Name        string `json:"name"`
 	Version     string `json:"version,omitempty"`
 	Description string `json:"description,omitempty"`
 }
 
+// ToolMetadata chat tool served by the engine
+type ToolMetadata struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// SystemPrompt replaces the engine system prompt for calls of this tool, empty uses the engine prompt
+	SystemPrompt string `json:"systemPrompt,omitempty"`
+}
+
 type Options struct {
 	Server Metadata `json:"server"`
-	Tool   Metadata `json:"tool"`
+	// Tool single chat tool using the engine prompt, registered before Tools when its name is set
+	Tool Metadata `json:"tool"`
+	// Tools named chat tools against the same engine, e.g. "analyze" and "summarize" with their own prompts
+	Tools []ToolMetadata `json:"tools,omitempty"`
+	Auth  AuthOptions    `json:"auth"`
+}
+
+// AuthOptions request authentication for MCP tool calls (disabled by default)
+type AuthOptions struct {
+	Enabled bool `json:"enabled"`
+	// Secret is accepted as "Authorization: Bearer <secret>" or used as the HMAC-SHA256 key
+	// for the X-Cortex-Signature header
+	Secret string `json:"secret"`
+	// ExemptTools can be called without authentication, defaults to ["ping"] when nil
+	ExemptTools []string `json:"exemptTools,omitempty"`
+	// MaxClockSkew allowed age of X-Cortex-Timestamp for signed requests, defaults to 5 minutes
+	MaxClockSkew time.Duration `json:"maxClockSkew,omitempty"`
+}
+
+// Validate reports auth settings that would reject every call, an enabled auth without a secret
+func (o AuthOptions) Validate() error {
+	if o.Enabled && o.Secret == "" {
+		return fmt.Errorf("auth secret is required when MCP auth is enabled")
+	}
+	return nil
 }
@@ -0,0 +1,343 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/gorilla/websocket"
+	"github.com/xichan96/cortex/agent/engine"
+	"github.com/xichan96/cortex/pkg/errors"
+	"github.com/xichan96/cortex/pkg/logger"
+	"github.com/xichan96/cortex/trigger/auth"
+)
+
+type Handler interface {
+	ChatAPI(c *gin.Context, engine *engine.AgentEngine)
+}
+
+type handler struct {
+	logger   *logger.Logger
+	opt      Options
+	upgrader websocket.Upgrader
+}
+
+func NewHandler() Handler {
+	return NewHandlerWithOptions(Options{})
+}
+
+// NewHandlerWithOptions creates a handler with trigger options
+func NewHandlerWithOptions(opt Options) Handler {
+	if opt.MaxMessageSize <= 0 {
+		opt.MaxMessageSize = DefaultMaxMessageSize
+	}
+	if opt.PingInterval <= 0 {
+		opt.PingInterval = DefaultPingInterval
+	}
+	if opt.WriteTimeout <= 0 {
+		opt.WriteTimeout = DefaultWriteTimeout
+	}
+	h := &handler{
+		logger: logger.NewLogger(),
+		opt:    opt,
+	}
+	if len(opt.AllowedOrigins) > 0 {
+		// Without a CheckOrigin the upgrader only accepts same-origin requests
+		h.upgrader.CheckOrigin = h.checkOrigin
+	}
+	return h
+}
+
+// checkOrigin accepts clients without an Origin header and the configured origins
+func (h *handler) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	for _, allowed := range h.opt.AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *handler) formatError(err error) string {
+	if e, ok := err.(*errors.Error); ok {
+		return fmt.Sprintf("%d: %s", e.Code, e.Message)
+	}
+	return err.Error()
+}
+
+// systemPromptError reports why the connection may not override the system prompt, nil when it may
+// The secret is checked once on the upgrade request, frames carry no credentials
+func (h *handler) systemPromptError(r *http.Request) error {
+	if !h.opt.SystemPromptOverride.Enabled {
+		return errors.EC_FORBIDDEN
+	}
+	if !auth.BearerAuthorized(r, h.opt.SystemPromptOverride.Secret) {
+		return errors.EC_UNAUTHORIZED
+	}
+	return nil
+}
+
+// session is one WebSocket connection and the execution it is running
+type session struct {
+	conn         *websocket.Conn
+	writeTimeout time.Duration
+	// promptErr rejects message frames carrying a system prompt, nil when overrides are allowed
+	promptErr error
+
+	// writeMu serializes frame writes, the connection supports one concurrent writer
+	writeMu sync.Mutex
+
+	mu     sync.Mutex
+	cancel context.CancelFunc // cancels the running execution, nil when idle
+	runs   sync.WaitGroup
+}
+
+// send writes an event frame to the client
+func (s *session) send(event Event) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	if err := s.conn.SetWriteDeadline(time.Now().Add(s.writeTimeout)); err != nil {
+		return err
+	}
+	return s.conn.WriteJSON(event)
+}
+
+// ping writes a keepalive ping to the client
+func (s *session) ping() error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	return s.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(s.writeTimeout))
+}
+
+// startRun reserves the session for a new execution, reporting false when one is already running
+func (s *session) startRun(ctx context.Context) (context.Context, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cancel != nil {
+		return nil, false
+	}
+	runCtx, cancel := context.WithCancel(ctx)
+	s.cancel = cancel
+	s.runs.Add(1)
+	return runCtx, true
+}
+
+// finishRun releases the session once its execution has ended
+func (s *session) finishRun() {
+	s.mu.Lock()
+	if s.cancel != nil {
+		s.cancel()
+		s.cancel = nil
+	}
+	s.mu.Unlock()
+	s.runs.Done()
+}
+
+// cancelRun cancels the running execution, reporting false when the session is idle
+func (s *session) cancelRun() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cancel == nil {
+		return false
+	}
+	s.cancel()
+	return true
+}
+
+// executeOptions builds per-run engine options from a message frame
+func (h *handler) executeOptions(ctx context.Context, frame *Frame) *engine.ExecuteOptions {
+	opts := &engine.ExecuteOptions{
+		Context:      ctx,
+		Tools:        frame.Tools,
+		SystemPrompt: frame.SystemPrompt,
+	}
+	for _, doc := range frame.Documents {
+		opts.Documents = append(opts.Documents, engine.Document{
+			Name:    doc.Name,
+			Content: doc.Content,
+		})
+	}
+	return opts
+}
+
+// ChatAPI upgrades the request to a WebSocket and runs the agent for each message frame
+// Events mirror the HTTP trigger's SSE stream; a cancel frame or a disconnect stops the running execution
+func (h *handler) ChatAPI(c *gin.Context, engine *engine.AgentEngine) {
+	if engine == nil {
+		h.logger.LogError("ChatAPI", fmt.Errorf("agent engine is nil"))
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Status: errors.EC_HTTP_EXECUTE_FAILED.Code,
+			Msg:    "agent engine is not available",
+		})
+		return
+	}
+
+	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// The upgrader has already written the HTTP error response
+		h.logger.LogError("ChatAPI", err, slog.String("operation", "upgrade"))
+		return
+	}
+	defer conn.Close()
+
+	s := &session{
+		conn:         conn,
+		writeTimeout: h.opt.WriteTimeout,
+		promptErr:    h.systemPromptError(c.Request),
+	}
+	// On disconnect the running execution is cancelled and waited for before the connection closes
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	defer s.runs.Wait()
+	defer cancel()
+
+	readTimeout := 2 * h.opt.PingInterval
+	conn.SetReadLimit(h.opt.MaxMessageSize)
+	_ = conn.SetReadDeadline(time.Now().Add(readTimeout))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(readTimeout))
+	})
+	go h.keepalive(ctx, s)
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				h.logger.LogError("ChatAPI", err, slog.String("operation", "read"))
+			}
+			return
+		}
+
+		var frame Frame
+		err = json.Unmarshal(data, &frame)
+		if err == nil {
+			err = binding.Validator.ValidateStruct(&frame)
+		}
+		if err != nil {
+			if s.send(Event{
+				Type:  "error",
+				Error: h.formatError(errors.EC_HTTP_INVALID_REQUEST),
+			}) != nil {
+				return
+			}
+			continue
+		}
+
+		switch frame.Type {
+		case FrameCancel:
+			if s.cancelRun() {
+				h.logger.Info("WebSocket execution cancelled by client")
+			}
+		case FrameMessage:
+			if frame.SystemPrompt != "" && s.promptErr != nil {
+				if s.send(Event{
+					Type:  "error",
+					Error: h.formatError(s.promptErr),
+				}) != nil {
+					return
+				}
+				continue
+			}
+			runCtx, ok := s.startRun(ctx)
+			if !ok {
+				if s.send(Event{
+					Type:  "error",
+					Error: h.formatError(errors.EC_AGENT_BUSY),
+				}) != nil {
+					return
+				}
+				continue
+			}
+			go h.run(runCtx, s, engine, &frame)
+		}
+	}
+}
+
+// keepalive pings the client until the connection is done
+func (h *handler) keepalive(ctx context.Context, s *session) {
+	ticker := time.NewTicker(h.opt.PingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.ping(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// run executes one message frame and relays its stream as event frames
+func (h *handler) run(ctx context.Context, s *session, engine *engine.AgentEngine, frame *Frame) {
+	defer s.finishRun()
+
+	stream, err := engine.ExecuteStreamWithOptions(frame.Message, nil, h.executeOptions(ctx, frame))
+	if err != nil {
+		h.logger.LogError("ChatAPI", err, slog.String("operation", "execute"))
+		_ = s.send(Event{
+			Type:  "error",
+			Error: h.formatError(err),
+		})
+		return
+	}
+	// The execution is cancelled with the run, let it finish without blocking on the stream
+	defer func() {
+		go func() {
+			for range stream {
+			}
+		}()
+	}()
+
+	for result := range stream {
+		event := Event{Type: result.Type}
+		switch result.Type {
+		case "chunk", "warning":
+			event.Content = result.Content
+		case "approval_required":
+			event.Content = result.Content
+			event.Data = result.ToolCall
+		case "partial_json":
+			event.Data = result.JSON
+		case "plan":
+			event.Data = result.Plan
+		case "tool_start", "tool_end":
+			event.Content = result.Content
+			event.Data = result.ToolEvent
+		case "error":
+			if result.Error != nil {
+				event.Error = h.formatError(result.Error)
+			}
+		case "end":
+			event.End = true
+			event.Data = result.Result
+			if result.Result != nil {
+				event.StoppedReason = result.Result.StoppedReason
+			}
+			event.FinishReason = result.FinishReason
+		default:
+			continue
+		}
+		if err := s.send(event); err != nil {
+			h.logger.LogError("ChatAPI", err,
+				slog.String("event_type", event.Type),
+				slog.String("operation", "write"))
+			return
+		}
+		if result.Type == "end" {
+			// Memory may still be persisted after the end event, the session accepts the next message now
+			return
+		}
+	}
+}
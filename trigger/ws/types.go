@@ -0,0 +1,81 @@
+package ws
+
+import "time"
+
+// Client frame types
+const (
+	FrameMessage = "message" // runs the agent on Message, one run per connection at a time
+	FrameCancel  = "cancel"  // cancels the running execution, which ends with stopped_reason "cancelled"
+)
+
+// Defaults of Options left zero
+const (
+	DefaultMaxMessageSize = 2 << 20
+	DefaultPingInterval   = 30 * time.Second
+	DefaultWriteTimeout   = 10 * time.Second
+)
+
+// Frame defines the structure of JSON frames sent by the client
+// Message frames carry the fields of the HTTP trigger's MessageRequest
+type Frame struct {
+	Type      string          `json:"type" binding:"required,oneof=message cancel"`
+	Message   string          `json:"message,omitempty" binding:"required_if=Type message"`
+	Documents []DocumentInput `json:"documents,omitempty" binding:"omitempty,max=10,dive"`
+	Tools     []string        `json:"tools,omitempty" binding:"omitempty,max=100,dive,min=1"`
+	// SystemPrompt replaces the engine system prompt for this run, e.g. per tenant
+	// Rejected unless SystemPromptOverrideOptions is enabled and the upgrade request carried its secret
+	SystemPrompt string `json:"system_prompt,omitempty" binding:"omitempty,max=32000"`
+}
+
+// DocumentInput defines a document attached to a message frame as context
+type DocumentInput struct {
+	Name    string `json:"name" binding:"max=256"`
+	Content string `json:"content" binding:"required,max=1048576"`
+}
+
+// Event defines the structure of JSON frames sent to the client, the same shape as the HTTP trigger's SSEvent
+type Event struct {
+	Type    string      `json:"type"`
+	Content string      `json:"content,omitempty"`
+	Error   string      `json:"error,omitempty"`
+	End     bool        `json:"end,omitempty"`
+	Data    interface{} `json:"data,omitempty"`
+
+	// StoppedReason is set on the end event when the run was cut short
+	// (e.g. "max_iterations", "max_tool_calls", "cancelled"), empty for complete responses
+	StoppedReason string `json:"stopped_reason,omitempty"`
+
+	// FinishReason is set on the end event, why the run ended: "stop", "length" (response truncated
+	// by the output token limit), "tool_calls", "max_iterations" or "tool_budget_exhausted"
+	FinishReason string `json:"finish_reason,omitempty"`
+}
+
+// ErrorResponse defines the structure for error responses sent before the upgrade
+type ErrorResponse struct {
+	Status int    `json:"status"`
+	Msg    string `json:"msg"`
+}
+
+// Options WebSocket trigger options
+type Options struct {
+	// AllowedOrigins Origin headers accepted on upgrade, "*" accepts any origin
+	// Empty accepts same-origin requests and clients that send no Origin
+	AllowedOrigins []string `json:"allowedOrigins,omitempty"`
+	// MaxMessageSize maximum bytes of a client frame, defaults to DefaultMaxMessageSize
+	MaxMessageSize int64 `json:"maxMessageSize,omitempty"`
+	// PingInterval keepalive ping period, connections without a pong for two periods are closed
+	// Defaults to DefaultPingInterval
+	PingInterval time.Duration `json:"pingInterval,omitempty"`
+	// WriteTimeout deadline of each frame write, defaults to DefaultWriteTimeout
+	WriteTimeout time.Duration `json:"writeTimeout,omitempty"`
+	// SystemPromptOverride gates the system_prompt field of message frames
+	SystemPromptOverride SystemPromptOverrideOptions `json:"systemPromptOverride"`
+}
+
+// SystemPromptOverrideOptions per-run system prompts (disabled by default)
+// The prompt replaces the operator's, including its guardrails, so only trusted clients may send one
+type SystemPromptOverrideOptions struct {
+	Enabled bool `json:"enabled"`
+	// Secret is required as "Authorization: Bearer <secret>" on the upgrade request, overrides are rejected when it is empty
+	Secret string `json:"secret"`
+}